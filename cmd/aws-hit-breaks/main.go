@@ -8,6 +8,7 @@ import (
 
 func main() {
 	if err := cli.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cli.ExitGeneralError)
 	}
+	os.Exit(cli.ExitStatus())
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/notify"
+	"github.com/aicoder2009/aws-hit-breaks/internal/schedule"
+	"github.com/aicoder2009/aws-hit-breaks/internal/state"
+)
+
+// armedCheckInterval is how often a waiting invocation checks the brake
+// wasn't called off
+const armedCheckInterval = 5 * time.Second
+
+// armPause arms the brake for region when a grace period is configured,
+// sharing the armed brakes with the CLI through the snapshot bucket so
+// 'awsbreak cancel' can call it off. The first invocation arms it and
+// notifies the team; the pause fires in the same invocation when the grace
+// period ends before its timeout, and otherwise on the first invocation after
+// it ends. It returns the claimed brake and true when the pause should go
+// ahead now, or nil and true when no grace period applies.
+func armPause(ctx, runCtx context.Context, cfg *models.Config, awsCfg aws.Config, store state.DocumentStore, region string, resp *Response) (*schedule.Armed, bool, error) {
+	grace := time.Duration(cfg.GracePeriodMinutes) * time.Minute
+	if grace <= 0 {
+		return nil, true, nil
+	}
+
+	all, err := schedule.LoadArmed(ctx, store)
+	if err != nil {
+		return nil, false, err
+	}
+	i := slices.IndexFunc(all, func(a schedule.Armed) bool { return a.Lambda && a.Region == region })
+	var armed schedule.Armed
+	if i >= 0 {
+		armed = all[i]
+	} else {
+		now := time.Now()
+		armed = schedule.Armed{
+			Region:    region,
+			Requester: invoker(),
+			Source:    "lambda",
+			Armed:     now,
+			At:        now.Add(grace),
+			Lambda:    true,
+		}
+		err := schedule.UpdateArmed(ctx, store, func(all []schedule.Armed) []schedule.Armed {
+			return append(all, armed)
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		log.Printf("brake armed in %s, pausing at %s unless called off", region, armed.At.Format(time.RFC3339))
+		send(ctx, cfg, awsCfg, notify.NewArmed(region, armed.Requester, armed.At))
+	}
+	resp.ArmedUntil = armed.At
+
+	if !waitArmed(runCtx, store, armed) {
+		return nil, false, nil
+	}
+
+	claimed, err := schedule.Claim(ctx, store, armed, invoker(), time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	if !claimed {
+		log.Printf("brake in %s was called off or is already firing", region)
+		resp.CalledOff = true
+		return nil, false, nil
+	}
+	return &armed, true, nil
+}
+
+// waitArmed waits for an armed brake to come due. It returns false when it
+// was called off, or won't be due before ctx ends, leaving it for a later
+// invocation.
+func waitArmed(ctx context.Context, store state.DocumentStore, armed schedule.Armed) bool {
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(armed.At) {
+		log.Printf("brake in %s pauses at %s, after this invocation times out; the first invocation after that fires it",
+			armed.Region, armed.At.Format(time.RFC3339))
+		return false
+	}
+
+	ticker := time.NewTicker(armedCheckInterval)
+	defer ticker.Stop()
+	for time.Now().Before(armed.At) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			all, err := schedule.LoadArmed(ctx, store)
+			if err != nil {
+				// Don't call off a brake because the list can't be read right now
+				log.Printf("%v", err)
+				continue
+			}
+			if !slices.ContainsFunc(all, armed.Same) {
+				log.Printf("brake in %s was called off", armed.Region)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// finishArmed takes a brake that fired off the list, or releases it to be
+// retried on the next invocation if the pause failed
+func finishArmed(ctx context.Context, store state.DocumentStore, armed schedule.Armed, ok bool) {
+	var err error
+	if ok {
+		_, err = schedule.Disarm(ctx, store, armed)
+	} else {
+		err = schedule.Release(ctx, store, armed)
+	}
+	if err != nil {
+		log.Printf("%v", err)
+	}
+}
+
+// invoker names this function invocation in armed brakes and claims
+func invoker() string {
+	if name := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); name != "" {
+		return fmt.Sprintf("lambda %s", name)
+	}
+	return "lambda"
+}
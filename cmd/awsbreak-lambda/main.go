@@ -0,0 +1,379 @@
+// Command awsbreak-lambda runs the pause or resume workflow as an AWS Lambda
+// function, so a nightly brake can run from an EventBridge schedule without
+// any machine being on.
+//
+// The function expects events such as {"action": "pause", "region": "us-east-1"},
+// set as the constant input of an EventBridge rule. Configuration comes from
+// the environment:
+//
+//	AWSBREAK_CONFIG_PARAMETER     SSM parameter holding config.json (optional)
+//	AWSBREAK_ROLE_ARN             role to assume; defaults to the function's own role
+//	AWSBREAK_DEFAULT_REGION       region when the event names none; defaults to AWS_REGION
+//	AWSBREAK_SNAPSHOT_BUCKET      S3 bucket for snapshots; defaults to snapshot_storage in config
+//	AWSBREAK_SNAPSHOT_PREFIX      key prefix for snapshots (default "awsbreak/snapshots")
+//	AWSBREAK_SNAPSHOT_KMS_KEY_ID  encrypt snapshots with this KMS key instead of SSE-S3
+//	AWSBREAK_INCLUDE_NETWORK      "true" to delete NAT gateways on pause
+//	AWSBREAK_INCLUDE_RESERVATIONS "true" to cancel idle capacity reservations on pause
+//	AWSBREAK_DEBUG                set to log debug output and every AWS API call
+//
+// With grace_period_minutes set in config.json, a pause event arms the brake
+// in the snapshot bucket and notifies the team instead of braking at once, so
+// 'awsbreak cancel' can call it off. The invocation waits out the grace
+// period when it ends before the function times out; otherwise a later
+// pause event, such as a second rule after the grace period, fires it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/notify"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+	"github.com/aicoder2009/aws-hit-breaks/internal/state"
+	"github.com/aicoder2009/aws-hit-breaks/internal/sweep"
+)
+
+// saveMargin is the time kept back from the function's timeout to save the
+// snapshot and send notifications
+const saveMargin = 30 * time.Second
+
+// Event is the payload an EventBridge rule sends to the function
+type Event struct {
+	Action string `json:"action"` // "pause" or "resume"
+	Region string `json:"region,omitempty"`
+}
+
+// Response summarizes what the function did
+type Response struct {
+	Action     string `json:"action"`
+	Region     string `json:"region"`
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	Succeeded  int    `json:"succeeded"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped,omitempty"`
+	// DiscoveryErrors lists services that couldn't be fully discovered
+	DiscoveryErrors []string `json:"discovery_errors,omitempty"`
+	// AllClear is set when the sweep after a pause found nothing left running
+	AllClear bool `json:"all_clear,omitempty"`
+	// StillRunning lists what the sweep found still billing, as
+	// service:id@region
+	StillRunning []string `json:"still_running,omitempty"`
+	// ArmedUntil is when an armed brake pauses, with a grace period set
+	ArmedUntil time.Time `json:"armed_until,omitzero"`
+	// CalledOff is set when the armed brake was called off instead
+	CalledOff bool `json:"called_off,omitempty"`
+}
+
+func main() {
+	// Lambda sends stderr to CloudWatch Logs
+	if os.Getenv("AWSBREAK_DEBUG") != "" {
+		if _, err := logging.Setup(logging.Options{Verbose: true, TraceAWS: true}); err != nil {
+			log.Printf("failed to set up debug logging: %v", err)
+		}
+	}
+	lambda.Start(handle)
+}
+
+func handle(ctx context.Context, event Event) (Response, error) {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	if s := cfg.Session; s != nil {
+		session := auth.Session{ExternalID: s.ExternalID, Duration: time.Duration(s.DurationMinutes) * time.Minute, Name: s.Name}
+		if err := session.Validate(); err != nil {
+			return Response{}, fmt.Errorf("invalid session config: %w", err)
+		}
+		auth.SetSession(session)
+	}
+
+	region := event.Region
+	if region == "" {
+		region = cfg.DefaultRegion
+	}
+	if err := config.ValidateRegion(region); err != nil {
+		return Response{}, err
+	}
+
+	// Share the CLI's snapshot bucket unless the environment names another
+	var bucket, prefix, kmsKeyID string
+	if st := cfg.SnapshotStorage; st != nil {
+		bucket, prefix, kmsKeyID = st.Bucket, st.Prefix, st.KMSKeyID
+	}
+	if v := os.Getenv("AWSBREAK_SNAPSHOT_BUCKET"); v != "" {
+		bucket = v
+	}
+	if v := os.Getenv("AWSBREAK_SNAPSHOT_PREFIX"); v != "" {
+		prefix = v
+	}
+	if v := os.Getenv("AWSBREAK_SNAPSHOT_KMS_KEY_ID"); v != "" {
+		kmsKeyID = v
+	}
+	if bucket == "" {
+		return Response{}, fmt.Errorf("no snapshot bucket: set AWSBREAK_SNAPSHOT_BUCKET or snapshot_storage.bucket")
+	}
+
+	base, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithAPIOptions(logging.APIOptions()))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	// Snapshots live next to the function, whatever account it brakes
+	store := state.NewS3Store(base, bucket, prefix, kmsKeyID)
+	snapshots := state.NewSnapshotManagerWithStore(store)
+
+	awsCfg := base.Copy()
+	if cfg.IAMRoleARN != "" {
+		awsCfg = auth.AccountConfig(base, cfg.IAMRoleARN)
+	}
+	awsCfg.Region = region
+
+	opts := services.OptionsFromConfig(cfg)
+	opts.IncludeNetwork, _ = strconv.ParseBool(os.Getenv("AWSBREAK_INCLUDE_NETWORK"))
+	opts.IncludeReservations, _ = strconv.ParseBool(os.Getenv("AWSBREAK_INCLUDE_RESERVATIONS"))
+	orchestrator := services.NewOrchestrator(awsCfg, opts)
+
+	// Stop operations, and the waits between phases, short of the function's
+	// timeout so there is time left to record what was done
+	runCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithDeadline(ctx, deadline.Add(-saveMargin))
+		defer cancel()
+	}
+
+	var resp Response
+	switch event.Action {
+	case "pause":
+		resp, err = pause(ctx, runCtx, cfg, awsCfg, opts, orchestrator, store, snapshots, region)
+	case "resume":
+		resp, err = resume(ctx, runCtx, orchestrator, snapshots, region)
+	default:
+		return Response{}, fmt.Errorf("unknown action %q (use pause or resume)", event.Action)
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	log.Printf("%s in %s: %d succeeded, %d failed, %d skipped", resp.Action, resp.Region, resp.Succeeded, resp.Failed, resp.Skipped)
+	return resp, nil
+}
+
+// pause discovers and stops every actionable resource in the region, skipping
+// the same report-only, protected and busy resources as the daemon. Discovery
+// and the pause run under runCtx; ctx is kept for recording the outcome.
+// A pause that ran is followed by the verification sweep, in whatever time
+// runCtx has left. With a grace period the brake is armed first, see
+// armPause.
+func pause(ctx, runCtx context.Context, cfg *models.Config, awsCfg aws.Config, opts services.Options, orchestrator *services.Orchestrator, store *state.S3Store, snapshots *state.SnapshotManager, region string) (resp Response, err error) {
+	resp = Response{Action: "pause", Region: region}
+
+	armed, fire, err := armPause(ctx, runCtx, cfg, awsCfg, store, region, &resp)
+	if err != nil || !fire {
+		return resp, err
+	}
+	if armed != nil {
+		defer func() { finishArmed(ctx, store, *armed, err == nil) }()
+	}
+
+	discovered, report, err := orchestrator.DiscoverAll(runCtx, region)
+	if err != nil {
+		return resp, fmt.Errorf("discovery failed: %w", err)
+	}
+	for _, s := range report.Failed() {
+		resp.DiscoveryErrors = append(resp.DiscoveryErrors, fmt.Sprintf("%s: %s", s.ServiceType, s.Error))
+	}
+	for _, s := range report.Partial() {
+		resp.DiscoveryErrors = append(resp.DiscoveryErrors, fmt.Sprintf("%s: %s", s.ServiceType, strings.Join(s.Warnings, "; ")))
+	}
+	for _, e := range resp.DiscoveryErrors {
+		log.Printf("discovery incomplete: %s", e)
+	}
+
+	var resources []models.Resource
+	for _, r := range discovered {
+		if !services.IsReportOnly(r) {
+			resources = append(resources, r)
+		}
+	}
+
+	resources, protected := services.NewGuard(cfg.Protection).Split(resources)
+	for _, p := range protected {
+		log.Printf("skipping %s: %s", p.Resource.ResourceID, p.Reason)
+	}
+
+	checks := services.NewConnectionChecker(awsCfg, cfg.ConnectionThreshold).CheckAll(runCtx, resources)
+	before := len(resources)
+	resources = services.FilterBlocked(resources, checks)
+	resp.Skipped = len(protected) + before - len(resources)
+
+	if len(resources) == 0 {
+		return resp, nil
+	}
+
+	// Saved before anything is touched, so a run cut short can still be resumed
+	snapshot := state.NewPlannedSnapshot(region, resources)
+	if err := snapshots.Save(ctx, snapshot); err != nil {
+		return resp, fmt.Errorf("failed to save snapshot before pausing: %w", err)
+	}
+
+	results, err := orchestrator.PauseAll(runCtx, resources)
+	if err != nil {
+		// The planned snapshot stays, covering every resource the run may have stopped
+		resp.SnapshotID = snapshot.SnapshotID
+		return resp, err
+	}
+	countResults(&resp, results)
+
+	if err := snapshots.Complete(ctx, snapshot, results); err != nil {
+		return resp, err
+	}
+	if len(snapshot.Resources) > 0 {
+		resp.SnapshotID = snapshot.SnapshotID
+	}
+
+	notifyRun(ctx, cfg, awsCfg, "pause", region, results)
+	if cfg.Verify == nil || !cfg.Verify.Disabled {
+		verify(runCtx, cfg, awsCfg, opts, region, &resp)
+	}
+	return resp, nil
+}
+
+// verify sweeps every enabled region, or the paused one and those in
+// verify.regions, for anything still running and logs the result
+func verify(ctx context.Context, cfg *models.Config, awsCfg aws.Config, opts services.Options, region string, resp *Response) {
+	var only []string
+	if cfg.Verify != nil {
+		only = cfg.Verify.Regions
+	}
+	regions, err := sweep.Regions(ctx, awsCfg, region, only)
+	discover := func(ctx context.Context, r string) ([]models.Resource, *models.DiscoveryReport, error) {
+		regionCfg := awsCfg.Copy()
+		regionCfg.Region = r
+		return services.NewOrchestrator(regionCfg, opts).DiscoverAll(ctx, r)
+	}
+
+	report := sweep.Run(ctx, regions, discover, services.NewGuard(cfg.Protection))
+	if err != nil {
+		report.RegionsError = err.Error()
+		log.Printf("sweep: couldn't list enabled regions, only %s checked: %v", region, err)
+	}
+	if err := report.Seal(); err != nil {
+		log.Printf("sweep: %v", err)
+	}
+
+	resp.AllClear = report.AllClear()
+	for _, r := range report.Regions {
+		if r.Error != "" {
+			log.Printf("sweep: couldn't sweep %s: %s", r.Region, r.Error)
+		}
+		if len(r.Incomplete) > 0 {
+			log.Printf("sweep: couldn't fully check %s in %s", strings.Join(r.Incomplete, ", "), r.Region)
+		}
+		for _, res := range r.Running {
+			resp.StillRunning = append(resp.StillRunning, fmt.Sprintf("%s:%s@%s", res.ServiceType, res.ResourceID, r.Region))
+		}
+	}
+	log.Printf("sweep of %d region(s): all clear %t, %d still running (%s)", len(report.Regions), resp.AllClear, len(resp.StillRunning), report.Checksum)
+}
+
+// resume restarts the resources in the latest snapshot with anything still paused
+func resume(ctx, runCtx context.Context, orchestrator *services.Orchestrator, snapshots *state.SnapshotManager, region string) (Response, error) {
+	resp := Response{Action: "resume", Region: region}
+
+	snapshot, err := snapshots.Latest(runCtx, region)
+	if err != nil {
+		return resp, err
+	}
+	if snapshot == nil {
+		return resp, nil
+	}
+	resp.SnapshotID = snapshot.SnapshotID
+
+	results, err := orchestrator.ResumeAll(runCtx, snapshot.PendingResources())
+	countResults(&resp, results)
+
+	// Whatever came back before an interruption is recorded so it isn't resumed twice
+	if merr := snapshots.MarkResumed(ctx, snapshot, results); merr != nil && err == nil {
+		err = merr
+	}
+	if err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+func countResults(resp *Response, results []models.OperationResult) {
+	for _, r := range results {
+		if r.Success {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+			log.Printf("failed to %s %s %s: %s", resp.Action, r.Resource.ServiceType, r.Resource.ResourceID, r.Error)
+		}
+	}
+}
+
+func notifyRun(ctx context.Context, cfg *models.Config, awsCfg aws.Config, operation, region string, results []models.OperationResult) {
+	send(ctx, cfg, awsCfg, notify.NewSummary(operation, region, results))
+}
+
+// send delivers a message through the configured notification channels
+func send(ctx context.Context, cfg *models.Config, awsCfg aws.Config, s notify.Summary) {
+	notifiers, err := notify.FromConfig(cfg.Notifications, awsCfg)
+	if err != nil {
+		log.Printf("using the built-in notification message: %v", err)
+	}
+	if err := notify.Send(ctx, notifiers, s); err != nil {
+		log.Printf("failed to send notification: %v", err)
+	}
+}
+
+// loadConfig reads config.json from SSM Parameter Store, if configured, and
+// applies environment overrides
+func loadConfig(ctx context.Context) (*models.Config, error) {
+	cfg := &models.Config{}
+
+	if name := os.Getenv("AWSBREAK_CONFIG_PARAMETER"); name != "" {
+		base, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithAPIOptions(logging.APIOptions()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+		}
+		output, err := ssm.NewFromConfig(base).GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parameter %s: %w", name, err)
+		}
+		if err := json.Unmarshal([]byte(aws.ToString(output.Parameter.Value)), cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse parameter %s: %w", name, err)
+		}
+	}
+
+	if roleARN := os.Getenv("AWSBREAK_ROLE_ARN"); roleARN != "" {
+		cfg.IAMRoleARN = roleARN
+	}
+	if region := os.Getenv("AWSBREAK_DEFAULT_REGION"); region != "" {
+		cfg.DefaultRegion = region
+	}
+	if cfg.DefaultRegion == "" {
+		cfg.DefaultRegion = os.Getenv("AWS_REGION")
+	}
+
+	return cfg, nil
+}
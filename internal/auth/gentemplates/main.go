@@ -0,0 +1,65 @@
+// Command gentemplates rewrites the policy statements in the CloudFormation
+// templates under cloudformation/ from auth.RolePolicy, so the standalone
+// templates grant exactly what 'awsbreak setup' and 'awsbreak role check'
+// expect. Run it with `go generate ./internal/auth` after changing RolePolicy.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+)
+
+// templatesDir is the template directory, relative to internal/auth
+const templatesDir = "../../cloudformation"
+
+// statementIndent is the indentation of the policy statements in the templates
+const statementIndent = 10
+
+var (
+	beginMarker = []byte("# BEGIN generated from RolePolicy")
+	endMarker   = []byte("# END generated")
+)
+
+func main() {
+	templates := []struct {
+		file   string
+		groups []auth.PolicyGroup
+	}{
+		{"iam-role.yaml", auth.RolePolicy},
+		{"observer-role.yaml", auth.ObserverPolicy()},
+	}
+	for _, t := range templates {
+		path := filepath.Join(templatesDir, t.file)
+		if err := rewrite(path, t.groups); err != nil {
+			log.Fatalf("failed to update %s: %v", path, err)
+		}
+	}
+}
+
+// rewrite replaces the lines between the BEGIN and END markers in path with
+// the statements for groups
+func rewrite(path string, groups []auth.PolicyGroup) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	begin := bytes.Index(data, beginMarker)
+	end := bytes.Index(data, endMarker)
+	if begin < 0 || end < begin {
+		return errors.New("generated section markers not found")
+	}
+	// Keep the marker lines themselves and replace everything between them
+	begin += bytes.IndexByte(data[begin:], '\n') + 1
+	end = bytes.LastIndexByte(data[:end], '\n') + 1
+
+	var out bytes.Buffer
+	out.Write(data[:begin])
+	out.WriteString(auth.PolicyStatements(groups, statementIndent))
+	out.Write(data[end:])
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
@@ -7,16 +7,19 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 const (
-	// SessionDuration is the duration for assumed role sessions
+	// SessionDuration is the default duration for assumed role sessions
 	SessionDuration = 1 * time.Hour
-	// SessionName is the name used for STS sessions
+	// SessionName is the default name used for STS sessions
 	SessionName = "aws-hit-breaks-session"
+	// ExternalID is the default external ID required by the awsbreak role trust policy
+	ExternalID = "aws-hit-breaks"
+	// DefaultMemberRoleName is the role name deployed to member accounts via StackSets
+	DefaultMemberRoleName = "AWSHitBreaksRole"
 )
 
 // IAMAuthenticator handles IAM role-based authentication
@@ -59,10 +62,10 @@ func (a *IAMAuthenticator) refreshCredentials(ctx context.Context) (aws.Config,
 		return *a.awsCfg, nil
 	}
 
-	// Load default AWS config
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(a.region))
+	// Load the selected profile's credentials
+	cfg, err := BaseConfig(ctx, a.region)
 	if err != nil {
-		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+		return aws.Config{}, err
 	}
 
 	// If no role ARN specified, use default credentials
@@ -76,10 +79,7 @@ func (a *IAMAuthenticator) refreshCredentials(ctx context.Context) (aws.Config,
 	stsClient := sts.NewFromConfig(cfg)
 
 	// Create credentials provider that assumes the role
-	creds := stscreds.NewAssumeRoleProvider(stsClient, a.roleARN, func(o *stscreds.AssumeRoleOptions) {
-		o.RoleSessionName = SessionName
-		o.Duration = SessionDuration
-	})
+	creds := stscreds.NewAssumeRoleProvider(stsClient, a.roleARN, session.apply)
 
 	// Update config with assumed role credentials
 	cfg.Credentials = aws.NewCredentialsCache(creds)
@@ -90,7 +90,7 @@ func (a *IAMAuthenticator) refreshCredentials(ctx context.Context) (aws.Config,
 	}
 
 	a.awsCfg = &cfg
-	a.expiration = time.Now().Add(SessionDuration - 5*time.Minute) // Refresh 5 min early
+	a.expiration = time.Now().Add(session.duration() - 5*time.Minute) // Refresh 5 min early
 
 	return cfg, nil
 }
@@ -105,6 +105,30 @@ func (a *IAMAuthenticator) verifyCredentials(ctx context.Context, cfg aws.Config
 	return nil
 }
 
+// CallerIdentity returns the ARN of the default credentials awsbreak starts
+// from, before any role is assumed
+func CallerIdentity(ctx context.Context, region string) (string, error) {
+	cfg, err := BaseConfig(ctx, region)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to identify AWS credentials: %w", err)
+	}
+	return aws.ToString(output.Arn), nil
+}
+
+// Identity returns the ARN of the identity an AWS config acts as
+func Identity(ctx context.Context, cfg aws.Config) (string, error) {
+	output, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to identify AWS credentials: %w", err)
+	}
+	return aws.ToString(output.Arn), nil
+}
+
 // GetAWSConfigForRegion returns an AWS config for a specific region
 func (a *IAMAuthenticator) GetAWSConfigForRegion(ctx context.Context, region string) (aws.Config, error) {
 	cfg, err := a.GetAWSConfig(ctx)
@@ -115,6 +139,21 @@ func (a *IAMAuthenticator) GetAWSConfigForRegion(ctx context.Context, region str
 	return cfg, nil
 }
 
+// MemberRoleARN returns the ARN of the awsbreak role in an organization member account
+func MemberRoleARN(accountID, roleName string) string {
+	return fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, roleName)
+}
+
+// AccountConfig returns a copy of base that assumes roleARN, typically the
+// awsbreak role in an organization member account. Credentials are fetched
+// lazily and refreshed automatically.
+func AccountConfig(base aws.Config, roleARN string) aws.Config {
+	cfg := base.Copy()
+	creds := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(base), roleARN, session.apply)
+	cfg.Credentials = aws.NewCredentialsCache(creds)
+	return cfg
+}
+
 // GetRoleARN returns the configured role ARN
 func (a *IAMAuthenticator) GetRoleARN() string {
 	return a.roleARN
@@ -124,62 +163,3 @@ func (a *IAMAuthenticator) GetRoleARN() string {
 func (a *IAMAuthenticator) IsConfigured() bool {
 	return a.roleARN != ""
 }
-
-// CloudFormationTemplate returns the IAM role CloudFormation template
-func CloudFormationTemplate() string {
-	return `AWSTemplateFormatVersion: '2010-09-09'
-Description: IAM Role for AWS Hit Breaks CLI
-
-Resources:
-  AWSHitBreaksRole:
-    Type: AWS::IAM::Role
-    Properties:
-      RoleName: AWSHitBreaksRole
-      AssumeRolePolicyDocument:
-        Version: '2012-10-17'
-        Statement:
-          - Effect: Allow
-            Principal:
-              AWS: !Sub 'arn:aws:iam::${AWS::AccountId}:root'
-            Action: sts:AssumeRole
-      Policies:
-        - PolicyName: AWSHitBreaksPolicy
-          PolicyDocument:
-            Version: '2012-10-17'
-            Statement:
-              - Effect: Allow
-                Action:
-                  # EC2 permissions
-                  - ec2:DescribeInstances
-                  - ec2:StopInstances
-                  - ec2:StartInstances
-                  # RDS permissions
-                  - rds:DescribeDBInstances
-                  - rds:DescribeDBClusters
-                  - rds:StopDBInstance
-                  - rds:StartDBInstance
-                  - rds:StopDBCluster
-                  - rds:StartDBCluster
-                  # ECS permissions
-                  - ecs:DescribeServices
-                  - ecs:DescribeClusters
-                  - ecs:ListClusters
-                  - ecs:ListServices
-                  - ecs:UpdateService
-                  # Auto Scaling permissions
-                  - autoscaling:DescribeAutoScalingGroups
-                  - autoscaling:SuspendProcesses
-                  - autoscaling:ResumeProcesses
-                  - autoscaling:SetDesiredCapacity
-                  # Pricing permissions
-                  - pricing:GetProducts
-                Resource: '*'
-
-Outputs:
-  RoleARN:
-    Description: ARN of the IAM role for AWS Hit Breaks
-    Value: !GetAtt AWSHitBreaksRole.Arn
-    Export:
-      Name: AWSHitBreaksRoleARN
-`
-}
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+//go:generate go run ./gentemplates
+
+// PolicyGroup is a set of IAM actions awsbreak needs for one service
+type PolicyGroup struct {
+	Name     string
+	Actions  []string
+	Optional bool // only needed by optional commands such as 'awsbreak savings'
+}
+
+// RolePolicy lists the actions the awsbreak role needs. It is the source of
+// the CloudFormation templates and of 'awsbreak role check'; add actions here
+// when a service manager starts calling a new API, then run
+// `go generate ./internal/auth` to update cloudformation/.
+var RolePolicy = []PolicyGroup{
+	{Name: "EC2", Actions: []string{
+		"ec2:DescribeInstances",
+		"ec2:StopInstances",
+		"ec2:StartInstances",
+		"ec2:DescribeSpotFleetRequests",
+		"ec2:ModifySpotFleetRequest",
+		"ec2:DescribeCapacityReservations",
+		"ec2:CancelCapacityReservation",
+		"ec2:CreateCapacityReservation",
+	}},
+	{Name: "NAT gateway and Elastic IP", Actions: []string{
+		"ec2:DescribeNatGateways",
+		"ec2:DescribeRouteTables",
+		"ec2:DescribeAddresses",
+		"ec2:CreateNatGateway",
+		"ec2:DeleteNatGateway",
+		"ec2:CreateRoute",
+		"ec2:ReplaceRoute",
+		"ec2:CreateTags",
+		"ec2:DeleteTags",
+	}},
+	{Name: "RDS", Actions: []string{
+		"rds:DescribeDBInstances",
+		"rds:DescribeDBClusters",
+		"rds:StopDBInstance",
+		"rds:StartDBInstance",
+		"rds:StopDBCluster",
+		"rds:StartDBCluster",
+		"rds:ModifyDBCluster",
+	}},
+	{Name: "ECS", Actions: []string{
+		"ecs:DescribeServices",
+		"ecs:DescribeClusters",
+		"ecs:ListClusters",
+		"ecs:ListServices",
+		"ecs:UpdateService",
+	}},
+	{Name: "Auto Scaling", Actions: []string{
+		"autoscaling:DescribeAutoScalingGroups",
+		"autoscaling:DescribeAutoScalingInstances",
+		"autoscaling:SuspendProcesses",
+		"autoscaling:ResumeProcesses",
+		"autoscaling:SetDesiredCapacity",
+		"autoscaling:UpdateAutoScalingGroup",
+		"autoscaling:SetInstanceProtection",
+		"ecs:DescribeCapacityProviders",
+		"ecs:UpdateCapacityProvider",
+	}},
+	{Name: "EKS", Actions: []string{
+		"eks:ListClusters",
+		"eks:ListNodegroups",
+		"eks:DescribeNodegroup",
+		"eks:UpdateNodegroupConfig",
+		"eks:ListFargateProfiles",
+	}},
+	{Name: "WorkSpaces", Actions: []string{
+		"workspaces:DescribeWorkspaces",
+		"workspaces:DescribeTags",
+		"workspaces:StopWorkspaces",
+		"workspaces:StartWorkspaces",
+		"workspaces:ModifyWorkspaceProperties",
+	}},
+	{Name: "AppStream", Actions: []string{
+		"appstream:DescribeFleets",
+		"appstream:ListTagsForResource",
+		"appstream:StopFleet",
+		"appstream:StartFleet",
+		"appstream:UpdateFleet",
+	}},
+	{Name: "OpenSearch", Actions: []string{
+		"es:ListDomainNames",
+		"es:DescribeDomains",
+		"es:DescribeDomain",
+		"es:ListTags",
+		"es:UpdateDomainConfig",
+	}},
+	{Name: "DynamoDB", Actions: []string{
+		"dynamodb:ListTables",
+		"dynamodb:DescribeTable",
+		"dynamodb:ListTagsOfResource",
+		"dynamodb:UpdateTable",
+		"application-autoscaling:DescribeScalableTargets",
+		"application-autoscaling:RegisterScalableTarget",
+	}},
+	{Name: "EMR", Actions: []string{
+		"elasticmapreduce:ListClusters",
+		"elasticmapreduce:DescribeCluster",
+		"elasticmapreduce:ListInstanceGroups",
+		"elasticmapreduce:ListInstanceFleets",
+		"elasticmapreduce:ModifyInstanceGroups",
+		"elasticmapreduce:ModifyInstanceFleet",
+		"elasticmapreduce:GetManagedScalingPolicy",
+		"elasticmapreduce:PutManagedScalingPolicy",
+		"elasticmapreduce:RemoveManagedScalingPolicy",
+	}},
+	{Name: "Kinesis", Actions: []string{
+		"kinesis:ListStreams",
+		"kinesis:DescribeStream",
+		"kinesis:DescribeStreamSummary",
+		"kinesis:ListTagsForStream",
+		"kinesis:UpdateShardCount",
+		"kinesis:UpdateStreamMode",
+	}},
+	{Name: "Lightsail", Actions: []string{
+		"lightsail:GetInstances",
+		"lightsail:GetBundles",
+		"lightsail:GetRelationalDatabases",
+		"lightsail:GetRelationalDatabaseBundles",
+		"lightsail:GetContainerServices",
+		"lightsail:GetContainerServicePowers",
+		"lightsail:StopInstance",
+		"lightsail:StartInstance",
+		"lightsail:StopRelationalDatabase",
+		"lightsail:StartRelationalDatabase",
+		"lightsail:UpdateContainerService",
+	}},
+	{Name: "CloudWatch", Actions: []string{
+		"cloudwatch:GetMetricStatistics",
+		"cloudwatch:DescribeAlarms",
+	}},
+	{Name: "SNS notifications", Optional: true, Actions: []string{
+		"sns:Publish",
+	}},
+	{Name: "Cost Explorer", Optional: true, Actions: []string{
+		"ce:GetCostAndUsage",
+	}},
+	{Name: "Pricing", Optional: true, Actions: []string{
+		"pricing:GetProducts",
+	}},
+	{Name: "Region scan", Optional: true, Actions: []string{
+		"ec2:DescribeRegions",
+		"resource-explorer-2:ListIndexes",
+		"resource-explorer-2:Search",
+	}},
+	{Name: "Audit", Optional: true, Actions: []string{
+		"ec2:DescribeVolumes",
+		"ec2:DescribeSnapshots",
+		"ec2:DescribeImages",
+		"elasticloadbalancing:DescribeLoadBalancers",
+		"elasticloadbalancing:DescribeTargetGroups",
+		"elasticloadbalancing:DescribeTargetHealth",
+		"elasticloadbalancing:DescribeInstanceHealth",
+		"globalaccelerator:ListAccelerators",
+		"globalaccelerator:ListListeners",
+		"globalaccelerator:ListEndpointGroups",
+	}},
+}
+
+// ObserverPolicy lists the read-only actions in RolePolicy: what an observer
+// role needs for the dashboard, reports and audits, without any action that
+// stops, starts or changes a resource
+func ObserverPolicy() []PolicyGroup {
+	return filterPolicy(IsReadOnlyAction)
+}
+
+// WritePolicy lists the actions in RolePolicy that change something, which an
+// observer role must not allow
+func WritePolicy() []PolicyGroup {
+	return filterPolicy(func(action string) bool { return !IsReadOnlyAction(action) })
+}
+
+func filterPolicy(keep func(action string) bool) []PolicyGroup {
+	var groups []PolicyGroup
+	for _, g := range RolePolicy {
+		var actions []string
+		for _, a := range g.Actions {
+			if keep(a) {
+				actions = append(actions, a)
+			}
+		}
+		if len(actions) > 0 {
+			groups = append(groups, PolicyGroup{Name: g.Name, Actions: actions, Optional: g.Optional})
+		}
+	}
+	return groups
+}
+
+// IsReadOnlyAction reports whether an IAM action such as "ec2:DescribeInstances"
+// only reads
+func IsReadOnlyAction(action string) bool {
+	_, op, _ := strings.Cut(action, ":")
+	return IsReadOnlyOperation(op)
+}
+
+// ActionCheck is whether the deployed role allows one required action
+type ActionCheck struct {
+	Group    string
+	Action   string
+	Optional bool
+	Allowed  bool
+	Decision string // IAM evaluation decision, e.g. "implicitDeny"
+}
+
+// CheckRolePolicy evaluates every action in groups, usually RolePolicy,
+// against the role's attached and inline policies with the IAM policy
+// simulator. cfg must be allowed iam:SimulatePrincipalPolicy on the role; the
+// awsbreak role itself usually isn't.
+func CheckRolePolicy(ctx context.Context, cfg aws.Config, roleARN string, groups []PolicyGroup) ([]ActionCheck, error) {
+	var checks []ActionCheck
+	index := make(map[string]int)
+	var actions []string
+	for _, g := range groups {
+		for _, a := range g.Actions {
+			index[a] = len(checks)
+			checks = append(checks, ActionCheck{Group: g.Name, Action: a, Optional: g.Optional})
+			actions = append(actions, a)
+		}
+	}
+
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(iam.NewFromConfig(cfg), &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(roleARN),
+		ActionNames:     actions,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate role policy: %w", err)
+		}
+		for _, r := range output.EvaluationResults {
+			i, ok := index[aws.ToString(r.EvalActionName)]
+			if !ok {
+				continue
+			}
+			checks[i].Decision = string(r.EvalDecision)
+			checks[i].Allowed = r.EvalDecision == types.PolicyEvaluationDecisionTypeAllowed
+		}
+	}
+
+	return checks, nil
+}
+
+// RoleName returns the name of the role in an IAM role ARN, without its path
+func RoleName(roleARN string) string {
+	i := strings.LastIndex(roleARN, "/")
+	if i < 0 {
+		return roleARN
+	}
+	return roleARN[i+1:]
+}
+
+// CloudFormationTemplate returns the IAM role CloudFormation template
+func CloudFormationTemplate() string {
+	return roleTemplate("AWSHitBreaksRole", "AWSHitBreaksRoleARN", RolePolicy)
+}
+
+// ObserverCloudFormationTemplate returns the CloudFormation template for an
+// observer role, which can only read. awsbreak set up with it shows the
+// dashboard and reports and refuses to pause or resume.
+func ObserverCloudFormationTemplate() string {
+	return roleTemplate("AWSHitBreaksObserverRole", "AWSHitBreaksObserverRoleARN", ObserverPolicy())
+}
+
+// PolicyStatements renders groups as the statements of a CloudFormation
+// policy document, one per group, with each line indented by indent spaces.
+// The setup template and the templates in cloudformation/ are both written
+// with it, so they grant the same actions.
+func PolicyStatements(groups []PolicyGroup, indent int) string {
+	pad := strings.Repeat(" ", indent)
+	var b strings.Builder
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		comment := g.Name + " permissions"
+		if g.Optional {
+			comment += " (optional)"
+		}
+		fmt.Fprintf(&b, "%s# %s\n", pad, comment)
+		fmt.Fprintf(&b, "%s- Sid: %s\n", pad, statementID(g.Name))
+		fmt.Fprintf(&b, "%s  Effect: Allow\n", pad)
+		fmt.Fprintf(&b, "%s  Action:\n", pad)
+		for _, a := range g.Actions {
+			fmt.Fprintf(&b, "%s    - %s\n", pad, a)
+		}
+		fmt.Fprintf(&b, "%s  Resource: '*'\n", pad)
+	}
+	return b.String()
+}
+
+// statementID turns a group name such as "NAT gateway and Elastic IP" into a
+// statement ID, "NATGatewayAndElasticIP"
+func statementID(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+func roleTemplate(roleName, export string, groups []PolicyGroup) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `AWSTemplateFormatVersion: '2010-09-09'
+Description: IAM Role for AWS Hit Breaks CLI
+
+Parameters:
+  ExternalId:
+    Type: String
+    Default: %s
+    MinLength: 2
+    Description: >-
+      External ID awsbreak must send to assume the role. Set session.external_id
+      in config.json to match when you change it.
+  MaxSessionDuration:
+    Type: Number
+    Default: %d
+    MinValue: %d
+    MaxValue: %d
+    Description: >-
+      Longest role session in seconds. Raise it to use session.duration_minutes
+      above 60 for long multi-region runs.
+
+Resources:
+  AWSHitBreaksRole:
+    Type: AWS::IAM::Role
+    Properties:
+      RoleName: %s
+      AssumeRolePolicyDocument:
+        Version: '2012-10-17'
+        Statement:
+          - Effect: Allow
+            Principal:
+              AWS: !Sub 'arn:aws:iam::${AWS::AccountId}:root'
+            Action: sts:AssumeRole
+            Condition:
+              StringEquals:
+                sts:ExternalId: !Ref ExternalId
+      MaxSessionDuration: !Ref MaxSessionDuration
+      Policies:
+        - PolicyName: AWSHitBreaksPolicy
+          PolicyDocument:
+            Version: '2012-10-17'
+            Statement:
+`, ExternalID, int(SessionDuration.Seconds()), int(SessionDuration.Seconds()), int(MaxSessionDuration.Seconds()), roleName)
+	b.WriteString(PolicyStatements(groups, 14))
+	fmt.Fprintf(&b, `
+Outputs:
+  RoleARN:
+    Description: ARN of the IAM role for AWS Hit Breaks
+    Value: !GetAtt AWSHitBreaksRole.Arn
+    Export:
+      Name: %s
+`, export)
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"fmt"
+	"os/user"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+)
+
+// Limits STS puts on assumed role sessions
+const (
+	MinSessionDuration = 15 * time.Minute
+	MaxSessionDuration = 12 * time.Hour
+	maxSessionNameLen  = 64
+)
+
+// sessionNameInvalid matches characters STS doesn't allow in a session name
+var sessionNameInvalid = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// Session customizes the role sessions awsbreak starts
+type Session struct {
+	// ExternalID is sent with every AssumeRole; empty uses ExternalID
+	ExternalID string
+	// Duration is how long each session lasts; zero uses SessionDuration.
+	// The role's MaxSessionDuration must allow it, and AWS caps sessions
+	// assumed with role credentials, such as an IAM Identity Center
+	// profile's, at an hour regardless.
+	Duration time.Duration
+	// Name shows in CloudTrail; empty uses SessionName. "{user}" is replaced
+	// with the local user name, so each person's actions can be told apart.
+	Name string
+}
+
+// session applies to every role assumed from now on
+var session Session
+
+// SetSession sets how roles are assumed, for the IAM authenticator and
+// organization member accounts alike
+func SetSession(s Session) {
+	session = s
+}
+
+// Validate checks the session against the limits STS enforces
+func (s Session) Validate() error {
+	if s.Duration != 0 && (s.Duration < MinSessionDuration || s.Duration > MaxSessionDuration) {
+		return fmt.Errorf("session duration %s is outside the %s to %s STS allows", s.Duration, MinSessionDuration, MaxSessionDuration)
+	}
+	if s.ExternalID != "" && (len(s.ExternalID) < 2 || len(s.ExternalID) > 1224) {
+		return fmt.Errorf("external ID must be 2 to 1224 characters")
+	}
+	return nil
+}
+
+// duration returns how long sessions last
+func (s Session) duration() time.Duration {
+	if s.Duration == 0 {
+		return SessionDuration
+	}
+	return s.Duration
+}
+
+// name returns the session name with {user} filled in and anything STS
+// rejects replaced, cut to the 64 characters STS allows
+func (s Session) name() string {
+	if s.Name == "" {
+		return SessionName
+	}
+
+	name := s.Name
+	if strings.Contains(name, "{user}") {
+		who := "unknown"
+		if u, err := user.Current(); err == nil && u.Username != "" {
+			who = u.Username
+		}
+		name = strings.ReplaceAll(name, "{user}", who)
+	}
+	name = sessionNameInvalid.ReplaceAllString(name, "-")
+	if len(name) > maxSessionNameLen {
+		name = name[:maxSessionNameLen]
+	}
+	if len(name) < 2 {
+		return SessionName
+	}
+	return name
+}
+
+// apply sets the session options on an AssumeRole call
+func (s Session) apply(o *stscreds.AssumeRoleOptions) {
+	o.RoleSessionName = s.name()
+	o.Duration = s.duration()
+	o.ExternalID = aws.String(ExternalID)
+	if s.ExternalID != "" {
+		o.ExternalID = aws.String(s.ExternalID)
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+)
+
+// readOnlyPrefixes start the names of AWS operations that only read
+var readOnlyPrefixes = []string{"Describe", "List", "Get", "BatchGet", "Search", "Lookup", "Simulate"}
+
+// credentialOperations obtain credentials without changing the account
+var credentialOperations = map[string]bool{
+	"AssumeRole":                true,
+	"AssumeRoleWithWebIdentity": true,
+	"CreateToken":               true,
+	"RegisterClient":            true,
+	"StartDeviceAuthorization":  true,
+}
+
+// ErrSpectator is returned for AWS calls refused in spectator mode
+var ErrSpectator = errors.New("refused in spectator mode")
+
+// spectator makes every AWS config awsbreak loads read-only
+var spectator bool
+
+// SetSpectator turns spectator mode on for the rest of the process. AWS
+// clients created afterwards refuse every call that could change something,
+// whatever the credentials allow; calls are refused before they are signed
+// or sent.
+func SetSpectator() {
+	spectator = true
+}
+
+// Spectating reports whether spectator mode is on
+func Spectating() bool {
+	return spectator
+}
+
+// IsReadOnlyOperation reports whether an AWS API operation only reads
+func IsReadOnlyOperation(name string) bool {
+	if credentialOperations[name] {
+		return true
+	}
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// APIOptions returns the SDK middleware for every AWS config awsbreak loads:
+// call tracing, and the read-only guard in spectator mode
+func APIOptions() []func(*middleware.Stack) error {
+	opts := logging.APIOptions()
+	if spectator {
+		opts = append(opts, addReadOnlyGuard)
+	}
+	return opts
+}
+
+func addReadOnlyGuard(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AWSBreakReadOnly",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			op := awsmiddleware.GetOperationName(ctx)
+			if !IsReadOnlyOperation(op) {
+				return middleware.InitializeOutput{}, middleware.Metadata{},
+					fmt.Errorf("%s:%s %w", strings.ToLower(awsmiddleware.GetServiceID(ctx)), op, ErrSpectator)
+			}
+			return next.HandleInitialize(ctx, in)
+		}), middleware.After) // after the operation name is registered
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+)
+
+const (
+	ssoClientName     = "awsbreak"
+	ssoScope          = "sso:account:access"
+	deviceCodeGrant   = "urn:ietf:params:oauth:grant-type:device_code"
+	refreshTokenGrant = "refresh_token"
+	// ssoRefreshWindow is how long before it expires a cached token is renewed
+	ssoRefreshWindow = 5 * time.Minute
+	// ssoSlowDown is added to the polling interval when asked to slow down
+	ssoSlowDown = 5 * time.Second
+)
+
+// ErrSSOLoginRequired is returned when the profile's SSO session has expired
+// and can't be renewed
+var ErrSSOLoginRequired = errors.New("SSO session expired or missing: run 'awsbreak login'")
+
+// profile is the shared config profile credentials are loaded from
+var profile string
+
+// SetProfile selects the shared AWS config profile awsbreak starts from, for
+// every AWS config loaded afterwards. Empty means AWS_PROFILE or the default
+// profile, as with the AWS CLI.
+func SetProfile(name string) {
+	profile = name
+}
+
+// Profile returns the name of the shared config profile in use
+func Profile() string {
+	if profile != "" {
+		return profile
+	}
+	if env := os.Getenv("AWS_PROFILE"); env != "" {
+		return env
+	}
+	return "default"
+}
+
+// BaseConfig loads the credentials awsbreak starts from, before assuming any
+// role: the selected profile, with its SSO token renewed first when it is
+// about to expire
+func BaseConfig(ctx context.Context, region string) (aws.Config, error) {
+	if p, err := LoadSSOProfile(ctx, Profile()); err == nil && p != nil {
+		if err := refreshSSOToken(ctx, p); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	opts := []func(*config.LoadOptions) error{config.WithRegion(region), config.WithAPIOptions(APIOptions())}
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return cfg, nil
+}
+
+// SSOProfile is a shared config profile that signs in through IAM Identity Center
+type SSOProfile struct {
+	Name      string
+	Session   string // sso-session name; empty for legacy profiles
+	StartURL  string
+	Region    string
+	AccountID string
+	RoleName  string
+}
+
+// cacheKey is what the SDK and AWS CLI hash to name the profile's token cache
+func (p *SSOProfile) cacheKey() string {
+	if p.Session != "" {
+		return p.Session
+	}
+	return p.StartURL
+}
+
+// LoadSSOProfile reads a profile's Identity Center settings from the shared
+// AWS config. It returns nil when the profile doesn't use SSO.
+func LoadSSOProfile(ctx context.Context, name string) (*SSOProfile, error) {
+	sc, err := config.LoadSharedConfigProfile(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile %s: %w", name, err)
+	}
+
+	p := &SSOProfile{
+		Name:      name,
+		StartURL:  sc.SSOStartURL,
+		Region:    sc.SSORegion,
+		AccountID: sc.SSOAccountID,
+		RoleName:  sc.SSORoleName,
+	}
+	if s := sc.SSOSession; s != nil {
+		p.Session = s.Name
+		p.StartURL = s.SSOStartURL
+		p.Region = s.SSORegion
+	}
+	if p.StartURL == "" {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// ssoToken is the token cache format shared with the SDK and the AWS CLI
+type ssoToken struct {
+	StartURL              string    `json:"startUrl,omitempty"`
+	Region                string    `json:"region,omitempty"`
+	AccessToken           string    `json:"accessToken"`
+	ExpiresAt             time.Time `json:"expiresAt"`
+	RefreshToken          string    `json:"refreshToken,omitempty"`
+	ClientID              string    `json:"clientId,omitempty"`
+	ClientSecret          string    `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt time.Time `json:"registrationExpiresAt,omitzero"`
+}
+
+// LoginSSO signs in to the profile's Identity Center with the device
+// authorization flow and caches the token where the SDK and AWS CLI look for
+// it. show is given the verification URL, with the code filled in, and the
+// code the user should see there. It returns when the token expires.
+func LoginSSO(ctx context.Context, p *SSOProfile, show func(verificationURL, userCode string)) (time.Time, error) {
+	client := ssoOIDCClient(p.Region)
+
+	reg, err := client.RegisterClient(ctx, &ssooidc.RegisterClientInput{
+		ClientName: aws.String(ssoClientName),
+		ClientType: aws.String("public"),
+		Scopes:     []string{ssoScope},
+		GrantTypes: []string{deviceCodeGrant, refreshTokenGrant},
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to register with IAM Identity Center: %w", err)
+	}
+
+	device, err := client.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     reg.ClientId,
+		ClientSecret: reg.ClientSecret,
+		StartUrl:     aws.String(p.StartURL),
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to start SSO login: %w", err)
+	}
+	show(aws.ToString(device.VerificationUriComplete), aws.ToString(device.UserCode))
+
+	interval := time.Duration(device.Interval) * time.Second
+	if interval <= 0 {
+		interval = ssoSlowDown
+	}
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		out, err := client.CreateToken(ctx, &ssooidc.CreateTokenInput{
+			ClientId:     reg.ClientId,
+			ClientSecret: reg.ClientSecret,
+			DeviceCode:   device.DeviceCode,
+			GrantType:    aws.String(deviceCodeGrant),
+		})
+		var pending *types.AuthorizationPendingException
+		var slowDown *types.SlowDownException
+		switch {
+		case errors.As(err, &pending):
+			continue
+		case errors.As(err, &slowDown):
+			interval += ssoSlowDown
+			continue
+		case err != nil:
+			return time.Time{}, fmt.Errorf("SSO login failed: %w", err)
+		}
+
+		tok := ssoToken{
+			StartURL:              p.StartURL,
+			Region:                p.Region,
+			AccessToken:           aws.ToString(out.AccessToken),
+			ExpiresAt:             expiresIn(out.ExpiresIn),
+			RefreshToken:          aws.ToString(out.RefreshToken),
+			ClientID:              aws.ToString(reg.ClientId),
+			ClientSecret:          aws.ToString(reg.ClientSecret),
+			RegistrationExpiresAt: time.Unix(reg.ClientSecretExpiresAt, 0).UTC(),
+		}
+		if err := saveSSOToken(p, tok); err != nil {
+			return time.Time{}, err
+		}
+		return tok.ExpiresAt, nil
+	}
+	return time.Time{}, fmt.Errorf("SSO login timed out waiting for approval")
+}
+
+// refreshSSOToken renews the profile's cached token shortly before it
+// expires using its refresh token, so unattended runs don't stop for a new
+// login every few hours. A token that can't be renewed is left to expire.
+func refreshSSOToken(ctx context.Context, p *SSOProfile) error {
+	tok, err := loadSSOToken(p)
+	if err != nil {
+		return err
+	}
+	if time.Until(tok.ExpiresAt) > ssoRefreshWindow {
+		return nil
+	}
+
+	expired := !time.Now().Before(tok.ExpiresAt)
+	canRefresh := tok.RefreshToken != "" && tok.ClientID != "" &&
+		(tok.RegistrationExpiresAt.IsZero() || time.Now().Before(tok.RegistrationExpiresAt))
+	if !canRefresh {
+		if expired {
+			return ErrSSOLoginRequired
+		}
+		return nil
+	}
+
+	out, err := ssoOIDCClient(p.Region).CreateToken(ctx, &ssooidc.CreateTokenInput{
+		ClientId:     aws.String(tok.ClientID),
+		ClientSecret: aws.String(tok.ClientSecret),
+		GrantType:    aws.String(refreshTokenGrant),
+		RefreshToken: aws.String(tok.RefreshToken),
+	})
+	if err != nil {
+		if expired {
+			return fmt.Errorf("%w (%v)", ErrSSOLoginRequired, err)
+		}
+		return nil
+	}
+
+	tok.AccessToken = aws.ToString(out.AccessToken)
+	tok.ExpiresAt = expiresIn(out.ExpiresIn)
+	if out.RefreshToken != nil {
+		tok.RefreshToken = *out.RefreshToken
+	}
+	return saveSSOToken(p, tok)
+}
+
+// ssoOIDCClient returns an Identity Center OIDC client. Its calls are unsigned,
+// so no credentials are loaded.
+func ssoOIDCClient(region string) *ssooidc.Client {
+	return ssooidc.NewFromConfig(aws.Config{Region: region, APIOptions: APIOptions()})
+}
+
+func expiresIn(seconds int32) time.Time {
+	return time.Now().Add(time.Duration(seconds) * time.Second).UTC().Truncate(time.Second)
+}
+
+func loadSSOToken(p *SSOProfile) (ssoToken, error) {
+	path, err := ssocreds.StandardCachedTokenFilepath(p.cacheKey())
+	if err != nil {
+		return ssoToken{}, fmt.Errorf("failed to locate SSO token cache: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ssoToken{}, ErrSSOLoginRequired
+		}
+		return ssoToken{}, fmt.Errorf("failed to read SSO token cache: %w", err)
+	}
+
+	var tok ssoToken
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return ssoToken{}, fmt.Errorf("failed to parse SSO token cache: %w", err)
+	}
+	return tok, nil
+}
+
+func saveSSOToken(p *SSOProfile, tok ssoToken) error {
+	path, err := ssocreds.StandardCachedTokenFilepath(p.cacheKey())
+	if err != nil {
+		return fmt.Errorf("failed to locate SSO token cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create SSO token cache: %w", err)
+	}
+
+	data, err := json.MarshalIndent(tok, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal SSO token: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save SSO token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+)
+
+// stackUpdateTimeout bounds the wait for a role stack update; IAM changes
+// usually complete in under a minute
+const stackUpdateTimeout = 10 * time.Minute
+
+// RoleStackName is the stack 'awsbreak setup' deploys the role with
+const RoleStackName = "AWSHitBreaks"
+
+// ObserverRoleStackName is the stack 'awsbreak setup --observer' deploys the
+// read-only role with
+const ObserverRoleStackName = "AWSHitBreaksObserver"
+
+// RoleStack is the CloudFormation stack that deployed the awsbreak role
+type RoleStack struct {
+	Name       string
+	Parameters []string // parameter keys the deployed template declares
+}
+
+// setupParameters are the parameters of the template printed by 'awsbreak
+// setup'. Stacks created before it had any declare none.
+var setupParameters = []string{"ExternalId", "MaxSessionDuration"}
+
+// Managed reports whether the stack was created from the template printed
+// by 'awsbreak setup', which declares only setupParameters and can be
+// replaced with CloudFormationTemplate. Stacks from
+// cloudformation/iam-role.yaml or StackSets must be updated from their own
+// template.
+func (s *RoleStack) Managed() bool {
+	for _, p := range s.Parameters {
+		if !slices.Contains(setupParameters, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// stackParameters sets the setup template's parameters from the session
+// settings, so the role accepts the external ID and session length awsbreak
+// will ask for
+func (s Session) stackParameters() []types.Parameter {
+	externalID := ExternalID
+	if s.ExternalID != "" {
+		externalID = s.ExternalID
+	}
+	seconds := int(max(s.duration(), SessionDuration).Seconds())
+	return []types.Parameter{
+		{ParameterKey: aws.String("ExternalId"), ParameterValue: aws.String(externalID)},
+		{ParameterKey: aws.String("MaxSessionDuration"), ParameterValue: aws.String(strconv.Itoa(seconds))},
+	}
+}
+
+// FindRoleStack returns the stack that owns the named role, or nil if the
+// role wasn't created by CloudFormation
+func FindRoleStack(ctx context.Context, cfg aws.Config, roleName string) (*RoleStack, error) {
+	client := cloudformation.NewFromConfig(cfg)
+
+	resources, err := client.DescribeStackResources(ctx, &cloudformation.DescribeStackResourcesInput{
+		PhysicalResourceId: aws.String(roleName),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationError" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find stack for role %s: %w", roleName, err)
+	}
+
+	var stackName string
+	for _, r := range resources.StackResources {
+		if aws.ToString(r.ResourceType) == "AWS::IAM::Role" {
+			stackName = aws.ToString(r.StackName)
+			break
+		}
+	}
+	if stackName == "" {
+		return nil, nil
+	}
+
+	stacks, err := client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stack %s: %w", stackName, err)
+	}
+	if len(stacks.Stacks) == 0 {
+		return nil, fmt.Errorf("stack %s not found", stackName)
+	}
+
+	stack := &RoleStack{Name: stackName}
+	for _, p := range stacks.Stacks[0].Parameters {
+		stack.Parameters = append(stack.Parameters, aws.ToString(p.ParameterKey))
+	}
+	return stack, nil
+}
+
+// UpdateRoleStack replaces the stack's template with template, either
+// CloudFormationTemplate or ObserverCloudFormationTemplate, and waits for the
+// update to finish
+func UpdateRoleStack(ctx context.Context, cfg aws.Config, stack *RoleStack, template string) error {
+	if !stack.Managed() {
+		return fmt.Errorf("stack %s was not created from the awsbreak setup template; update it from its own template", stack.Name)
+	}
+
+	// Stacks created before the template had parameters take them from the
+	// session settings; later ones keep what they were deployed with
+	params := session.stackParameters()
+	if len(stack.Parameters) > 0 {
+		params = nil
+		for _, p := range setupParameters {
+			params = append(params, types.Parameter{ParameterKey: aws.String(p), UsePreviousValue: aws.Bool(true)})
+		}
+	}
+
+	client := cloudformation.NewFromConfig(cfg)
+	_, err := client.UpdateStack(ctx, &cloudformation.UpdateStackInput{
+		StackName:    aws.String(stack.Name),
+		TemplateBody: aws.String(template),
+		Parameters:   params,
+		Capabilities: []types.Capability{types.CapabilityCapabilityNamedIam},
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && strings.Contains(apiErr.ErrorMessage(), "No updates are to be performed") {
+			return nil
+		}
+		return fmt.Errorf("failed to update stack %s: %w", stack.Name, err)
+	}
+
+	waiter := cloudformation.NewStackUpdateCompleteWaiter(client)
+	if err := waiter.Wait(ctx, &cloudformation.DescribeStacksInput{StackName: aws.String(stack.Name)}, stackUpdateTimeout); err != nil {
+		return fmt.Errorf("stack %s did not finish updating: %w", stack.Name, err)
+	}
+	return nil
+}
+
+// DeployRoleStack creates a stack from template, either CloudFormationTemplate
+// or ObserverCloudFormationTemplate, with the caller's own credentials, waits
+// for it to finish and returns the ARN of the role it created. A stack of the
+// same name that already finished creating is reused.
+func DeployRoleStack(ctx context.Context, cfg aws.Config, stackName, template string) (string, error) {
+	client := cloudformation.NewFromConfig(cfg)
+	_, err := client.CreateStack(ctx, &cloudformation.CreateStackInput{
+		StackName:    aws.String(stackName),
+		TemplateBody: aws.String(template),
+		Parameters:   session.stackParameters(),
+		Capabilities: []types.Capability{types.CapabilityCapabilityNamedIam},
+		Tags: []types.Tag{
+			{Key: aws.String("ManagedBy"), Value: aws.String("awsbreak")},
+		},
+	})
+	if err != nil {
+		var exists *types.AlreadyExistsException
+		if !errors.As(err, &exists) {
+			return "", fmt.Errorf("failed to create stack %s: %w", stackName, err)
+		}
+	}
+
+	input := &cloudformation.DescribeStacksInput{StackName: aws.String(stackName)}
+	waiter := cloudformation.NewStackCreateCompleteWaiter(client)
+	if err := waiter.Wait(ctx, input, stackUpdateTimeout); err != nil {
+		if reason := stackFailure(ctx, client, stackName); reason != "" {
+			return "", fmt.Errorf("stack %s failed to create: %s", stackName, reason)
+		}
+		return "", fmt.Errorf("stack %s did not finish creating: %w", stackName, err)
+	}
+
+	stacks, err := client.DescribeStacks(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe stack %s: %w", stackName, err)
+	}
+	if len(stacks.Stacks) == 0 {
+		return "", fmt.Errorf("stack %s not found", stackName)
+	}
+	for _, o := range stacks.Stacks[0].Outputs {
+		if aws.ToString(o.OutputKey) == "RoleARN" {
+			return aws.ToString(o.OutputValue), nil
+		}
+	}
+	return "", fmt.Errorf("stack %s has no RoleARN output", stackName)
+}
+
+// stackFailure returns why the first resource of a stack failed to create,
+// or "" if it can't be told
+func stackFailure(ctx context.Context, client *cloudformation.Client, stackName string) string {
+	out, err := client.DescribeStackEvents(ctx, &cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return ""
+	}
+
+	// Events are newest first; the earliest failure is the cause
+	var reason string
+	for _, e := range out.StackEvents {
+		if e.ResourceStatus == types.ResourceStatusCreateFailed && aws.ToString(e.ResourceStatusReason) != "" {
+			reason = fmt.Sprintf("%s: %s", aws.ToString(e.LogicalResourceId), aws.ToString(e.ResourceStatusReason))
+		}
+	}
+	return reason
+}
@@ -0,0 +1,326 @@
+// Package bundle packs awsbreak's local state into a single encrypted
+// archive, so it can be moved to another machine or shared with a teammate.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// magic starts every archive and identifies its format version
+	magic = "AWSBREAK-STATE-1\n"
+	// manifestName is the archive entry describing its contents
+	manifestName = "manifest.json"
+
+	saltSize       = 16
+	keySize        = 32
+	kdfIterations  = 600000
+	maxArchiveSize = 512 << 20
+)
+
+// Entries are the files and directories under the config directory that
+// make up awsbreak's state. Caches are left out; they are rebuilt on demand.
+var Entries = []string{
+	"config.json",   // settings, schedules and budgets
+	"snapshots",     // what each pause stopped, for resume
+	"ledger.jsonl",  // savings history
+	"journal",       // in-flight and failed operations
+	"issues.json",   // issues filed for recurring failures
+	"sweeps.jsonl",  // signed-off verification sweeps
+	"openings.json", // groups opened for a while, awaiting re-pause
+	"runs",          // step-by-step event log of each run
+}
+
+// ErrBadPassphrase is returned when an archive can't be decrypted
+var ErrBadPassphrase = errors.New("wrong passphrase or damaged archive")
+
+// Manifest describes an archive
+type Manifest struct {
+	Version  string    `json:"version"` // awsbreak version that wrote it
+	Created  time.Time `json:"created"`
+	Hostname string    `json:"hostname,omitempty"`
+	Files    []string  `json:"files"`
+}
+
+// Export archives the state in configDir, encrypted with a key derived from
+// passphrase, and writes it to w. Files named in contents are archived with
+// those contents rather than what is on disk, such as a config that is
+// encrypted with a key only this machine has.
+func Export(configDir, version string, passphrase []byte, contents map[string][]byte, w io.Writer) (*Manifest, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("a passphrase is required")
+	}
+
+	manifest := &Manifest{Version: version, Created: time.Now().UTC()}
+	manifest.Hostname, _ = os.Hostname()
+
+	var files []string
+	for _, entry := range Entries {
+		err := filepath.WalkDir(filepath.Join(configDir, entry), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			// Temporary files are half-written saves
+			if d.Type().IsRegular() && !strings.HasSuffix(p, ".tmp") {
+				rel, err := filepath.Rel(configDir, p)
+				if err != nil {
+					return err
+				}
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry, err)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no awsbreak state found in %s", configDir)
+	}
+	manifest.Files = files
+
+	var plain bytes.Buffer
+	gz := gzip.NewWriter(&plain)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeEntry(tw, manifestName, data); err != nil {
+		return nil, err
+	}
+	for _, name := range files {
+		data, ok := contents[name]
+		if !ok {
+			if data, err = os.ReadFile(filepath.Join(configDir, filepath.FromSlash(name))); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+		}
+		if err := writeEntry(tw, name, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(magic)
+	out.Write(salt)
+	out.Write(nonce)
+	out.Write(aead.Seal(nil, nonce, plain.Bytes(), []byte(magic)))
+	if _, err := w.Write(out.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Archive is a decrypted archive, ready to restore
+type Archive struct {
+	Manifest *Manifest
+	plain    []byte
+}
+
+// Open decrypts an archive and reads its manifest without restoring
+// anything, so callers can see which files it holds first
+func Open(r io.Reader, passphrase []byte) (*Archive, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxArchiveSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if len(data) > maxArchiveSize {
+		return nil, fmt.Errorf("archive is larger than %d MB", maxArchiveSize>>20)
+	}
+	if !bytes.HasPrefix(data, []byte(magic)) {
+		return nil, fmt.Errorf("not an awsbreak state archive")
+	}
+	data = data[len(magic):]
+
+	if len(data) < saltSize {
+		return nil, ErrBadPassphrase
+	}
+	salt, data := data[:saltSize], data[saltSize:]
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrBadPassphrase
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(magic))
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+
+	a := &Archive{plain: plain}
+	err = a.walk(func(name string, r io.Reader) error {
+		if name != manifestName {
+			return nil
+		}
+		a.Manifest = &Manifest{}
+		if err := json.NewDecoder(r).Decode(a.Manifest); err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if a.Manifest == nil {
+		return nil, fmt.Errorf("archive has no manifest")
+	}
+	return a, nil
+}
+
+// Restore writes the archive's files into configDir. Files that already
+// exist are never overwritten.
+func (a *Archive) Restore(configDir string) error {
+	return a.walk(func(name string, r io.Reader) error {
+		if name == manifestName {
+			return nil
+		}
+		local, err := safeName(name)
+		if err != nil {
+			return err
+		}
+		return restoreFile(filepath.Join(configDir, local), r)
+	})
+}
+
+// Roots returns the top-level Entries the archive holds files for
+func (a *Archive) Roots() []string {
+	held := make(map[string]bool)
+	for _, f := range a.Manifest.Files {
+		top, _, _ := strings.Cut(f, "/")
+		held[top] = true
+	}
+	var roots []string
+	for _, entry := range Entries {
+		if held[entry] {
+			roots = append(roots, entry)
+		}
+	}
+	return roots
+}
+
+// walk calls fn for each regular file in the archive
+func (a *Archive) walk(fn func(name string, r io.Reader) error) error {
+	gz, err := gzip.NewReader(bytes.NewReader(a.plain))
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// newAEAD derives the archive key from the passphrase
+func newAEAD(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, kdfIterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// safeName checks that an archive path stays inside one of Entries and
+// returns it as a local path
+func safeName(name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("archive contains an unsafe path: %s", name)
+	}
+	for _, entry := range Entries {
+		if clean == entry || strings.HasPrefix(clean, entry+"/") {
+			return filepath.FromSlash(clean), nil
+		}
+	}
+	return "", fmt.Errorf("archive contains an unexpected file: %s", name)
+}
+
+func restoreFile(dst string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dst), err)
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", dst, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to restore %s: %w", dst, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,460 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/notify"
+	"github.com/aicoder2009/aws-hit-breaks/internal/schedule"
+	"github.com/aicoder2009/aws-hit-breaks/internal/state"
+)
+
+// armedCheckInterval is how often a waiting brake checks it wasn't called off
+const armedCheckInterval = 5 * time.Second
+
+var (
+	flagAfter time.Duration
+	afterSet  bool // --after was given, even as 0 to skip the configured grace period
+)
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel [region...]",
+	Short: "Call off an armed brake before it fires",
+	Long: `Call off brakes armed with 'awsbreak --after' or by a scheduled pause with
+grace_period_minutes set in config.json. Anyone on the team can run it while
+the brake counts down; the team is notified that it was called off.
+
+Without regions, every armed brake is called off. A brake whose awsbreak
+process was killed before it fired is listed as never fired; cancel clears
+it, and 'awsbreak daemon' calls such brakes off on its own.`,
+	Example: `  awsbreak cancel
+  awsbreak cancel us-east-1`,
+	Run: runCancel,
+}
+
+func init() {
+	rootCmd.Flags().DurationVar(&flagAfter, "after", 0, "Arm the brake and pause after this long, e.g. 30m; 'awsbreak cancel' calls it off")
+	rootCmd.AddCommand(cancelCmd)
+}
+
+// checkAfterFlag rejects --after where it can't apply
+func checkAfterFlag(cmd *cobra.Command) {
+	afterSet = cmd.Flags().Changed("after")
+	if !afterSet {
+		return
+	}
+	switch {
+	case flagAfter < 0:
+		fmt.Println("❌ --after must not be negative")
+		exit(ExitConfigError)
+	case flagGo:
+		fmt.Println("❌ --after only applies to pausing")
+		exit(ExitConfigError)
+	case flagScanRegions || canaryRequested():
+		fmt.Println("❌ --after can't be combined with --scan-regions, --canary or --canary-tag")
+		exit(ExitConfigError)
+	}
+}
+
+// graceDelay returns how long a pause waits before braking: --after, or the
+// config's grace period. Dry runs preview at once.
+func graceDelay(cfg *models.Config) time.Duration {
+	switch {
+	case flagDryRun:
+		return 0
+	case afterSet:
+		return flagAfter
+	case flagScanRegions || canaryRequested():
+		return 0
+	}
+	return time.Duration(cfg.GracePeriodMinutes) * time.Minute
+}
+
+// armedPause arms the brake for a region, tells the team and counts down in
+// the foreground, then pauses unless someone called it off
+func armedPause(cfg *models.Config, region string, delay time.Duration) {
+	// SIGTERM calls the brake off too, so it isn't left armed with no
+	// process to fire it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	plan := selectedPlan(cfg)
+	if plan != nil && plan.Region != "" && flagRegion == "" {
+		region = plan.Region
+	}
+	if err := refuseSpectator("pause"); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	what := region
+	if plan != nil {
+		what = fmt.Sprintf("plan %s in %s", plan.Name, region)
+	}
+	if !confirm(fmt.Sprintf("\nArm the brake for %s to pause in %s? [y/N]: ", what, delay)) {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	now := time.Now()
+	armed := schedule.Armed{
+		Region:    region,
+		Plan:      flagPlan,
+		Requester: notify.Requester(),
+		Source:    "--after",
+		Armed:     now,
+		At:        now.Add(delay),
+	}
+	if err := armBrake(ctx, cfg, armed); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	fmt.Printf("\n⏳ Brake armed: %s pauses at %s\n", what, formatWhen(armed.At))
+	if cfg.Notifications == nil {
+		fmt.Println("   ⚠️  No notification channels are set up, so the team isn't warned.")
+	} else {
+		fmt.Println("   📣 The team has been notified.")
+	}
+	fmt.Println("   Anyone can call it off with 'awsbreak cancel'; Ctrl+C here does too.")
+
+	if !waitArmed(ctx, cfg, armed) {
+		return
+	}
+
+	// Fire with a fresh context so a late Ctrl+C doesn't cut the pause short
+	fireCtx := context.Background()
+	fmt.Println("\n🛑 Time's up - slamming the brakes!")
+	awsCfg, err := assumeRole(fireCtx, cfg, region)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+	var match func(models.Resource) bool
+	if plan != nil {
+		match = inPlan(plan)
+	}
+	results, err := brakeRegion(fireCtx, cfg, awsCfg, region, match)
+	displayResults(results)
+	if err != nil {
+		fmt.Printf("❌ Engine trouble: %v\n", err)
+		exit(ExitServiceError)
+	}
+	if countSuccessful(results) < len(results) {
+		exit(ExitPartialFailure)
+	}
+}
+
+// armedStore returns where armed brakes are kept: next to the snapshots, so
+// with S3 snapshot storage a brake armed on one machine can be called off
+// from any other
+func armedStore() (state.DocumentStore, error) {
+	store, err := snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+	if docs, ok := store.(state.DocumentStore); ok {
+		return docs, nil
+	}
+	return state.NewLocalStore(configMgr.GetConfigDir()), nil
+}
+
+// loadArmed reads the armed brakes from armedStore
+func loadArmed(ctx context.Context) ([]schedule.Armed, error) {
+	store, err := armedStore()
+	if err != nil {
+		return nil, err
+	}
+	return schedule.LoadArmed(ctx, store)
+}
+
+// updateArmed changes the armed brakes in armedStore, see schedule.UpdateArmed
+func updateArmed(ctx context.Context, update func([]schedule.Armed) []schedule.Armed) error {
+	store, err := armedStore()
+	if err != nil {
+		return err
+	}
+	return schedule.UpdateArmed(ctx, store, update)
+}
+
+// armBrake records an armed brake and notifies the team
+func armBrake(ctx context.Context, cfg *models.Config, armed schedule.Armed) error {
+	err := updateArmed(ctx, func(all []schedule.Armed) []schedule.Armed {
+		return append(all, armed)
+	})
+	if err != nil {
+		return err
+	}
+
+	notifyTeam(ctx, cfg, armed.Region, notify.NewArmed(armed.Region, armed.Requester, armed.At))
+	return nil
+}
+
+// waitArmed counts down to an armed brake. It returns true once the brake is
+// due, having taken it off the armed list, and false if it was called off
+// with 'awsbreak cancel' or Ctrl+C.
+func waitArmed(ctx context.Context, cfg *models.Config, armed schedule.Armed) bool {
+	countdown := func() string {
+		return fmt.Sprintf("Braking %s in %s...", armed.Region, time.Until(armed.At).Round(time.Second))
+	}
+	spin := startSpinner(countdown())
+	defer spin.halt()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastCheck := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			spin.halt()
+			fmt.Println("\n✋ Brake called off")
+			cancelArmed(context.Background(), cfg, []schedule.Armed{armed})
+			return false
+		case now := <-ticker.C:
+			if !now.Before(armed.At) {
+				spin.halt()
+				if !disarm(context.Background(), armed) {
+					fmt.Println("\n✋ The brake was called off with 'awsbreak cancel'")
+					return false
+				}
+				return true
+			}
+			if now.Sub(lastCheck) >= armedCheckInterval {
+				lastCheck = now
+				if !isArmed(ctx, armed) {
+					spin.halt()
+					fmt.Println("\n✋ The brake was called off with 'awsbreak cancel'")
+					return false
+				}
+			}
+			spin.update(countdown())
+		}
+	}
+}
+
+// isArmed reports whether a brake is still on the armed list
+func isArmed(ctx context.Context, armed schedule.Armed) bool {
+	all, err := loadArmed(ctx)
+	if err != nil {
+		// Don't call off a brake because the list can't be read right now
+		return true
+	}
+	return slices.ContainsFunc(all, armed.Same)
+}
+
+// disarm takes a brake off the armed list, returning false if it was no
+// longer there
+func disarm(ctx context.Context, armed schedule.Armed) bool {
+	store, err := armedStore()
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return true
+	}
+	found, err := schedule.Disarm(ctx, store, armed)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return true
+	}
+	return found
+}
+
+// claimArmed marks a brake as firing for this process, returning false if it
+// was called off or another daemon is already firing it
+func claimArmed(ctx context.Context, armed schedule.Armed) bool {
+	store, err := armedStore()
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return false
+	}
+	owner := fmt.Sprintf("%s (pid %d)", notify.Requester(), os.Getpid())
+	claimed, err := schedule.Claim(ctx, store, armed, owner, time.Now())
+	if err != nil {
+		// Don't fire a brake that may have been called off
+		log.Printf("⚠️  %v", err)
+		return false
+	}
+	return claimed
+}
+
+// releaseArmed drops this process's claim on a brake that failed to fire
+func releaseArmed(ctx context.Context, armed schedule.Armed) {
+	store, err := armedStore()
+	if err == nil {
+		err = schedule.Release(ctx, store, armed)
+	}
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+}
+
+func runCancel(cmd *cobra.Command, args []string) {
+	cfg := loadConfigOrExit()
+
+	all, err := loadArmed(context.Background())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	var selected []schedule.Armed
+	firing := 0
+	for _, a := range all {
+		if len(args) > 0 && !slices.Contains(args, a.Region) {
+			continue
+		}
+		if !a.Claimable(time.Now()) {
+			fmt.Printf("   ⚠️  %s is already being paused and can't be called off\n", a.Region)
+			firing++
+			continue
+		}
+		selected = append(selected, a)
+	}
+	if len(selected) == 0 && firing > 0 {
+		return
+	}
+	if len(selected) == 0 {
+		fmt.Println("✅ No brake is armed - nothing to call off.")
+		return
+	}
+
+	now := time.Now()
+	for _, a := range selected {
+		if a.Abandoned(now) {
+			fmt.Printf("   • %-16s armed by %s (%s), never fired - its awsbreak process is gone\n", a.Region, a.Requester, a.Source)
+			continue
+		}
+		fmt.Printf("   • %-16s armed by %s (%s), pauses %s\n", a.Region, a.Requester, a.Source, formatWhen(a.At))
+	}
+	if flagDryRun {
+		fmt.Println("\n👀 DRY RUN - Nothing called off")
+		return
+	}
+
+	cancelled := cancelArmed(context.Background(), cfg, selected)
+	fmt.Printf("\n✋ Called off %d armed brake(s)\n", len(cancelled))
+}
+
+// cancelArmed takes brakes off the armed list and tells the team, returning
+// the ones it called off. Brakes that started firing in the meantime stay.
+func cancelArmed(ctx context.Context, cfg *models.Config, brakes []schedule.Armed) []schedule.Armed {
+	var cancelled []schedule.Armed
+	err := updateArmed(ctx, func(all []schedule.Armed) []schedule.Armed {
+		cancelled = nil
+		return slices.DeleteFunc(all, func(a schedule.Armed) bool {
+			if !a.Claimable(time.Now()) || !slices.ContainsFunc(brakes, a.Same) {
+				return false
+			}
+			cancelled = append(cancelled, a)
+			return true
+		})
+	})
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return nil
+	}
+
+	for _, a := range cancelled {
+		notifyTeam(ctx, cfg, a.Region, notify.NewCancelled(a.Region, a.Requester, a.At))
+	}
+	return cancelled
+}
+
+// notifyTeam sends a message through the configured notification channels
+func notifyTeam(ctx context.Context, cfg *models.Config, region string, s notify.Summary) {
+	if cfg.Notifications == nil {
+		return
+	}
+	awsCfg, err := assumeRole(ctx, cfg, region)
+	if err != nil {
+		fmt.Printf("⚠️  Not notifying the team: %v\n", err)
+		return
+	}
+	notifiers, err := notify.FromConfig(cfg.Notifications, awsCfg)
+	if err != nil {
+		fmt.Printf("⚠️  Using the built-in notification message: %v\n", err)
+	}
+	if err := notify.Send(ctx, notifiers, s); err != nil {
+		fmt.Printf("⚠️  Failed to notify the team: %v\n", err)
+	}
+}
+
+// armScheduled arms a scheduled pause for the daemon to fire after the
+// grace period
+func armScheduled(ctx context.Context, cfg *models.Config, s models.Schedule, region string, grace time.Duration) error {
+	now := time.Now()
+	armed := schedule.Armed{
+		Region:    region,
+		Requester: notify.Requester(),
+		Source:    "schedule " + s.Name,
+		Armed:     now,
+		At:        now.Add(grace),
+		Daemon:    true,
+	}
+	if err := armBrake(ctx, cfg, armed); err != nil {
+		return err
+	}
+	log.Printf("⏳ %s: brake armed in %s, pausing at %s unless called off", s.Name, region, formatWhen(armed.At))
+	return nil
+}
+
+// fireArmed pauses the regions of daemon-armed brakes that are due. Brakes
+// that fail are retried on the next tick.
+func fireArmed(ctx context.Context, cfg *models.Config, now time.Time) {
+	all, err := loadArmed(ctx)
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return
+	}
+
+	var abandoned []schedule.Armed
+	for _, a := range all {
+		if a.Abandoned(now) {
+			abandoned = append(abandoned, a)
+		}
+	}
+	if len(abandoned) > 0 {
+		// Their processes are gone, so they would never fire; tell the team
+		for _, a := range abandoned {
+			log.Printf("✋ Calling off the brake %s armed in %s: it never fired", a.Requester, a.Region)
+		}
+		cancelArmed(ctx, cfg, abandoned)
+	}
+
+	for _, a := range all {
+		if !a.Daemon || now.Before(a.At) || !a.Claimable(now) {
+			continue
+		}
+
+		log.Printf("🛑 Armed brake in %s (%s) is due", a.Region, a.Source)
+		awsCfg, err := assumeRole(ctx, cfg, a.Region)
+		if err != nil {
+			log.Printf("❌ Armed brake in %s: %v", a.Region, err)
+			continue
+		}
+		// Mark it firing first, so 'awsbreak cancel' can't call it off
+		// halfway through the pause. It is taken off the list only once the
+		// pause succeeds, and can be called off again after a failure.
+		if !claimArmed(ctx, a) {
+			continue
+		}
+		results, err := brakeRegion(ctx, cfg, awsCfg, a.Region, nil)
+		if err != nil {
+			log.Printf("❌ Armed brake in %s, retrying on the next tick: %v", a.Region, err)
+			releaseArmed(ctx, a)
+		} else {
+			disarm(ctx, a)
+		}
+		logResults("🛑 Paused", results)
+		daemonMetrics.RecordRun("pause", a.Region, time.Now(), err == nil)
+	}
+}
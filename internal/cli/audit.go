@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var (
+	flagAuditSnapshotDays int
+	flagAuditMinCost      float64
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Find idle resources that pausing can't stop",
+	Long: `Find resources that cost money while doing nothing and that the brakes
+can't pause: load balancers with no healthy targets, unattached EBS volumes,
+old snapshots, Global Accelerators with no healthy endpoints, stopped
+instances still paying for their volumes, and DynamoDB tables using little
+of their provisioned capacity or storing data nobody reads.
+
+The audit only reads. Findings are ranked by estimated monthly cost so you
+know what to delete by hand.
+
+Examples:
+  awsbreak audit
+  awsbreak audit --snapshot-age 180 --min-cost 5`,
+	Run: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().IntVar(&flagAuditSnapshotDays, "snapshot-age", 90, "Report snapshots older than N days")
+	auditCmd.Flags().Float64Var(&flagAuditMinCost, "min-cost", 1, "Hide findings costing less than this per month (USD)")
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	fmt.Println("\n🔍 AWSBREAK - Idle Resource Audit")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	if !checkConfiguration() {
+		fmt.Println("❌ No configuration found. Run setup first.")
+		exit(ExitConfigError)
+	}
+
+	_, region, awsCfg, err := connect(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	spin := startSpinner(fmt.Sprintf("Auditing %s...", region))
+	findings, err := services.NewAuditor(awsCfg).Audit(ctx, region, services.AuditOptions{
+		SnapshotAge: time.Duration(flagAuditSnapshotDays) * 24 * time.Hour,
+	})
+	spin.halt()
+
+	var partial *services.PartialError
+	if errors.As(err, &partial) {
+		for _, e := range partial.Errs {
+			fmt.Printf("⚠️  Skipped: %v\n", e)
+		}
+	} else if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitServiceError)
+	}
+
+	var shown []services.Finding
+	var total float64
+	for _, f := range findings {
+		if f.MonthlyCost >= flagAuditMinCost {
+			shown = append(shown, f)
+			total += f.MonthlyCost
+		}
+	}
+
+	if len(shown) == 0 {
+		fmt.Printf("✅ Nothing idle found in %s.\n", region)
+		return
+	}
+
+	fmt.Printf("   %d idle resource(s) in %s, most expensive first\n\n", len(shown), region)
+	t := newTable(
+		column{title: "KIND"},
+		column{title: "RESOURCE", flex: true},
+		column{title: "MONTHLY", right: true},
+		column{title: "WHY", flex: true},
+	)
+	for _, f := range shown {
+		id := f.ID
+		if f.Name != "" && f.Name != f.ID {
+			id = fmt.Sprintf("%s (%s)", f.ID, f.Name)
+		}
+		t.addRow("", strings.ReplaceAll(f.Kind, "_", " "), id, fmt.Sprintf("$%.2f", f.MonthlyCost), f.Reason)
+	}
+	t.print()
+
+	fmt.Println()
+	fmt.Printf("💰 About $%.2f/month in idle resources. Delete what you're sure is unused.\n", total)
+	if len(shown) < len(findings) {
+		fmt.Printf("   %d cheaper finding(s) hidden by --min-cost.\n", len(findings)-len(shown))
+	}
+}
@@ -0,0 +1,445 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/events"
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/notify"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+// brakeRegion discovers and pauses every actionable resource in a region
+// without prompting, or only those match accepts when it isn't nil.
+// Report-only, protected and managed resources and databases with live
+// connections are always skipped; this is the unattended path used by the daemon and web UI.
+// A pause that ran is followed by the verification sweep.
+func brakeRegion(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string, match func(models.Resource) bool) ([]models.OperationResult, error) {
+	return brakeRegionInto(ctx, cfg, awsCfg, region, match, "")
+}
+
+// brakeRegionInto is brakeRegion recording what it pauses in an existing
+// snapshot, or a new one when snapshotID is empty
+func brakeRegionInto(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string, match func(models.Resource) bool, snapshotID string) ([]models.OperationResult, error) {
+	opts, err := orchestratorOptions()
+	if err != nil {
+		return nil, err
+	}
+	cache := newInventoryCache(services.NewOrchestrator(awsCfg, opts))
+	view, err := cache.Get(ctx, region, inventory.RefreshAlways)
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+
+	daemonMetrics.SetBurn(region, view.Resources)
+
+	for _, s := range view.Discovery.Failed() {
+		log.Printf("⚠️  %s discovery failed in %s: %s", s.ServiceType, region, s.Error)
+	}
+	for _, s := range view.Discovery.Partial() {
+		log.Printf("⚠️  %s discovery incomplete in %s: %s", s.ServiceType, region, strings.Join(s.Warnings, "; "))
+	}
+
+	resources, _ := splitReportOnly(view.Resources)
+	if match != nil {
+		resources = filterResources(resources, match)
+	}
+
+	resources, protected := services.NewGuard(cfg.Protection).Split(resources)
+	for _, p := range protected {
+		log.Printf("🛡️  Skipping %s: %s", p.Resource.ResourceID, p.Reason)
+	}
+	for _, r := range resources {
+		if by := services.ManagedBy(r); by != "" {
+			log.Printf("🏗️  %s is managed by %s; pausing it shows up as drift", r.ResourceID, by)
+		}
+	}
+
+	checks := services.NewConnectionChecker(awsCfg, cfg.ConnectionThreshold).CheckAll(ctx, resources)
+	for _, c := range checks {
+		if c.Blocked {
+			log.Printf("🚦 Skipping %s: %.0f connections in the last hour", c.Resource.ResourceID, c.Connections)
+		}
+	}
+	resources = services.FilterBlocked(resources, checks)
+
+	if len(resources) == 0 {
+		log.Printf("✅ Nothing to pause in %s", region)
+		return nil, nil
+	}
+
+	entry := &journal.Entry{Operation: "pause", Region: region, Resources: resources, Discovery: view.LiveTook(), SnapshotID: snapshotID}
+	results, err := runOperation(ctx, awsCfg, entry)
+	if err == nil {
+		run := runSummary("pause", results, entry)
+		log.Printf("⏱️  Pause in %s %s", region, run.Efficiency())
+		if run.DiscoveryOverhead() {
+			log.Printf("⚠️  Discovery in %s took longer than the pause to stop $%.2f/hour; consider pausing less often", region, run.HourlyRate())
+		}
+		logSweep(ctx, cfg, awsCfg, region)
+	}
+	return results, err
+}
+
+// hasTag returns a matcher for resources with a tag, given as key or key=value
+func hasTag(spec string) func(models.Resource) bool {
+	key, value, hasValue := strings.Cut(spec, "=")
+	return func(r models.Resource) bool {
+		v, ok := r.Tags[key]
+		return ok && (!hasValue || v == value)
+	}
+}
+
+// filterResources returns the resources match accepts
+func filterResources(resources []models.Resource, match func(models.Resource) bool) []models.Resource {
+	var kept []models.Resource
+	for _, r := range resources {
+		if match(r) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// releaseRegion resumes the resources in the latest pending snapshot for a region
+func releaseRegion(ctx context.Context, awsCfg aws.Config, region string) ([]models.OperationResult, error) {
+	snapshots, err := snapshotManager()
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := snapshots.Latest(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	var resources []models.Resource
+	if snapshot != nil {
+		resources = filterResources(snapshot.PendingResources(), serviceSelected)
+	}
+	if len(resources) == 0 {
+		log.Printf("✅ Nothing parked in %s", region)
+		return nil, nil
+	}
+
+	return runOperation(ctx, awsCfg, &journal.Entry{
+		Operation:  "resume",
+		Region:     region,
+		SnapshotID: snapshot.SnapshotID,
+		Resources:  resources,
+	})
+}
+
+// runOperation journals and executes a pause or resume. If the process dies
+// part way through, the journal entry lets recoverJournal finish the run.
+func runOperation(ctx context.Context, awsCfg aws.Config, entry *journal.Entry) ([]models.OperationResult, error) {
+	if err := refuseSpectator(entry.Operation); err != nil {
+		return nil, err
+	}
+	j := journal.NewJournal(configMgr.GetConfigDir())
+	if err := j.Begin(entry); err != nil {
+		return nil, err
+	}
+
+	started := []events.Event{{
+		Time:      entry.Started,
+		Type:      events.RunStarted,
+		Operation: entry.Operation,
+		Region:    entry.Region,
+		Resources: len(entry.Resources),
+		Duration:  entry.Discovery,
+		Snapshot:  entry.SnapshotID,
+	}}
+	// Only a live discovery found the resources during this run; cached or
+	// snapshot resources are covered by RunStarted
+	if entry.Discovery > 0 {
+		for _, r := range entry.Resources {
+			e := events.ForResource(events.ResourceDiscovered, r)
+			e.Time = entry.Started
+			started = append(started, e)
+		}
+	}
+	recordEvents(entry, started...)
+
+	return completeOperation(ctx, awsCfg, j, entry)
+}
+
+// recordEvents appends to a run's event log. A failed write is logged; it
+// never fails the run.
+func recordEvents(entry *journal.Entry, evs ...events.Event) {
+	if err := events.NewLog(configMgr.GetConfigDir(), entry.ID).Append(evs...); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+}
+
+// observeStates discovers the services a run touched again and records the
+// state each successfully braked resource is seen in. Discovery only finds
+// resources that are running, so a paused resource is observed by its absence;
+// a resumed one that isn't up yet, or a service that can't be read, records
+// nothing.
+func observeStates(ctx context.Context, awsCfg aws.Config, entry *journal.Entry, results []models.OperationResult) {
+	var touched []models.ServiceType
+	for _, r := range results {
+		if r.Success && !slices.Contains(touched, r.Resource.ServiceType) {
+			touched = append(touched, r.Resource.ServiceType)
+		}
+	}
+	if len(touched) == 0 {
+		return
+	}
+
+	opts, err := orchestratorOptions()
+	if err != nil {
+		return
+	}
+	opts.Services = touched
+	running, report, err := services.NewOrchestrator(awsCfg, opts).DiscoverAll(ctx, entry.Region)
+	if err != nil {
+		log.Printf("⚠️  Could not observe the states %s left: %v", entry.Operation, err)
+		return
+	}
+	unread := make(map[models.ServiceType]bool)
+	for _, s := range report.Services {
+		if s.Error != "" || len(s.Warnings) > 0 {
+			unread[s.ServiceType] = true
+		}
+	}
+
+	var observed []events.Event
+	for _, r := range results {
+		if !r.Success || unread[r.Resource.ServiceType] {
+			continue
+		}
+		e := events.ForResource(events.StateReached, r.Resource)
+		i := slices.IndexFunc(running, r.Resource.SameAs)
+		switch {
+		case i >= 0:
+			e.State = running[i].CurrentState
+		case entry.Operation == "pause":
+			e.State = models.StatePaused
+		default:
+			continue
+		}
+		observed = append(observed, e)
+	}
+	recordEvents(entry, observed...)
+}
+
+// completeOperation runs the resources in a journal entry that have no result
+// yet, then records the whole run in the ledger and snapshots
+func completeOperation(ctx context.Context, awsCfg aws.Config, j *journal.Journal, entry *journal.Entry) ([]models.OperationResult, error) {
+	if err := refuseSpectator(entry.Operation); err != nil {
+		return nil, err
+	}
+	opts, err := orchestratorOptions()
+	if err != nil {
+		return nil, err
+	}
+	stopHeartbeat := j.Heartbeat(entry)
+	defer stopHeartbeat()
+	opts.OnIssue = func(r models.Resource, operation string) {
+		if err := j.Issue(entry, r); err != nil {
+			log.Printf("⚠️  Failed to journal %s: %v", r.ResourceID, err)
+		}
+		issued := events.PauseIssued
+		if operation == "resume" {
+			issued = events.ResumeIssued
+		}
+		recordEvents(entry, events.ForResource(issued, r))
+	}
+	opts.OnResult = func(result models.OperationResult) {
+		if err := j.Record(entry, result); err != nil {
+			log.Printf("⚠️  Failed to journal %s: %v", result.Resource.ResourceID, err)
+		}
+
+		e := events.ForResource(events.OperationAccepted, result.Resource)
+		e.Retries = result.Retries
+		e.Duration = result.Duration
+		if !result.Success {
+			e.Type = events.OperationFailed
+			e.Error = result.Error
+		}
+		recordEvents(entry, e)
+	}
+	orchestrator := services.NewOrchestrator(awsCfg, opts)
+
+	if entry.Operation == "pause" {
+		_, err = orchestrator.PauseAll(ctx, entry.Remaining())
+	} else {
+		_, err = orchestrator.ResumeAll(ctx, entry.Remaining())
+	}
+	newInventoryCache(orchestrator).Invalidate(entry.Region)
+
+	// entry.Results holds results from any earlier, interrupted attempt too
+	results := entry.Results
+	// An interrupted run still records what it did
+	saveCtx := context.WithoutCancel(ctx)
+
+	switch {
+	case entry.Operation == "pause" && entry.SnapshotID == "":
+		// Keep the snapshot ID with the entry so a retry of any failures
+		// adds to the same snapshot
+		if entry.SnapshotID = saveSnapshot(saveCtx, entry.Region, results); entry.SnapshotID != "" {
+			if err := j.Update(entry); err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}
+	case entry.SnapshotID != "":
+		var snapshot *models.AccountSnapshot
+		snapshots, snapErr := snapshotManager()
+		if snapErr == nil {
+			snapshot, snapErr = snapshots.Load(saveCtx, entry.SnapshotID)
+		}
+		if snapErr == nil {
+			if entry.Operation == "pause" {
+				snapErr = snapshots.AddResults(saveCtx, snapshot, results)
+			} else {
+				snapErr = snapshots.MarkResumed(saveCtx, snapshot, results)
+			}
+		}
+		if snapErr != nil {
+			log.Printf("⚠️  Failed to update snapshot: %v", snapErr)
+		}
+	}
+	entry.Finished = time.Now()
+	if !entry.Batched {
+		recordRun(entry, results)
+	}
+	observeStates(saveCtx, awsCfg, entry, results)
+
+	completed := events.Event{
+		Time:      entry.Finished,
+		Type:      events.RunCompleted,
+		Operation: entry.Operation,
+		Region:    entry.Region,
+		Resources: len(entry.Resources),
+		Duration:  entry.Duration(),
+		Snapshot:  entry.SnapshotID,
+	}
+	for _, r := range results {
+		if r.Success {
+			completed.Succeeded++
+		} else {
+			completed.Failed++
+		}
+	}
+	if err != nil {
+		completed.Error = err.Error()
+	}
+	recordEvents(entry, completed)
+	if _, err := events.Prune(configMgr.GetConfigDir(), time.Now().Add(-events.Retention)); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	daemonMetrics.RecordResults(entry.Operation, results)
+	if !entry.Batched {
+		notifyRun(ctx, awsCfg, entry.Operation, entry.Region, results)
+		fileRecurringFailures(ctx, entry, results)
+	}
+
+	if err != nil {
+		return results, fmt.Errorf("%s failed: %w", entry.Operation, err)
+	}
+
+	if err := j.Finish(entry); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	return results, nil
+}
+
+// notifyRun sends a summary of a run to the configured notification channels.
+// Delivery problems are logged; they never fail the run.
+func notifyRun(ctx context.Context, awsCfg aws.Config, operation, region string, results []models.OperationResult) {
+	if len(results) == 0 || !configMgr.Exists() {
+		return
+	}
+	cfg, err := configMgr.Load()
+	if err != nil {
+		return
+	}
+
+	notifiers, err := notify.FromConfig(cfg.Notifications, awsCfg)
+	if err != nil {
+		log.Printf("⚠️  Using the built-in notification message: %v", err)
+	}
+	if len(notifiers) == 0 {
+		return
+	}
+	if err := notify.Send(ctx, notifiers, notify.NewSummary(operation, region, results)); err != nil {
+		log.Printf("⚠️  Failed to send notification: %v", err)
+	}
+}
+
+// recoverJournal finishes operations left behind by an interrupted run so no
+// environment stays half paused and every paused resource ends up in a snapshot
+func recoverJournal(ctx context.Context, cfg *models.Config) error {
+	j := journal.NewJournal(configMgr.GetConfigDir())
+	entries, err := j.Pending()
+	if err != nil {
+		return err
+	}
+	entries, unselected := entriesSelected(entries)
+	for _, entry := range unselected {
+		log.Printf("⏭️  Leaving interrupted %s %s to a run that includes all its services", entry.Operation, entry.ID)
+	}
+
+	for _, entry := range entries {
+		log.Printf("🩹 Finishing interrupted %s in %s from %s (%d of %d resources left)",
+			entry.Operation, entry.Region, formatWhen(entry.Started),
+			len(entry.Remaining()), len(entry.Resources))
+
+		awsCfg, err := entryConfig(ctx, cfg, entry)
+		if err != nil {
+			return err
+		}
+
+		recordEvents(entry, events.Event{
+			Type:      events.RunRecovered,
+			Operation: entry.Operation,
+			Region:    entry.Region,
+			Resources: len(entry.Remaining()),
+		})
+		results, err := completeOperation(ctx, awsCfg, j, entry)
+		if err != nil {
+			return err
+		}
+		logResults("🩹 Recovered", results)
+	}
+
+	return nil
+}
+
+// entriesSelected splits journal entries into those whose unfinished
+// resources all belong to selected services and those that don't. An entry
+// is finished as a whole, so one that still has resources of a service left
+// out with --services or --skip-services is left for a run that includes it.
+func entriesSelected(entries []*journal.Entry) (selected, unselected []*journal.Entry) {
+	for _, e := range entries {
+		if len(filterResources(e.Remaining(), serviceSelected)) == len(e.Remaining()) {
+			selected = append(selected, e)
+		} else {
+			unselected = append(unselected, e)
+		}
+	}
+	return selected, unselected
+}
+
+// entryConfig returns credentials for the account a journal entry ran in
+func entryConfig(ctx context.Context, cfg *models.Config, entry *journal.Entry) (aws.Config, error) {
+	if entry.RoleARN == "" {
+		return assumeRole(ctx, cfg, entry.Region)
+	}
+
+	base, err := orgBaseConfig(ctx, entry.Region)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	return auth.AccountConfig(base, entry.RoleARN), nil
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/cost"
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+// maxBudgetSuggestions caps how many resources an over-budget check suggests pausing
+const maxBudgetSuggestions = 5
+
+var flagThreshold float64
+
+func init() {
+	rootCmd.Flags().Float64Var(&flagThreshold, "threshold", 0, "With --check, exit non-zero when projected monthly spend exceeds this many dollars")
+}
+
+// checkThresholdFlag rejects --threshold outside --check
+func checkThresholdFlag() {
+	if flagThreshold != 0 && !flagCheck {
+		fmt.Println("❌ --threshold only applies to --check")
+		exit(ExitConfigError)
+	}
+}
+
+// checkBudget shows progress against the monthly spend target and compares
+// the region's burn rate with the monthly budget from --threshold or config.
+// Over budget it prints a warning with the resources to pause and exits with
+// ExitOverBudget, so it can drive shell prompts and cron mail. Without a
+// budget or target it does nothing.
+func checkBudget() {
+	limit := flagThreshold
+	if limit < 0 {
+		fmt.Println("❌ --threshold must be a positive amount of dollars")
+		exit(ExitConfigError)
+	}
+	if configMgr == nil || !configMgr.Exists() {
+		if limit > 0 {
+			fmt.Println("❌ No configuration found to check the budget against. Run setup first.")
+			exit(ExitConfigError)
+		}
+		return
+	}
+
+	cfg, err := configMgr.Load()
+	if err != nil {
+		fmt.Printf("❌ Could not check the budget: %v\n", err)
+		exit(ExitConfigError)
+	}
+	var target float64
+	if cfg.Budget != nil {
+		target = cfg.Budget.MonthlyTarget
+		if limit == 0 {
+			limit = cfg.Budget.MonthlyLimit
+		}
+	}
+	if limit <= 0 && target <= 0 {
+		return
+	}
+
+	ctx := context.Background()
+	_, region, awsCfg, err := connect(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	if target > 0 {
+		showTargetProgress(ctx, awsCfg, region, target)
+	}
+	if limit <= 0 {
+		return
+	}
+
+	// A recent inventory is good enough for a budget check and keeps
+	// prompts fast
+	view, err := newInventoryCache(services.NewOrchestrator(awsCfg, mustOrchestratorOptions())).Get(ctx, region, inventory.RefreshIfStale)
+	if err != nil {
+		fmt.Printf("❌ Discovery failed: %v\n", err)
+		exit(ExitServiceError)
+	}
+
+	burn := calculateMonthlyCost(view.Resources)
+	fmt.Println()
+	fmt.Printf("💰 Budget:     $%.2f/month projected in %s, of $%.2f (%.0f%%)\n", burn, region, limit, burn/limit*100)
+	displayDiscoveryReport(view.Discovery)
+
+	if burn <= limit {
+		fmt.Println("   ✅ Within budget")
+		return
+	}
+
+	over := burn - limit
+	fmt.Println()
+	fmt.Printf("🚨 OVER BUDGET - projected spend is $%.2f/month over the $%.2f budget\n", over, limit)
+	suggestPauses(cfg, view.Resources, over)
+	exit(ExitOverBudget)
+}
+
+// suggestPauses lists the costliest resources awsbreak may pause, stopping
+// once pausing them would save at least target per month
+func suggestPauses(cfg *models.Config, resources []models.Resource, target float64) {
+	candidates, _ := splitReportOnly(resources)
+	candidates, _ = services.NewGuard(cfg.Protection).Split(candidates)
+	if len(candidates) == 0 {
+		fmt.Println("   Nothing awsbreak can pause would bring this down.")
+		return
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].CostPerHour > candidates[j].CostPerHour
+	})
+
+	var picked []models.Resource
+	var saved float64
+	for _, r := range candidates {
+		if saved >= target || len(picked) == maxBudgetSuggestions {
+			break
+		}
+		picked = append(picked, r)
+		saved += r.CostPerHour * 24 * 30
+	}
+
+	fmt.Println()
+	if saved >= target {
+		fmt.Println("💡 Pausing these would get you under budget:")
+	} else {
+		fmt.Println("💡 The biggest savings available:")
+	}
+	for _, r := range picked {
+		cost := fmt.Sprintf("$%.2f/month", r.CostPerHour*24*30)
+		fmt.Printf("     - %s %s (%s)\n", r.ServiceType, consoleLink(fitLine(r.ResourceID, 10+len(r.ServiceType)+len(cost)), r), cost)
+	}
+	fmt.Printf("   Saving ~$%.2f/month. Run 'awsbreak' to pause them.\n", saved)
+}
+
+// showTargetProgress prints month-to-date spend against the monthly target
+func showTargetProgress(ctx context.Context, awsCfg aws.Config, region string, target float64) {
+	progress, err := cost.NewExplorer(awsCfg).MonthToDate(ctx, region, target, time.Now())
+	fmt.Println()
+	switch {
+	case err != nil:
+		fmt.Printf("⚠️  Target:     could not read this month's spend: %v\n", err)
+		if services.IsAccessDenied(err) {
+			fmt.Println("   The role needs ce:GetCostAndUsage - see 'awsbreak role check'.")
+		}
+	case progress.Days == 0:
+		fmt.Printf("🎯 Target:     $%.2f this month - no spend recorded yet\n", target)
+	case progress.OnTrack():
+		fmt.Printf("🎯 Target:     $%.2f of $%.2f this month, on track (projected $%.2f)\n",
+			progress.Spent, target, progress.Projected)
+	default:
+		fmt.Printf("⚠️  Target:     $%.2f of $%.2f this month, at risk (projected $%.2f)\n",
+			progress.Spent, target, progress.Projected)
+	}
+}
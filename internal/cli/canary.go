@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+const (
+	// defaultSoak is how long a canary stays paused before the rest follow
+	defaultSoak = 10 * time.Minute
+	// soakPollInterval is how often alarms are checked during the soak
+	soakPollInterval = 30 * time.Second
+)
+
+var (
+	flagCanary    string
+	flagCanaryTag string
+	flagSoak      time.Duration
+)
+
+func init() {
+	rootCmd.Flags().StringVar(&flagCanary, "canary", "", "Pause this percentage of resources first, e.g. 10%, and soak before the rest")
+	rootCmd.Flags().StringVar(&flagCanaryTag, "canary-tag", "", "Pause resources with this tag (key or key=value) first, and soak before the rest")
+	rootCmd.Flags().DurationVar(&flagSoak, "soak", 0, "How long to watch alarms after the canary pause (default 10m)")
+}
+
+// canaryRequested reports whether the pause should start with a canary subset
+func canaryRequested() bool {
+	return flagCanary != "" || flagCanaryTag != ""
+}
+
+// checkCanaryFlags rejects canary flags that can't be used together or with --go
+func checkCanaryFlags() {
+	if !canaryRequested() {
+		return
+	}
+	switch {
+	case flagGo:
+		fmt.Println("❌ --canary and --canary-tag only apply to pausing")
+		exit(ExitConfigError)
+	case flagCanary != "" && flagCanaryTag != "":
+		fmt.Println("❌ Use either --canary or --canary-tag, not both")
+		exit(ExitConfigError)
+	}
+	if flagCanary != "" {
+		if _, err := parseCanaryPercent(flagCanary); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+	}
+}
+
+// parseCanaryPercent parses "10%" or "10"
+func parseCanaryPercent(s string) (float64, error) {
+	p, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || p <= 0 || p >= 100 {
+		return 0, fmt.Errorf("invalid --canary %q: use a percentage between 0 and 100, e.g. 10%%", s)
+	}
+	return p, nil
+}
+
+// splitCanary picks the canary subset. With --canary-tag it is every resource
+// with the tag; with --canary it is the given share of resources, taken in
+// turn from each service so every service is exercised.
+func splitCanary(resources []models.Resource) (canary, rest []models.Resource) {
+	if flagCanaryTag != "" {
+		tagged := hasTag(flagCanaryTag)
+		for _, r := range resources {
+			if tagged(r) {
+				canary = append(canary, r)
+			} else {
+				rest = append(rest, r)
+			}
+		}
+		return canary, rest
+	}
+
+	percent, _ := parseCanaryPercent(flagCanary)
+	want := int(math.Ceil(float64(len(resources)) * percent / 100))
+
+	// Group by service, cheapest first within each, then deal round-robin
+	var order []models.ServiceType
+	byService := make(map[models.ServiceType][]int)
+	for i, r := range resources {
+		if _, ok := byService[r.ServiceType]; !ok {
+			order = append(order, r.ServiceType)
+		}
+		byService[r.ServiceType] = append(byService[r.ServiceType], i)
+	}
+	for _, idx := range byService {
+		sort.SliceStable(idx, func(a, b int) bool {
+			return resources[idx[a]].CostPerHour < resources[idx[b]].CostPerHour
+		})
+	}
+
+	picked := make(map[int]bool)
+	for round := 0; len(picked) < want; round++ {
+		for _, st := range order {
+			if idx := byService[st]; round < len(idx) && len(picked) < want {
+				picked[idx[round]] = true
+			}
+		}
+	}
+
+	for i, r := range resources {
+		if picked[i] {
+			canary = append(canary, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	return canary, rest
+}
+
+// soakDuration resolves the soak time from --soak, then config
+func soakDuration(cfg *models.Config) time.Duration {
+	if flagSoak > 0 {
+		return flagSoak
+	}
+	if cfg.Canary != nil && cfg.Canary.SoakMinutes > 0 {
+		return time.Duration(cfg.Canary.SoakMinutes) * time.Minute
+	}
+	return defaultSoak
+}
+
+// pauseWithCanary pauses a canary subset, watches CloudWatch alarms for the
+// soak time, then pauses the rest into the same snapshot. If an alarm starts
+// firing the user can roll the canary back instead.
+func pauseWithCanary(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string, resources []models.Resource, discovery time.Duration) {
+	canary, rest := splitCanary(resources)
+	if len(canary) == 0 {
+		fmt.Printf("❌ No selected resources are tagged %s - nothing to use as a canary.\n", flagCanaryTag)
+		exit(ExitGeneralError)
+	}
+
+	fmt.Println()
+	fmt.Printf("🐤 CANARY - Pausing %d of %d resources first...\n", len(canary), len(resources))
+
+	var prefixes []string
+	if cfg.Canary != nil {
+		prefixes = cfg.Canary.AlarmPrefixes
+	}
+	watcher := services.NewAlarmWatcher(awsCfg, prefixes)
+	if err := watcher.Baseline(ctx); err != nil {
+		fmt.Printf("⚠️  Alarms won't be checked during the soak: %v\n", err)
+		watcher = nil
+	}
+
+	entry := &journal.Entry{Operation: "pause", Region: region, Resources: canary, Discovery: discovery}
+	results, err := runOperation(ctx, awsCfg, entry)
+	if err != nil {
+		fmt.Printf("❌ Brake failure: %v\n", err)
+	}
+	displayResults(results)
+	if err == nil {
+		results = triageFailures(ctx, awsCfg, entry, results)
+	}
+	noteFailures(results, err)
+
+	if len(rest) == 0 {
+		finishPause(ctx, cfg, awsCfg, region, results, resources, entry)
+		return
+	}
+
+	if countSuccessful(results) < len(results) && !confirm(fmt.Sprintf("\nSome canary resources failed. Continue with the remaining %d? [y/N]: ", len(rest))) {
+		stopAfterCanary(entry, results, canary)
+		return
+	}
+
+	firing := soak(ctx, watcher, soakDuration(cfg))
+	if len(firing) > 0 {
+		fmt.Printf("\n🚨 %d alarm(s) started firing after the canary pause:\n", len(firing))
+		for _, name := range firing {
+			fmt.Printf("   - %s\n", fitLine(name, 5))
+		}
+		if confirm("\nRoll back the canary? [y/N]: ") {
+			rollbackCanary(ctx, awsCfg, entry)
+			return
+		}
+	}
+
+	if !confirm(fmt.Sprintf("\nContinue with the remaining %d resources ($%.2f/month)? [y/N]: ", len(rest), calculateMonthlyCost(rest))) {
+		stopAfterCanary(entry, results, canary)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("🛑 BRAKES ENGAGED - Stopping the remaining resources...")
+
+	// Pausing into the canary's snapshot lets one --go restore everything
+	restEntry := &journal.Entry{
+		Operation:  "pause",
+		Region:     region,
+		SnapshotID: entry.SnapshotID,
+		Resources:  rest,
+	}
+	restResults, err := runOperation(ctx, awsCfg, restEntry)
+	if err != nil {
+		fmt.Printf("❌ Brake failure: %v\n", err)
+	}
+	displayResults(restResults)
+	if err == nil {
+		restResults = triageFailures(ctx, awsCfg, restEntry, restResults)
+	}
+	noteFailures(restResults, err)
+
+	finishPause(ctx, cfg, awsCfg, region, append(results, restResults...), resources, entry, restEntry)
+}
+
+// soak waits for d, polling alarms, and returns any that started firing.
+// It ends early when an alarm fires or on Ctrl-C.
+func soak(ctx context.Context, watcher *services.AlarmWatcher, d time.Duration) []string {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	fmt.Println()
+	if watcher == nil {
+		fmt.Printf("⏳ Soaking for %s (Ctrl-C to stop early)\n", d)
+	} else {
+		fmt.Printf("⏳ Soaking for %s while watching CloudWatch alarms (Ctrl-C to stop early)\n", d)
+	}
+
+	deadline := time.Now().Add(d)
+	spin := startSpinner("")
+	defer spin.halt()
+
+	ticker := time.NewTicker(soakPollInterval)
+	defer ticker.Stop()
+	countdown := time.NewTicker(time.Second)
+	defer countdown.Stop()
+
+	for {
+		left := time.Until(deadline).Round(time.Second)
+		if left <= 0 {
+			spin.println("✅ Soak complete - no new alarms")
+			return nil
+		}
+		spin.update(fmt.Sprintf("Soaking... %s left", left))
+
+		select {
+		case <-ctx.Done():
+			spin.println("⏹️  Soak stopped early")
+			return nil
+		case <-countdown.C:
+		case <-ticker.C:
+			if watcher == nil {
+				continue
+			}
+			firing, err := watcher.Firing(ctx)
+			if err != nil {
+				spin.println(fmt.Sprintf("⚠️  %v", err))
+				continue
+			}
+			if len(firing) > 0 {
+				return firing
+			}
+		}
+	}
+}
+
+// rollbackCanary resumes what the canary paused
+func rollbackCanary(ctx context.Context, awsCfg aws.Config, entry *journal.Entry) {
+	if entry.SnapshotID == "" {
+		fmt.Println("\n✅ Nothing was paused - nothing to roll back.")
+		return
+	}
+
+	snapshot, err := mustSnapshotManager().Load(ctx, entry.SnapshotID)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	fmt.Println()
+	fmt.Println("🟢 Rolling back the canary...")
+	results, err := runOperation(ctx, awsCfg, &journal.Entry{
+		Operation:  "resume",
+		Region:     entry.Region,
+		SnapshotID: entry.SnapshotID,
+		Resources:  snapshot.PendingResources(),
+	})
+	if err != nil {
+		fmt.Printf("❌ Rollback failure: %v\n", err)
+	}
+	displayResults(results)
+	noteFailures(results, err)
+
+	fmt.Println()
+	fmt.Printf("↩️  Rolled back %d canary resources. Nothing else was paused.\n", countSuccessful(results))
+}
+
+// stopAfterCanary leaves only the canary paused
+func stopAfterCanary(entry *journal.Entry, results []models.OperationResult, canary []models.Resource) {
+	fmt.Println()
+	fmt.Printf("🐤 Stopped after the canary: %d resources paused, saving ~$%.2f/month\n",
+		countSuccessful(results), calculateMonthlyCost(canary))
+	if entry.SnapshotID != "" {
+		fmt.Printf("   Run 'awsbreak --go --snapshot %s' to resume them.\n", entry.SnapshotID)
+	}
+}
+
+// finishPause prints the summary of a completed pause made of one or more
+// runs, then sweeps for anything still running
+func finishPause(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string, results []models.OperationResult, resources []models.Resource, runs ...*journal.Entry) {
+	fmt.Println()
+	fmt.Printf("🏁 Done! Stopped %d resources. Saving ~$%.2f/month\n",
+		countSuccessful(results), calculateMonthlyCost(resources))
+	displayEfficiency(runSummary("pause", results, runs...))
+	var paused []models.Resource
+	for _, r := range results {
+		if r.Success {
+			paused = append(paused, r.Resource)
+		}
+	}
+	displayRestartDeadlines(paused, time.Now())
+	fmt.Println("   Run 'awsbreak --resume' when you're ready to go again.")
+	verifySweep(ctx, cfg, awsCfg, region)
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+// capabilityOrchestrator is an orchestrator that is only asked how resources
+// brake under the current options. It only describes the managers, so no
+// credentials are needed.
+func capabilityOrchestrator() *services.Orchestrator {
+	opts, _ := orchestratorOptions()
+	return services.NewOrchestrator(aws.Config{}, opts)
+}
+
+// displayDestructive warns before a pause that deletes resources rather than
+// stopping them
+func displayDestructive(resources []models.Resource) {
+	orchestrator := capabilityOrchestrator()
+	counts := make(map[models.ServiceType]int)
+	for _, r := range resources {
+		if orchestrator.CapabilitiesOf(r).Destructive {
+			counts[r.ServiceType]++
+		}
+	}
+
+	for _, st := range sortedServices(counts) {
+		fmt.Printf("⚠️  %d %s resource(s) will be deleted and recreated on resume; their IDs may change\n", counts[st], st)
+	}
+}
+
+// displayRestartDeadlines warns about services AWS starts again on its own
+// when they stay paused too long, given when the resources were paused
+func displayRestartDeadlines(resources []models.Resource, paused time.Time) {
+	orchestrator := capabilityOrchestrator()
+	counts := make(map[models.ServiceType]int)
+	deadlines := make(map[models.ServiceType]time.Duration)
+	for _, r := range resources {
+		if deadline := orchestrator.CapabilitiesOf(r).RestartDeadline; deadline > 0 {
+			counts[r.ServiceType]++
+			deadlines[r.ServiceType] = deadline
+		}
+	}
+
+	for _, st := range sortedServices(counts) {
+		deadline := paused.Add(deadlines[st])
+		if time.Now().After(deadline) {
+			fmt.Printf("   ⚠️  AWS has restarted %d paused %s resource(s) on its own (due %s) - pause again\n", counts[st], st, formatWhen(deadline))
+			continue
+		}
+		fmt.Printf("   ⏰ AWS restarts %d paused %s resource(s) on its own at %s, and they bill again from then\n", counts[st], st, formatWhen(deadline))
+	}
+}
+
+// restarted reports whether AWS has started a paused resource again on its
+// own, so it no longer saves anything
+func restarted(orchestrator *services.Orchestrator, r models.Resource, paused time.Time) bool {
+	deadline := orchestrator.CapabilitiesOf(r).RestartDeadline
+	return deadline > 0 && time.Now().After(paused.Add(deadline))
+}
+
+// stillBilling totals what paused resources keep costing per month, by kind
+// of charge, with how many resources each kind covers
+func stillBilling(resources []models.Resource) (float64, map[string]float64, map[string]int) {
+	var total float64
+	byKind := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, r := range resources {
+		for _, c := range services.PausedCharges(r) {
+			total += c.MonthlyCost
+			byKind[c.Kind] += c.MonthlyCost
+			counts[c.Kind]++
+		}
+	}
+	return total, byKind, counts
+}
+
+// displayStillBilling shows what paused resources keep costing, such as the
+// disks of stopped instances, and what the pause really saves once that and
+// databases AWS restarted are taken off
+func displayStillBilling(resources []models.Resource, paused time.Time) {
+	total, byKind, counts := stillBilling(resources)
+
+	orchestrator := capabilityOrchestrator()
+	var stopped float64
+	var aurora, backups bool
+	for _, r := range resources {
+		if !restarted(orchestrator, r, paused) {
+			stopped += r.CostPerHour * 24 * 30
+		}
+		if r.ServiceType != models.ServiceRDS || services.AuroraServerless(r) != "" {
+			continue
+		}
+		if engine, _ := r.Metadata["engine"].(string); strings.HasPrefix(engine, "aurora") {
+			aurora = true
+		}
+		if days, _ := r.Metadata["backup_retention_days"].(float64); days > 0 {
+			backups = true
+		}
+	}
+
+	if total > 0 {
+		fmt.Printf("   💸 Still billing while paused: ~$%.2f/month\n", total)
+		for _, kind := range []string{services.ChargeVolumes, services.ChargeElasticIPs, services.ChargeStorage, services.ChargeLightsail} {
+			if byKind[kind] > 0 {
+				fmt.Printf("      • %s of %d resource(s): $%.2f/month\n", kind, counts[kind], byKind[kind])
+			}
+		}
+	}
+	if aurora {
+		fmt.Println("      • Aurora storage keeps billing by the GB stored (not estimated)")
+	}
+	if backups {
+		fmt.Printf("      • RDS backups beyond the free allowance bill $%.3f/GB-month (not estimated)\n", services.RDSBackupGBMonthCost)
+	}
+	if total > 0 || stopped > 0 {
+		fmt.Printf("   💰 Net saving: ~$%.2f/month ($%.2f stopped, less $%.2f still billing)\n", stopped-total, stopped, total)
+	}
+}
+
+// sortedServices returns the services in counts by name
+func sortedServices(counts map[models.ServiceType]int) []models.ServiceType {
+	types := make([]models.ServiceType, 0, len(counts))
+	for st := range counts {
+		types = append(types, st)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
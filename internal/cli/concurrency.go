@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var (
+	flagConcurrency          int
+	flagDiscoveryConcurrency int
+	flagServiceConcurrency   map[string]int
+	flagAdaptiveConcurrency  bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&flagConcurrency, "concurrency", 0, "Pauses and resumes to run at once (default 5)")
+	rootCmd.PersistentFlags().IntVar(&flagDiscoveryConcurrency, "discovery-concurrency", 0, "Services to discover at once (default 4)")
+	rootCmd.PersistentFlags().StringToIntVar(&flagServiceConcurrency, "service-concurrency", nil, "Per-service operation limits, e.g. rds=2,ec2=10")
+	rootCmd.PersistentFlags().BoolVar(&flagAdaptiveConcurrency, "adaptive-concurrency", false, "Reduce concurrency while AWS is throttling requests")
+}
+
+// concurrencyOptions combines the config file's concurrency settings, which
+// may be nil, with the command-line flags, which take precedence
+func concurrencyOptions(cfg *models.ConcurrencyConfig) services.Concurrency {
+	c := services.ConcurrencyFromConfig(cfg)
+	if flagConcurrency > 0 {
+		c.Operations = flagConcurrency
+	}
+	if flagDiscoveryConcurrency > 0 {
+		c.Discovery = flagDiscoveryConcurrency
+	}
+	if len(flagServiceConcurrency) > 0 {
+		perService := make(map[models.ServiceType]int)
+		for svc, n := range c.PerService {
+			perService[svc] = n
+		}
+		for svc, n := range flagServiceConcurrency {
+			perService[models.ServiceType(svc)] = n
+		}
+		c.PerService = perService
+	}
+	if flagAdaptiveConcurrency {
+		c.Adaptive = true
+	}
+	return c
+}
+
+// checkConcurrencyFlags rejects --service-concurrency limits below 1, which
+// would otherwise lift the service's cap entirely, and unknown service names
+func checkConcurrencyFlags() {
+	names := make([]string, 0, len(flagServiceConcurrency))
+	for svc := range flagServiceConcurrency {
+		names = append(names, svc)
+	}
+	sort.Strings(names)
+	for _, svc := range names {
+		if _, err := services.ParseServices([]string{svc}); err != nil {
+			fmt.Printf("❌ --service-concurrency: %v\n", err)
+			exit(ExitConfigError)
+		}
+		if n := flagServiceConcurrency[svc]; n < 1 {
+			fmt.Printf("❌ --service-concurrency %s=%d: the limit must be at least 1\n", svc, n)
+			exit(ExitConfigError)
+		}
+	}
+}
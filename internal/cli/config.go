@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage how the configuration is stored",
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt config.json with a key kept in the OS keychain",
+	Long: `Encrypt config.json at rest. It holds the role ARN and external ID, and
+webhook URLs and other secrets as they are configured.
+
+The file is encrypted with AES-256-GCM using a random key kept in the macOS
+Keychain, the Secret Service (GNOME Keyring or KWallet, through secret-tool)
+on Linux, or a DPAPI-protected file on Windows. Every command decrypts and
+re-encrypts it transparently. Systems without a keychain keep config.json in
+plaintext.
+
+A copy of the config directory can't be decrypted on another machine; move it
+with 'awsbreak export-state', which archives config.json decrypted under the
+archive's passphrase.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigEncryption(true)
+	},
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Store config.json in plaintext again",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigEncryption(false)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigEncryption(on bool) {
+	fmt.Println("\n🔐 AWSBREAK - Config Encryption")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	if !checkConfiguration() {
+		fmt.Println("❌ No configuration found. Run setup first.")
+		exit(ExitConfigError)
+	}
+	cfg, err := configMgr.Load()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	if configMgr.Encrypted() == on {
+		if on {
+			fmt.Printf("✅ config.json is already encrypted (key in %s)\n", configMgr.KeyStore())
+		} else {
+			fmt.Println("✅ config.json is already plaintext")
+		}
+		return
+	}
+
+	if err := configMgr.SetEncryption(on); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("   config.json stays in plaintext.")
+		exit(ExitConfigError)
+	}
+	if err := configMgr.Save(cfg); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	if on {
+		fmt.Printf("🔒 config.json encrypted (key in %s)\n", configMgr.KeyStore())
+	} else {
+		fmt.Println("🔓 config.json is plaintext again")
+	}
+}
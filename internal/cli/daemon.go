@@ -0,0 +1,461 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/cost"
+	"github.com/aicoder2009/aws-hit-breaks/internal/metrics"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/schedule"
+)
+
+// daemonTick is how often the daemon checks for due schedules
+const daemonTick = 30 * time.Second
+
+// digestAction is the internal schedule action used for the weekly digest
+const digestAction = "digest"
+
+// maxCatchUp is how far back a restarted daemon looks for missed schedules
+const maxCatchUp = 15 * time.Minute
+
+var (
+	flagShutdownTimeout time.Duration
+	flagMetricsAddr     string
+)
+
+// daemonMetrics is exposed on --metrics-addr; nil outside the daemon or when
+// metrics are off, which makes recording a no-op
+var daemonMetrics *metrics.Metrics
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run configured schedules in the foreground",
+	Long: `Run the schedules defined with 'awsbreak schedule' until interrupted.
+
+Scheduled pauses skip report-only resources and databases that still have
+connections (--force is never applied). Scheduled resumes restore resources
+from the latest snapshot. When digest.weekday and digest.hour are set in
+config.json, the weekly digest is sent as well.
+
+Schedules are re-read from config.json every tick, so changes made with
+'awsbreak schedule' take effect without restarting the daemon.
+
+On SIGTERM the daemon stops starting new work and waits for in-flight
+operations to finish. Operations are journaled as they run; anything cut
+short is completed when the daemon next starts, and schedules missed during
+a restart of up to 15 minutes are caught up.
+
+When budget.monthly_target and budget.tighten_minutes are set in
+config.json and this month's spend is projected over the target, pauses
+run that many minutes earlier and resumes that many minutes later.
+
+Groups opened with 'awsbreak open' are paused again when their time is up,
+after a reminder to whoever opened them.
+
+When grace_period_minutes is set in config.json, scheduled pauses are armed
+first: the team is notified and the pause runs that many minutes later
+unless someone calls it off with 'awsbreak cancel'.
+
+With --metrics-addr, Prometheus metrics are served on /metrics: resources
+paused and hourly burn found by region, operations by result, and when each
+scheduled action last ran. OpenTelemetry collectors can scrape the same
+endpoint with their Prometheus receiver.`,
+	Example: `  awsbreak daemon
+  awsbreak daemon --metrics-addr :9464`,
+	Args: cobra.NoArgs,
+	Run:  runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&flagShutdownTimeout, "shutdown-timeout", 10*time.Minute,
+		"How long to wait for in-flight operations after SIGTERM before exiting")
+	daemonCmd.Flags().StringVar(&flagMetricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464")
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// AWS calls use a context that signals don't cancel, so an operation that
+	// has started runs to completion instead of leaving resources half paused
+	opCtx := context.WithoutCancel(ctx)
+
+	cfg := loadConfigOrExit()
+
+	for _, s := range cfg.Schedules {
+		if err := schedule.Validate(s); err != nil {
+			fmt.Printf("❌ Schedule %s: %v\n", s.Name, err)
+			exit(ExitConfigError)
+		}
+	}
+
+	fmt.Println("\n🕒 AWSBREAK - Daemon running (Ctrl+C to stop)")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	now := time.Now()
+	for _, s := range daemonSchedules(cfg) {
+		if next, err := schedule.Next(s, now); err == nil {
+			fmt.Printf("   • %-16s %-32s next: %s\n", s.Name, schedule.Describe(s), formatWhen(next))
+		}
+	}
+	fmt.Println()
+
+	if flagMetricsAddr != "" {
+		serveMetrics(flagMetricsAddr)
+	}
+
+	// Finish anything a previous daemon was doing when it stopped
+	if err := recoverJournal(opCtx, cfg); err != nil {
+		log.Printf("⚠️  Could not finish interrupted operations: %v", err)
+	}
+
+	// Pick up where the previous daemon left off so schedules that fell
+	// inside a short restart still run
+	last := now
+	ran := make(map[string]time.Time)
+	st, err := schedule.LoadState(configMgr.GetConfigDir())
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+	} else if !st.LastCheck.IsZero() && now.Sub(st.LastCheck) <= maxCatchUp {
+		last = st.LastCheck
+		if st.Ran != nil {
+			ran = st.Ran
+		}
+		log.Printf("⏪ Catching up on schedules since %s", formatWhen(last))
+	}
+	saveState := func() {
+		if err := schedule.SaveState(configMgr.GetConfigDir(), &schedule.State{LastCheck: last, Ran: ran}); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Printf("🛑 Shutting down - waiting up to %s for in-flight operations", flagShutdownTimeout)
+		time.Sleep(flagShutdownTimeout)
+		log.Printf("⚠️  Shutdown timeout reached; unfinished work is journaled and resumes on next start")
+		exit(ExitGeneralError)
+	}()
+
+	ticker := time.NewTicker(daemonTick)
+	defer ticker.Stop()
+
+	watch := &targetWatch{}
+
+	for {
+		if ctx.Err() == nil {
+			now := time.Now()
+
+			// Reload so schedule edits apply without a restart; keep the last
+			// good configuration if the file is mid-write or invalid
+			if reloaded, err := configMgr.Load(); err != nil {
+				log.Printf("⚠️  Failed to reload configuration: %v", err)
+			} else {
+				cfg = reloaded
+			}
+
+			schedules, runs := watch.tighten(opCtx, cfg, daemonSchedules(cfg), now)
+			for _, s := range schedule.Due(schedules, last, now) {
+				at, _ := schedule.Next(s, last)
+				runs = append(runs, dueRun{schedule: s, at: at})
+			}
+
+			completed := true
+			for _, r := range runs {
+				s, due := r.schedule, r.at
+				// Don't start new work once shutdown has begun; the next
+				// daemon catches up from the saved state instead
+				if ctx.Err() != nil {
+					completed = false
+					break
+				}
+
+				// Skip schedules a previous daemon ran before it stopped
+				if ran[s.Name].Equal(due) {
+					continue
+				}
+				runScheduled(opCtx, cfg, s)
+
+				// Record each schedule as it completes; if shutdown stops
+				// the batch, only the ones that didn't run are caught up
+				ran[s.Name] = due
+				saveState()
+			}
+			if completed {
+				last = now
+				clear(ran)
+			}
+			if ctx.Err() == nil {
+				closeOpenings(opCtx, cfg, now)
+				fireArmed(opCtx, cfg, now)
+			}
+			updatePausedMetrics(ctx, cfg)
+			saveState()
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("👋 Daemon stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// serveMetrics starts serving Prometheus metrics in the background
+func serveMetrics(addr string) {
+	daemonMetrics = metrics.New()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", daemonMetrics)
+
+	// Listen before going on, so a taken or invalid address stops the daemon
+	// rather than leaving it running without metrics
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Printf("❌ Could not serve metrics: %v\n", err)
+		exit(ExitGeneralError)
+	}
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Printf("❌ Metrics server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("📈 Metrics on http://%s/metrics\n\n", listener.Addr())
+}
+
+// updatePausedMetrics counts what is paused in each region the daemon
+// manages, from the snapshots
+func updatePausedMetrics(ctx context.Context, cfg *models.Config) {
+	if daemonMetrics == nil {
+		return
+	}
+	snapshots, err := snapshotManager()
+	if err != nil {
+		return
+	}
+
+	regions := map[string]bool{configMgr.GetDefaultRegion(): true}
+	for _, s := range cfg.Schedules {
+		if s.Region != "" {
+			regions[s.Region] = true
+		}
+	}
+	for region := range regions {
+		pending, err := snapshots.PendingResources(ctx, region)
+		if err != nil {
+			continue
+		}
+		daemonMetrics.SetPaused(region, len(pending))
+	}
+}
+
+// daemonSchedules returns the configured schedules plus the weekly digest, if enabled
+func daemonSchedules(cfg *models.Config) []models.Schedule {
+	schedules := cfg.Schedules
+
+	if d := cfg.Digest; d != nil && d.Weekday != "" {
+		days, err := schedule.ParseDays(d.Weekday)
+		if err == nil {
+			schedules = append(schedules[:len(schedules):len(schedules)], models.Schedule{
+				Name:   "weekly-digest",
+				Action: digestAction,
+				Days:   days,
+				At:     fmt.Sprintf("%02d:00", d.Hour),
+			})
+		}
+	}
+
+	return schedules
+}
+
+// targetWatch tightens brake windows while the monthly spend target is at
+// risk. Cost Explorer is asked at most once per local day, the days schedules
+// run by, so windows don't move within a day.
+type targetWatch struct {
+	day     string
+	atRisk  bool
+	decided time.Time
+}
+
+// dueRun is a schedule to run now and the time it was due, which the run is
+// recorded under
+type dueRun struct {
+	schedule models.Schedule
+	at       time.Time
+}
+
+// tighten returns the schedules with pauses moved earlier and resumes later
+// by budget.tighten_minutes when the target is at risk, else unchanged. A
+// resume that already ran today isn't moved, so it doesn't run again. Pauses
+// moved to before the check, but not yet run, are returned to run now rather
+// than being skipped for the day.
+func (w *targetWatch) tighten(ctx context.Context, cfg *models.Config, schedules []models.Schedule, now time.Time) ([]models.Schedule, []dueRun) {
+	b := cfg.Budget
+	if b == nil || b.MonthlyTarget <= 0 || b.TightenMinutes <= 0 {
+		return schedules, nil
+	}
+
+	fresh := false
+	if day := now.Format("2006-01-02"); day != w.day {
+		w.day = day
+		w.atRisk = w.check(ctx, cfg)
+		w.decided = now
+		fresh = true
+	}
+	if !w.atRisk {
+		return schedules, nil
+	}
+
+	shift := time.Duration(b.TightenMinutes) * time.Minute
+	tightened := make([]models.Schedule, 0, len(schedules))
+	var pending []dueRun
+	for _, s := range schedules {
+		original, today := schedule.On(s, now)
+		shifted := s
+		var err error
+		switch s.Action {
+		case "pause":
+			shifted, err = schedule.Shift(s, -shift)
+			if at, ok := schedule.On(shifted, now); fresh && ok && today && !at.After(w.decided) && w.decided.Before(original) {
+				pending = append(pending, dueRun{schedule: shifted, at: at})
+			}
+		case "resume":
+			if today && !original.After(w.decided) {
+				break
+			}
+			shifted, err = schedule.Shift(s, shift)
+		}
+		if err != nil {
+			log.Printf("⚠️  Schedule %s: %v", s.Name, err)
+		}
+		tightened = append(tightened, shifted)
+	}
+	return tightened, pending
+}
+
+// check asks Cost Explorer whether the month is projected over target
+func (w *targetWatch) check(ctx context.Context, cfg *models.Config) bool {
+	region := configMgr.GetDefaultRegion()
+	awsCfg, err := assumeRole(ctx, cfg, region)
+	if err != nil {
+		log.Printf("⚠️  Spend target: %v", err)
+		return false
+	}
+
+	progress, err := cost.NewExplorer(awsCfg).MonthToDate(ctx, region, cfg.Budget.MonthlyTarget, time.Now())
+	if err != nil {
+		log.Printf("⚠️  Spend target: %v", err)
+		return false
+	}
+	if progress.Days == 0 || progress.OnTrack() {
+		return false
+	}
+
+	log.Printf("🎯 Spend target at risk: projected $%.2f of $%.2f - pausing %d minutes earlier and resuming %d minutes later today",
+		progress.Projected, progress.Target, cfg.Budget.TightenMinutes, cfg.Budget.TightenMinutes)
+	return true
+}
+
+// runScheduled executes a single due schedule, logging rather than exiting on failure
+func runScheduled(ctx context.Context, cfg *models.Config, s models.Schedule) {
+	region := s.Region
+	if region == "" {
+		region = configMgr.GetDefaultRegion()
+	}
+
+	log.Printf("⏰ %s: %s in %s", s.Name, s.Action, region)
+
+	awsCfg, err := assumeRole(ctx, cfg, region)
+	if err != nil {
+		log.Printf("❌ %s: %v", s.Name, err)
+		return
+	}
+
+	// With a grace period the pause is armed now and run by fireArmed
+	if s.Action == "pause" && cfg.GracePeriodMinutes > 0 {
+		if err := armScheduled(ctx, cfg, s, region, time.Duration(cfg.GracePeriodMinutes)*time.Minute); err != nil {
+			log.Printf("❌ %s: %v", s.Name, err)
+			daemonMetrics.RecordRun(s.Action, region, time.Now(), false)
+		}
+		return
+	}
+
+	switch s.Action {
+	case "pause":
+		err = scheduledPause(ctx, cfg, awsCfg, region)
+	case "resume":
+		err = scheduledResume(ctx, awsCfg, region)
+	case digestAction:
+		err = scheduledDigest(ctx, cfg, awsCfg, region)
+	default:
+		err = fmt.Errorf("unknown action %q", s.Action)
+	}
+
+	if err != nil {
+		log.Printf("❌ %s: %v", s.Name, err)
+	}
+	daemonMetrics.RecordRun(s.Action, region, time.Now(), err == nil)
+}
+
+// scheduledPause pauses everything in the region that passes the safety checks
+func scheduledPause(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string) error {
+	results, err := brakeRegion(ctx, cfg, awsCfg, region, nil)
+	if err != nil {
+		return err
+	}
+	logResults("🛑 Paused", results)
+	return nil
+}
+
+// scheduledResume restores the resources in the latest pending snapshot
+func scheduledResume(ctx context.Context, awsCfg aws.Config, region string) error {
+	results, err := releaseRegion(ctx, awsCfg, region)
+	if err != nil {
+		return err
+	}
+	logResults("🚀 Resumed", results)
+	return nil
+}
+
+// scheduledDigest builds and sends the weekly digest
+func scheduledDigest(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string) error {
+	d, err := buildDigest(ctx, awsCfg, region, 7)
+	if err != nil {
+		return err
+	}
+	if err := sendDigest(ctx, cfg.Digest, d); err != nil {
+		return err
+	}
+	log.Printf("📬 Digest sent")
+	return nil
+}
+
+func logResults(verb string, results []models.OperationResult) {
+	var failed []string
+	for _, r := range results {
+		if !r.Success {
+			failed = append(failed, fmt.Sprintf("%s (%s)", r.Resource.ResourceID, r.Error))
+		}
+	}
+
+	if len(results) == 0 {
+		return
+	}
+
+	log.Printf("%s %d of %d resources", verb, countSuccessful(results), len(results))
+	if len(failed) > 0 {
+		log.Printf("⚠️  Failed: %s", strings.Join(failed, ", "))
+	}
+}
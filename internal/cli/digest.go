@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/digest"
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/notify"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var (
+	flagDigestDays int
+	flagDigestSend bool
+)
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summarize paused hours, savings, top burners and drift",
+	Long: `Build a summary of recent awsbreak activity: paused hours, estimated
+savings, the most expensive resources still running, and resources that were
+restarted outside awsbreak (drift).
+
+With --send the digest is delivered to the Slack webhook and/or email
+recipients configured under "digest" in config.json.`,
+	Run: runDigest,
+}
+
+func init() {
+	digestCmd.Flags().IntVar(&flagDigestDays, "days", 7, "Number of days to summarize")
+	digestCmd.Flags().BoolVar(&flagDigestSend, "send", false, "Deliver the digest to configured channels")
+	rootCmd.AddCommand(digestCmd)
+}
+
+func runDigest(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	if !checkConfiguration() {
+		fmt.Println("❌ No configuration found. Run setup first.")
+		exit(ExitConfigError)
+	}
+
+	cfg, region, awsCfg, err := connect(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	d, err := buildDigest(ctx, awsCfg, region, flagDigestDays)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitServiceError)
+	}
+
+	fmt.Println()
+	fmt.Print(d.Text())
+
+	if !flagDigestSend {
+		return
+	}
+
+	fmt.Println()
+	if err := sendDigest(ctx, cfg.Digest, d); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	fmt.Println("📬 Digest sent.")
+}
+
+// connect loads the configuration and assumes the configured role in the target region
+func connect(ctx context.Context) (*models.Config, string, aws.Config, error) {
+	cfg, err := configMgr.Load()
+	if err != nil {
+		return nil, "", aws.Config{}, err
+	}
+
+	region := flagRegion
+	if region == "" {
+		region = configMgr.GetDefaultRegion()
+	}
+
+	awsCfg, err := assumeRole(ctx, cfg, region)
+	if err != nil {
+		return nil, "", aws.Config{}, err
+	}
+
+	return cfg, region, awsCfg, nil
+}
+
+// assumeRole returns AWS credentials for the configured role in a region
+func assumeRole(ctx context.Context, cfg *models.Config, region string) (aws.Config, error) {
+	authMgr = auth.NewIAMAuthenticator(cfg.IAMRoleARN, region)
+	awsCfg, err := authMgr.GetAWSConfig(ctx)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("authentication failed: %w", err)
+	}
+	return awsCfg, nil
+}
+
+// buildDigest gathers the ledger, current inventory and pending snapshots into a digest
+func buildDigest(ctx context.Context, awsCfg aws.Config, region string, days int) (*digest.Digest, error) {
+	entries, err := ledger.NewLedger(configMgr.GetConfigDir()).Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := snapshotManager()
+	if err != nil {
+		return nil, err
+	}
+	paused, err := snapshots.PendingResources(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := orchestratorOptions()
+	if err != nil {
+		return nil, err
+	}
+	view, err := newInventoryCache(services.NewOrchestrator(awsCfg, opts)).Get(ctx, region, inventory.RefreshIfStale)
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+
+	until := time.Now()
+	return digest.Build(entries, view.Resources, paused, until.AddDate(0, 0, -days), until), nil
+}
+
+// sendDigest delivers a digest to every configured channel
+func sendDigest(ctx context.Context, cfg *models.DigestConfig, d *digest.Digest) error {
+	if cfg == nil || (cfg.SlackWebhookURL == "" && cfg.Email == nil) {
+		return fmt.Errorf("no digest channels configured (set digest.slack_webhook_url or digest.email in config.json)")
+	}
+
+	var texts models.MessageTemplates
+	if cfg.Templates != nil {
+		texts = *cfg.Templates
+	}
+
+	var errs []error
+	if cfg.SlackWebhookURL != "" {
+		tmpl, err := notify.ParseTemplate("Slack", texts.Slack)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if err := digest.SendSlack(ctx, cfg.SlackWebhookURL, tmpl, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.Email != nil {
+		tmpl, err := notify.ParseTemplate("email", texts.Email)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if err := digest.SendEmail(*cfg.Email, tmpl, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+// checkPlan runs the permission checks for a dry run and prints which
+// planned operations would fail, so a clean dry run means the real one has
+// the access it needs. It reports whether every check passed.
+func checkPlan(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string, resources []models.Resource, operation string) bool {
+	// The role usually can't simulate its own policy; the credentials that
+	// assume it often can
+	simCfg, err := auth.NewIAMAuthenticator("", region).GetAWSConfig(ctx)
+	if err != nil {
+		simCfg = awsCfg
+	}
+
+	spin := startSpinner(fmt.Sprintf("Checking permissions for %d resources...", len(resources)))
+	checks := services.NewPlanChecker(awsCfg, simCfg, cfg.IAMRoleARN).CheckAll(ctx, resources, operation)
+	spin.halt()
+
+	return displayPlannedChecks(checks, operation)
+}
+
+// displayPlannedChecks prints failed and unchecked operations and reports
+// whether every check passed
+func displayPlannedChecks(checks []services.PlannedCheck, operation string) bool {
+	var denied, unknown []services.PlannedCheck
+	for _, c := range checks {
+		switch {
+		case len(c.Denied) > 0:
+			denied = append(denied, c)
+		case c.Error != "":
+			unknown = append(unknown, c)
+		}
+	}
+
+	fmt.Println()
+	if len(denied) == 0 && len(unknown) == 0 {
+		fmt.Printf("🔐 Permissions: all %d planned %s operations are allowed\n", len(checks), operation)
+		return true
+	}
+
+	fmt.Printf("🔐 Permissions: %d allowed, %d denied, %d not checked\n",
+		len(checks)-len(denied)-len(unknown), len(denied), len(unknown))
+	for _, c := range denied {
+		fmt.Printf("   ⛔ %s %s: would fail, role lacks %s\n", c.Resource.ServiceType,
+			consoleLink(c.Resource.ResourceID, c.Resource), strings.Join(c.Denied, ", "))
+	}
+	for _, c := range unknown {
+		fmt.Printf("   ? %s %s: could not check (%s)\n", c.Resource.ServiceType,
+			consoleLink(c.Resource.ResourceID, c.Resource), c.Error)
+	}
+	if len(denied) > 0 {
+		fmt.Println("   Run 'awsbreak role check' to compare the role with what awsbreak needs.")
+	}
+	return len(denied) == 0
+}
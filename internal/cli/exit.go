@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// exitStatus is the code awsbreak exits with once the command finishes
+var exitStatus = ExitSuccess
+
+// ExitStatus returns the code the finished command should exit with
+func ExitStatus() int {
+	return exitStatus
+}
+
+// setExitStatus records a failure for the command's exit code. The first
+// failure wins, so one region failing isn't hidden by a later one.
+func setExitStatus(code int) {
+	if exitStatus == ExitSuccess {
+		exitStatus = code
+	}
+}
+
+// noteFailures records an operation that failed outright, or left some of
+// its resources failed, so scripts can tell from the exit code. Only each
+// resource's last result counts, so a failure fixed by a retry during triage
+// doesn't fail the command.
+func noteFailures(results []models.OperationResult, err error) {
+	final := finalOutcomes(results)
+	switch {
+	case err != nil:
+		setExitStatus(ExitServiceError)
+	case countSuccessful(final) < len(final):
+		setExitStatus(ExitPartialFailure)
+	}
+}
+
+// finalOutcomes keeps the last result of each resource, in the order the
+// resources first appear
+func finalOutcomes(results []models.OperationResult) []models.OperationResult {
+	index := make(map[string]int)
+	var final []models.OperationResult
+	for _, r := range results {
+		key := r.Resource.Key()
+		if i, ok := index[key]; ok {
+			final[i] = r
+			continue
+		}
+		index[key] = len(final)
+		final = append(final, r)
+	}
+	return final
+}
+
+// exit flushes output filtered by --quiet and exits with code
+func exit(code int) {
+	stopQuiet()
+	os.Exit(code)
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/events"
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
+)
+
+var flagHistoryDays int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Review past pause and resume runs and cumulative savings",
+	Long: `List every pause and resume run recorded in the ledger, newest first,
+with the total saved while resources were braked.
+
+Examples:
+  awsbreak history
+  awsbreak history --days 7 --region us-east-1
+  awsbreak history show pause-us-east-1-20250101-190000.000`,
+	Run: runHistory,
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Show every resource in a past run",
+	Args:  cobra.ExactArgs(1),
+	Run:   runHistoryShow,
+}
+
+func init() {
+	historyCmd.Flags().IntVar(&flagHistoryDays, "days", 0, "Only show runs from the last N days (0 for all)")
+	historyCmd.AddCommand(historyShowCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	fmt.Println("\n📜 AWSBREAK - History")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	entries := loadLedger()
+
+	until := time.Now()
+	var since time.Time
+	if flagHistoryDays > 0 {
+		since = until.AddDate(0, 0, -flagHistoryDays)
+	}
+
+	t := newTable(
+		column{title: "RUN", flex: true},
+		column{title: "WHEN"},
+		column{title: "ACTION"},
+		column{title: "REGION"},
+		column{title: "OK/TOTAL", right: true},
+		column{title: "$/MONTH", right: true},
+		column{title: "TOOK", right: true},
+	)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Timestamp.Before(since) || (flagRegion != "" && e.Region != flagRegion) {
+			continue
+		}
+
+		icon := "✅"
+		if e.Succeeded() < len(e.Results) {
+			icon = "⚠️ "
+		}
+		took := "-"
+		if e.Took() > 0 {
+			took = e.Took().Round(time.Second).String()
+		}
+		t.addRow(icon, e.ID, formatWhen(e.Timestamp), e.Operation, e.Region,
+			fmt.Sprintf("%d/%d", e.Succeeded(), len(e.Results)), fmt.Sprintf("$%.2f", e.MonthlyRate()), took)
+	}
+
+	if len(t.rows) == 0 {
+		fmt.Println("\n   No runs recorded yet.")
+		return
+	}
+	fmt.Println()
+	t.print()
+
+	// Realized savings: hours each resource actually spent paused
+	var saved float64
+	for _, ts := range ledger.Leaderboard(entries, configMgr.GetAttributionTag(), since, until) {
+		saved += ts.Savings
+	}
+
+	fmt.Println()
+	if flagHistoryDays > 0 {
+		fmt.Printf("💰 Saved in the last %d days: $%.2f\n", flagHistoryDays, saved)
+	} else {
+		fmt.Printf("💰 Saved since the first run: $%.2f\n", saved)
+	}
+}
+
+func runHistoryShow(cmd *cobra.Command, args []string) {
+	var e *ledger.Entry
+	entries := loadLedger()
+	for i := range entries {
+		if entries[i].ID == args[0] {
+			e = &entries[i]
+		}
+	}
+	if e == nil {
+		fmt.Printf("❌ No run with ID %s\n", args[0])
+		exit(ExitGeneralError)
+	}
+
+	fmt.Printf("\n📜 Run %s\n", e.ID)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("   When:     %s\n", formatWhen(e.Timestamp))
+	fmt.Printf("   Action:   %s\n", e.Operation)
+	fmt.Printf("   Region:   %s\n", e.Region)
+	if e.SnapshotID != "" {
+		fmt.Printf("   Snapshot: %s\n", e.SnapshotID)
+	}
+	if e.Took() > 0 {
+		fmt.Printf("   Took:     %s (discovery %s)\n", e.Took().Round(time.Second), e.Discovery.Round(time.Second))
+	}
+	fmt.Println()
+
+	displayResults(e.Results)
+
+	fmt.Println()
+	if e.Operation == "pause" {
+		fmt.Printf("💰 Saving ~$%.2f/month while paused\n", e.MonthlyRate())
+	} else {
+		fmt.Printf("🔥 Restored ~$%.2f/month of spend\n", e.MonthlyRate())
+	}
+	displayEfficiency(*e)
+	if _, err := events.NewLog(configMgr.GetConfigDir(), e.ID).Events(); err == nil {
+		fmt.Printf("   Step by step: awsbreak runs show %s\n", e.ID)
+	}
+}
+
+// loadLedger loads configuration, if any, and returns every ledger entry
+func loadLedger() []ledger.Entry {
+	var err error
+	configMgr, err = config.NewManager()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	if configMgr.Exists() {
+		if _, err := configMgr.Load(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+	}
+
+	entries, err := ledger.NewLedger(configMgr.GetConfigDir()).Entries()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	return entries
+}
@@ -1,16 +1,23 @@
 package cli
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
 	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
 	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/schedule"
 	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+	"github.com/aicoder2009/aws-hit-breaks/internal/state"
 )
 
 var (
@@ -27,9 +34,37 @@ func checkConfiguration() bool {
 	return configMgr.Exists()
 }
 
+// loadConfigManager initializes configMgr for subcommands that work with or
+// without a saved configuration, loading the config if there is one
+func loadConfigManager() {
+	var err error
+	configMgr, err = config.NewManager()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	if configMgr.Exists() {
+		if _, err := configMgr.Load(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+	}
+}
+
 func interactiveSetup() {
-	fmt.Println("We need to install your brake system (IAM role).")
-	fmt.Println("This gives awsbreak permission to stop/start your services.")
+	// A role supplied up front means the role already exists; skip the install guide
+	if flagRoleARN != "" || os.Getenv(envRoleARN) != "" {
+		completeSetup("", "")
+		return
+	}
+
+	if flagSetupObserver {
+		fmt.Println("We need to install a read-only observer role.")
+		fmt.Println("It lets awsbreak see your services and their costs, never stop or start them.")
+	} else {
+		fmt.Println("We need to install your brake system (IAM role).")
+		fmt.Println("This gives awsbreak permission to stop/start your services.")
+	}
 	fmt.Println()
 	fmt.Println("How would you like to install?")
 	fmt.Println("1. 🏎️  Quick install (CloudFormation - recommended)")
@@ -54,8 +89,24 @@ func interactiveSetup() {
 
 func setupWithCloudFormation() {
 	fmt.Println()
-	fmt.Println("📋 CloudFormation Template")
+	fmt.Println("📋 CloudFormation Stack")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println()
+	fmt.Println("1. 🚀 Deploy the stack now with your current AWS credentials")
+	fmt.Println("2. 📋 Show the template to paste into the AWS Console")
+	fmt.Println()
+
+	choice := prompt("Enter choice [1]: ")
+	if choice == "" || choice == "1" {
+		region := askRegion()
+		if roleARN, ok := deployRoleStack(region); ok {
+			completeSetup(roleARN, region)
+			return
+		}
+		fmt.Println()
+		fmt.Println("Falling back to the template instead.")
+	}
+
 	fmt.Println()
 	fmt.Println("1. Copy the template below")
 	fmt.Println("2. Go to AWS Console > CloudFormation > Create Stack")
@@ -63,11 +114,43 @@ func setupWithCloudFormation() {
 	fmt.Println("4. Copy the Role ARN from the Outputs tab")
 	fmt.Println()
 	fmt.Println("--- TEMPLATE START ---")
-	fmt.Println(auth.CloudFormationTemplate())
+	template, _ := setupRoleTemplate()
+	fmt.Println(template)
 	fmt.Println("--- TEMPLATE END ---")
 	fmt.Println()
 
-	completeSetup()
+	completeSetup("", "")
+}
+
+// deployRoleStack creates the role stack in region with the user's own
+// credentials and returns the role ARN from its outputs
+func deployRoleStack(region string) (string, bool) {
+	ctx := context.Background()
+
+	baseCfg, err := auth.BaseConfig(ctx, region)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return "", false
+	}
+	if who, err := auth.Identity(ctx, baseCfg); err == nil {
+		fmt.Printf("\n   Deploying as %s\n", who)
+	}
+
+	template, stackName := setupRoleTemplate()
+	spin := startSpinner(fmt.Sprintf("Creating stack %s in %s...", stackName, region))
+	roleARN, err := auth.DeployRoleStack(ctx, baseCfg, stackName, template)
+	spin.halt()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		if strings.Contains(err.Error(), "AccessDenied") {
+			fmt.Println("   Your credentials can't create IAM roles with CloudFormation; ask an administrator to deploy the template.")
+		}
+		return "", false
+	}
+
+	fmt.Printf("✅ Stack %s created\n", stackName)
+	fmt.Printf("   Role ARN: %s\n", roleARN)
+	return roleARN, true
 }
 
 func setupManual() {
@@ -76,83 +159,102 @@ func setupManual() {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println()
 	fmt.Println("Create an IAM role with these permissions:")
-	fmt.Println("  - ec2:DescribeInstances, ec2:StopInstances, ec2:StartInstances")
-	fmt.Println("  - rds:DescribeDBInstances, rds:StopDBInstance, rds:StartDBInstance")
-	fmt.Println("  - ecs:DescribeServices, ecs:UpdateService")
-	fmt.Println("  - autoscaling:DescribeAutoScalingGroups, autoscaling:SuspendProcesses")
+	for _, g := range setupPolicy() {
+		line := "  - " + strings.Join(g.Actions, ", ")
+		if g.Optional {
+			line += " (optional)"
+		}
+		fmt.Println(line)
+	}
+	if !flagSetupObserver {
+		fmt.Println("  - sns:Publish (only for SNS notifications)")
+	}
 	fmt.Println()
 
-	completeSetup()
+	completeSetup("", "")
 }
 
-func completeSetup() {
-	roleARN := prompt("Enter IAM Role ARN: ")
+// completeSetup verifies and saves the configuration, asking for the role
+// ARN and region unless they are already known
+func completeSetup(roleARN, region string) {
+	if roleARN == "" {
+		roleARN = stdPrompter.ask(question{
+			message: "Enter IAM Role ARN: ",
+			flag:    flagRoleARN,
+			env:     envRoleARN,
+		})
+	}
 	if roleARN == "" {
 		fmt.Println("❌ Role ARN is required")
-		os.Exit(ExitConfigError)
+		exit(ExitConfigError)
 	}
 
 	// Validate ARN format
 	if err := config.ValidateIAMRoleARN(roleARN); err != nil {
 		fmt.Printf("❌ %v\n", err)
-		os.Exit(ExitConfigError)
+		exit(ExitConfigError)
 	}
 
-	// Get default region
-	region := prompt("Enter default AWS region [us-east-1]: ")
 	if region == "" {
-		region = "us-east-1"
-	}
-
-	if err := config.ValidateRegion(region); err != nil {
-		fmt.Printf("❌ %v\n", err)
-		os.Exit(ExitConfigError)
+		region = askRegion()
 	}
 
-	// Verify credentials work
-	fmt.Println()
-	fmt.Print("🔐 Verifying IAM role... ")
-
-	authMgr = auth.NewIAMAuthenticator(roleARN, region)
-	ctx := context.Background()
-	_, err := authMgr.GetAWSConfig(ctx)
-	if err != nil {
-		fmt.Println("❌")
-		fmt.Printf("   Failed to assume role: %v\n", err)
-		fmt.Println("   Please check the role ARN and trust policy.")
-		os.Exit(ExitAuthError)
+	// Verify credentials, trust policy and permissions
+	if !verifySetup(roleARN, region) && !confirm("\nSave configuration anyway? [y/N]: ") {
+		exit(ExitAuthError)
 	}
-	fmt.Println("✅")
 
 	// Save configuration
 	cfg := &models.Config{
 		IAMRoleARN:    roleARN,
 		DefaultRegion: region,
+		Observer:      flagSetupObserver,
 	}
 
 	if err := configMgr.Save(cfg); err != nil {
 		fmt.Printf("❌ Failed to save configuration: %v\n", err)
-		os.Exit(ExitConfigError)
+		exit(ExitConfigError)
 	}
 
 	fmt.Println()
+	if flagSetupObserver {
+		fmt.Println("✅ Observer installed! Run 'awsbreak --check' or 'awsbreak serve' to watch your costs.")
+		return
+	}
 	fmt.Println("✅ Brakes installed! Run 'awsbreak' to slam the brakes on your costs.")
 }
 
-func interactivePause() {
+// askRegion asks for the default region, exiting if it isn't valid
+func askRegion() string {
+	region := stdPrompter.ask(question{
+		message: "Enter default AWS region [us-east-1]: ",
+		flag:    flagDefaultRegion,
+		env:     envDefaultRegion,
+	})
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	if err := config.ValidateRegion(region); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	warnUnknownRegion(region)
+	return region
+}
+
+func interactivePause(region string) {
 	ctx := context.Background()
 
 	// Load configuration
 	cfg, err := configMgr.Load()
 	if err != nil {
 		fmt.Printf("❌ %v\n", err)
-		os.Exit(ExitConfigError)
+		exit(ExitConfigError)
 	}
-
-	// Determine region
-	region := flagRegion
-	if region == "" {
-		region = configMgr.GetDefaultRegion()
+	plan := selectedPlan(cfg)
+	if plan != nil && plan.Region != "" && flagRegion == "" {
+		region = plan.Region
 	}
 
 	fmt.Printf("\n🔍 Checking what's running in your AWS account...\n")
@@ -163,64 +265,134 @@ func interactivePause() {
 	awsCfg, err := authMgr.GetAWSConfig(ctx)
 	if err != nil {
 		fmt.Printf("❌ Authentication failed: %v\n", err)
-		os.Exit(ExitAuthError)
+		exit(ExitAuthError)
 	}
 
-	// Create orchestrator and discover resources
-	orchestrator := services.NewOrchestrator(awsCfg)
-	resources, err := orchestrator.DiscoverAll(ctx, region)
-	if err != nil {
-		fmt.Printf("❌ Discovery failed: %v\n", err)
-		os.Exit(ExitServiceError)
-	}
+	// Always rediscover before braking; the refreshed view is shared with
+	// other commands through the inventory cache
+	view := discoverRegion(awsCfg, region)
+	resources := applyPlan(plan, view.Resources)
+	displayDiscoveryReport(view.Discovery)
 
 	if len(resources) == 0 {
-		fmt.Println("\n✅ All clear! No running resources burning money.")
+		if view.Discovery.Complete() {
+			fmt.Println("\n✅ All clear! No running resources burning money.")
+		} else {
+			fmt.Println("\n⚠️  No running resources found in the services that could be checked.")
+		}
 		return
 	}
 
+	// Some resources (idle Elastic IPs, NAT gateways without --include-network)
+	// are only reported so users can see what else is costing them money
+	allResources := resources
+	resources, reportOnly := splitReportOnly(resources)
+
 	// Display discovered resources
-	displayResources(resources)
+	if len(resources) > 0 {
+		displayResources(resources)
+	}
+	displayReportOnly(reportOnly)
+
+	if len(resources) == 0 {
+		fmt.Println()
+		fmt.Printf("🔥 Burning: $%.2f/month\n", calculateMonthlyCost(allResources))
+		fmt.Println("\n✅ Nothing awsbreak can stop - see the list above for manual cleanup.")
+		return
+	}
+
+	// Never touch protected or externally managed resources unless forced
+	allowed, protected := services.NewGuard(cfg.Protection).Split(resources)
+	displayProtected(protected)
+	if !flagForce {
+		resources = allowed
+		if len(resources) == 0 {
+			fmt.Println("\n✅ Nothing left to stop - everything found is protected.")
+			return
+		}
+	}
+	displayIaCManaged(resources)
+
+	// Check databases for live traffic before stopping them
+	checker := services.NewConnectionChecker(awsCfg, cfg.ConnectionThreshold)
+	checks := checker.CheckAll(ctx, resources)
+	displayConnectionChecks(checks)
+	if !flagForce {
+		resources = services.FilterBlocked(resources, checks)
+		if len(resources) == 0 {
+			fmt.Println("\n✅ Nothing left to stop after safety checks.")
+			return
+		}
+	}
 
 	// Calculate costs
 	totalMonthlyCost := calculateMonthlyCost(resources)
 
 	fmt.Println()
-	fmt.Printf("🔥 Burning: $%.2f/month\n", totalMonthlyCost)
+	fmt.Printf("🔥 Burning: $%.2f/month\n", calculateMonthlyCost(allResources))
 	fmt.Printf("💰 You could save: $%.2f/month\n", totalMonthlyCost)
+	if billing, _, _ := stillBilling(resources); billing > 0 {
+		fmt.Printf("💸 Still billing while paused: $%.2f/month for disks, addresses and storage\n", billing)
+	}
+	displayPricingAsOf(region)
 	fmt.Println()
 
 	if flagDryRun {
+		allowed := checkPlan(ctx, cfg, awsCfg, region, resources, "pause")
+		fmt.Println()
 		fmt.Println("👀 DRY RUN - Just checking mirrors, no brakes applied")
+		if !allowed {
+			exit(ExitAuthError)
+		}
+		return
+	}
+
+	// Let the user pick what to stop rather than all-or-nothing
+	resources = selectResources(resources)
+	if resources == nil {
+		fmt.Println("Cancelled.")
+		return
+	}
+	if len(resources) == 0 {
+		fmt.Println("\n✅ Nothing selected - no brakes applied.")
 		return
 	}
+	totalMonthlyCost = calculateMonthlyCost(resources)
 
-	fmt.Println("🛑 Ready to hit the brakes on all these resources?")
+	fmt.Println()
+	fmt.Printf("🛑 Ready to hit the brakes on %d resources ($%.2f/month)?\n", len(resources), totalMonthlyCost)
 	fmt.Println("   (Resume anytime with 'awsbreak --resume')")
+	displayDestructive(resources)
 	fmt.Println()
 
-	confirm := prompt("Continue? [y/N]: ")
-	if !strings.HasPrefix(strings.ToLower(confirm), "y") {
+	if !confirm("Continue? [y/N]: ") {
 		fmt.Println("Cancelled.")
 		return
 	}
 
+	if canaryRequested() {
+		pauseWithCanary(ctx, cfg, awsCfg, region, resources, view.LiveTook())
+		return
+	}
+
 	// Execute pause
 	fmt.Println()
 	fmt.Println("🛑 BRAKES ENGAGED - Stopping resources...")
 
-	results, err := orchestrator.PauseAll(ctx, resources)
+	entry := &journal.Entry{Operation: "pause", Region: region, Resources: resources, Discovery: view.LiveTook()}
+	results, err := runOperation(ctx, awsCfg, entry)
 	if err != nil {
 		fmt.Printf("❌ Brake failure: %v\n", err)
 	}
 
 	// Display results
 	displayResults(results)
+	if err == nil {
+		results = triageFailures(ctx, awsCfg, entry, results)
+	}
+	noteFailures(results, err)
 
-	fmt.Println()
-	fmt.Printf("🏁 Done! Stopped %d resources. Saving ~$%.2f/month\n",
-		countSuccessful(results), totalMonthlyCost)
-	fmt.Println("   Run 'awsbreak --resume' when you're ready to go again.")
+	finishPause(ctx, cfg, awsCfg, region, results, resources, entry)
 }
 
 func interactiveResume() {
@@ -230,14 +402,34 @@ func interactiveResume() {
 	cfg, err := configMgr.Load()
 	if err != nil {
 		fmt.Printf("❌ %v\n", err)
-		os.Exit(ExitConfigError)
+		exit(ExitConfigError)
 	}
 
+	plan := selectedPlan(cfg)
 	region := flagRegion
+	if region == "" && plan != nil {
+		region = plan.Region
+	}
 	if region == "" {
 		region = configMgr.GetDefaultRegion()
 	}
 
+	// A named snapshot decides the region it is restored in
+	snapshots := mustSnapshotManager()
+	var snapshot *models.AccountSnapshot
+	if flagSnapshot != "" {
+		snapshot, err = snapshots.Load(ctx, flagSnapshot)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitGeneralError)
+		}
+		if flagRegion != "" && flagRegion != snapshot.Region {
+			fmt.Printf("❌ Snapshot %s is from %s, not %s\n", snapshot.SnapshotID, snapshot.Region, flagRegion)
+			exit(ExitConfigError)
+		}
+		region = snapshot.Region
+	}
+
 	fmt.Printf("\n🟢 Releasing brakes in %s...\n", region)
 
 	// Initialize authenticator
@@ -245,22 +437,55 @@ func interactiveResume() {
 	awsCfg, err := authMgr.GetAWSConfig(ctx)
 	if err != nil {
 		fmt.Printf("❌ Authentication failed: %v\n", err)
-		os.Exit(ExitAuthError)
+		exit(ExitAuthError)
 	}
 
-	// Create orchestrator
-	orchestrator := services.NewOrchestrator(awsCfg)
+	var stoppedResources []models.Resource
+	if flagAllStopped {
+		view := discoverRegion(awsCfg, region)
 
-	// TODO: Load snapshot and resume from it
-	// For now, just discover stopped resources
-	resources, err := orchestrator.DiscoverAll(ctx, region)
-	if err != nil {
-		fmt.Printf("❌ Discovery failed: %v\n", err)
-		os.Exit(ExitServiceError)
+		// Every stopped resource, including ones stopped before awsbreak ran
+		stoppedResources = filterStopped(view.Resources)
+		displayDiscoveryReport(view.Discovery)
+	}
+
+	// Resources an interrupted run left paused aren't in a snapshot yet
+	var interrupted []*journal.Entry
+	if flagSnapshot == "" && len(flagOnly) == 0 && plan == nil {
+		var pending []models.Resource
+		pending, interrupted = interruptedResources(region)
+		stoppedResources = mergeResources(stoppedResources, pending)
 	}
 
-	// Filter for stopped resources
-	stoppedResources := filterStopped(resources)
+	// Only resume what awsbreak paused, unless --all-stopped widens the net
+	if snapshot == nil {
+		snapshot, err = snapshots.Latest(ctx, region)
+		if err != nil {
+			fmt.Printf("⚠️  Could not load snapshot: %v\n", err)
+		}
+	}
+	if snapshot != nil {
+		fmt.Printf("📸 Restoring from snapshot %s (%s)\n", snapshot.SnapshotID,
+			formatWhen(snapshot.Timestamp))
+		// The snapshot has the metadata needed to restore resources that
+		// discovery can't see once paused (scaled-to-zero services, deleted NAT gateways)
+		stoppedResources = mergeResources(snapshot.PendingResources(), stoppedResources)
+	} else if !flagAllStopped && len(interrupted) == 0 {
+		fmt.Println("\n✅ Nothing awsbreak paused is waiting to resume.")
+		fmt.Println("   Use --all-stopped to start everything that is stopped.")
+		return
+	}
+
+	if len(flagOnly) > 0 {
+		var unmatched []string
+		stoppedResources, unmatched = filterOnly(stoppedResources, flagOnly)
+		for _, spec := range unmatched {
+			fmt.Printf("⚠️  %s is not parked - skipping\n", spec)
+		}
+	}
+	stoppedResources = applyPlan(plan, stoppedResources)
+	stoppedResources = filterResources(stoppedResources, serviceSelected)
+
 	if len(stoppedResources) == 0 {
 		fmt.Println("\n✅ Nothing parked - all services already running!")
 		return
@@ -269,23 +494,37 @@ func interactiveResume() {
 	displayResources(stoppedResources)
 
 	if flagDryRun {
+		allowed := checkPlan(ctx, cfg, awsCfg, region, stoppedResources, "resume")
 		fmt.Println("\n👀 DRY RUN - Just checking, not starting anything")
+		if !allowed {
+			exit(ExitAuthError)
+		}
 		return
 	}
 
-	confirm := prompt("\nRelease brakes and start these? [y/N]: ")
-	if !strings.HasPrefix(strings.ToLower(confirm), "y") {
+	if !confirm("\nRelease brakes and start these? [y/N]: ") {
 		fmt.Println("Staying parked.")
 		return
 	}
 
 	fmt.Println("\n🚀 Releasing brakes - starting resources...")
-	results, err := orchestrator.ResumeAll(ctx, stoppedResources)
+	entry := &journal.Entry{Operation: "resume", Region: region, Resources: stoppedResources}
+	if snapshot != nil {
+		entry.SnapshotID = snapshot.SnapshotID
+	}
+	results, err := runOperation(ctx, awsCfg, entry)
 	if err != nil {
 		fmt.Printf("❌ Engine trouble: %v\n", err)
+	} else {
+		// This run's journal entry now tracks their resources
+		abandonEntries(interrupted)
 	}
 
 	displayResults(results)
+	if err == nil {
+		results = triageFailures(ctx, awsCfg, entry, results)
+	}
+	noteFailures(results, err)
 	fmt.Printf("\n🏎️  Back on the road! Started %d resources.\n", countSuccessful(results))
 }
 
@@ -315,18 +554,45 @@ func showStatus() {
 	fmt.Printf("   IAM Role:   %s\n", cfg.IAMRoleARN)
 	fmt.Printf("   Region:     %s\n", cfg.DefaultRegion)
 	fmt.Printf("   Version:    %s\n", cfg.Version)
-	fmt.Printf("   Installed:  %s\n", cfg.CreatedAt.Format("2006-01-02 15:04:05"))
-}
+	fmt.Printf("   Installed:  %s\n", formatWhen(cfg.CreatedAt))
 
-// Helper functions
+	region := flagRegion
+	if region == "" {
+		region = configMgr.GetDefaultRegion()
+	}
+	if snapshots, err := snapshotManager(); err == nil {
+		if s, err := snapshots.Latest(context.Background(), region); err == nil && s != nil {
+			fmt.Printf("   Paused:     %d resources in %s, %s\n", len(s.PendingResources()), region, formatWhen(s.Timestamp))
+			displayRestartDeadlines(s.PendingResources(), s.Timestamp)
+			displayStillBilling(s.PendingResources(), s.Timestamp)
+		}
+	}
+	if next, ok := nextScheduled(cfg, "resume", region); ok {
+		fmt.Printf("   Auto-resume: %s\n", formatWhen(next))
+	}
+}
 
-func prompt(message string) string {
-	fmt.Print(message)
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	return strings.TrimSpace(input)
+// nextScheduled returns the next time a schedule runs action in region
+func nextScheduled(cfg *models.Config, action, region string) (time.Time, bool) {
+	var next time.Time
+	now := time.Now()
+	for _, s := range cfg.Schedules {
+		scheduleRegion := s.Region
+		if scheduleRegion == "" {
+			scheduleRegion = cfg.DefaultRegion
+		}
+		if s.Action != action || scheduleRegion != region {
+			continue
+		}
+		if t, err := schedule.Next(s, now); err == nil && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next, !next.IsZero()
 }
 
+// Helper functions
+
 func displayResources(resources []models.Resource) {
 	fmt.Println()
 	fmt.Println("📊 Found running resources:")
@@ -340,11 +606,25 @@ func displayResources(resources []models.Resource) {
 	for svcType, items := range byType {
 		fmt.Printf("   • %d %s\n", len(items), svcType)
 		for _, r := range items {
-			fmt.Printf("     - %s (%s)\n", r.ResourceID, r.CurrentState)
+			fmt.Printf("     - %s (%s)\n", consoleLink(fitLine(r.ResourceID, 10+len(r.CurrentState)), r), r.CurrentState)
 		}
 	}
 }
 
+func displayReportOnly(resources []models.Resource) {
+	if len(resources) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("💸 Also costing you money (not stopped by awsbreak):")
+	for _, r := range resources {
+		kind, _ := r.Metadata["kind"].(string)
+		cost := fmt.Sprintf("$%.2f/month", r.CostPerHour*24*30)
+		fmt.Printf("     - %s %s (%s)\n", kind, consoleLink(fitLine(r.ResourceID, 10+len(kind)+len(cost)), r), cost)
+	}
+}
+
 func displayResults(results []models.OperationResult) {
 	successes := 0
 	failures := 0
@@ -352,10 +632,12 @@ func displayResults(results []models.OperationResult) {
 	for _, r := range results {
 		if r.Success {
 			successes++
-			fmt.Printf("   ✅ %s %s\n", r.Resource.ServiceType, r.Resource.ResourceID)
+			note := retryNote(r)
+			fmt.Printf("   ✅ %s %s%s\n", r.Resource.ServiceType, consoleLink(fitLine(r.Resource.ResourceID, 7+len(r.Resource.ServiceType)+len(note)), r.Resource), note)
 		} else {
 			failures++
-			fmt.Printf("   ❌ %s %s: %s\n", r.Resource.ServiceType, r.Resource.ResourceID, r.Error)
+			// The error explains the failure, so it is never shortened
+			fmt.Printf("   ❌ %s %s: %s%s\n", r.Resource.ServiceType, consoleLink(r.Resource.ResourceID, r.Resource), r.Error, retryNote(r))
 		}
 	}
 
@@ -364,6 +646,237 @@ func displayResults(results []models.OperationResult) {
 	}
 }
 
+// retryNote describes how often an operation was retried, if at all
+func retryNote(r models.OperationResult) string {
+	switch r.Retries {
+	case 0:
+		return ""
+	case 1:
+		return " (after 1 retry)"
+	default:
+		return fmt.Sprintf(" (after %d retries)", r.Retries)
+	}
+}
+
+// orchestratorOptions builds orchestrator options from command-line flags and
+// the loaded config. Config is read only if no command has loaded it yet; an
+// unreadable config.json is an error rather than a silent fall back to
+// defaults.
+func orchestratorOptions() (services.Options, error) {
+	// Ordering, retries, concurrency and the cost model are optional config; commands that run without a
+	// config file use the defaults
+	var cfg *models.Config
+	if configMgr != nil {
+		cfg = configMgr.GetConfig()
+		if cfg == nil && configMgr.Exists() {
+			var err error
+			if cfg, err = configMgr.Load(); err != nil {
+				return services.Options{}, err
+			}
+		}
+	}
+
+	opts := services.OptionsFromConfig(cfg)
+	opts.IncludeNetwork = flagIncludeNetwork
+	opts.IncludeReservations = flagIncludeReservations
+	opts.Services = selectedServices
+	opts.SkipServices = skippedServices
+	if cfg != nil {
+		opts.Concurrency = concurrencyOptions(cfg.Concurrency)
+	} else {
+		opts.Concurrency = concurrencyOptions(nil)
+	}
+	if configMgr != nil {
+		opts.PricingDir = configMgr.GetConfigDir()
+	}
+	return opts, nil
+}
+
+// mustOrchestratorOptions is orchestratorOptions for interactive commands,
+// exiting when config.json can't be read
+func mustOrchestratorOptions() services.Options {
+	opts, err := orchestratorOptions()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	return opts
+}
+
+// newInventoryCache returns the inventory cache shared by all awsbreak commands.
+// Discovery results depend on orchestrator options, so each combination gets
+// its own cache directory.
+func newInventoryCache(discoverer inventory.Discoverer) *inventory.Cache {
+	scope := "default"
+	if flagIncludeNetwork {
+		scope = "network"
+	}
+	if flagIncludeReservations {
+		scope += "-reservations"
+	}
+	// A partial view must never stand in for a full one
+	if len(selectedServices) > 0 || len(skippedServices) > 0 {
+		var only, skip []string
+		for _, svc := range selectedServices {
+			only = append(only, string(svc))
+		}
+		for _, svc := range skippedServices {
+			skip = append(skip, "no-"+string(svc))
+		}
+		sort.Strings(only)
+		sort.Strings(skip)
+		scope += "-" + strings.Join(append(only, skip...), "-")
+	}
+	dir := filepath.Join(configMgr.GetConfigDir(), "cache", scope)
+	return inventory.NewCache(discoverer, dir, inventory.DefaultTTL)
+}
+
+// saveSnapshot records successfully paused resources so they can be restored
+// later, returning the snapshot ID or "" if nothing was saved
+func saveSnapshot(ctx context.Context, region string, results []models.OperationResult) string {
+	snapshot := state.NewSnapshot(region, results)
+	if len(snapshot.Resources) == 0 {
+		return ""
+	}
+
+	snapshots, err := snapshotManager()
+	if err == nil {
+		err = snapshots.Save(ctx, snapshot)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to save snapshot: %v\n", err)
+		return ""
+	}
+	fmt.Printf("📸 Snapshot saved: %s\n", snapshot.SnapshotID)
+	return snapshot.SnapshotID
+}
+
+// recordRun appends a finished run to the ledger, which backs history,
+// leaderboard and digest. The journal ID is reused so runs can be looked up
+// under the same ID retry shows.
+func recordRun(entry *journal.Entry, results []models.OperationResult) {
+	record := ledger.NewEntry(entry.Operation, entry.Region, results)
+	record.ID = entry.ID
+	record.SnapshotID = entry.SnapshotID
+	record.Duration = entry.Duration()
+	record.Discovery = entry.Discovery
+
+	l := ledger.NewLedger(configMgr.GetConfigDir())
+	if err := l.Append(record); err != nil {
+		fmt.Printf("⚠️  Failed to record run in ledger: %v\n", err)
+	}
+}
+
+// runSummary combines the runs of one operation, such as a canary and the
+// rest, into a ledger entry for reporting their efficiency
+func runSummary(operation string, results []models.OperationResult, runs ...*journal.Entry) ledger.Entry {
+	summary := ledger.Entry{Operation: operation, Results: results}
+	for _, r := range runs {
+		summary.Duration += r.Duration()
+		summary.Discovery += r.Discovery
+	}
+	return summary
+}
+
+// displayEfficiency prints how long a run took against the burn it changed,
+// warning when discovery outweighed a pause that stopped very little
+func displayEfficiency(e ledger.Entry) {
+	if eff := e.Efficiency(); eff != "" {
+		fmt.Printf("⏱️  This run %s\n", eff)
+	}
+	if e.DiscoveryOverhead() {
+		fmt.Printf("⚠️  Discovery took %s, longer than the pause itself, to stop $%.2f/hour.\n",
+			e.Discovery.Round(time.Second), e.HourlyRate())
+		fmt.Println("   On an account this small, pausing less often saves nearly as much.")
+	}
+}
+
+func displayConnectionChecks(checks []services.ConnectionCheck) {
+	if len(checks) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("⚠️  Databases with recent connections:")
+	for _, c := range checks {
+		switch {
+		case c.Error != "":
+			fmt.Printf("   ? %s: could not check connections (%s)\n", c.Resource.ResourceID, c.Error)
+		case c.Blocked && !flagForce:
+			fmt.Printf("   ⛔ %s: %.0f connections in the last hour - skipping (use --force to stop anyway)\n",
+				c.Resource.ResourceID, c.Connections)
+		default:
+			fmt.Printf("   ⚠️  %s: %.0f connections in the last hour\n", c.Resource.ResourceID, c.Connections)
+		}
+	}
+}
+
+func displayProtected(protected []services.ProtectedResource) {
+	if len(protected) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("🛡️  Protected resources:")
+	for _, p := range protected {
+		if flagForce {
+			fmt.Printf("   ⚠️  %s: %s - stopping anyway (--force)\n", p.Resource.ResourceID, p.Reason)
+		} else {
+			fmt.Printf("   ⛔ %s: %s - skipping (use --force to stop anyway)\n", p.Resource.ResourceID, p.Reason)
+		}
+	}
+}
+
+// displayIaCManaged groups the resources infrastructure as code manages,
+// whose pause the next apply may undo
+func displayIaCManaged(resources []models.Resource) {
+	byOwner := make(map[string][]string)
+	for _, r := range resources {
+		if by := services.ManagedBy(r); by != "" {
+			byOwner[by] = append(byOwner[by], r.ResourceID)
+		}
+	}
+	if len(byOwner) == 0 {
+		return
+	}
+
+	owners := make([]string, 0, len(byOwner))
+	for by := range byOwner {
+		owners = append(owners, by)
+	}
+	sort.Strings(owners)
+
+	fmt.Println()
+	fmt.Println("🏗️  Managed by infrastructure as code (pausing shows up as drift; the next apply may undo it):")
+	for _, by := range owners {
+		fmt.Printf("   • %s\n", by)
+		for _, id := range byOwner[by] {
+			fmt.Printf("     - %s\n", id)
+		}
+	}
+}
+
+// displayDiscoveryReport warns about services that couldn't be fully
+// discovered, so missing permissions don't look like an empty account
+func displayDiscoveryReport(report *models.DiscoveryReport) {
+	if report.Complete() {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("⚠️  Discovery was incomplete:")
+	for _, s := range report.Failed() {
+		fmt.Printf("   ❌ %s: %s\n", s.ServiceType, s.Error)
+	}
+	for _, s := range report.Partial() {
+		fmt.Printf("   ⚠️  %s: found %d, but skipped:\n", s.ServiceType, s.Resources)
+		for _, w := range s.Warnings {
+			fmt.Printf("      - %s\n", w)
+		}
+	}
+	fmt.Println("   Run 'awsbreak role check' if these look like missing permissions.")
+}
+
 func calculateMonthlyCost(resources []models.Resource) float64 {
 	var total float64
 	for _, r := range resources {
@@ -391,3 +904,57 @@ func filterStopped(resources []models.Resource) []models.Resource {
 	}
 	return stopped
 }
+
+func splitReportOnly(resources []models.Resource) (actionable, reportOnly []models.Resource) {
+	for _, r := range resources {
+		if services.IsReportOnly(r) {
+			reportOnly = append(reportOnly, r)
+		} else {
+			actionable = append(actionable, r)
+		}
+	}
+	return actionable, reportOnly
+}
+
+// filterOnly keeps resources matching ARN, "service:id" or bare "id" specs
+// and returns the specs that matched nothing
+func filterOnly(resources []models.Resource, specs []string) (kept []models.Resource, unmatched []string) {
+	matched := make(map[string]bool)
+	for _, r := range resources {
+		for _, spec := range specs {
+			svc, id, hasService := strings.Cut(spec, ":")
+			if strings.HasPrefix(spec, "arn:") || !hasService {
+				svc, id, hasService = "", spec, false
+			}
+			if !r.Matches(id) || (hasService && models.ServiceType(svc) != r.ServiceType) {
+				continue
+			}
+			kept = append(kept, r)
+			matched[spec] = true
+			break
+		}
+	}
+
+	for _, spec := range specs {
+		if !matched[spec] {
+			unmatched = append(unmatched, spec)
+		}
+	}
+	return kept, unmatched
+}
+
+// mergeResources combines resource lists, keeping the first occurrence of each resource
+func mergeResources(lists ...[]models.Resource) []models.Resource {
+	seen := models.NewKeySet()
+	var merged []models.Resource
+	for _, list := range lists {
+		for _, r := range list {
+			if seen.Has(r) {
+				continue
+			}
+			seen.Add(r)
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"log"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/issues"
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// fileRecurringFailures opens a tracker issue for each resource that has now
+// failed the same operation in several runs in a row, once per streak. The
+// run's results are compared with the earlier runs in the ledger. Problems
+// are logged; they never fail the run.
+func fileRecurringFailures(ctx context.Context, run *journal.Entry, results []models.OperationResult) {
+	operation := run.Operation
+	if !configMgr.Exists() {
+		return
+	}
+	cfg, err := configMgr.Load()
+	if err != nil || cfg.Issues == nil {
+		return
+	}
+
+	filed, err := issues.LoadFiled(configMgr.GetConfigDir())
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return
+	}
+	filed.Forget(operation, results)
+	defer func() {
+		if err := filed.Save(); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}()
+
+	entries, err := ledger.NewLedger(configMgr.GetConfigDir()).Entries()
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return
+	}
+
+	var pending []issues.Failure
+	latest := ledger.Entry{ID: run.ID, Region: run.Region, Operation: operation, Results: results}
+	for _, f := range issues.Recurring(entries, latest, cfg.Issues.AfterRuns) {
+		if !filed.Has(f) {
+			pending = append(pending, f)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	tracker, err := issues.FromConfig(cfg.Issues)
+	if err != nil {
+		log.Printf("⚠️  Can't file issues for recurring failures: %v", err)
+		return
+	}
+	for _, f := range pending {
+		url, err := tracker.Create(ctx, f.Issue(cfg.Issues.Labels))
+		if err != nil {
+			log.Printf("⚠️  Failed to file an issue for %s: %v", f.Resource.ResourceID, err)
+			continue
+		}
+		filed.Add(f, url)
+		log.Printf("📝 %s has failed to %s %d runs in a row - filed %s", f.Resource.ResourceID, operation, len(f.Runs), url)
+	}
+}
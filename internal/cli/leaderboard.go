@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
+)
+
+var (
+	flagLeaderboardDays int
+	flagLeaderboardTag  string
+)
+
+var leaderboardCmd = &cobra.Command{
+	Use:   "leaderboard",
+	Short: "Rank teams by savings from paused resources",
+	Long: `Rank teams by the money saved while their resources were braked.
+
+Savings are attributed using a resource tag (default "team", configurable
+with attribution_tag in config.json or --tag).`,
+	Run: runLeaderboard,
+}
+
+func init() {
+	leaderboardCmd.Flags().IntVar(&flagLeaderboardDays, "days", 30, "Number of days to include")
+	leaderboardCmd.Flags().StringVar(&flagLeaderboardTag, "tag", "", "Tag key used to attribute savings")
+	rootCmd.AddCommand(leaderboardCmd)
+}
+
+func runLeaderboard(cmd *cobra.Command, args []string) {
+	fmt.Println("\n🏆 AWSBREAK - Savings Leaderboard")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	var err error
+	configMgr, err = config.NewManager()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	if configMgr.Exists() {
+		if _, err := configMgr.Load(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+	}
+
+	tagKey := flagLeaderboardTag
+	if tagKey == "" {
+		tagKey = configMgr.GetAttributionTag()
+	}
+
+	entries, err := ledger.NewLedger(configMgr.GetConfigDir()).Entries()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	until := time.Now()
+	since := until.AddDate(0, 0, -flagLeaderboardDays)
+	board := ledger.Leaderboard(entries, tagKey, since, until)
+
+	fmt.Printf("   Last %d days, attributed by tag %q\n\n", flagLeaderboardDays, tagKey)
+
+	if len(board) == 0 {
+		fmt.Println("   No savings recorded yet. Slam the brakes to get on the board!")
+		return
+	}
+
+	var total float64
+	fmt.Printf("   %-4s %-24s %9s %12s %10s\n", "#", "TEAM", "RESOURCES", "PAUSED HRS", "SAVED")
+	for i, ts := range board {
+		medal := fmt.Sprintf("%d", i+1)
+		switch i {
+		case 0:
+			medal = "🥇"
+		case 1:
+			medal = "🥈"
+		case 2:
+			medal = "🥉"
+		}
+		fmt.Printf("   %-4s %-24s %9d %12.1f %10s\n", medal, ts.Team, ts.Resources, ts.PausedHours,
+			fmt.Sprintf("$%.2f", ts.Savings))
+		total += ts.Savings
+	}
+
+	fmt.Println()
+	fmt.Printf("💰 Total saved: $%.2f\n", total)
+}
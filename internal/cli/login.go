@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+var flagProfile string
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Sign in to an IAM Identity Center (SSO) profile",
+	Long: `Sign in to the IAM Identity Center profile awsbreak starts from, using the
+device code flow: approve the login in a browser and awsbreak caches the token
+where the AWS CLI and SDKs look for it.
+
+The profile comes from --profile, the profile saved in the awsbreak config, or
+AWS_PROFILE. It must already be set up with 'aws configure sso'. With
+--profile and an existing configuration, the profile is saved so later runs
+use it without the flag.
+
+Tokens are renewed automatically shortly before they expire, so scheduled
+runs keep working until the Identity Center session itself ends.`,
+	Run: runLogin,
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+}
+
+// configureAuth points credential loading at --profile, or at the profile
+// saved in the config without the flag, and applies the config's role
+// session settings and observer mode. An invalid session setting stops every
+// command but setup, which falls back to the default session so it can be
+// used to fix the config.
+func configureAuth(cmd *cobra.Command) {
+	if flagProfile != "" {
+		auth.SetProfile(flagProfile)
+	}
+	mgr, err := config.NewManager()
+	if err != nil || !mgr.Exists() {
+		return
+	}
+	cfg, err := mgr.Load()
+	if err != nil {
+		return
+	}
+	if flagProfile == "" && cfg.Profile != "" {
+		auth.SetProfile(cfg.Profile)
+	}
+	observerInstall = cfg.Observer
+
+	s := sessionFromConfig(cfg.Session)
+	if err := s.Validate(); err != nil {
+		if cmd.Name() == "setup" {
+			fmt.Printf("⚠️  session in config.json: %v; using the default session\n", err)
+			return
+		}
+		fmt.Printf("❌ session in config.json: %v\n", err)
+		exit(ExitConfigError)
+	}
+	auth.SetSession(s)
+}
+
+// sessionFromConfig converts the config's session settings for auth
+func sessionFromConfig(c *models.SessionConfig) auth.Session {
+	if c == nil {
+		return auth.Session{}
+	}
+	return auth.Session{
+		ExternalID: c.ExternalID,
+		Duration:   time.Duration(c.DurationMinutes) * time.Minute,
+		Name:       c.Name,
+	}
+}
+
+func runLogin(cmd *cobra.Command, args []string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Println("\n🔐 AWSBREAK - Login")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	name := auth.Profile()
+	profile, err := auth.LoadSSOProfile(ctx, name)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	if profile == nil {
+		fmt.Printf("❌ Profile %s isn't an IAM Identity Center profile\n", name)
+		fmt.Println("   Run 'aws configure sso' to set one up, then 'awsbreak login --profile <name>'.")
+		exit(ExitConfigError)
+	}
+
+	fmt.Printf("Profile: %s (%s)\n", profile.Name, profile.StartURL)
+	expires, err := auth.LoginSSO(ctx, profile, func(link, code string) {
+		fmt.Println("\nApprove the login in your browser and check it shows this code:")
+		fmt.Printf("   🔑 %s\n", code)
+		fmt.Printf("   🔗 %s\n", link)
+		if err := openBrowser(link); err != nil {
+			fmt.Println("   (couldn't open a browser - copy the link above)")
+		}
+		fmt.Println()
+	})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+	fmt.Printf("✅ Signed in until %s\n", formatTime(expires))
+
+	if flagProfile != "" && checkConfiguration() {
+		cfg, err := configMgr.Load()
+		if err == nil && cfg.Profile != flagProfile {
+			cfg.Profile = flagProfile
+			err = configMgr.Save(cfg)
+		}
+		if err != nil {
+			fmt.Printf("⚠️  Couldn't save the profile: %v\n", err)
+		} else {
+			fmt.Printf("   Saved %s as the profile awsbreak starts from\n", flagProfile)
+		}
+	}
+
+	region := flagRegion
+	if region == "" {
+		region = profile.Region
+	}
+	caller, err := auth.CallerIdentity(ctx, region)
+	if err != nil {
+		fmt.Printf("⚠️  Signed in, but the profile's credentials don't work yet: %v\n", err)
+		fmt.Printf("   Check the account and role set for %s in your AWS config.\n", profile.Name)
+		exit(ExitAuthError)
+	}
+	fmt.Printf("   Credentials: %s\n", caller)
+}
@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/notify"
+	"github.com/aicoder2009/aws-hit-breaks/internal/schedule"
+)
+
+// groupTagKey tags resources into a group that isn't configured
+const groupTagKey = "awsbreak:group"
+
+var (
+	flagOpenGroup string
+	flagOpenFor   time.Duration
+	flagOpenWarn  time.Duration
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open",
+	Short: "Resume a group for a while, then pause it again",
+	Long: `Resume a group of paused resources for a limited time, e.g. staging for a
+quick QA pass, and pause it again automatically when the time is up.
+
+A group is configured under "groups" in config.json with a tag (key or
+key=value) and optional region; otherwise it is every resource tagged
+awsbreak:group=<name>. Only what awsbreak paused is resumed.
+
+The re-pause is run by 'awsbreak daemon', which must be running. Before it,
+the requester is reminded through the configured notification channels.
+Opening a group that is already open moves its re-pause to the new time.
+
+Without --group, lists the groups that are open.`,
+	Example: `  awsbreak open --group staging --for 2h
+  awsbreak open --group staging --for 30m --warn 5m
+  awsbreak open`,
+	Args: cobra.NoArgs,
+	Run:  runOpen,
+}
+
+func init() {
+	openCmd.Flags().StringVar(&flagOpenGroup, "group", "", "Group to resume")
+	openCmd.Flags().DurationVar(&flagOpenFor, "for", 2*time.Hour, "How long to keep the group running")
+	openCmd.Flags().DurationVar(&flagOpenWarn, "warn", 15*time.Minute, "How long before the re-pause to remind the requester")
+	rootCmd.AddCommand(openCmd)
+}
+
+// resolveGroup returns a group's configuration, falling back to the group tag
+func resolveGroup(cfg *models.Config, name string) models.GroupConfig {
+	for _, g := range cfg.Groups {
+		if g.Name == name {
+			return g
+		}
+	}
+	return models.GroupConfig{Name: name, Tag: groupTagKey + "=" + name}
+}
+
+func runOpen(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	if flagOpenGroup == "" {
+		listOpenings()
+		return
+	}
+	if flagOpenFor <= 0 {
+		fmt.Println("❌ --for must be a positive duration, e.g. 2h")
+		exit(ExitConfigError)
+	}
+	if flagOpenWarn < 0 || flagOpenWarn >= flagOpenFor {
+		fmt.Println("❌ --warn must be shorter than --for")
+		exit(ExitConfigError)
+	}
+
+	group := resolveGroup(cfg, flagOpenGroup)
+	if group.Tag == "" {
+		fmt.Printf("❌ Group %s has no tag in config.json\n", group.Name)
+		exit(ExitConfigError)
+	}
+	region := flagRegion
+	if region == "" {
+		region = group.Region
+	}
+	if region == "" {
+		region = configMgr.GetDefaultRegion()
+	}
+
+	fmt.Printf("\n🔓 Opening %s (%s) in %s for %s...\n", group.Name, group.Tag, region, flagOpenFor)
+
+	snapshots := mustSnapshotManager()
+	snapshot, err := snapshots.Latest(ctx, region)
+	if err != nil {
+		fmt.Printf("❌ Could not load snapshot: %v\n", err)
+		exit(ExitGeneralError)
+	}
+	var resources []models.Resource
+	if snapshot != nil {
+		resources = filterResources(filterResources(snapshot.PendingResources(), hasTag(group.Tag)), serviceSelected)
+	}
+
+	if len(resources) == 0 {
+		fmt.Printf("\n✅ Nothing in %s is parked - it is already running.\n", group.Name)
+	} else {
+		displayResources(resources)
+		if flagDryRun {
+			fmt.Println("\n👀 DRY RUN - Just checking, not starting anything")
+			return
+		}
+
+		awsCfg, err := assumeRole(ctx, cfg, region)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitAuthError)
+		}
+
+		fmt.Println("\n🚀 Releasing brakes - starting resources...")
+		entry := &journal.Entry{Operation: "resume", Region: region, SnapshotID: snapshot.SnapshotID, Resources: resources}
+		results, err := runOperation(ctx, awsCfg, entry)
+		displayResults(results)
+		if err != nil {
+			fmt.Printf("❌ Engine trouble: %v\n", err)
+			exit(ExitServiceError)
+		}
+		noteFailures(results, nil)
+	}
+	if flagDryRun {
+		return
+	}
+
+	now := time.Now()
+	var snapshotID string
+	if len(resources) > 0 {
+		snapshotID = snapshot.SnapshotID
+	}
+	opening := schedule.Opening{
+		Group:     group.Name,
+		Region:    region,
+		Tag:       group.Tag,
+		Requester: notify.Requester(),
+		Opened:    now,
+		Until:     now.Add(flagOpenFor),
+		WarnAt:    now.Add(flagOpenFor - flagOpenWarn),
+		// Reopening a group that is still open keeps its original snapshot
+		SnapshotID: snapshotID,
+	}
+	if previous, ok := findOpening(opening); ok && opening.SnapshotID == "" {
+		opening.SnapshotID = previous.SnapshotID
+	}
+	if err := saveOpening(opening); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("   The group is running but won't be paused again automatically.")
+		exit(ExitGeneralError)
+	}
+
+	fmt.Printf("\n⏱️  %s pauses again at %s\n", group.Name, formatWhen(opening.Until))
+	if cfg.Notifications == nil {
+		fmt.Println("   ⚠️  No notification channels are set up, so the reminder only goes to the daemon log.")
+	} else {
+		fmt.Printf("   📣 You'll get a reminder %s before.\n", flagOpenWarn)
+	}
+	if !daemonRunning() {
+		fmt.Println("   ⚠️  'awsbreak daemon' doesn't seem to be running - start it, or nothing will pause the group.")
+	}
+}
+
+// saveOpening records an opening, replacing any for the same group and region
+func saveOpening(opening schedule.Opening) error {
+	openings, err := schedule.LoadOpenings(configMgr.GetConfigDir())
+	if err != nil {
+		return err
+	}
+
+	kept := []schedule.Opening{opening}
+	for _, o := range openings {
+		if !o.Same(opening) {
+			kept = append(kept, o)
+		}
+	}
+	return schedule.SaveOpenings(configMgr.GetConfigDir(), kept)
+}
+
+// findOpening returns the saved opening for the same group and region, if any
+func findOpening(opening schedule.Opening) (schedule.Opening, bool) {
+	openings, err := schedule.LoadOpenings(configMgr.GetConfigDir())
+	if err != nil {
+		return schedule.Opening{}, false
+	}
+	for _, o := range openings {
+		if o.Same(opening) {
+			return o, true
+		}
+	}
+	return schedule.Opening{}, false
+}
+
+// openingSnapshot returns the snapshot a group's re-pause is recorded in: the
+// one it was resumed from, if it still exists, otherwise a new one
+func openingSnapshot(ctx context.Context, o schedule.Opening) string {
+	if o.SnapshotID == "" {
+		return ""
+	}
+	snapshots, err := snapshotManager()
+	if err != nil {
+		return ""
+	}
+	if _, err := snapshots.Load(ctx, o.SnapshotID); err != nil {
+		log.Printf("⚠️  Open group %s: snapshot %s is gone, recording the pause in a new one", o.Group, o.SnapshotID)
+		return ""
+	}
+	return o.SnapshotID
+}
+
+// daemonRunning reports whether a daemon has checked schedules recently
+func daemonRunning() bool {
+	st, err := schedule.LoadState(configMgr.GetConfigDir())
+	return err == nil && time.Since(st.LastCheck) <= 3*daemonTick
+}
+
+func listOpenings() {
+	openings, err := schedule.LoadOpenings(configMgr.GetConfigDir())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	if len(openings) == 0 {
+		fmt.Println("No groups are open. Open one with 'awsbreak open --group <name> --for 2h'.")
+		return
+	}
+
+	fmt.Println("🔓 Open groups")
+	for _, o := range openings {
+		fmt.Printf("   • %-16s %-16s pauses %s (opened by %s)\n", o.Group, o.Region, formatWhen(o.Until), o.Requester)
+	}
+}
+
+// closeOpenings reminds requesters of groups about to re-pause and pauses
+// groups whose time is up. Failed re-pauses are retried on the next tick.
+func closeOpenings(ctx context.Context, cfg *models.Config, now time.Time) {
+	openings, err := schedule.LoadOpenings(configMgr.GetConfigDir())
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return
+	}
+	if len(openings) == 0 {
+		return
+	}
+
+	var kept []schedule.Opening
+	for _, o := range openings {
+		if now.Before(o.WarnAt) || (o.Warned && now.Before(o.Until)) {
+			kept = append(kept, o)
+			continue
+		}
+
+		awsCfg, err := assumeRole(ctx, cfg, o.Region)
+		if err != nil {
+			log.Printf("❌ Open group %s: %v", o.Group, err)
+			kept = append(kept, o)
+			continue
+		}
+
+		if !o.Warned {
+			warnClosing(ctx, cfg, awsCfg, o)
+			o.Warned = true
+		}
+		if now.Before(o.Until) {
+			kept = append(kept, o)
+			continue
+		}
+
+		log.Printf("🔒 %s: open time is up, pausing %s again", o.Group, o.Region)
+		results, err := brakeRegionInto(ctx, cfg, awsCfg, o.Region, hasTag(o.Tag), openingSnapshot(ctx, o))
+		if err != nil {
+			log.Printf("❌ Open group %s: %v", o.Group, err)
+			kept = append(kept, o)
+			continue
+		}
+		logResults("🛑 Paused", results)
+		// Whatever didn't stop is tried again on the next tick
+		if failed := len(results) - countSuccessful(results); failed > 0 {
+			log.Printf("⚠️  Open group %s: %d resource(s) didn't pause; retrying next check", o.Group, failed)
+			kept = append(kept, o)
+		}
+	}
+
+	// Keep openings made while this tick ran
+	latest, err := schedule.LoadOpenings(configMgr.GetConfigDir())
+	if err == nil {
+		for _, o := range latest {
+			if !containsOpening(openings, o) {
+				kept = append(slices.DeleteFunc(kept, o.Same), o)
+			}
+		}
+	}
+	if err := schedule.SaveOpenings(configMgr.GetConfigDir(), kept); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+}
+
+// containsOpening reports whether openings has one identical to o
+func containsOpening(openings []schedule.Opening, o schedule.Opening) bool {
+	for _, existing := range openings {
+		if existing.Same(o) && existing.Opened.Equal(o.Opened) {
+			return true
+		}
+	}
+	return false
+}
+
+// warnClosing reminds the requester that a group is about to pause again
+func warnClosing(ctx context.Context, cfg *models.Config, awsCfg aws.Config, o schedule.Opening) {
+	log.Printf("⏰ %s: pausing %s again at %s (opened by %s)", o.Group, o.Region, formatWhen(o.Until), o.Requester)
+
+	notifiers, err := notify.FromConfig(cfg.Notifications, awsCfg)
+	if err != nil {
+		log.Printf("⚠️  Using the built-in notification message: %v", err)
+	}
+	if len(notifiers) == 0 {
+		return
+	}
+	if err := notify.Send(ctx, notifiers, notify.NewClosing(o.Group, o.Region, o.Requester, o.Until)); err != nil {
+		log.Printf("⚠️  Failed to send reminder: %v", err)
+	}
+}
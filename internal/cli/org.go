@@ -0,0 +1,549 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/fanout"
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/org"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var (
+	flagOrgRoleName    string
+	flagOrgOU          string
+	flagOrgAccounts    []string
+	flagOrgExclude     []string
+	flagOrgRegions     []string
+	flagOrgConcurrency int
+	flagOrgYes         bool
+
+	flagOrgAccountConcurrency int
+)
+
+var orgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Brake every account in an AWS Organization",
+	Long: `Run awsbreak across the member accounts of an AWS Organization.
+
+Accounts are listed with the Organizations API using your default AWS
+credentials (typically the management or a delegated administrator account).
+awsbreak then assumes --role-name in each member account, so deploy the role
+to every account first, e.g. with a service-managed StackSet of
+cloudformation/iam-role.yaml with TrustedAccountId set to this account.
+
+Examples:
+  awsbreak org accounts --ou ou-abcd-12345678
+  awsbreak org scan --ou ou-abcd-12345678
+  awsbreak org pause --ou ou-abcd-12345678
+  awsbreak org resume --accounts 111111111111,222222222222
+  awsbreak org pause --regions us-east-1,eu-west-1
+
+Work is split into (account, region, service) tasks for discovery and
+(account, region) tasks for pause and resume, run in parallel under a global
+cap (--concurrency) and a per-account cap (--account-concurrency). Progress
+is shown as a live account × region matrix.`,
+}
+
+var orgAccountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "List the member accounts awsbreak would act on",
+	Args:  cobra.NoArgs,
+	Run:   runOrgAccounts,
+}
+
+var orgScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Report running resources in every account",
+	Args:  cobra.NoArgs,
+	Run:   runOrgScan,
+}
+
+var orgPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause resources in every account",
+	Args:  cobra.NoArgs,
+	Run:   runOrgPause,
+}
+
+var orgResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume the latest snapshot in every account",
+	Args:  cobra.NoArgs,
+	Run:   runOrgResume,
+}
+
+func init() {
+	flags := orgCmd.PersistentFlags()
+	flags.StringVar(&flagOrgRoleName, "role-name", auth.DefaultMemberRoleName, "Role to assume in each member account")
+	flags.StringVar(&flagOrgOU, "ou", "", "Only include accounts under this organizational unit (or root) ID")
+	flags.StringSliceVar(&flagOrgAccounts, "accounts", nil, "Only include these account IDs or names")
+	flags.StringSliceVar(&flagOrgExclude, "exclude", nil, "Skip these account IDs or names")
+	flags.StringSliceVar(&flagOrgRegions, "regions", nil, "Regions to cover (defaults to --region or the configured region)")
+	flags.IntVar(&flagOrgConcurrency, "concurrency", fanout.DefaultGlobal, "Maximum tasks running at once across all accounts")
+	flags.IntVar(&flagOrgAccountConcurrency, "account-concurrency", fanout.DefaultPerAccount, "Maximum tasks running at once in one account")
+	flags.BoolVarP(&flagOrgYes, "yes", "y", false, "Don't ask for confirmation")
+
+	orgCmd.AddCommand(orgAccountsCmd, orgScanCmd, orgPauseCmd, orgResumeCmd)
+	rootCmd.AddCommand(orgCmd)
+}
+
+func runOrgAccounts(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	_, _, accounts := orgSetup(ctx)
+
+	fmt.Printf("\n🏢 %d accounts\n", len(accounts))
+	for _, a := range accounts {
+		fmt.Printf("   • %s  %-32s %s\n", a.ID, a.Name, a.Email)
+	}
+}
+
+func runOrgScan(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	cfg, base, accounts := orgSetup(ctx)
+
+	reports := scanOrg(ctx, cfg, base, accounts, orgRegions())
+	displayOrgReport(reports, false)
+}
+
+func runOrgPause(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	cfg, base, accounts := orgSetup(ctx)
+	regions := orgRegions()
+
+	reports := scanOrg(ctx, cfg, base, accounts, regions)
+	displayOrgReport(reports, false)
+
+	// Remember what to stop per account and region from the scan
+	targets := make(map[string][]models.Resource)
+	total := 0
+	for _, r := range reports {
+		for _, res := range actionableResources(r) {
+			key := res.AccountID + "|" + res.Region
+			targets[key] = append(targets[key], res)
+			total++
+		}
+	}
+
+	if total == 0 {
+		fmt.Println("\n✅ Nothing to stop in any account.")
+		return
+	}
+	if flagDryRun {
+		fmt.Println("\n👀 DRY RUN - Just checking mirrors, no brakes applied")
+		return
+	}
+	if !flagOrgYes {
+		if !confirm(fmt.Sprintf("\n🛑 Hit the brakes on %d resources across %d accounts? [y/N]: ", total, len(accounts))) {
+			fmt.Println("Cancelled.")
+			return
+		}
+	}
+
+	fmt.Println("\n🛑 BRAKES ENGAGED - Stopping resources in every account...")
+	reports = operateOrg(ctx, base, accounts, regions, "pause", func(account, region string) (*journal.Entry, bool) {
+		resources := targets[account+"|"+region]
+		return &journal.Entry{Operation: "pause", Region: region, Resources: resources}, len(resources) > 0
+	})
+	displayOrgReport(reports, true)
+	sweepOrg(ctx, cfg, base, reports, regions[0])
+}
+
+// sweepOrg runs the verification sweep in each account where the pause
+// stopped something and prints a line per account. The full reports go to
+// the sweep history.
+func sweepOrg(ctx context.Context, cfg *models.Config, base aws.Config, reports []org.AccountReport, region string) {
+	if !sweepEnabled(cfg) {
+		return
+	}
+	fmt.Println("\n🧾 Verification sweep")
+	for _, r := range reports {
+		if countSuccessful(r.Results) == 0 {
+			continue
+		}
+		acctCfg := auth.AccountConfig(base, auth.MemberRoleARN(r.Account.ID, flagOrgRoleName))
+		acctCfg.Region = region
+
+		spin := startSpinner(fmt.Sprintf("Sweeping %s...", r.Account.Name))
+		report := sweepAfterPause(ctx, cfg, acctCfg, region)
+		spin.halt()
+
+		still := 0
+		for _, region := range report.Regions {
+			still += len(region.Running)
+		}
+		switch {
+		case report.AllClear():
+			fmt.Printf("✅ %s: all clear in %d region(s)\n", r.Account.Name, len(report.Regions))
+		case still > 0:
+			fmt.Printf("🚨 %s: %d still running, ~$%.2f/month\n", r.Account.Name, still, report.MonthlyCost())
+		default:
+			fmt.Printf("⚠️  %s: some regions or services couldn't be checked\n", r.Account.Name)
+		}
+	}
+}
+
+func runOrgResume(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	_, base, accounts := orgSetup(ctx)
+	regions := orgRegions()
+
+	snapshots := mustSnapshotManager()
+	pending := make(map[string]*models.AccountSnapshot)
+	total, parkedAccounts := 0, make(map[string]bool)
+	for _, a := range accounts {
+		for _, region := range regions {
+			snapshot, err := snapshots.LatestForAccount(ctx, a.ID, region)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				exit(ExitGeneralError)
+			}
+			if snapshot == nil {
+				continue
+			}
+			if n := len(filterResources(snapshot.PendingResources(), serviceSelected)); n > 0 {
+				pending[a.ID+"|"+region] = snapshot
+				parkedAccounts[a.ID] = true
+				total += n
+			}
+		}
+	}
+
+	if total == 0 {
+		fmt.Println("\n✅ Nothing parked - all accounts already running!")
+		return
+	}
+
+	fmt.Printf("\n📸 %d parked resources across %d accounts\n", total, len(parkedAccounts))
+	if flagDryRun {
+		fmt.Println("\n👀 DRY RUN - Just checking, not starting anything")
+		return
+	}
+	if !flagOrgYes {
+		if !confirm("\nRelease brakes in every account? [y/N]: ") {
+			fmt.Println("Staying parked.")
+			return
+		}
+	}
+
+	fmt.Println("\n🚀 Releasing brakes in every account...")
+	reports := operateOrg(ctx, base, accounts, regions, "resume", func(account, region string) (*journal.Entry, bool) {
+		snapshot := pending[account+"|"+region]
+		if snapshot == nil {
+			return nil, false
+		}
+		return &journal.Entry{
+			Operation:  "resume",
+			Region:     region,
+			SnapshotID: snapshot.SnapshotID,
+			Resources:  filterResources(snapshot.PendingResources(), serviceSelected),
+		}, true
+	})
+	displayOrgReport(reports, true)
+}
+
+// scanOrg discovers every (account, region, service) combination in parallel
+// and applies the same safety checks as a single-account pause
+func scanOrg(ctx context.Context, cfg *models.Config, base aws.Config, accounts []org.Account, regions []string) []org.AccountReport {
+	reports := make(map[string]*org.AccountReport)
+	labels := make(map[string]string)
+	var (
+		mu    sync.Mutex
+		tasks []fanout.Task
+	)
+
+	opts := mustOrchestratorOptions()
+	for _, a := range accounts {
+		reports[a.ID] = &org.AccountReport{Account: a}
+		labels[a.ID] = a.Name
+		acctCfg := auth.AccountConfig(base, auth.MemberRoleARN(a.ID, flagOrgRoleName))
+
+		for _, region := range regions {
+			regionCfg := acctCfg.Copy()
+			regionCfg.Region = region
+			orchestrator := services.NewOrchestrator(regionCfg, opts)
+
+			for _, svc := range orchestrator.ServiceTypes() {
+				tasks = append(tasks, fanout.Task{
+					Account: a.ID,
+					Region:  region,
+					Service: string(svc),
+					Run: func(ctx context.Context) error {
+						// A partly discovered service still reports what was
+						// found; its error marks the task failed afterwards
+						resources, err := orchestrator.Discover(ctx, region, svc)
+						var partial *services.PartialError
+						if err != nil && !errors.As(err, &partial) {
+							return err
+						}
+						for i := range resources {
+							resources[i].AccountID = a.ID
+						}
+
+						actionable, skipped := splitReportOnly(resources)
+						if !flagForce {
+							var protected []services.ProtectedResource
+							actionable, protected = services.NewGuard(cfg.Protection).Split(actionable)
+							for _, p := range protected {
+								skipped = append(skipped, p.Resource)
+							}
+							checks := services.NewConnectionChecker(regionCfg, cfg.ConnectionThreshold).CheckAll(ctx, actionable)
+							for _, c := range checks {
+								if c.Blocked {
+									skipped = append(skipped, c.Resource)
+								}
+							}
+						}
+
+						mu.Lock()
+						defer mu.Unlock()
+						report := reports[a.ID]
+						report.Resources = append(report.Resources, resources...)
+						report.Skipped = append(report.Skipped, skipped...)
+						return err
+					},
+				})
+			}
+		}
+	}
+
+	fmt.Printf("\n🔍 Scanning %d accounts in %s (%d tasks)...\n", len(accounts), strings.Join(regions, ", "), len(tasks))
+	errs := runFanout(ctx, tasks, labels)
+	return collectReports(accounts, reports, tasks, errs)
+}
+
+// operateOrg pauses or resumes each (account, region) in parallel. entryFor
+// returns the journal entry to run, or false to skip the pair.
+func operateOrg(ctx context.Context, base aws.Config, accounts []org.Account, regions []string, operation string,
+	entryFor func(account, region string) (*journal.Entry, bool)) []org.AccountReport {
+	reports := make(map[string]*org.AccountReport)
+	labels := make(map[string]string)
+	var (
+		mu    sync.Mutex
+		tasks []fanout.Task
+	)
+
+	for _, a := range accounts {
+		reports[a.ID] = &org.AccountReport{Account: a}
+		labels[a.ID] = a.Name
+		roleARN := auth.MemberRoleARN(a.ID, flagOrgRoleName)
+		acctCfg := auth.AccountConfig(base, roleARN)
+
+		for _, region := range regions {
+			entry, ok := entryFor(a.ID, region)
+			if !ok {
+				continue
+			}
+			entry.RoleARN = roleARN
+
+			regionCfg := acctCfg.Copy()
+			regionCfg.Region = region
+			tasks = append(tasks, fanout.Task{
+				Account: a.ID,
+				Region:  region,
+				Service: operation,
+				Run: func(ctx context.Context) error {
+					results, err := runOperation(ctx, regionCfg, entry)
+
+					mu.Lock()
+					defer mu.Unlock()
+					report := reports[a.ID]
+					report.Resources = append(report.Resources, entry.Resources...)
+					report.Results = append(report.Results, results...)
+					return err
+				},
+			})
+		}
+	}
+
+	errs := runFanout(ctx, tasks, labels)
+	return collectReports(accounts, reports, tasks, errs)
+}
+
+// runFanout runs tasks within the org concurrency limits, showing a live
+// account × region progress matrix
+func runFanout(ctx context.Context, tasks []fanout.Task, labels map[string]string) []error {
+	matrix := fanout.NewMatrix(os.Stdout, tasks, labels)
+	matrix.Width = currentLayout().width
+	matrix.Start()
+	errs := fanout.Run(ctx, tasks, fanout.Limits{
+		Global:     flagOrgConcurrency,
+		PerAccount: flagOrgAccountConcurrency,
+	}, matrix.Observe)
+	matrix.Stop()
+	return errs
+}
+
+// collectReports attaches task errors to their accounts and returns reports in account order
+func collectReports(accounts []org.Account, reports map[string]*org.AccountReport, tasks []fanout.Task, errs []error) []org.AccountReport {
+	failures := make(map[string][]string)
+	for i, err := range errs {
+		if err != nil {
+			t := tasks[i]
+			failures[t.Account] = append(failures[t.Account], fmt.Sprintf("%s/%s: %v", t.Region, t.Service, err))
+		}
+	}
+
+	ordered := make([]org.AccountReport, 0, len(accounts))
+	for _, a := range accounts {
+		report := reports[a.ID]
+		report.Error = strings.Join(failures[a.ID], "; ")
+		ordered = append(ordered, *report)
+	}
+	return ordered
+}
+
+// orgSetup loads local configuration (optional in org mode), connects to the
+// organization and returns the accounts selected by the filter flags
+func orgSetup(ctx context.Context) (*models.Config, aws.Config, []org.Account) {
+	var err error
+	configMgr, err = config.NewManager()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	cfg := &models.Config{}
+	if configMgr.Exists() {
+		if cfg, err = configMgr.Load(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+	}
+
+	base, err := orgBaseConfig(ctx, orgRegion())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	accounts, err := org.ListAccounts(ctx, base, flagOrgOU)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+	accounts = org.FilterAccounts(accounts, flagOrgAccounts, flagOrgExclude)
+	if len(accounts) == 0 {
+		fmt.Println("❌ No matching accounts in the organization.")
+		exit(ExitConfigError)
+	}
+
+	return cfg, base, accounts
+}
+
+// orgBaseConfig returns the default credentials used to reach member accounts
+func orgBaseConfig(ctx context.Context, region string) (aws.Config, error) {
+	awsCfg, err := auth.NewIAMAuthenticator("", region).GetAWSConfig(ctx)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("authentication failed: %w", err)
+	}
+	return awsCfg, nil
+}
+
+func orgRegion() string {
+	if flagRegion != "" {
+		return flagRegion
+	}
+	return configMgr.GetDefaultRegion()
+}
+
+// orgRegions returns the regions to fan out over: --regions, else the single region
+func orgRegions() []string {
+	if len(flagOrgRegions) > 0 {
+		for _, region := range flagOrgRegions {
+			if err := config.ValidateRegion(region); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				exit(ExitConfigError)
+			}
+			warnUnknownRegion(region)
+		}
+		return flagOrgRegions
+	}
+	return []string{orgRegion()}
+}
+
+// actionableResources returns the scanned resources that weren't skipped
+func actionableResources(r org.AccountReport) []models.Resource {
+	skipped := make(map[string]bool)
+	for _, s := range r.Skipped {
+		skipped[s.Key()] = true
+	}
+
+	var actionable []models.Resource
+	for _, res := range r.Resources {
+		if !skipped[res.Key()] {
+			actionable = append(actionable, res)
+		}
+	}
+	return actionable
+}
+
+func displayOrgReport(reports []org.AccountReport, withResults bool) {
+	fmt.Println()
+	var t *table
+	if withResults {
+		t = newTable(column{title: "ACCOUNT"}, column{title: "NAME", flex: true},
+			column{title: "SUCCEEDED", right: true}, column{title: "FAILED", right: true},
+			column{title: "STATUS", flex: true})
+	} else {
+		t = newTable(column{title: "ACCOUNT"}, column{title: "NAME", flex: true},
+			column{title: "RESOURCES", right: true}, column{title: "SKIPPED", right: true},
+			column{title: "$/MONTH", right: true}, column{title: "STATUS", flex: true})
+	}
+
+	var (
+		totalResources, totalSkipped, totalOK, totalFailed, failedAccounts int
+		totalCost                                                          float64
+	)
+	for _, r := range reports {
+		status := "✅"
+		if r.Error != "" {
+			status = "❌ " + r.Error
+			failedAccounts++
+		}
+
+		if withResults {
+			failed := len(r.Results) - r.Succeeded()
+			if failed > 0 && r.Error == "" {
+				status = "⚠️"
+			}
+			t.addRow("", r.Account.ID, r.Account.Name, strconv.Itoa(r.Succeeded()), strconv.Itoa(failed), status)
+			totalOK += r.Succeeded()
+			totalFailed += failed
+			continue
+		}
+
+		t.addRow("", r.Account.ID, r.Account.Name, strconv.Itoa(len(r.Resources)), strconv.Itoa(len(r.Skipped)),
+			fmt.Sprintf("$%.2f", r.MonthlyCost()), status)
+		totalResources += len(r.Resources)
+		totalSkipped += len(r.Skipped)
+		totalCost += r.MonthlyCost()
+	}
+	t.print()
+
+	fmt.Println()
+	if withResults {
+		fmt.Printf("🏁 %d succeeded, %d failed across %d accounts\n", totalOK, totalFailed, len(reports))
+	} else {
+		fmt.Printf("🔥 Burning: $%.2f/month across %d resources (%d skipped)\n", totalCost, totalResources, totalSkipped)
+	}
+	if failedAccounts > 0 {
+		setExitStatus(ExitServiceError)
+		fmt.Printf("⚠️  %d accounts could not be processed - check that %s is deployed there\n", failedAccounts, flagOrgRoleName)
+	}
+	if totalFailed > 0 {
+		setExitStatus(ExitPartialFailure)
+	}
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var (
+	flagPlan         string
+	flagPlanTags     []string
+	flagPlanServices []string
+	flagPlanRegion   string
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Manage saved brake plans",
+	Long: `Manage brake plans: named selections of resources that are braked and
+released together, so a team that always parks the same stack doesn't pick it
+out every time.
+
+A resource is in a plan when it has every --tag (key or key=value) and, if
+--services is given, belongs to one of those services.
+
+Examples:
+  awsbreak plan create dev-stack --tag env=dev --services ec2,rds
+  awsbreak --plan dev-stack         Brake the plan
+  awsbreak --go --plan dev-stack    Release it
+  awsbreak plan list
+  awsbreak plan remove dev-stack`,
+}
+
+var planListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved plans",
+	Args:  cobra.NoArgs,
+	Run:   runPlanList,
+}
+
+var planCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Save or replace a plan",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPlanCreate,
+}
+
+var planRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"delete"},
+	Short:   "Remove a plan",
+	Args:    cobra.ExactArgs(1),
+	Run:     runPlanRemove,
+}
+
+func init() {
+	planCreateCmd.Flags().StringSliceVar(&flagPlanTags, "tag", nil, "Tag resources must have, key or key=value (repeatable)")
+	planCreateCmd.Flags().StringSliceVar(&flagPlanServices, "services", nil, "Services to include, e.g. ec2,rds (default: all)")
+	planCreateCmd.Flags().StringVar(&flagPlanRegion, "region", "", "AWS region (defaults to the configured region)")
+
+	rootCmd.Flags().StringVar(&flagPlan, "plan", "", "Brake or release only the resources in this saved plan")
+
+	planCmd.AddCommand(planListCmd, planCreateCmd, planRemoveCmd)
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlanList(cmd *cobra.Command, args []string) {
+	cfg := loadConfigOrExit()
+
+	fmt.Println("\n📋 AWSBREAK - Plans")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	if len(cfg.Plans) == 0 {
+		fmt.Println("   No plans. Save one with 'awsbreak plan create'.")
+		return
+	}
+
+	for _, p := range cfg.Plans {
+		region := p.Region
+		if region == "" {
+			region = configMgr.GetDefaultRegion()
+		}
+		fmt.Printf("   • %-16s %-40s %s\n", p.Name, describePlan(p), region)
+	}
+}
+
+func runPlanCreate(cmd *cobra.Command, args []string) {
+	cfg := loadConfigOrExit()
+
+	planServices, err := services.ParseServices(flagPlanServices)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	p := models.PlanConfig{
+		Name:     args[0],
+		Tags:     flagPlanTags,
+		Services: planServices,
+		Region:   flagPlanRegion,
+	}
+	if len(p.Tags) == 0 && len(p.Services) == 0 {
+		fmt.Println("❌ A plan needs at least one --tag or --services")
+		exit(ExitConfigError)
+	}
+
+	replaced := false
+	for i := range cfg.Plans {
+		if cfg.Plans[i].Name == p.Name {
+			cfg.Plans[i] = p
+			replaced = true
+		}
+	}
+	if !replaced {
+		cfg.Plans = append(cfg.Plans, p)
+	}
+
+	if err := configMgr.Save(cfg); err != nil {
+		fmt.Printf("❌ Failed to save configuration: %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	fmt.Printf("✅ Plan %s: %s\n", p.Name, describePlan(p))
+	fmt.Printf("   Brake it with 'awsbreak --plan %s'.\n", p.Name)
+}
+
+func runPlanRemove(cmd *cobra.Command, args []string) {
+	cfg := loadConfigOrExit()
+
+	var kept []models.PlanConfig
+	for _, p := range cfg.Plans {
+		if p.Name != args[0] {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(cfg.Plans) {
+		fmt.Printf("❌ No plan named %s\n", args[0])
+		exit(ExitConfigError)
+	}
+
+	cfg.Plans = kept
+	if err := configMgr.Save(cfg); err != nil {
+		fmt.Printf("❌ Failed to save configuration: %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	fmt.Printf("✅ Removed plan %s\n", args[0])
+}
+
+// describePlan summarizes a plan's selection
+func describePlan(p models.PlanConfig) string {
+	var parts []string
+	if len(p.Tags) > 0 {
+		parts = append(parts, "tags "+strings.Join(p.Tags, ", "))
+	}
+	if len(p.Services) > 0 {
+		names := make([]string, len(p.Services))
+		for i, s := range p.Services {
+			names[i] = string(s)
+		}
+		parts = append(parts, "services "+strings.Join(names, ","))
+	} else {
+		parts = append(parts, "all services")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// selectedPlan returns the plan named by --plan, or nil without it. An
+// unknown plan exits.
+func selectedPlan(cfg *models.Config) *models.PlanConfig {
+	if flagPlan == "" {
+		return nil
+	}
+	for i := range cfg.Plans {
+		if cfg.Plans[i].Name == flagPlan {
+			return &cfg.Plans[i]
+		}
+	}
+	fmt.Printf("❌ No plan named %s. See 'awsbreak plan list'.\n", flagPlan)
+	exit(ExitConfigError)
+	return nil
+}
+
+// inPlan returns a filter accepting the resources a plan selects
+func inPlan(p *models.PlanConfig) func(models.Resource) bool {
+	tags := make([]func(models.Resource) bool, len(p.Tags))
+	for i, spec := range p.Tags {
+		tags[i] = hasTag(spec)
+	}
+	return func(r models.Resource) bool {
+		if len(p.Services) > 0 && !slices.Contains(p.Services, r.ServiceType) {
+			return false
+		}
+		for _, match := range tags {
+			if !match(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// applyPlan narrows resources to the plan, if one was selected
+func applyPlan(p *models.PlanConfig, resources []models.Resource) []models.Resource {
+	if p == nil {
+		return resources
+	}
+	kept := filterResources(resources, inPlan(p))
+	fmt.Printf("📋 Plan %s: %d of %d resources (%s)\n", p.Name, len(kept), len(resources), describePlan(*p))
+	return kept
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/cost"
+)
+
+var pricingCmd = &cobra.Command{
+	Use:   "pricing",
+	Short: "Show the cached AWS prices used for savings estimates",
+	Long: `Savings estimates use built-in rates unless current prices have been
+downloaded with 'awsbreak pricing update'. Cached prices are stored per region
+in the config directory and work offline.
+
+Examples:
+  awsbreak pricing
+  awsbreak pricing update
+  awsbreak pricing update us-east-1 eu-west-1`,
+	Run: runPricing,
+}
+
+var pricingUpdateCmd = &cobra.Command{
+	Use:   "update [region...]",
+	Short: "Download current EC2 and RDS prices for regions",
+	Long: `Download the public AWS price list for EC2 and RDS and cache the on-demand
+rates for each region. With no regions, updates --region or the configured
+default region.`,
+	Run: runPricingUpdate,
+}
+
+func init() {
+	pricingCmd.AddCommand(pricingUpdateCmd)
+	rootCmd.AddCommand(pricingCmd)
+}
+
+func runPricing(cmd *cobra.Command, args []string) {
+	fmt.Println("\n🏷️  AWSBREAK - Pricing")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	loadConfigManager()
+	offers, err := cost.NewOfferCache(configMgr.GetConfigDir()).List()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	if len(offers) == 0 {
+		fmt.Println("\n   No prices cached - estimates use built-in rates.")
+		fmt.Println("   Run 'awsbreak pricing update' to download current prices.")
+		return
+	}
+
+	t := newTable(
+		column{title: "REGION"},
+		column{title: "PUBLISHED"},
+		column{title: "DOWNLOADED"},
+		column{title: "RATES", right: true},
+	)
+	for _, o := range offers {
+		icon := "✅"
+		if o.Stale() {
+			icon = "⚠️ "
+		}
+		t.addRow(icon, o.Region, formatDate(o.PublishedAt), formatWhen(o.DownloadedAt), strconv.Itoa(len(o.Rates)))
+	}
+	fmt.Println()
+	t.print()
+}
+
+func runPricingUpdate(cmd *cobra.Command, args []string) {
+	fmt.Println("\n🏷️  AWSBREAK - Pricing Update")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	loadConfigManager()
+	regions := args
+	if len(regions) == 0 {
+		region := flagRegion
+		if region == "" {
+			region = configMgr.GetDefaultRegion()
+		}
+		regions = []string{region}
+	}
+	for _, region := range regions {
+		if err := config.ValidateRegion(region); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitGeneralError)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cache := cost.NewOfferCache(configMgr.GetConfigDir())
+	failed := 0
+	for _, region := range regions {
+		fmt.Printf("\n⏳ Downloading prices for %s...\n", region)
+		start := time.Now()
+		offer, err := cache.Update(ctx, region)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s: %d rates, published %s (%s)\n", region, len(offer.Rates),
+			formatDate(offer.PublishedAt), time.Since(start).Round(time.Second))
+	}
+
+	if failed > 0 {
+		exit(ExitGeneralError)
+	}
+}
+
+// displayPricingAsOf notes the date of cached prices used for estimates in a
+// region, and suggests a refresh when they are stale
+func displayPricingAsOf(region string) {
+	if configMgr == nil {
+		return
+	}
+	offer, err := cost.NewOfferCache(configMgr.GetConfigDir()).Load(region)
+	if err != nil || offer == nil {
+		return
+	}
+
+	fmt.Printf("   Pricing as of %s\n", formatDate(offer.PublishedAt))
+	if offer.Stale() {
+		fmt.Println("   ⚠️  Cached prices are over 90 days old - run 'awsbreak pricing update'")
+	}
+}
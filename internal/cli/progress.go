@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+// discoveryProgress shows which services are being scanned while discovery
+// runs, and a line for each service as it finishes
+type discoveryProgress struct {
+	ctx      context.Context // failures after it is cancelled are cancellations
+	spin     *spinner
+	total    int
+	done     int
+	scanning []models.ServiceType
+}
+
+// started marks a service as being scanned
+func (p *discoveryProgress) started(service models.ServiceType) {
+	p.scanning = append(p.scanning, service)
+	p.spin.update(p.status())
+}
+
+// finished reports a service's result and takes it off the scanning list
+func (p *discoveryProgress) finished(sd models.ServiceDiscovery) {
+	p.scanning = slices.DeleteFunc(p.scanning, func(s models.ServiceType) bool { return s == sd.ServiceType })
+	p.done++
+
+	switch {
+	case sd.Error != "" && p.ctx.Err() != nil:
+		p.spin.println(fmt.Sprintf("   ✋ %s: cancelled", sd.ServiceType))
+	case sd.Error != "":
+		p.spin.println(fmt.Sprintf("   ❌ %s: failed", sd.ServiceType))
+	case len(sd.Warnings) > 0:
+		p.spin.println(fmt.Sprintf("   ⚠️  %s: found %d, incomplete (%s)", sd.ServiceType, sd.Resources, sd.Duration))
+	default:
+		p.spin.println(fmt.Sprintf("   ✅ %s: found %d (%s)", sd.ServiceType, sd.Resources, sd.Duration))
+	}
+	p.spin.update(p.status())
+}
+
+// status is the spinner line: how many services are done and which are
+// still being scanned
+func (p *discoveryProgress) status() string {
+	names := make([]string, len(p.scanning))
+	for i, s := range p.scanning {
+		names[i] = string(s)
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("Scanning services (%d/%d done)...", p.done, p.total)
+	}
+	return fmt.Sprintf("Scanning %s (%d/%d done)...", strings.Join(names, ", "), p.done, p.total)
+}
+
+// discoverRegion rediscovers a region, showing each service's progress.
+// Ctrl+C cancels the discoveries still running and exits.
+func discoverRegion(awsCfg aws.Config, region string) *inventory.View {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	p := &discoveryProgress{ctx: ctx}
+	opts := mustOrchestratorOptions()
+	opts.OnDiscoveryStart = p.started
+	opts.OnDiscovered = p.finished
+	orchestrator := services.NewOrchestrator(awsCfg, opts)
+	p.total = len(orchestrator.ServiceTypes())
+
+	p.spin = startSpinner(p.status())
+	view, err := newInventoryCache(orchestrator).Get(ctx, region, inventory.RefreshAlways)
+	p.spin.halt()
+
+	switch {
+	case ctx.Err() != nil:
+		fmt.Println("\n✋ Discovery cancelled - nothing was changed.")
+		exit(ExitGeneralError)
+	case err != nil:
+		fmt.Printf("❌ Discovery failed: %v\n", err)
+		exit(ExitServiceError)
+	}
+	return view
+}
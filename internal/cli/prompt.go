@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables that answer prompts ahead of time
+const (
+	envRoleARN       = "AWSBREAK_ROLE_ARN"
+	envDefaultRegion = "AWSBREAK_DEFAULT_REGION"
+	envAssumeYes     = "AWSBREAK_ASSUME_YES"
+)
+
+// question is something awsbreak asks the user. An answer given through a
+// flag or the environment is used without prompting, so flows can be
+// scripted in provisioning pipelines.
+type question struct {
+	message string
+	flag    string // value of the matching command-line flag, if any
+	env     string // environment variable consulted when flag is empty
+}
+
+// prompter reads answers from flags, the environment or an input stream. One
+// reader is shared across questions so piped answers aren't lost to buffering.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	yes bool // AWSBREAK_ASSUME_YES, parsed once
+}
+
+var stdPrompter = newPrompter(os.Stdin, os.Stdout)
+
+// newPrompter creates a prompter reading from in and writing prompts to out.
+// AWSBREAK_ASSUME_YES is read now with strconv.ParseBool; a value it doesn't
+// accept is ignored with a warning.
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	p := &prompter{in: bufio.NewReader(in), out: out}
+	if value := strings.TrimSpace(os.Getenv(envAssumeYes)); value != "" {
+		yes, err := strconv.ParseBool(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Ignoring %s=%q: expected true or false\n", envAssumeYes, value)
+		}
+		p.yes = yes
+	}
+	return p
+}
+
+// ask returns the preset answer to q, or prompts for one
+func (p *prompter) ask(q question) string {
+	if q.flag != "" {
+		return q.flag
+	}
+	if q.env != "" {
+		if value := strings.TrimSpace(os.Getenv(q.env)); value != "" {
+			return value
+		}
+	}
+
+	fmt.Fprint(p.out, q.message)
+	input, _ := p.in.ReadString('\n')
+	return strings.TrimSpace(input)
+}
+
+// confirm asks a yes/no question; a true AWSBREAK_ASSUME_YES answers yes to all of them
+func (p *prompter) confirm(message string) bool {
+	if p.yes {
+		return true
+	}
+	answer := strings.ToLower(p.ask(question{message: message}))
+	return strings.HasPrefix(answer, "y") || answer == "1" || answer == "true"
+}
+
+// assumeYes reports whether AWSBREAK_ASSUME_YES is set to a true value
+func (p *prompter) assumeYes() bool {
+	return p.yes
+}
+
+func prompt(message string) string {
+	return stdPrompter.ask(question{message: message})
+}
+
+func confirm(message string) bool {
+	return stdPrompter.confirm(message)
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/textwidth"
+)
+
+var flagQuiet bool
+
+// While --quiet is on, os.Stdout is a pipe whose output is copied to the
+// real standard output through a plainWriter
+var (
+	quietStdout *os.File
+	quietPipe   *os.File
+	quietDone   chan struct{}
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&flagQuiet, "quiet", "q", false, "Plain output without the banner and emoji, for cron jobs and logs")
+
+	help := rootCmd.HelpFunc()
+	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if flagQuiet && cmd == rootCmd {
+			cmd.Long = strings.TrimPrefix(cmd.Long, banner)
+		}
+		help(cmd, args)
+	})
+}
+
+// startQuiet strips emoji and rules from everything awsbreak prints and logs
+func startQuiet() {
+	if !flagQuiet || quietPipe != nil {
+		return
+	}
+	log.SetOutput(&plainWriter{w: os.Stderr})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		// Noisy output beats none
+		return
+	}
+	quietStdout, quietPipe, quietDone = os.Stdout, w, make(chan struct{})
+	os.Stdout = w
+	stdPrompter.out = w
+	go func() {
+		defer close(quietDone)
+		io.Copy(&plainWriter{w: quietStdout}, r)
+		r.Close()
+	}()
+}
+
+// stopQuiet writes out whatever --quiet still holds and restores stdout
+func stopQuiet() {
+	if quietPipe == nil {
+		return
+	}
+	os.Stdout = quietStdout
+	stdPrompter.out = quietStdout
+	quietPipe.Close()
+	<-quietDone
+	quietPipe = nil
+}
+
+// plainWriter drops emoji and the ━ rules under headings, along with the
+// spaces that separated them from the text. Lines left with nothing else
+// are dropped entirely.
+type plainWriter struct {
+	w       io.Writer
+	partial []byte // an incomplete UTF-8 sequence from the previous write
+	indent  []byte // leading spaces of the current line, held until text follows
+	text    bool   // the current line has text
+	dropped bool   // something was dropped from the current line
+	skip    bool   // skip spaces following a dropped rune
+}
+
+func (p *plainWriter) Write(b []byte) (int, error) {
+	n := len(b)
+	if len(p.partial) > 0 {
+		b = append(p.partial, b...)
+		p.partial = nil
+	}
+
+	out := make([]byte, 0, len(b))
+	for len(b) > 0 {
+		if !utf8.FullRune(b) {
+			p.partial = append([]byte(nil), b...)
+			break
+		}
+		r, size := utf8.DecodeRune(b)
+		raw := b[:size]
+		b = b[size:]
+
+		switch {
+		case isDecoration(r):
+			p.dropped, p.skip = true, true
+		case r == ' ' && p.skip:
+		case r == '\n':
+			if p.text || !p.dropped {
+				out = append(append(out, p.indent...), '\n')
+			}
+			p.indent, p.text, p.dropped, p.skip = p.indent[:0], false, false, false
+		case !p.text && (r == ' ' || r == '\t'):
+			p.indent = append(p.indent, raw...)
+		default:
+			if !p.text {
+				out = append(out, p.indent...)
+				p.indent, p.text = p.indent[:0], true
+			}
+			out = append(out, raw...)
+			p.skip = false
+		}
+	}
+
+	if _, err := p.w.Write(out); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// isDecoration reports whether r is an emoji or rule that --quiet drops
+func isDecoration(r rune) bool {
+	return textwidth.IsEmoji(r) ||
+		r == 0x2501 || // ━
+		r == 0xFE0F || r == 0x200D // emoji presentation and joiners
+}
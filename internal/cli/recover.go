@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/events"
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+var flagRecoverRollback bool
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover [run-id...]",
+	Short: "Finish or roll back runs that were interrupted part way",
+	Long: `Finish or roll back pauses and resumes that stopped part way, e.g. because
+the process was killed or the machine lost power.
+
+Every run is journaled before any resource is touched, again just before each
+resource's API call, and as each resource finishes. An interrupted run
+therefore knows which resources were done, which were in flight and which
+were never started.
+
+By default the run is finished: the resources without a result are paused or
+resumed, and a pause's snapshot is saved as usual. With --rollback an
+interrupted pause is undone instead: everything it stopped, or may have
+stopped, is resumed. Interrupted resumes can only be finished.
+
+'awsbreak --go' also picks up the resources of interrupted runs in its region,
+and 'awsbreak daemon' finishes interrupted runs when it starts. Runs whose
+awsbreak is still pausing or resuming keep a live heartbeat and are left
+alone, so recover is safe to run alongside them.
+
+Examples:
+  awsbreak recover --dry-run
+  awsbreak recover
+  awsbreak recover --rollback pause-us-east-1-20250101-190000.000`,
+	Run: runRecover,
+}
+
+func init() {
+	recoverCmd.Flags().BoolVar(&flagRecoverRollback, "rollback", false, "Undo interrupted pauses instead of finishing them")
+	rootCmd.AddCommand(recoverCmd)
+}
+
+func runRecover(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	fmt.Println("\n🩹 AWSBREAK - Recover interrupted runs")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	var err error
+	configMgr, err = config.NewManager()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	cfg := &models.Config{}
+	if configMgr.Exists() {
+		if cfg, err = configMgr.Load(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+	}
+
+	j := journal.NewJournal(configMgr.GetConfigDir())
+	entries, err := j.Pending()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	entries, unselected := entriesSelected(selectEntries(entries, args))
+	for _, e := range unselected {
+		fmt.Printf("⏭️  Skipping %s: it has resources of services left out with --services or --skip-services\n", e.ID)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("✅ Nothing to recover - no interrupted runs on record.")
+		return
+	}
+
+	for _, e := range entries {
+		fmt.Printf("\n   %s: %s in %s from %s\n", e.ID, e.Operation, e.Region, formatWhen(e.Started))
+		fmt.Printf("     %d done, %d in flight, %d not started\n",
+			len(e.Results), len(e.InFlight()), len(e.Remaining())-len(e.InFlight()))
+	}
+
+	if flagDryRun {
+		fmt.Println("\n👀 DRY RUN - Nothing recovered")
+		return
+	}
+
+	question := "\nFinish these runs? [y/N]: "
+	if flagRecoverRollback {
+		question = "\nRoll back these runs? [y/N]: "
+	}
+	if !confirm(question) {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	exitCode := ExitSuccess
+	for _, e := range entries {
+		awsCfg, err := entryConfig(ctx, cfg, e)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exitCode = ExitAuthError
+			continue
+		}
+
+		var results []models.OperationResult
+		switch {
+		case !flagRecoverRollback:
+			fmt.Printf("\n🩹 Finishing %s...\n", e.ID)
+			recordEvents(e, events.Event{
+				Type:      events.RunRecovered,
+				Operation: e.Operation,
+				Region:    e.Region,
+				Resources: len(e.Remaining()),
+			})
+			results, err = completeOperation(ctx, awsCfg, j, e)
+		case e.Operation != "pause":
+			fmt.Printf("\n⚠️  %s is a resume; it can only be finished (run recover without --rollback)\n", e.ID)
+			continue
+		default:
+			fmt.Printf("\n⏪ Rolling back %s...\n", e.ID)
+			results, err = rollbackPause(ctx, awsCfg, j, e)
+		}
+		displayResults(results)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exitCode = ExitServiceError
+			continue
+		}
+		if countSuccessful(results) < len(results) {
+			exitCode = ExitPartialFailure
+		}
+	}
+
+	exit(exitCode)
+}
+
+// rollbackPause resumes what an interrupted pause stopped or may have
+// stopped, then drops the pause from the journal. Anything that fails to
+// resume stays in the rollback's own journal entry for 'awsbreak retry'.
+func rollbackPause(ctx context.Context, awsCfg aws.Config, j *journal.Journal, e *journal.Entry) ([]models.OperationResult, error) {
+	var results []models.OperationResult
+	if changed := e.Changed(); len(changed) > 0 {
+		var err error
+		results, err = runOperation(ctx, awsCfg, &journal.Entry{
+			Operation: "resume",
+			Region:    e.Region,
+			RoleARN:   e.RoleARN,
+			Resources: changed,
+		})
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, j.Abandon(e)
+}
+
+// interruptedResources returns the resources interrupted runs in a region
+// left paused: what an interrupted pause stopped, or may have stopped, and
+// what an interrupted resume hadn't started yet. Runs in other accounts are
+// left to 'awsbreak recover'.
+func interruptedResources(region string) ([]models.Resource, []*journal.Entry) {
+	entries, err := journal.NewJournal(configMgr.GetConfigDir()).Pending()
+	if err != nil {
+		fmt.Printf("⚠️  Could not read the journal: %v\n", err)
+		return nil, nil
+	}
+
+	entries, _ = entriesSelected(entries)
+
+	var resources []models.Resource
+	var taken []*journal.Entry
+	for _, e := range entries {
+		if e.Region != region || e.RoleARN != "" {
+			continue
+		}
+		if e.Operation == "pause" {
+			resources = mergeResources(resources, e.Changed())
+		} else {
+			resources = mergeResources(resources, e.Remaining())
+		}
+		taken = append(taken, e)
+		fmt.Printf("🩹 Including resources from an interrupted %s (%s)\n", e.Operation, formatWhen(e.Started))
+	}
+	return resources, taken
+}
+
+// abandonEntries drops interrupted runs whose resources another run took over
+func abandonEntries(entries []*journal.Entry) {
+	j := journal.NewJournal(configMgr.GetConfigDir())
+	for _, e := range entries {
+		if err := j.Abandon(e); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var flagScanRegions bool
+
+func init() {
+	rootCmd.Flags().BoolVar(&flagScanRegions, "scan-regions", false, "Find which regions hold resources, then pause each of them")
+}
+
+// checkScanRegionsFlag rejects --scan-regions outside a pause or with a fixed region
+func checkScanRegionsFlag() {
+	if !flagScanRegions {
+		return
+	}
+	switch {
+	case flagGo:
+		fmt.Println("❌ --scan-regions only applies to pausing; resume follows the regions in your snapshots")
+		exit(ExitConfigError)
+	case flagRegion != "":
+		fmt.Println("❌ Use either --scan-regions or --region, not both")
+		exit(ExitConfigError)
+	case flagPlan != "":
+		fmt.Println("❌ Use either --scan-regions or --plan, not both; a plan has its own region")
+		exit(ExitConfigError)
+	}
+}
+
+// pauseScannedRegions finds the regions that hold resources with a cheap
+// pre-scan, then runs the usual pause in each of them in turn
+func pauseScannedRegions() {
+	ctx := context.Background()
+
+	cfg, err := configMgr.Load()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	awsCfg, err := assumeRole(ctx, cfg, configMgr.GetDefaultRegion())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	fmt.Println("\n🌍 Finding the regions your resources live in...")
+	spin := startSpinner("Scanning regions...")
+	found, err := services.NewRegionScanner(awsCfg).Scan(ctx)
+	spin.halt()
+	if err != nil {
+		fmt.Printf("❌ Region scan failed: %v\n", err)
+		exit(ExitServiceError)
+	}
+
+	if len(found) == 0 {
+		fmt.Println("\n✅ All clear! No region holds anything awsbreak can stop.")
+		return
+	}
+	if found[0].Source == services.ScanSourceResourceExplorer {
+		fmt.Println("   Using your Resource Explorer aggregator index; resources created in the last few minutes may not show yet.")
+	}
+	for _, a := range found {
+		if a.Err != nil {
+			fmt.Printf("   ⚠️  %-16s couldn't be checked - discovering anyway\n", a.Region)
+			fmt.Printf("      %s\n", fitLine(a.Err.Error(), 6))
+			continue
+		}
+		fmt.Printf("   📍 %-16s %d possible resource(s)\n", a.Region, a.Candidates)
+	}
+
+	for _, a := range found {
+		fmt.Printf("\n━━━ %s ━━━\n", a.Region)
+		interactivePause(a.Region)
+	}
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/report"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var (
+	flagReportFormat   string
+	flagReportOut      string
+	flagReportSnapshot string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Export a cost report as CSV, HTML or JSON",
+	Long: `Write a shareable cost report for a region: every running resource with
+its hourly, daily and monthly cost, tags and state, and what pausing them
+would save.
+
+When a pause is still in effect, the report compares cost before and after
+it and lists the resources the pause is keeping stopped. --snapshot picks
+the pause to compare with; it defaults to the latest one in the region.
+
+The format follows --format, or the extension of --out. Use --out - to write
+to stdout. The file defaults to awsbreak-report-YYYYMMDD.html in the current
+directory.`,
+	Example: `  awsbreak report
+  awsbreak report --format csv --out costs.csv
+  awsbreak report --out report.html --snapshot <id>
+  awsbreak report --format json --out -`,
+	Args: cobra.NoArgs,
+	Run:  runReport,
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&flagReportFormat, "format", "", "Report format: "+strings.Join(report.Formats, ", "))
+	reportCmd.Flags().StringVar(&flagReportOut, "out", "", "File to write, or - for stdout")
+	reportCmd.Flags().StringVar(&flagReportSnapshot, "snapshot", "", "Compare with this snapshot instead of the latest")
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReport(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	format, out := reportTarget(flagReportFormat, flagReportOut)
+	if !slices.Contains(report.Formats, format) {
+		fmt.Printf("❌ Unknown report format %q (use %s)\n", format, strings.Join(report.Formats, ", "))
+		exit(ExitConfigError)
+	}
+	// Keep progress off stdout when the report goes there
+	say := func(format string, a ...any) {
+		if out != "-" {
+			fmt.Printf(format, a...)
+		}
+	}
+
+	say("\n📄 AWSBREAK - Cost Report\n")
+	say("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+
+	region := flagRegion
+	if region == "" {
+		region = configMgr.GetDefaultRegion()
+	}
+
+	snapshots := mustSnapshotManager()
+	var snapshot *models.AccountSnapshot
+	var err error
+	if flagReportSnapshot != "" {
+		snapshot, err = snapshots.Load(ctx, flagReportSnapshot)
+		if err == nil {
+			region = snapshot.Region
+		}
+	} else {
+		snapshot, err = snapshots.Latest(ctx, region)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	awsCfg, err := assumeRole(ctx, cfg, region)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+	say("🔍 Checking what's running in %s...\n", region)
+	view, err := newInventoryCache(services.NewOrchestrator(awsCfg, mustOrchestratorOptions())).Get(ctx, region, inventory.RefreshIfStale)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Discovery failed: %v\n", err)
+		exit(ExitServiceError)
+	}
+
+	r := report.Build(region, view.Resources, snapshot, time.Now())
+	var buf bytes.Buffer
+	if err := report.Write(&buf, r, format); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	if out == "-" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+		fmt.Printf("❌ Failed to write %s: %v\n", out, err)
+		exit(ExitGeneralError)
+	}
+
+	fmt.Printf("\n   %d resources running\n", len(r.Resources))
+	fmt.Printf("💰 Pausing them could save $%.2f/month\n", r.MonthlySavings)
+	if c := r.Comparison; c != nil {
+		fmt.Printf("📸 Since %s: $%.2f/month before, $%.2f/month now\n",
+			c.SnapshotID, c.BeforeHourly*24*30, c.AfterHourly*24*30)
+	}
+	fmt.Printf("\n✅ Wrote %s report to %s\n", strings.ToUpper(format), out)
+}
+
+// reportTarget settles the report format and file: the format comes from
+// --format or the file extension, and the file defaults to a dated name
+func reportTarget(format, out string) (string, string) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(out)), ".")
+	}
+	if format == "" || format == "htm" {
+		format = "html"
+	}
+	if out == "" {
+		out = fmt.Sprintf("awsbreak-report-%s.%s", time.Now().Format("20060102"), format)
+	}
+	return format, out
+}
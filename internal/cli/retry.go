@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+var flagRetryList bool
+
+var retryCmd = &cobra.Command{
+	Use:   "retry [run-id...]",
+	Short: "Retry only the resources that failed in earlier runs",
+	Long: `Re-attempt the pause or resume of resources that failed, without touching
+anything that already succeeded.
+
+Runs with failures are kept in the journal. Retrying a pause adds the newly
+stopped resources to the snapshot of the original run, and retrying a resume
+marks them resumed in the snapshot being restored. With no arguments every
+run with failures is retried.
+
+Examples:
+  awsbreak retry --list
+  awsbreak retry
+  awsbreak retry pause-us-east-1-20250101-190000.000`,
+	Run: runRetry,
+}
+
+func init() {
+	retryCmd.Flags().BoolVar(&flagRetryList, "list", false, "List runs with failed resources")
+	rootCmd.AddCommand(retryCmd)
+}
+
+func runRetry(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	fmt.Println("\n🔁 AWSBREAK - Retry failures")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	var err error
+	configMgr, err = config.NewManager()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	cfg := &models.Config{}
+	if configMgr.Exists() {
+		if cfg, err = configMgr.Load(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+	}
+
+	j := journal.NewJournal(configMgr.GetConfigDir())
+	entries, err := j.Failed()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	entries = selectEntries(entries, args)
+
+	if len(entries) == 0 {
+		fmt.Println("✅ Nothing to retry - no failed resources on record.")
+		return
+	}
+
+	for _, e := range entries {
+		fmt.Printf("\n   %s: %s in %s, %d failed\n", e.ID, e.Operation, e.Region, len(e.FailedResources()))
+		for _, r := range e.Results {
+			if !r.Success {
+				fmt.Printf("     - %s %s: %s\n", r.Resource.ServiceType, consoleLink(r.Resource.ResourceID, r.Resource), r.Error)
+			}
+		}
+	}
+
+	if flagRetryList {
+		return
+	}
+	if flagDryRun {
+		fmt.Println("\n👀 DRY RUN - Nothing retried")
+		return
+	}
+
+	if !confirm("\nRetry these? [y/N]: ") {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	exitCode := ExitSuccess
+	for _, e := range entries {
+		fmt.Printf("\n🔁 Retrying %s...\n", e.ID)
+
+		awsCfg, err := entryConfig(ctx, cfg, e)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exitCode = ExitAuthError
+			continue
+		}
+
+		results, err := runOperation(ctx, awsCfg, &journal.Entry{
+			Operation:  e.Operation,
+			Region:     e.Region,
+			SnapshotID: e.SnapshotID,
+			RoleARN:    e.RoleARN,
+			Resources:  e.FailedResources(),
+		})
+		displayResults(results)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exitCode = ExitServiceError
+			continue
+		}
+
+		// Anything that failed again now lives in the new journal entry
+		if err := j.Discard(e); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		if countSuccessful(results) < len(results) {
+			exitCode = ExitPartialFailure
+		}
+	}
+
+	exit(exitCode)
+}
+
+// selectEntries keeps entries named in ids, or all entries if none are given
+func selectEntries(entries []*journal.Entry, ids []string) []*journal.Entry {
+	if len(ids) == 0 {
+		return entries
+	}
+
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var selected []*journal.Entry
+	for _, e := range entries {
+		if wanted[e.ID] {
+			selected = append(selected, e)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var roleCmd = &cobra.Command{
+	Use:   "role",
+	Short: "Inspect the IAM role awsbreak assumes",
+}
+
+var roleCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Compare the deployed role policy with the permissions this version needs",
+	Long: `Check that the configured role allows every action this version of awsbreak
+calls. New service managers need new permissions, so a role deployed by an
+older version can fail with AccessDenied after an upgrade.
+
+The role's policies are evaluated with the IAM policy simulator using your
+default AWS credentials, which need iam:SimulatePrincipalPolicy. If the role
+was created from the setup template, awsbreak offers to update its stack
+(this needs cloudformation:UpdateStack and IAM write access).
+
+For an observer install ('awsbreak setup --observer') only the read-only
+actions are required, and the check also fails if the role allows any action
+that stops, starts or changes a resource.
+
+Exits with a non-zero status while required permissions are missing.`,
+	Run: runRoleCheck,
+}
+
+func init() {
+	roleCmd.AddCommand(roleCheckCmd)
+	rootCmd.AddCommand(roleCmd)
+}
+
+func runRoleCheck(cmd *cobra.Command, args []string) {
+	fmt.Println("\n🔐 AWSBREAK - Role Check")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	if !checkConfiguration() {
+		fmt.Println("❌ No configuration found. Run setup first.")
+		exit(ExitConfigError)
+	}
+	cfg, err := configMgr.Load()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	region := flagRegion
+	if region == "" {
+		region = configMgr.GetDefaultRegion()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// The awsbreak role can't read its own policies; use the credentials
+	// that assume it
+	baseCfg, err := auth.NewIAMAuthenticator("", region).GetAWSConfig(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	fmt.Printf("   Role: %s\n\n", cfg.IAMRoleARN)
+
+	policy, template := auth.RolePolicy, auth.CloudFormationTemplate()
+	if cfg.Observer {
+		fmt.Println("   👀 Observer install: checking for read-only access")
+		policy, template = auth.ObserverPolicy(), auth.ObserverCloudFormationTemplate()
+	}
+
+	spin := startSpinner("Evaluating role policy...")
+	checks, err := auth.CheckRolePolicy(ctx, baseCfg, cfg.IAMRoleARN, policy)
+	var writes []auth.ActionCheck
+	if err == nil && cfg.Observer {
+		writes, err = auth.CheckRolePolicy(ctx, baseCfg, cfg.IAMRoleARN, auth.WritePolicy())
+	}
+	spin.halt()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		if services.IsAccessDenied(err) {
+			fmt.Println("   Your AWS credentials need iam:SimulatePrincipalPolicy on the role.")
+		}
+		exit(ExitAuthError)
+	}
+
+	missingRequired, missingOptional := displayRoleChecks(checks)
+	if allowed := allowedActions(writes); len(allowed) > 0 {
+		fmt.Printf("\n❌ The observer role allows %d action(s) that change resources:\n", len(allowed))
+		for _, a := range allowed {
+			fmt.Printf("   - %s\n", a)
+		}
+		fmt.Println("   Remove them, or redeploy the role from cloudformation/observer-role.yaml.")
+		exit(ExitConfigError)
+	}
+	if len(missingRequired) == 0 && len(missingOptional) == 0 {
+		fmt.Println("\n✅ The role allows everything this version of awsbreak needs.")
+		return
+	}
+
+	if len(missingRequired) == 0 {
+		fmt.Println("\n✅ The role allows every required action; optional commands noted above will fail.")
+	} else {
+		fmt.Printf("\n⚠️  The role is missing %d required action(s).\n", len(missingRequired))
+	}
+
+	if updateRoleStack(ctx, baseCfg, cfg.IAMRoleARN, template, append(missingRequired, missingOptional...)) {
+		return
+	}
+	if len(missingRequired) > 0 {
+		exit(ExitConfigError)
+	}
+}
+
+// displayRoleChecks prints one line per policy group and returns the actions
+// the role is missing
+func displayRoleChecks(checks []auth.ActionCheck) (missingRequired, missingOptional []string) {
+	var groups []string
+	missing := make(map[string][]string)
+	optional := make(map[string]bool)
+	for _, c := range checks {
+		if _, ok := missing[c.Group]; !ok {
+			groups = append(groups, c.Group)
+			missing[c.Group] = nil
+		}
+		optional[c.Group] = c.Optional
+		if c.Allowed {
+			continue
+		}
+		missing[c.Group] = append(missing[c.Group], c.Action)
+		if c.Optional {
+			missingOptional = append(missingOptional, c.Action)
+		} else {
+			missingRequired = append(missingRequired, c.Action)
+		}
+	}
+
+	for _, g := range groups {
+		switch {
+		case len(missing[g]) == 0:
+			fmt.Printf("✅ %s\n", g)
+		case optional[g]:
+			fmt.Printf("⚠️  %s (optional): missing %s\n", g, strings.Join(missing[g], ", "))
+		default:
+			fmt.Printf("❌ %s: missing %s\n", g, strings.Join(missing[g], ", "))
+		}
+	}
+	return missingRequired, missingOptional
+}
+
+// allowedActions returns the actions the role allows among checks
+func allowedActions(checks []auth.ActionCheck) []string {
+	var allowed []string
+	for _, c := range checks {
+		if c.Allowed {
+			allowed = append(allowed, c.Action)
+		}
+	}
+	return allowed
+}
+
+// updateRoleStack offers to update the stack that deployed the role to
+// template, or explains how to add the missing actions. It returns true once
+// the stack has been updated.
+func updateRoleStack(ctx context.Context, baseCfg aws.Config, roleARN, template string, missing []string) bool {
+	stack, err := auth.FindRoleStack(ctx, baseCfg, auth.RoleName(roleARN))
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+
+	switch {
+	case stack == nil:
+		fmt.Println("\n   The role wasn't created by CloudFormation. Add these actions to its policy:")
+		for _, a := range missing {
+			fmt.Printf("   - %s\n", a)
+		}
+		return false
+	case !stack.Managed():
+		file := "cloudformation/iam-role.yaml"
+		if observerInstall {
+			file = "cloudformation/observer-role.yaml"
+		}
+		fmt.Printf("\n   The role belongs to stack %s, deployed from %s.\n", stack.Name, file)
+		fmt.Println("   Update it from the template in this release:")
+		fmt.Printf("   aws cloudformation deploy --template-file %s --stack-name %s --capabilities CAPABILITY_NAMED_IAM\n", file, stack.Name)
+		return false
+	case flagDryRun:
+		fmt.Printf("\n   Dry run: stack %s would be updated to the current template.\n", stack.Name)
+		return false
+	}
+
+	if !confirm(fmt.Sprintf("\nUpdate stack %s to the current template? [y/N]: ", stack.Name)) {
+		return false
+	}
+
+	spin := startSpinner("Updating stack " + stack.Name + "...")
+	err = auth.UpdateRoleStack(ctx, baseCfg, stack, template)
+	spin.halt()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitServiceError)
+	}
+	fmt.Printf("✅ Stack %s updated. IAM changes can take a minute to apply.\n", stack.Name)
+	return true
+}
@@ -5,6 +5,11 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
 )
 
 // Exit codes for different error types
@@ -14,6 +19,10 @@ const (
 	ExitConfigError  = 2
 	ExitAuthError    = 3
 	ExitServiceError = 4
+	ExitOverBudget   = 5
+	// ExitPartialFailure means the run finished but some resources failed
+	// to pause or resume
+	ExitPartialFailure = 6
 )
 
 var (
@@ -23,43 +32,117 @@ var (
 	flagRegion  string
 	flagCheck   bool
 	flagVersion bool
+	flagForce   bool
+
+	flagIncludeNetwork      bool
+	flagIncludeReservations bool
+
+	flagServices     []string
+	flagSkipServices []string
+	// selectedServices and skippedServices are --services and --skip-services, parsed
+	selectedServices []models.ServiceType
+	skippedServices  []models.ServiceType
+
+	flagSnapshot string
+	flagOnly     []string
+
+	flagAllStopped bool
+
+	flagVerbose  bool
+	flagLogFile  string
+	flagTraceAWS bool
 
 	// Version info
 	version = "1.0.0"
 )
 
-// rootCmd represents the base command
-var rootCmd = &cobra.Command{
-	Use:   "awsbreak",
-	Short: "Hit the brakes on your AWS spending",
-	Long: `
+// banner heads the root command's help; --quiet leaves it out
+const banner = `
     ___  _       _______ ____  ____  _____  ___   __ __
    /   || |     / / ___// __ )/ __ \/ ___/ /   | / //_/
   / /| || | /| / /\__ \/ __  / /_/ / __/  / /| |/ ,<
  / ___ || |/ |/ /___/ / /_/ / _, _/ /___ / ___ / /| |
 /_/  |_||__/|__//____/_____/_/ |_/_____//_/  |_/_/ |_|
 
-Emergency brake for your AWS account.
+`
+
+// rootCmd represents the base command
+var rootCmd = &cobra.Command{
+	Use:   "awsbreak",
+	Short: "Hit the brakes on your AWS spending",
+	Long: banner + `Emergency brake for your AWS account.
 Stop all running services instantly. Resume anytime.
 
 Examples:
   awsbreak                    Slam the brakes (pause all)
   awsbreak --go               Release brakes (resume all)
+  awsbreak --go --snapshot <id>
+                              Restore one snapshot
+  awsbreak --go --only ec2:i-0abc123,rds:mydb
+                              Resume specific resources
+  awsbreak --plan dev-stack   Brake a saved plan (see 'awsbreak plan')
+  awsbreak --canary 10%       Pause 10% first, watch alarms, then the rest
+  awsbreak --after 30m        Warn the team, then pause in 30 minutes
+  awsbreak cancel             Call off an armed brake
+  awsbreak --scan-regions     Find the regions with resources, then pause each
+  awsbreak --verify-regions us-west-2
+                              Pause, then sweep only here and us-west-2, not every region
+  awsbreak open --group staging --for 2h
+                              Resume staging, pause it again in two hours
+  awsbreak login --profile dev
+                              Sign in to an IAM Identity Center profile
+  awsbreak recover            Finish or roll back an interrupted run
   awsbreak --check            Dashboard status
-  awsbreak --dry-run          Preview only`,
+  awsbreak --spectate         Dashboard that can't change anything
+  awsbreak --dry-run          Preview only
+  awsbreak --quiet            Plain output for cron jobs and logs
+
+Exit codes:
+  0  Success
+  1  General error
+  2  Configuration error
+  3  Authentication error
+  4  An AWS call or discovery failed
+  5  Over budget (--check --threshold)
+  6  Some resources failed to pause or resume`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		startQuiet()
+		setupLogging()
+		checkRegionFlag()
+		checkServiceFlags()
+		checkConcurrencyFlags()
+		configureAuth(cmd)
+		startSpectating(cmd)
+	},
 	Run: runRoot,
 }
 
 func init() {
 	rootCmd.Flags().BoolVarP(&flagGo, "go", "g", false, "Release brakes and resume services")
-	rootCmd.Flags().BoolVarP(&flagDryRun, "dry-run", "d", false, "Preview without making changes")
-	rootCmd.Flags().StringVar(&flagRegion, "region", "", "AWS region")
 	rootCmd.Flags().BoolVarP(&flagCheck, "check", "c", false, "Dashboard status")
 	rootCmd.Flags().BoolVarP(&flagVersion, "version", "v", false, "Show version")
+	rootCmd.Flags().StringVar(&flagSnapshot, "snapshot", "", "With --go, restore only what this snapshot paused")
+	rootCmd.Flags().StringSliceVar(&flagOnly, "only", nil, "With --go, resume only these resources (ARN or service:id, comma-separated)")
+	rootCmd.Flags().BoolVar(&flagAllStopped, "all-stopped", false, "With --go, also start stopped resources awsbreak did not pause")
+
+	// Shared with subcommands such as org, digest and serve
+	rootCmd.PersistentFlags().BoolVarP(&flagDryRun, "dry-run", "d", false, "Preview without making changes")
+	rootCmd.PersistentFlags().StringVar(&flagRegion, "region", "", "AWS region")
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "Shared AWS config profile to start from (default: AWS_PROFILE)")
+	rootCmd.PersistentFlags().BoolVarP(&flagForce, "force", "f", false, "Stop resources even when safety checks object")
+	rootCmd.PersistentFlags().BoolVar(&flagUTC, "utc", false, "Show timestamps in UTC (RFC 3339) without relative times")
+	rootCmd.PersistentFlags().BoolVar(&flagIncludeNetwork, "include-network", false, "Delete NAT gateways on pause and recreate them on resume")
+	rootCmd.PersistentFlags().BoolVar(&flagIncludeReservations, "include-reservations", false, "Cancel idle EC2 capacity reservations on pause and recreate them on resume")
+	rootCmd.PersistentFlags().StringSliceVar(&flagServices, "services", nil, "Only work with these services, e.g. ec2,rds")
+	rootCmd.PersistentFlags().StringSliceVar(&flagSkipServices, "skip-services", nil, "Leave these services out, e.g. ecs")
+	rootCmd.PersistentFlags().BoolVar(&flagVerbose, "verbose", false, "Print debug logs to stderr")
+	rootCmd.PersistentFlags().StringVar(&flagLogFile, "log-file", "", "Append debug logs as JSON lines to this file")
+	rootCmd.PersistentFlags().BoolVar(&flagTraceAWS, "trace-aws", false, "With --verbose or --log-file, log every AWS API call")
 }
 
 // Execute runs the root command
 func Execute() error {
+	defer stopQuiet()
 	return rootCmd.Execute()
 }
 
@@ -69,11 +152,18 @@ func runRoot(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	if flagCheck {
+	checkThresholdFlag()
+
+	// Spectators only ever get the dashboard
+	if flagCheck || flagSpectate {
 		runStatus()
 		return
 	}
 
+	checkCanaryFlags()
+	checkScanRegionsFlag()
+	checkAfterFlag(cmd)
+
 	if flagGo {
 		runResume()
 		return
@@ -93,8 +183,21 @@ func runPause() {
 		return
 	}
 
+	if flagScanRegions {
+		pauseScannedRegions()
+		return
+	}
+
 	// Run interactive pause workflow
-	interactivePause()
+	region := flagRegion
+	if region == "" {
+		region = configMgr.GetDefaultRegion()
+	}
+	if cfg := loadConfigOrExit(); graceDelay(cfg) > 0 {
+		armedPause(cfg, region, graceDelay(cfg))
+		return
+	}
+	interactivePause(region)
 }
 
 func runResume() {
@@ -103,17 +206,85 @@ func runResume() {
 
 	if !checkConfiguration() {
 		fmt.Println("❌ No configuration found. Run setup first.")
-		os.Exit(ExitConfigError)
+		exit(ExitConfigError)
 	}
 
 	interactiveResume()
 }
 
+// setupLogging starts the diagnostic log selected by --verbose, --log-file and
+// --trace-aws. The log file stays open until the process exits.
+func setupLogging() {
+	// The trace goes to the diagnostic log, so without one it would vanish
+	if flagTraceAWS && !flagVerbose && flagLogFile == "" {
+		fmt.Println("❌ --trace-aws needs --verbose or --log-file to say where the trace goes")
+		exit(ExitConfigError)
+	}
+
+	_, err := logging.Setup(logging.Options{
+		Verbose:  flagVerbose,
+		File:     flagLogFile,
+		TraceAWS: flagTraceAWS,
+	})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	logging.Debug("starting", "version", version, "args", os.Args[1:])
+}
+
+// checkServiceFlags parses --services and --skip-services, rejecting unknown
+// services and selections that leave nothing
+func checkServiceFlags() {
+	var err error
+	if selectedServices, err = services.ParseServices(flagServices); err == nil {
+		skippedServices, err = services.ParseServices(flagSkipServices)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	for svc := range services.DefaultPriorities {
+		if services.Selected(svc, selectedServices, skippedServices) {
+			return
+		}
+	}
+	fmt.Println("❌ --services and --skip-services leave no services to work with")
+	exit(ExitConfigError)
+}
+
+// serviceSelected reports whether a resource's service is selected by
+// --services and --skip-services
+func serviceSelected(r models.Resource) bool {
+	return services.Selected(r.ServiceType, selectedServices, skippedServices)
+}
+
+// checkRegionFlag rejects malformed --region values. Well-formed regions this
+// build doesn't know are allowed with a warning, so new regions work the day
+// AWS launches them.
+func checkRegionFlag() {
+	if flagRegion == "" {
+		return
+	}
+	if err := config.ValidateRegion(flagRegion); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	warnUnknownRegion(flagRegion)
+}
+
+func warnUnknownRegion(region string) {
+	if !config.IsKnownRegion(region) {
+		fmt.Printf("⚠️  %s is not a region this version of awsbreak knows about - continuing anyway\n", region)
+	}
+}
+
 func runStatus() {
 	fmt.Println("\n📊 AWSBREAK - Dashboard")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
 	showStatus()
+	checkBudget()
 }
 
 func runSetup() {
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/events"
+)
+
+var flagRunsJSON bool
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "List runs with a step-by-step event log",
+	Long: `List the pause and resume runs that have an event log, most recent first.
+
+Each run records what happened as it happened: when it started, every
+resource it found, each pause or resume issued, the state each resource
+reached or why it failed, and how the run ended. Show one with
+'awsbreak runs show <run-id>'; run IDs are the same as in 'awsbreak history'.`,
+	Args: cobra.NoArgs,
+	Run:  runRuns,
+}
+
+var runsShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Replay what happened during a run",
+	Example: `  awsbreak runs show pause-us-east-1-20250101-190000.000
+  awsbreak runs show pause-us-east-1-20250101-190000.000 --json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRunsShow,
+}
+
+func init() {
+	runsShowCmd.Flags().BoolVar(&flagRunsJSON, "json", false, "Print the raw events as JSON lines")
+	runsCmd.AddCommand(runsShowCmd)
+	rootCmd.AddCommand(runsCmd)
+}
+
+func runRuns(cmd *cobra.Command, args []string) {
+	loadConfigManager()
+
+	runs, err := events.Runs(configMgr.GetConfigDir())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded yet.")
+		return
+	}
+
+	fmt.Println("\n📜 Runs")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	for _, r := range runs {
+		fmt.Printf("   %-48s %s\n", r.ID, formatWhen(r.Modified))
+	}
+}
+
+func runRunsShow(cmd *cobra.Command, args []string) {
+	loadConfigManager()
+
+	evs, err := events.NewLog(configMgr.GetConfigDir(), args[0]).Events()
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("❌ No event log for run %s\n", args[0])
+		fmt.Println("   Runs from before event logs were kept are still in 'awsbreak history'.")
+		exit(ExitGeneralError)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	if flagRunsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range evs {
+			if err := enc.Encode(e); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				exit(ExitGeneralError)
+			}
+		}
+		return
+	}
+
+	fmt.Printf("\n📜 Run %s\n", args[0])
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	var start time.Time
+	for _, e := range evs {
+		if start.IsZero() {
+			start = e.Time
+		}
+		fmt.Printf("   %-8s %s\n", "+"+e.Time.Sub(start).Round(time.Second).String(), describeEvent(e))
+	}
+}
+
+// describeEvent renders one event as a line of the run's narrative
+func describeEvent(e events.Event) string {
+	resource := fmt.Sprintf("%s %s", e.Service, e.ResourceID)
+	switch e.Type {
+	case events.RunStarted:
+		s := fmt.Sprintf("🏁 %s of %d resources started in %s", e.Operation, e.Resources, e.Region)
+		if e.Duration > 0 {
+			s += fmt.Sprintf(" (found in %s)", e.Duration.Round(time.Second))
+		}
+		return s
+	case events.RunRecovered:
+		return fmt.Sprintf("🩹 Interrupted %s picked up again with %d resources left", e.Operation, e.Resources)
+	case events.ResourceDiscovered:
+		return fmt.Sprintf("🔍 Found %s (%s, $%.2f/month)", resource, e.State, e.CostPerHour*24*30)
+	case events.PauseIssued:
+		return fmt.Sprintf("🛑 Pausing %s", resource)
+	case events.ResumeIssued:
+		return fmt.Sprintf("🚀 Resuming %s", resource)
+	case events.OperationAccepted:
+		return fmt.Sprintf("☑️  AWS accepted the change to %s after %s%s", resource, e.Duration.Round(time.Millisecond), eventRetries(e))
+	case events.StateReached:
+		return fmt.Sprintf("✅ %s seen %s", resource, e.State)
+	case events.OperationFailed:
+		return fmt.Sprintf("❌ %s failed after %s%s: %s", resource, e.Duration.Round(time.Millisecond), eventRetries(e), e.Error)
+	case events.RunCompleted:
+		s := fmt.Sprintf("🏁 %s finished in %s: %d succeeded, %d failed", e.Operation, e.Duration.Round(time.Second), e.Succeeded, e.Failed)
+		if e.Snapshot != "" {
+			s += fmt.Sprintf(" (snapshot %s)", e.Snapshot)
+		}
+		if e.Error != "" {
+			s += ": " + e.Error
+		}
+		return s
+	}
+	return string(e.Type)
+}
+
+// eventRetries describes an event's retries, if any
+func eventRetries(e events.Event) string {
+	if e.Retries == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" and %d retries", e.Retries)
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/cost"
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
+)
+
+var flagSavingsBaselineDays int
+
+var savingsCmd = &cobra.Command{
+	Use:   "savings [run-id]",
+	Short: "Compare actual spend from Cost Explorer before and during a brake",
+	Long: `Compare the average daily spend of each service before a pause with its
+spend while braked, using actual billing data from AWS Cost Explorer, next to
+awsbreak's estimate.
+
+With no run ID, uses the most recent pause in the region (see 'awsbreak
+history'). Cost Explorer reports whole UTC days and lags by about a day, so
+only complete days before the pause and before the next resume are compared.
+Each run makes one Cost Explorer request, which AWS bills at $0.01.
+
+Examples:
+  awsbreak savings
+  awsbreak savings 20250301-180000 --baseline-days 14`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runSavings,
+}
+
+func init() {
+	savingsCmd.Flags().IntVar(&flagSavingsBaselineDays, "baseline-days", 7, "Number of days before the pause to average as the baseline")
+	rootCmd.AddCommand(savingsCmd)
+}
+
+func runSavings(cmd *cobra.Command, args []string) {
+	fmt.Println("\n💵 AWSBREAK - Actual Savings")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	if !checkConfiguration() {
+		fmt.Println("❌ No configuration found. Run setup first.")
+		exit(ExitConfigError)
+	}
+	if flagSavingsBaselineDays < 1 {
+		fmt.Println("❌ --baseline-days must be at least 1")
+		exit(ExitGeneralError)
+	}
+
+	ctx := context.Background()
+	_, region, awsCfg, err := connect(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	entries, err := ledger.NewLedger(configMgr.GetConfigDir()).Entries()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	var runID string
+	if len(args) > 0 {
+		runID = args[0]
+	}
+	pause, resumedAt, err := findBrake(entries, runID, region)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+
+	// Compare whole UTC days: the day of the pause and the day of the resume
+	// are partly braked, and today isn't billed yet
+	pauseDay := pause.Timestamp.UTC().Truncate(24 * time.Hour)
+	brakedStart := pauseDay.AddDate(0, 0, 1)
+	brakedEnd := time.Now().UTC().Truncate(24 * time.Hour)
+	if !resumedAt.IsZero() {
+		brakedEnd = resumedAt.UTC().Truncate(24 * time.Hour)
+	}
+	baselineStart := pauseDay.AddDate(0, 0, -flagSavingsBaselineDays)
+
+	brakedDays := int(brakedEnd.Sub(brakedStart).Hours() / 24)
+	if brakedDays < 1 {
+		fmt.Printf("❌ Run %s has no complete braked day yet - Cost Explorer reports whole UTC days.\n", pause.ID)
+		exit(ExitGeneralError)
+	}
+
+	fmt.Printf("   Run %s in %s, paused %s\n", pause.ID, pause.Region, formatWhen(pause.Timestamp))
+	fmt.Printf("   Baseline: %s to %s (UTC)\n", baselineStart.Format("2006-01-02"), pauseDay.AddDate(0, 0, -1).Format("2006-01-02"))
+	fmt.Printf("   Braked:   %s to %s (UTC, %d days)\n", brakedStart.Format("2006-01-02"), brakedEnd.AddDate(0, 0, -1).Format("2006-01-02"), brakedDays)
+
+	spend, err := cost.NewExplorer(awsCfg).Compare(ctx, pause.Region, baselineStart, brakedStart, brakedEnd)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitServiceError)
+	}
+
+	// Estimate per billing service from the resources the run paused
+	estimated := make(map[string]float64)
+	for _, r := range pause.Results {
+		if r.Success {
+			estimated[cost.BillingService(r.Resource.ServiceType)] += r.Resource.CostPerHour * 24 * float64(brakedDays)
+		}
+	}
+
+	if len(spend) == 0 {
+		fmt.Println("\n   Cost Explorer has no spend for these services in this period.")
+		fmt.Println("   Cost Explorer must be enabled in the billing console, and new accounts take up to a day to populate.")
+		return
+	}
+
+	t := newTable(
+		column{title: "SERVICE", flex: true},
+		column{title: "BEFORE/DAY", right: true},
+		column{title: "DURING/DAY", right: true},
+		column{title: "SAVED", right: true},
+		column{title: "ESTIMATED", right: true},
+	)
+	var totalSaved, totalEstimated float64
+	for _, s := range spend {
+		icon := "✅"
+		if s.Saved() <= 0 {
+			icon = "⚠️ "
+		}
+		t.addRow(icon, s.Service,
+			fmt.Sprintf("$%.2f", s.BaselineDaily),
+			fmt.Sprintf("$%.2f", s.BrakedDaily),
+			fmt.Sprintf("$%.2f", s.Saved()),
+			fmt.Sprintf("$%.2f", estimated[s.Service]))
+		totalSaved += s.Saved()
+		totalEstimated += estimated[s.Service]
+	}
+	fmt.Println()
+	t.print()
+
+	fmt.Printf("\n💰 Actually saved $%.2f over %d days (estimated $%.2f)\n", totalSaved, brakedDays, totalEstimated)
+	fmt.Println("   Actual figures cover all spend on these services in the region, not only braked resources.")
+}
+
+// findBrake returns the pause run with the given ID, or the latest pause in
+// region that changed anything, and when the region was next resumed (zero if
+// it is still braked)
+func findBrake(entries []ledger.Entry, runID, region string) (ledger.Entry, time.Time, error) {
+	index := -1
+	for i, e := range entries {
+		if e.Operation != "pause" {
+			continue
+		}
+		if runID != "" {
+			if e.ID == runID {
+				index = i
+				break
+			}
+		} else if e.Region == region && e.Succeeded() > 0 {
+			index = i
+		}
+	}
+
+	if index < 0 {
+		if runID != "" {
+			return ledger.Entry{}, time.Time{}, fmt.Errorf("no pause run with ID %s (see 'awsbreak history')", runID)
+		}
+		return ledger.Entry{}, time.Time{}, fmt.Errorf("no pause runs recorded in %s", region)
+	}
+
+	pause := entries[index]
+	for _, e := range entries[index+1:] {
+		if e.Operation == "resume" && e.Region == pause.Region && e.Succeeded() > 0 {
+			return pause, e.Timestamp, nil
+		}
+	}
+	return pause, time.Time{}, nil
+}
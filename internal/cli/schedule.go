@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/schedule"
+)
+
+var (
+	flagScheduleAction string
+	flagScheduleDays   string
+	flagScheduleAt     string
+	flagScheduleRegion string
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage recurring brake windows",
+	Long: `Manage recurring pause/resume windows run by 'awsbreak daemon'.
+
+Examples:
+  awsbreak schedule add nightly --action pause --days weekdays --at 19:00
+  awsbreak schedule add morning --action resume --days weekdays --at 08:00
+  awsbreak schedule list
+  awsbreak schedule remove nightly
+
+Pausing weekdays at 19:00 and resuming weekdays at 08:00 keeps the weekend
+braked as well.`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured schedules",
+	Args:  cobra.NoArgs,
+	Run:   runScheduleList,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or replace a schedule",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleAdd,
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a schedule",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleRemove,
+}
+
+func init() {
+	scheduleAddCmd.Flags().StringVar(&flagScheduleAction, "action", "", "Action to run: pause or resume")
+	scheduleAddCmd.Flags().StringVar(&flagScheduleDays, "days", "weekdays", "Days to run: mon-sun, weekdays, weekends or daily")
+	scheduleAddCmd.Flags().StringVar(&flagScheduleAt, "at", "", "Local time of day (HH:MM)")
+	scheduleAddCmd.Flags().StringVar(&flagScheduleRegion, "region", "", "AWS region (defaults to the configured region)")
+	scheduleAddCmd.MarkFlagRequired("action")
+	scheduleAddCmd.MarkFlagRequired("at")
+
+	scheduleCmd.AddCommand(scheduleListCmd, scheduleAddCmd, scheduleRemoveCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) {
+	cfg := loadConfigOrExit()
+
+	fmt.Println("\n🗓️  AWSBREAK - Schedules")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	if len(cfg.Schedules) == 0 {
+		fmt.Println("   No schedules. Add one with 'awsbreak schedule add'.")
+		return
+	}
+
+	now := time.Now()
+	for _, s := range cfg.Schedules {
+		region := s.Region
+		if region == "" {
+			region = configMgr.GetDefaultRegion()
+		}
+		next, err := schedule.Next(s, now)
+		nextStr := "invalid"
+		if err == nil {
+			nextStr = formatWhen(next)
+		}
+		fmt.Printf("   • %-16s %-32s %-14s next: %s\n", s.Name, schedule.Describe(s), region, nextStr)
+	}
+}
+
+func runScheduleAdd(cmd *cobra.Command, args []string) {
+	cfg := loadConfigOrExit()
+
+	days, err := schedule.ParseDays(flagScheduleDays)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	s := models.Schedule{
+		Name:   args[0],
+		Action: flagScheduleAction,
+		Days:   days,
+		At:     flagScheduleAt,
+		Region: flagScheduleRegion,
+	}
+	if err := schedule.Validate(s); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	replaced := false
+	for i := range cfg.Schedules {
+		if cfg.Schedules[i].Name == s.Name {
+			cfg.Schedules[i] = s
+			replaced = true
+		}
+	}
+	if !replaced {
+		cfg.Schedules = append(cfg.Schedules, s)
+	}
+
+	if err := configMgr.Save(cfg); err != nil {
+		fmt.Printf("❌ Failed to save configuration: %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	fmt.Printf("✅ Schedule %s: %s\n", s.Name, schedule.Describe(s))
+	fmt.Println("   Run 'awsbreak daemon' to execute schedules.")
+}
+
+func runScheduleRemove(cmd *cobra.Command, args []string) {
+	cfg := loadConfigOrExit()
+
+	var kept []models.Schedule
+	for _, s := range cfg.Schedules {
+		if s.Name != args[0] {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == len(cfg.Schedules) {
+		fmt.Printf("❌ No schedule named %s\n", args[0])
+		exit(ExitConfigError)
+	}
+
+	cfg.Schedules = kept
+	if err := configMgr.Save(cfg); err != nil {
+		fmt.Printf("❌ Failed to save configuration: %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	fmt.Printf("✅ Removed schedule %s\n", args[0])
+}
+
+// loadConfigOrExit loads the configuration, exiting if brakes aren't installed
+func loadConfigOrExit() *models.Config {
+	if !checkConfiguration() {
+		fmt.Println("❌ No configuration found. Run setup first.")
+		exit(ExitConfigError)
+	}
+
+	cfg, err := configMgr.Load()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	return cfg
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const selectHelp = `   Toggle: 3, 1-5 or 2,4   a: select all shown   n: select none shown
+   /text: filter by name, ID or tag (key=value)   /: clear filter
+   Enter: continue   q: cancel`
+
+// resourceSelection is the state of the interactive resource picker
+type resourceSelection struct {
+	resources []models.Resource
+	selected  []bool
+	filter    string
+}
+
+// selectResources lets the user choose which resources to stop. Resources are
+// grouped by service and start out selected. Returns nil if the user cancels
+// and an empty slice if they deselect everything.
+// With AWSBREAK_ASSUME_YES true every resource is chosen without prompting.
+func selectResources(resources []models.Resource) []models.Resource {
+	// Scripted runs take everything that was offered
+	if stdPrompter.assumeYes() {
+		return resources
+	}
+
+	sel := newResourceSelection(resources)
+
+	for {
+		sel.render()
+		input := prompt("Select> ")
+
+		switch {
+		case input == "":
+			return sel.chosen()
+		case input == "q":
+			return nil
+		case input == "a", input == "n":
+			sel.setVisible(input == "a")
+		case strings.HasPrefix(input, "/"):
+			sel.filter = strings.TrimSpace(input[1:])
+		case input == "?" || input == "h":
+			fmt.Println(selectHelp)
+		default:
+			if err := sel.toggle(input); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+		}
+	}
+}
+
+func newResourceSelection(resources []models.Resource) *resourceSelection {
+	sorted := append([]models.Resource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ServiceType != sorted[j].ServiceType {
+			return sorted[i].ServiceType < sorted[j].ServiceType
+		}
+		return sorted[i].ResourceID < sorted[j].ResourceID
+	})
+
+	selected := make([]bool, len(sorted))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	return &resourceSelection{resources: sorted, selected: selected}
+}
+
+func (s *resourceSelection) render() {
+	fmt.Println()
+	fmt.Println("☑️  Choose what to stop:")
+
+	var lastType models.ServiceType
+	shown := 0
+	for i, r := range s.resources {
+		if !s.visible(r) {
+			continue
+		}
+		shown++
+
+		if r.ServiceType != lastType {
+			fmt.Printf("   %s\n", r.ServiceType)
+			lastType = r.ServiceType
+		}
+
+		box := "[ ]"
+		if s.selected[i] {
+			box = "[x]"
+		}
+		name := r.ResourceID
+		if n := r.Tags["Name"]; n != "" && n != r.ResourceID {
+			name = fmt.Sprintf("%s (%s)", r.ResourceID, n)
+		}
+		cost := fmt.Sprintf("$%.2f/month", r.CostPerHour*24*30)
+		fmt.Printf("   %s %3d. %-50s %s\n", box, i+1, fitLine(name, 13+len(cost)), cost)
+	}
+
+	if shown == 0 {
+		fmt.Printf("   No resources match %q\n", s.filter)
+	}
+
+	chosen := s.chosen()
+	fmt.Println()
+	if s.filter != "" {
+		fmt.Printf("   Filter: %q (%d of %d shown)\n", s.filter, shown, len(s.resources))
+	}
+	fmt.Printf("   %d of %d selected · saving $%.2f/month · ? for help\n",
+		len(chosen), len(s.resources), calculateMonthlyCost(chosen))
+}
+
+// visible reports whether a resource matches the current filter
+func (s *resourceSelection) visible(r models.Resource) bool {
+	if s.filter == "" {
+		return true
+	}
+
+	filter := strings.ToLower(s.filter)
+	if key, value, ok := strings.Cut(s.filter, "="); ok {
+		return r.Tags[key] == value
+	}
+	if strings.Contains(strings.ToLower(r.ResourceID), filter) {
+		return true
+	}
+	for key, value := range r.Tags {
+		if strings.Contains(strings.ToLower(key), filter) || strings.Contains(strings.ToLower(value), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *resourceSelection) setVisible(selected bool) {
+	for i, r := range s.resources {
+		if s.visible(r) {
+			s.selected[i] = selected
+		}
+	}
+}
+
+// toggle flips the items named by a list such as "1,3,5-7"
+func (s *resourceSelection) toggle(input string) error {
+	var indexes []int
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			to = from
+		}
+
+		start, err1 := strconv.Atoi(strings.TrimSpace(from))
+		end, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || start < 1 || end > len(s.resources) || start > end {
+			return fmt.Errorf("invalid selection %q (type ? for help)", part)
+		}
+		for i := start; i <= end; i++ {
+			indexes = append(indexes, i-1)
+		}
+	}
+
+	for _, i := range indexes {
+		s.selected[i] = !s.selected[i]
+	}
+	return nil
+}
+
+func (s *resourceSelection) chosen() []models.Resource {
+	// Non-nil even when empty: nil means the user cancelled
+	chosen := []models.Resource{}
+	for i, r := range s.resources {
+		if s.selected[i] {
+			chosen = append(chosen, r)
+		}
+	}
+	return chosen
+}
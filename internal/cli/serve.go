@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/server"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var (
+	flagServeAddr     string
+	flagServeReadOnly bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the web UI and resource inventory over HTTP",
+	Long: `Run an HTTP server with a web dashboard showing the inventory, burn rate
+and snapshots, with pause and resume buttons.
+
+Endpoints:
+  GET  /            Web UI
+  GET  /healthz     Liveness check
+  GET  /resources   Inventory; filter with service, tag, region, state and
+                    min_cost, page with limit and offset. Responses carry an
+                    ETag so clients can poll with If-None-Match.
+  GET  /summary     Burn rate and parked totals
+  GET  /snapshots   Saved snapshots, newest first
+  POST /pause       Pause the region (body: {"confirm": true})
+  POST /resume      Resume the latest snapshot (body: {"confirm": true})
+
+Pausing from the web UI applies the same safety checks as scheduled pauses:
+report-only resources and databases with live connections are skipped.
+Use --read-only to disable /pause and /resume. --spectate serves a
+stakeholder view: snapshots and parked totals are shown, pause and resume
+are refused, and every AWS call the server makes is limited to reads.
+
+To guard against DNS rebinding, requests must name the server by IP
+address, localhost or the host given in --addr.`,
+	Run: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&flagServeAddr, "addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().BoolVar(&flagServeReadOnly, "read-only", false, "Disable pause and resume endpoints")
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	if !checkConfiguration() {
+		fmt.Println("❌ No configuration found. Run setup first.")
+		exit(ExitConfigError)
+	}
+
+	cfg, region, awsCfg, err := connect(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitAuthError)
+	}
+
+	cache := newInventoryCache(services.NewOrchestrator(awsCfg, mustOrchestratorOptions()))
+	var srv *server.Server
+	switch {
+	case flagSpectate:
+		srv = server.NewSpectatorServer(cache, snapshotLister{}, region)
+	case flagServeReadOnly:
+		srv = server.NewServer(cache, nil, region)
+	default:
+		srv = server.NewServer(cache, &brakeController{cfg: cfg, awsCfg: awsCfg}, region)
+	}
+
+	fmt.Printf("\n🌐 AWSBREAK - Serving %s on http://%s\n", region, flagServeAddr)
+	switch {
+	case flagSpectate:
+		fmt.Println("   👀 Spectator mode: dashboards and reports only, AWS access is read-only")
+	case flagServeReadOnly:
+		fmt.Println("   Read-only: pause and resume are disabled")
+	}
+	if err := srv.ListenAndServe(ctx, flagServeAddr); err != nil {
+		fmt.Printf("❌ Server stopped: %v\n", err)
+		exit(ExitGeneralError)
+	}
+}
+
+// brakeController lets the web UI pause and resume using the unattended brake path
+type brakeController struct {
+	cfg    *models.Config
+	awsCfg aws.Config
+}
+
+func (c *brakeController) Pause(ctx context.Context, region string) ([]models.OperationResult, error) {
+	return brakeRegion(ctx, c.cfg, c.awsCfg, region, nil)
+}
+
+func (c *brakeController) Resume(ctx context.Context, region string) ([]models.OperationResult, error) {
+	return releaseRegion(ctx, c.awsCfg, region)
+}
+
+func (c *brakeController) Snapshots(ctx context.Context) ([]*models.AccountSnapshot, error) {
+	return snapshotLister{}.Snapshots(ctx)
+}
+
+// snapshotLister shows saved snapshots to spectators
+type snapshotLister struct{}
+
+func (snapshotLister) Snapshots(ctx context.Context) ([]*models.AccountSnapshot, error) {
+	snapshots, err := snapshotManager()
+	if err != nil {
+		return nil, err
+	}
+	return snapshots.List(ctx)
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+)
+
+var (
+	flagRoleARN       string
+	flagDefaultRegion string
+	flagSetupObserver bool
+)
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Install the brake system (IAM role) and save configuration",
+	Long: `Configure the IAM role awsbreak assumes and the default region.
+
+Every prompt can be answered ahead of time so setup can run unattended in
+provisioning pipelines:
+
+  --role-arn          or AWSBREAK_ROLE_ARN
+  --default-region    or AWSBREAK_DEFAULT_REGION
+  confirmations       AWSBREAK_ASSUME_YES=1
+
+With --observer, setup installs a read-only observer instead: the role can
+only describe and list (see cloudformation/observer-role.yaml), and every
+command runs as with --spectate, showing the dashboard, reports and audits
+and refusing to pause or resume. Grant it to people who should see costs
+without being able to stop anything. Run setup again without --observer to
+switch to a full install.
+
+Examples:
+  awsbreak setup
+  awsbreak setup --role-arn arn:aws:iam::123456789012:role/AWSHitBreaksRole --default-region eu-west-1
+  awsbreak setup --observer`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		configMgr, err = config.NewManager()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+		runSetup()
+	},
+}
+
+func init() {
+	setupCmd.Flags().StringVar(&flagRoleARN, "role-arn", "", "IAM role ARN to assume")
+	setupCmd.Flags().StringVar(&flagDefaultRegion, "default-region", "", "Default AWS region")
+	setupCmd.Flags().BoolVar(&flagSetupObserver, "observer", false, "Install a read-only observer role for dashboards and reports")
+	rootCmd.AddCommand(setupCmd)
+}
+
+// setupRoleTemplate returns the role template and stack name setup deploys:
+// the read-only observer role with --observer, the full role otherwise
+func setupRoleTemplate() (template, stackName string) {
+	if flagSetupObserver {
+		return auth.ObserverCloudFormationTemplate(), auth.ObserverRoleStackName
+	}
+	return auth.CloudFormationTemplate(), auth.RoleStackName
+}
+
+// setupPolicy returns the actions the role setup installs needs
+func setupPolicy() []auth.PolicyGroup {
+	if flagSetupObserver {
+		return auth.ObserverPolicy()
+	}
+	return auth.RolePolicy
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/state"
+)
+
+// Snapshot storage backends accepted in snapshot_storage.backend
+const (
+	snapshotBackendLocal = "local"
+	snapshotBackendS3    = "s3"
+)
+
+var flagPruneDays int
+
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "List and prune saved snapshots",
+	Long: `Snapshots record what each pause stopped so it can be restored exactly.
+They are stored in the config directory, or in the S3 bucket configured under
+snapshot_storage in config.json so they are shared across machines.
+
+Examples:
+  awsbreak snapshots list
+  awsbreak snapshots prune --days 30`,
+}
+
+var snapshotsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved snapshots, newest first",
+	Run:   runSnapshotsList,
+}
+
+var snapshotsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old snapshots whose resources have all been resumed",
+	Long: `Delete snapshots older than --days whose resources have all been resumed.
+Snapshots with resources still paused are always kept.`,
+	Run: runSnapshotsPrune,
+}
+
+func init() {
+	snapshotsPruneCmd.Flags().IntVar(&flagPruneDays, "days", 30, "Delete snapshots older than N days")
+	snapshotsCmd.AddCommand(snapshotsListCmd, snapshotsPruneCmd)
+	rootCmd.AddCommand(snapshotsCmd)
+}
+
+// snapshotManager returns the snapshot manager for the configured backend
+func snapshotManager() (*state.SnapshotManager, error) {
+	store, err := snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+	return state.NewSnapshotManagerWithStore(store), nil
+}
+
+// snapshotStore returns the configured snapshot backend. S3 is accessed with
+// the default AWS credentials rather than the awsbreak role, so a team can
+// share one bucket across accounts.
+func snapshotStore() (state.SnapshotStore, error) {
+	cfg := configMgr.GetConfig()
+	if cfg == nil || cfg.SnapshotStorage == nil {
+		return state.NewLocalStore(configMgr.GetConfigDir()), nil
+	}
+
+	st := cfg.SnapshotStorage
+	switch st.Backend {
+	case "", snapshotBackendLocal:
+		return state.NewLocalStore(configMgr.GetConfigDir()), nil
+	case snapshotBackendS3:
+		if st.Bucket == "" {
+			return nil, fmt.Errorf("snapshot_storage.bucket is required for the s3 backend")
+		}
+		region := st.Region
+		if region == "" {
+			region = configMgr.GetDefaultRegion()
+		}
+		awsCfg, err := auth.BaseConfig(context.Background(), region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config for snapshot storage: %w", err)
+		}
+		return state.NewS3Store(awsCfg, st.Bucket, st.Prefix, st.KMSKeyID), nil
+	default:
+		return nil, fmt.Errorf("unknown snapshot_storage.backend %q (use %s or %s)", st.Backend, snapshotBackendLocal, snapshotBackendS3)
+	}
+}
+
+// mustSnapshotManager is snapshotManager for commands that can't continue without snapshots
+func mustSnapshotManager() *state.SnapshotManager {
+	snapshots, err := snapshotManager()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+	return snapshots
+}
+
+func runSnapshotsList(cmd *cobra.Command, args []string) {
+	fmt.Println("\n📸 AWSBREAK - Snapshots")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	loadConfigManager()
+	snapshots := mustSnapshotManager()
+	list, err := snapshots.List(context.Background())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	fmt.Printf("   Stored in %s\n", snapshots.Location())
+
+	t := newTable(
+		column{title: "SNAPSHOT", flex: true},
+		column{title: "WHEN"},
+		column{title: "REGION"},
+		column{title: "ACCOUNT"},
+		column{title: "PAUSED", right: true},
+		column{title: "$/MONTH", right: true},
+	)
+	for _, s := range list {
+		if flagRegion != "" && s.Region != flagRegion {
+			continue
+		}
+
+		pending := len(s.PendingResources())
+		icon := "✅"
+		if pending > 0 {
+			icon = "⏸️ "
+		}
+		account := s.AccountID
+		if account == "" {
+			account = "-"
+		}
+		t.addRow(icon, s.SnapshotID, formatWhen(s.Timestamp), s.Region, account,
+			fmt.Sprintf("%d/%d", pending, len(s.Resources)), fmt.Sprintf("$%.2f", s.TotalEstimatedSavings))
+	}
+
+	if len(t.rows) == 0 {
+		fmt.Println("\n   No snapshots yet.")
+		return
+	}
+	fmt.Println()
+	t.print()
+}
+
+func runSnapshotsPrune(cmd *cobra.Command, args []string) {
+	fmt.Println("\n📸 AWSBREAK - Prune Snapshots")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	if flagPruneDays < 0 {
+		fmt.Println("❌ --days must not be negative")
+		exit(ExitConfigError)
+	}
+
+	loadConfigManager()
+	snapshots := mustSnapshotManager()
+	cutoff := time.Now().AddDate(0, 0, -flagPruneDays)
+
+	fmt.Printf("   Deleting fully resumed snapshots older than %d days from %s\n", flagPruneDays, snapshots.Location())
+	if !confirm("\nContinue? [y/N]: ") {
+		fmt.Println("👍 Nothing deleted.")
+		return
+	}
+
+	pruned, err := snapshots.Prune(context.Background(), cutoff)
+	for _, id := range pruned {
+		fmt.Printf("🗑️  %s\n", id)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	fmt.Printf("\n✅ Deleted %d snapshots.\n", len(pruned))
+}
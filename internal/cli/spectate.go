@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+var flagSpectate bool
+
+// observerInstall is set when the config comes from 'awsbreak setup
+// --observer', which makes every run a spectator
+var observerInstall bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&flagSpectate, "spectate", false, "Read-only view for stakeholders: dashboards and reports, never a pause or resume")
+}
+
+// startSpectating turns on spectator mode for --spectate and observer
+// installs. Every AWS client awsbreak creates afterwards refuses calls that
+// change anything, so even a role allowed to stop resources can't be used to.
+// Setup is exempt from observer installs, since it deploys the role stack
+// and may be switching to a full install.
+func startSpectating(cmd *cobra.Command) {
+	if !flagSpectate && (!observerInstall || cmd == setupCmd) {
+		return
+	}
+	flagSpectate = true
+	auth.SetSpectator()
+	if flagGo {
+		if observerInstall {
+			fmt.Println("❌ awsbreak is set up as a read-only observer and can't release the brakes")
+		} else {
+			fmt.Println("❌ --spectate can't release the brakes; drop --go")
+		}
+		exit(ExitConfigError)
+	}
+}
+
+// refuseSpectator rejects a pause or resume before anything is journaled
+func refuseSpectator(operation string) error {
+	switch {
+	case observerInstall:
+		return fmt.Errorf("can't %s: awsbreak is set up as a read-only observer: %w", operation, auth.ErrSpectator)
+	case auth.Spectating():
+		return fmt.Errorf("can't %s in spectator mode: %w", operation, auth.ErrSpectator)
+	}
+	return nil
+}
+
+// readOnlyProbes drops permission probes that call write APIs, such as the
+// EC2 stop dry run, which spectator mode refuses before they reach AWS
+func readOnlyProbes(probes []services.PermissionProbe) []services.PermissionProbe {
+	var kept []services.PermissionProbe
+	for _, p := range probes {
+		if auth.IsReadOnlyAction(p.Action) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinner animates a status line while slow work runs. When stdout isn't a
+// terminal it prints nothing, so piped output stays clean.
+type spinner struct {
+	mu      sync.Mutex
+	message string
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// startSpinner shows message with an animation until stopped
+func startSpinner(message string) *spinner {
+	s := &spinner{message: message}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return s
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			s.mu.Lock()
+			fmt.Printf("\r\033[K%s %s", spinnerFrames[i%len(spinnerFrames)], s.message)
+			s.mu.Unlock()
+
+			select {
+			case <-s.stop:
+				fmt.Print("\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// update changes the spinner's message
+func (s *spinner) update(message string) {
+	s.mu.Lock()
+	s.message = message
+	s.mu.Unlock()
+}
+
+// println prints a line above the spinner
+func (s *spinner) println(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		fmt.Print("\r\033[K")
+	}
+	fmt.Println(line)
+}
+
+// halt stops the animation and clears its line
+func (s *spinner) halt() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	s.stop = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/bundle"
+)
+
+// envStatePassphrase supplies the archive passphrase without prompting
+const envStatePassphrase = "AWSBREAK_STATE_PASSPHRASE"
+
+var exportStateCmd = &cobra.Command{
+	Use:   "export-state [file]",
+	Short: "Bundle config, snapshots, savings history and journal into an encrypted archive",
+	Long: `Write awsbreak's local state to one passphrase-encrypted file, so moving to
+a new machine or sharing a reference setup doesn't lose pause history or
+pending resumes. The archive holds config.json (including schedules and
+budgets), local snapshots, the savings ledger and the operation journal.
+
+The passphrase is prompted for, or read from AWSBREAK_STATE_PASSPHRASE.
+The file defaults to awsbreak-state-YYYYMMDD.awsbreak in the current directory.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runExportState,
+}
+
+var importStateCmd = &cobra.Command{
+	Use:   "import-state <file>",
+	Short: "Restore state written by export-state",
+	Long: `Restore config, snapshots, savings history and journal from an archive
+written by 'awsbreak export-state'. If this machine already has any of the
+files the archive holds, --force moves just those aside to a timestamped
+backup first; everything else in the config directory is left alone.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runImportState,
+}
+
+func init() {
+	rootCmd.AddCommand(exportStateCmd)
+	rootCmd.AddCommand(importStateCmd)
+}
+
+func runExportState(cmd *cobra.Command, args []string) {
+	fmt.Println("\n📦 AWSBREAK - Export State")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	loadConfigManager()
+	path := fmt.Sprintf("awsbreak-state-%s.awsbreak", time.Now().Format("20060102"))
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if _, err := os.Stat(path); err == nil && !flagForce {
+		fmt.Printf("❌ %s already exists (use --force to overwrite)\n", path)
+		exit(ExitGeneralError)
+	}
+
+	passphrase, err := readPassphrase(true)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	// config.json goes in decrypted, since the key it may be encrypted with
+	// stays on this machine; the archive's passphrase protects it instead
+	contents := make(map[string][]byte)
+	if configMgr.Exists() {
+		plain, err := configMgr.Plaintext()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			exit(ExitConfigError)
+		}
+		contents["config.json"] = plain
+	}
+
+	var buf bytes.Buffer
+	manifest, err := bundle.Export(configMgr.GetConfigDir(), version, passphrase, contents, &buf)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		fmt.Printf("❌ Failed to write %s: %v\n", path, err)
+		exit(ExitGeneralError)
+	}
+
+	displayManifest(manifest)
+	if cfg, err := configMgr.Load(); err == nil && cfg.SnapshotStorage != nil && cfg.SnapshotStorage.Backend == "s3" {
+		fmt.Printf("   ℹ️  Snapshots in s3://%s aren't included - they stay shared in S3.\n", cfg.SnapshotStorage.Bucket)
+	}
+	if configMgr.Encrypted() {
+		fmt.Println("   🔓 config.json is exported decrypted, protected by the archive's passphrase.")
+		fmt.Println("      Run 'awsbreak config encrypt' after importing to encrypt it there.")
+	}
+	fmt.Printf("\n✅ Exported %d files to %s\n", len(manifest.Files), path)
+	fmt.Println("   Keep the passphrase: the archive can't be opened without it.")
+}
+
+func runImportState(cmd *cobra.Command, args []string) {
+	fmt.Println("\n📦 AWSBREAK - Import State")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	loadConfigManager()
+	dir := configMgr.GetConfigDir()
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitGeneralError)
+	}
+	defer f.Close()
+
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(ExitConfigError)
+	}
+
+	archive, err := bundle.Open(f, passphrase)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		if errors.Is(err, bundle.ErrBadPassphrase) {
+			exit(ExitConfigError)
+		}
+		exit(ExitGeneralError)
+	}
+	manifest := archive.Manifest
+
+	// Only the entries the archive brings are replaced; keys, caches and
+	// state the archive doesn't hold stay where they are
+	roots := archive.Roots()
+	var backup string
+	existing := existingEntries(dir, roots)
+	if len(existing) > 0 {
+		if !flagForce {
+			fmt.Printf("❌ %s already has %s.\n", dir, strings.Join(existing, ", "))
+			fmt.Println("   Use --force to move them aside to a backup and import anyway.")
+			exit(ExitGeneralError)
+		}
+		backup = fmt.Sprintf("%s.bak-%s", dir, time.Now().Format("20060102-150405"))
+		if err := moveEntries(dir, backup, existing); err != nil {
+			fmt.Printf("❌ Failed to back up %s: %v\n", dir, err)
+			restoreBackup(dir, backup, existing)
+			exit(ExitGeneralError)
+		}
+	}
+
+	if err := archive.Restore(dir); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		// Every root was absent or moved aside, so what's there now is
+		// half an import
+		for _, entry := range roots {
+			os.RemoveAll(filepath.Join(dir, entry))
+		}
+		restoreBackup(dir, backup, existing)
+		exit(ExitGeneralError)
+	}
+
+	displayManifest(manifest)
+	if backup != "" {
+		fmt.Printf("   Previous state moved to %s\n", backup)
+	}
+	fmt.Printf("\n✅ Imported %d files into %s\n", len(manifest.Files), dir)
+	fmt.Println("   Run 'awsbreak --check' to see the restored setup.")
+}
+
+// existingEntries returns which of entries are already present in dir
+func existingEntries(dir string, entries []string) []string {
+	var existing []string
+	for _, entry := range entries {
+		if _, err := os.Lstat(filepath.Join(dir, entry)); err == nil {
+			existing = append(existing, entry)
+		}
+	}
+	return existing
+}
+
+// moveEntries moves entries from dir into the backup directory
+func moveEntries(dir, backup string, entries []string) error {
+	if err := os.MkdirAll(backup, 0700); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Rename(filepath.Join(dir, entry), filepath.Join(backup, entry)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreBackup puts entries moved aside by a failed import back in place
+func restoreBackup(dir, backup string, entries []string) {
+	if backup == "" {
+		return
+	}
+	for _, entry := range entries {
+		if _, err := os.Lstat(filepath.Join(backup, entry)); err != nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(backup, entry), filepath.Join(dir, entry)); err != nil {
+			fmt.Printf("⚠️  Your previous %s is in %s: %v\n", entry, backup, err)
+		}
+	}
+	os.Remove(backup)
+}
+
+// displayManifest summarizes what an archive holds
+func displayManifest(m *bundle.Manifest) {
+	counts := make(map[string]int)
+	for _, f := range m.Files {
+		top, _, _ := strings.Cut(f, "/")
+		counts[top]++
+	}
+
+	from := m.Hostname
+	if from == "" {
+		from = "unknown host"
+	}
+	fmt.Printf("   Archive from %s, %s (awsbreak %s)\n", from, formatWhen(m.Created), m.Version)
+	for _, entry := range bundle.Entries {
+		if n := counts[entry]; n > 0 {
+			fmt.Printf("   • %-14s %d file(s)\n", entry, n)
+		}
+	}
+}
+
+// readPassphrase returns the archive passphrase from the environment or the
+// terminal. New passphrases are asked for twice.
+func readPassphrase(confirmIt bool) ([]byte, error) {
+	if p := os.Getenv(envStatePassphrase); p != "" {
+		return []byte(p), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no terminal to ask for a passphrase: set %s", envStatePassphrase)
+	}
+
+	fmt.Print("Passphrase: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(p) == 0 {
+		return nil, fmt.Errorf("a passphrase is required")
+	}
+
+	if confirmIt {
+		fmt.Print("Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if !bytes.Equal(p, again) {
+			return nil, fmt.Errorf("passphrases don't match")
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+	"github.com/aicoder2009/aws-hit-breaks/internal/sweep"
+)
+
+var (
+	flagNoVerify      bool
+	flagVerifyRegions []string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&flagNoVerify, "no-verify", false, "Skip the sweep confirming nothing is left running after a pause")
+	rootCmd.PersistentFlags().StringSliceVar(&flagVerifyRegions, "verify-regions", nil, "Sweep only these regions besides the paused one after a pause (default: every enabled region)")
+}
+
+// verifySweep runs the sweep after an interactive pause and prints the
+// result region by region
+func verifySweep(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string) {
+	if !sweepEnabled(cfg) {
+		return
+	}
+	fmt.Println()
+	spin := startSpinner("Sweeping for anything still running...")
+	report := sweepAfterPause(ctx, cfg, awsCfg, region)
+	spin.halt()
+	displaySweep(report)
+}
+
+// logSweep runs the sweep after an unattended pause and logs anything not
+// clear; the daemon, web UI, scheduled and re-armed pauses use it
+func logSweep(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string) {
+	if !sweepEnabled(cfg) {
+		return
+	}
+	report := sweepAfterPause(ctx, cfg, awsCfg, region)
+	if report.AllClear() {
+		log.Printf("✅ Sweep: all clear in %d region(s) (%s)", len(report.Regions), report.Checksum)
+		return
+	}
+	if report.RegionsError != "" {
+		log.Printf("⚠️  Sweep: couldn't list enabled regions, only %s was checked: %s", region, report.RegionsError)
+	}
+	for _, r := range report.Regions {
+		switch {
+		case r.Error != "":
+			log.Printf("❌ Sweep: couldn't sweep %s: %s", r.Region, r.Error)
+		case len(r.Running) > 0:
+			for _, res := range r.Running {
+				log.Printf("🚨 Sweep: %s %s still running in %s ($%.2f/month)", res.ServiceType, res.ResourceID, r.Region, res.CostPerHour*24*30)
+			}
+		}
+		if len(r.Incomplete) > 0 {
+			log.Printf("⚠️  Sweep: couldn't fully check %s in %s", strings.Join(r.Incomplete, ", "), r.Region)
+		}
+	}
+	log.Printf("🚨 Sweep: NOT ALL CLEAR, still burning ~$%.2f/month (%s)", report.MonthlyCost(), report.Checksum)
+}
+
+// sweepEnabled reports whether pauses are followed by a sweep
+func sweepEnabled(cfg *models.Config) bool {
+	return !flagNoVerify && (cfg.Verify == nil || !cfg.Verify.Disabled)
+}
+
+// sweepAfterPause rediscovers every enabled service after a pause, in every
+// region enabled for the account or only the paused region and those
+// configured, and reports whether anything billable by the hour is still
+// running. Protected resources don't count against the all clear. The report
+// is saved to the sweep history so its checksum can be checked later.
+func sweepAfterPause(ctx context.Context, cfg *models.Config, awsCfg aws.Config, region string) *sweep.Report {
+	configured := flagVerifyRegions
+	if len(configured) == 0 && cfg.Verify != nil {
+		configured = cfg.Verify.Regions
+	}
+	var only []string
+	for _, r := range configured {
+		only = append(only, strings.TrimSpace(r))
+	}
+	regions, regionsErr := sweep.Regions(ctx, awsCfg, region, only)
+
+	opts, optsErr := orchestratorOptions()
+	discover := func(ctx context.Context, r string) ([]models.Resource, *models.DiscoveryReport, error) {
+		if optsErr != nil {
+			return nil, nil, optsErr
+		}
+		regionCfg := awsCfg.Copy()
+		regionCfg.Region = r
+		view, err := newInventoryCache(services.NewOrchestrator(regionCfg, opts)).Get(ctx, r, inventory.RefreshAlways)
+		if err != nil {
+			return nil, nil, err
+		}
+		return view.Resources, view.Discovery, nil
+	}
+
+	report := sweep.Run(ctx, regions, discover, services.NewGuard(cfg.Protection))
+	if regionsErr != nil {
+		report.RegionsError = regionsErr.Error()
+	}
+	if account, err := auth.Identity(ctx, awsCfg); err == nil {
+		report.Account = account
+	}
+	if err := report.Seal(); err != nil {
+		log.Printf("⚠️  %v", err)
+	} else if err := sweep.Save(configMgr.GetConfigDir(), report); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	return report
+}
+
+// displaySweep prints each region's result and the checksum
+func displaySweep(report *sweep.Report) {
+	fmt.Println("🧾 Verification sweep")
+	if report.RegionsError != "" {
+		fmt.Printf("⚠️  Couldn't list the enabled regions, so only %s was checked: %s\n", report.Regions[0].Region, report.RegionsError)
+	}
+	for _, r := range report.Regions {
+		protected := ""
+		if r.Protected > 0 {
+			protected = fmt.Sprintf(" (%d protected, left running)", r.Protected)
+		}
+
+		switch {
+		case r.Error != "":
+			fmt.Printf("❌ %s: couldn't sweep: %s\n", r.Region, r.Error)
+		case r.Clear():
+			fmt.Printf("✅ %s: %d services checked, nothing running%s\n", r.Region, len(r.Services), protected)
+		case len(r.Running) > 0:
+			fmt.Printf("❌ %s: %d still running%s\n", r.Region, len(r.Running), protected)
+		default:
+			fmt.Printf("⚠️  %s: nothing found running%s\n", r.Region, protected)
+		}
+		for _, res := range r.Running {
+			cost := fmt.Sprintf("$%.2f/month", res.CostPerHour*24*30)
+			fmt.Printf("     - %s %s (%s)\n", res.ServiceType, consoleLink(fitLine(res.ResourceID, 10+len(res.ServiceType)+len(cost)), res), cost)
+		}
+		if len(r.Incomplete) > 0 {
+			fmt.Printf("   ⚠️  Couldn't fully check: %s\n", strings.Join(r.Incomplete, ", "))
+		}
+		for _, res := range r.ReportOnly {
+			kind, _ := res.Metadata["kind"].(string)
+			cost := fmt.Sprintf("$%.2f/month", res.CostPerHour*24*30)
+			fmt.Printf("   💸 %s %s (%s, not stopped by awsbreak)\n", kind, consoleLink(fitLine(res.ResourceID, 35+len(kind)+len(cost)), res), cost)
+		}
+	}
+
+	fmt.Println()
+	switch cost := report.MonthlyCost(); {
+	case report.AllClear():
+		fmt.Println("✅ ALL CLEAR - nothing awsbreak can stop is still running")
+	case cost > 0:
+		fmt.Printf("🚨 NOT ALL CLEAR - still burning ~$%.2f/month\n", cost)
+		fmt.Println("   Run 'awsbreak' again, or check the regions above.")
+	default:
+		fmt.Println("🚨 NOT ALL CLEAR - some services couldn't be checked")
+		fmt.Println("   Run 'awsbreak role check' if these look like missing permissions.")
+	}
+	if report.Account != "" {
+		fmt.Printf("   Account:  %s\n", report.Account)
+	}
+	regions := make([]string, len(report.Regions))
+	for i, r := range report.Regions {
+		regions[i] = r.Region
+	}
+	fmt.Printf("   Regions:  %s\n", strings.Join(regions, ", "))
+	fmt.Printf("   Swept:    %s\n", report.Time.Format("2006-01-02 15:04:05 MST"))
+	if report.Checksum != "" {
+		fmt.Printf("   Checksum: %s\n", report.Checksum)
+	}
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/textwidth"
+)
+
+// Overflow rules for cells wider than their column, set with output.overflow
+const (
+	overflowTruncate = "truncate" // shorten with an ellipsis in the middle, keeping both ends
+	overflowWrap     = "wrap"     // continue the cell on following lines
+	overflowNone     = "none"     // never shorten; columns may run past the edge
+)
+
+// minColumnWidth is the narrowest a shrinking column gets
+const minColumnWidth = 12
+
+// rowPrefixWidth is the space taken by a row's status icon, or its indent
+const rowPrefixWidth = 3
+
+// outputLayout is how wide output may be and what happens to cells that don't fit
+type outputLayout struct {
+	width    int // 0 means unlimited
+	overflow string
+}
+
+// currentLayout resolves the output layout from config, $COLUMNS and the
+// terminal. Piped output is unlimited by default so full IDs survive grep
+// and scripts.
+func currentLayout() outputLayout {
+	layout := outputLayout{overflow: overflowTruncate}
+
+	if configMgr != nil {
+		if cfg := configMgr.GetConfig(); cfg != nil && cfg.Output != nil {
+			layout.width = cfg.Output.Width
+			if cfg.Output.Overflow != "" {
+				layout.overflow = cfg.Output.Overflow
+			}
+		}
+	}
+	if layout.width > 0 {
+		return layout
+	}
+
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		layout.width = cols
+		return layout
+	}
+
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return outputLayout{overflow: overflowNone}
+	}
+	if w, _, err := term.GetSize(fd); err == nil && w > 0 {
+		layout.width = w
+	}
+	return layout
+}
+
+// fit shortens s to n columns according to the overflow rule, returning
+// one line per wrapped segment
+func (l outputLayout) fit(s string, n int) []string {
+	if n <= 0 || textwidth.Width(s) <= n || l.overflow == overflowNone {
+		return []string{s}
+	}
+	if l.overflow == overflowWrap {
+		return textwidth.Wrap(s, n)
+	}
+	return []string{textwidth.Truncate(s, n)}
+}
+
+// fitLine shortens a single-line value that starts after used columns, for
+// free-form lines such as "✅ ecs my-service"
+func fitLine(s string, used int) string {
+	l := currentLayout()
+	if l.width == 0 {
+		return s
+	}
+	if l.overflow == overflowWrap {
+		l.overflow = overflowTruncate
+	}
+	return l.fit(s, max(l.width-used, minColumnWidth))[0]
+}
+
+// consoleLink makes text, usually a resource ID, a clickable link to the
+// resource's AWS console page in terminals that support OSC 8 hyperlinks.
+// Piped output is left plain. Apply it after fitting, as the escape codes
+// take no columns.
+func consoleLink(text string, r models.Resource) string {
+	link := r.ConsoleURL()
+	if link == "" || os.Getenv("TERM") == "dumb" || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return text
+	}
+	return "\x1b]8;;" + link + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// column describes one table column
+type column struct {
+	title string
+	right bool // right-align, for numbers
+	flex  bool // shrinks first when the table is too wide
+}
+
+// table prints aligned rows, sizing columns to their content and shrinking
+// flexible columns to fit the output width
+type table struct {
+	columns []column
+	rows    []tableRow
+}
+
+type tableRow struct {
+	prefix string
+	cells  []string
+}
+
+func newTable(columns ...column) *table {
+	return &table{columns: columns}
+}
+
+// addRow adds a row. prefix is a status icon shown before the first column,
+// or "" for none.
+func (t *table) addRow(prefix string, cells ...string) {
+	t.rows = append(t.rows, tableRow{prefix: prefix, cells: cells})
+}
+
+// print writes the header and rows to stdout
+func (t *table) print() {
+	layout := currentLayout()
+	widths := t.widths(layout)
+
+	t.printLine("   ", t.titles(), widths, layout)
+	for _, r := range t.rows {
+		prefix := r.prefix
+		if prefix == "" {
+			prefix = "   "
+		} else if !strings.HasSuffix(prefix, " ") {
+			prefix += " "
+		}
+		t.printLine(prefix, r.cells, widths, layout)
+	}
+}
+
+func (t *table) titles() []string {
+	titles := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		titles[i] = c.title
+	}
+	return titles
+}
+
+// widths sizes each column to its widest cell, then shrinks flexible
+// columns, widest first, until the table fits
+func (t *table) widths(layout outputLayout) []int {
+	widths := make([]int, len(t.columns))
+	for i, c := range t.columns {
+		widths[i] = textwidth.Width(c.title)
+	}
+	for _, r := range t.rows {
+		for i, cell := range r.cells {
+			if i < len(widths) {
+				widths[i] = max(widths[i], textwidth.Width(cell))
+			}
+		}
+	}
+
+	if layout.width == 0 || layout.overflow == overflowNone {
+		return widths
+	}
+
+	total := rowPrefixWidth + len(widths) - 1
+	for _, w := range widths {
+		total += w
+	}
+	for total > layout.width {
+		widest := -1
+		for i, c := range t.columns {
+			if c.flex && widths[i] > minColumnWidth && (widest < 0 || widths[i] > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+	return widths
+}
+
+func (t *table) printLine(prefix string, cells []string, widths []int, layout outputLayout) {
+	fitted := make([][]string, len(widths))
+	lines := 1
+	for i := range widths {
+		var cell string
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		fitted[i] = layout.fit(cell, widths[i])
+		lines = max(lines, len(fitted[i]))
+	}
+
+	for n := 0; n < lines; n++ {
+		var b strings.Builder
+		if n == 0 {
+			b.WriteString(prefix)
+		} else {
+			b.WriteString("   ")
+		}
+		for i, w := range widths {
+			var part string
+			if n < len(fitted[i]) {
+				part = fitted[i][n]
+			}
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			if t.columns[i].right {
+				b.WriteString(textwidth.PadLeft(part, w))
+			} else if i < len(widths)-1 {
+				b.WriteString(textwidth.Pad(part, w))
+			} else {
+				b.WriteString(part)
+			}
+		}
+		fmt.Println(b.String())
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+)
+
+// flagUTC shows absolute UTC timestamps without relative phrasing, for scripts
+var flagUTC bool
+
+// formatTime formats a timestamp in local time with its zone, or as RFC 3339
+// UTC with --utc
+func formatTime(t time.Time) string {
+	if flagUTC {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
+
+// formatWhen is formatTime followed by how long ago or how soon t is, such as
+// "2025-01-03 19:00 CET (3 days ago)". --utc drops the relative part.
+func formatWhen(t time.Time) string {
+	if flagUTC {
+		return formatTime(t)
+	}
+	return fmt.Sprintf("%s (%s)", formatTime(t), relativeTime(t))
+}
+
+// formatDate formats the date of t in the display zone
+func formatDate(t time.Time) string {
+	if flagUTC {
+		return t.UTC().Format("2006-01-02")
+	}
+	return t.Local().Format("2006-01-02")
+}
+
+// relativeTime describes t relative to now: "just now", "5m ago", "in 6h",
+// "3 days ago"
+func relativeTime(t time.Time) string {
+	d := time.Until(t)
+	future := d > 0
+	if !future {
+		d = -d
+	}
+
+	var span string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		span = fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		span = fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		span = fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+
+	if future {
+		return "in " + span
+	}
+	return span + " ago"
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"golang.org/x/term"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// triageFailures walks through each failed resource of a finished run and
+// lets the user retry it, skip it, open it in the AWS console or read the
+// full error. It returns the run's results with successful retries folded
+// in. Without a terminal, or with AWSBREAK_ASSUME_YES true, nothing is asked and
+// failures are left for 'awsbreak retry'.
+func triageFailures(ctx context.Context, awsCfg aws.Config, entry *journal.Entry, results []models.OperationResult) []models.OperationResult {
+	var failed []int
+	for i, r := range results {
+		if !r.Success {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) == 0 || stdPrompter.assumeYes() || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return results
+	}
+
+	if !confirm(fmt.Sprintf("\nTriage the %d failure(s) now? [y/N]: ", len(failed))) {
+		fmt.Println("   Run 'awsbreak retry' to retry them later.")
+		return results
+	}
+
+	j := journal.NewJournal(configMgr.GetConfigDir())
+	var (
+		retried []models.Resource
+		retries []*journal.Entry
+		outcome = make(map[string]models.OperationResult) // last retry of each resource
+	)
+
+triage:
+	for n, i := range failed {
+		r := results[i]
+		fmt.Printf("\n❌ [%d/%d] %s %s\n", n+1, len(failed), r.Resource.ServiceType, consoleLink(r.Resource.ResourceID, r.Resource))
+		fmt.Printf("   %s\n", firstLine(r.Error))
+
+		for {
+			switch choice := strings.ToLower(prompt("   [r]etry  [s]kip  [o]pen console  [e]rror details  [q]uit: ")); choice {
+			case "r", "retry":
+				result, ok, run := retryOne(ctx, awsCfg, entry, r.Resource)
+				retried = append(retried, r.Resource)
+				retries = append(retries, run)
+				outcome[r.Resource.Key()] = result
+				if ok {
+					results[i] = result
+					fmt.Printf("   ✅ %sd %s\n", entry.Operation, r.Resource.ResourceID)
+					continue triage
+				}
+				results[i], r = result, result
+				fmt.Printf("   ❌ Still failing: %s\n", firstLine(r.Error))
+			case "s", "skip", "":
+				continue triage
+			case "o", "open":
+				openConsole(r.Resource)
+			case "e", "error":
+				fmt.Printf("\n%s\n\n", r.Error)
+			case "q", "quit":
+				break triage
+			default:
+				fmt.Println("   Choose r, s, o, e or q.")
+			}
+		}
+	}
+
+	// Each retry has its own journal entry now; only untouched failures are
+	// left for 'awsbreak retry' under the original run
+	if len(retried) > 0 {
+		if err := j.DropFailures(entry, retried); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		reportRetries(ctx, awsCfg, retries, outcome)
+	}
+
+	remaining := len(results) - countSuccessful(results)
+	if remaining > 0 {
+		fmt.Printf("\n⚠️  %d failure(s) left - run 'awsbreak retry' to try again later.\n", remaining)
+	}
+	return results
+}
+
+// reportRetries records the retries made during triage as one run: one ledger
+// entry, one notification and one pass over recurring failures, counting
+// only each resource's last attempt
+func reportRetries(ctx context.Context, awsCfg aws.Config, retries []*journal.Entry, outcome map[string]models.OperationResult) {
+	// A retry that failed before it began has no ID or start time
+	first := retries[0]
+	for _, r := range retries {
+		if r.ID != "" {
+			first = r
+			break
+		}
+	}
+	run := &journal.Entry{
+		ID:         first.ID,
+		Operation:  first.Operation,
+		Region:     first.Region,
+		SnapshotID: first.SnapshotID,
+		Started:    first.Started,
+		Finished:   first.Started,
+	}
+	for _, r := range retries {
+		run.Finished = run.Finished.Add(r.Duration())
+	}
+
+	var results []models.OperationResult
+	seen := make(map[string]bool)
+	for _, r := range retries {
+		key := r.Resources[0].Key()
+		if !seen[key] {
+			seen[key] = true
+			results = append(results, outcome[key])
+		}
+	}
+
+	recordRun(run, results)
+	notifyRun(ctx, awsCfg, run.Operation, run.Region, results)
+	fileRecurringFailures(ctx, run, results)
+}
+
+// retryOne repeats the run's operation for a single resource. A retried pause
+// joins the run's snapshot; a retried resume marks it restored.
+func retryOne(ctx context.Context, awsCfg aws.Config, entry *journal.Entry, resource models.Resource) (models.OperationResult, bool, *journal.Entry) {
+	spin := startSpinner(fmt.Sprintf("Retrying %s...", resource.ResourceID))
+	run := &journal.Entry{
+		Operation:  entry.Operation,
+		Region:     entry.Region,
+		SnapshotID: entry.SnapshotID,
+		RoleARN:    entry.RoleARN,
+		Resources:  []models.Resource{resource},
+		Batched:    true,
+	}
+	results, err := runOperation(ctx, awsCfg, run)
+	spin.halt()
+
+	if err != nil || len(results) == 0 {
+		result := models.OperationResult{Resource: resource, Operation: entry.Operation}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Error = "no result"
+		}
+		return result, false, run
+	}
+	return results[0], results[0].Success, run
+}
+
+// openConsole prints the resource's AWS console URL and tries to open it
+func openConsole(r models.Resource) {
+	link := r.ConsoleURL()
+	if link == "" {
+		fmt.Println("   No console page known for this resource.")
+		return
+	}
+
+	fmt.Printf("   🔗 %s\n", link)
+	if err := openBrowser(link); err != nil {
+		fmt.Println("   (couldn't open a browser - copy the link above)")
+	}
+}
+
+// openBrowser opens a URL with the desktop's default handler
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	return cmd.Start()
+}
+
+// firstLine returns the first line of a possibly multi-line message
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/auth"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+// verifySetup checks each step awsbreak depends on - base credentials, the
+// role's trust policy and the role's permissions - and explains any failure.
+// Permission probes run in parallel; Ctrl-C cancels them. It returns false
+// when the role works but some permissions are missing.
+func verifySetup(roleARN, region string) bool {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Println()
+	fmt.Println("🔐 Verifying setup")
+
+	// Base credentials
+	spin := startSpinner("Checking AWS credentials...")
+	start := time.Now()
+	caller, err := auth.CallerIdentity(ctx, region)
+	spin.halt()
+	if err != nil {
+		fmt.Printf("❌ AWS credentials (%s)\n", elapsed(start))
+		fmt.Printf("   %v\n", err)
+		fmt.Println("   No usable AWS credentials were found. Run 'aws configure' or set AWS_PROFILE")
+		fmt.Println("   (for an IAM Identity Center profile, run 'awsbreak login'), then run setup again.")
+		exit(ExitAuthError)
+	}
+	fmt.Printf("✅ AWS credentials: %s (%s)\n", caller, elapsed(start))
+
+	// Trust policy
+	spin = startSpinner("Assuming " + roleARN + "...")
+	start = time.Now()
+	authMgr = auth.NewIAMAuthenticator(roleARN, region)
+	awsCfg, err := authMgr.GetAWSConfig(ctx)
+	spin.halt()
+	if err != nil {
+		fmt.Printf("❌ Assume role (%s)\n", elapsed(start))
+		fmt.Printf("   %v\n", err)
+		if services.IsAccessDenied(err) {
+			fmt.Println("   The role's trust policy does not let these credentials assume it. Add")
+			fmt.Printf("   %s\n", caller)
+			fmt.Println("   (or its account root) as a principal allowed sts:AssumeRole, and check any")
+			fmt.Println("   sts:ExternalId condition.")
+		} else if strings.Contains(err.Error(), "DurationSeconds") && strings.Contains(caller, ":assumed-role/") {
+			fmt.Println("   These credentials are themselves a role session, as IAM Identity Center")
+			fmt.Println("   profiles are. AWS caps a role assumed from another role at one hour whatever")
+			fmt.Println("   its MaxSessionDuration, so set session.duration_minutes in config.json to 60")
+			fmt.Println("   or less.")
+		} else if strings.Contains(err.Error(), "DurationSeconds") {
+			fmt.Println("   session.duration_minutes in config.json is longer than the role allows. Raise")
+			fmt.Println("   the role's MaxSessionDuration (the setup template's MaxSessionDuration parameter).")
+		} else {
+			fmt.Println("   Check that the role ARN is correct and the role exists.")
+		}
+		exit(ExitAuthError)
+	}
+	fmt.Printf("✅ Assume role (%s)\n", elapsed(start))
+
+	// Permissions, one probe per service in parallel
+	probes := services.SelectProbes(services.PermissionProbes(awsCfg), selectedServices, skippedServices)
+	if auth.Spectating() || flagSetupObserver {
+		probes = readOnlyProbes(probes)
+	}
+	done := 0
+	spin = startSpinner(fmt.Sprintf("Checking permissions (0/%d)...", len(probes)))
+	results := services.RunProbes(ctx, probes, func(r services.ProbeResult) {
+		done++
+		spin.update(fmt.Sprintf("Checking permissions (%d/%d)...", done, len(probes)))
+	})
+	spin.halt()
+
+	failed := 0
+	for _, r := range results {
+		if r.OK() {
+			fmt.Printf("✅ %-13s %-40s %8s\n", r.Service, r.Action, r.Duration.Round(time.Millisecond))
+			continue
+		}
+		failed++
+		fmt.Printf("❌ %-13s %-40s %8s\n", r.Service, r.Action, r.Duration.Round(time.Millisecond))
+		fmt.Printf("   %v\n", r.Err)
+		fmt.Printf("   %s\n", r.Remediation())
+	}
+
+	if ctx.Err() != nil {
+		fmt.Println("\n⚠️  Verification cancelled")
+		exit(ExitGeneralError)
+	}
+	if failed > 0 {
+		fmt.Printf("\n⚠️  %d of %d permission checks failed - awsbreak will skip what it can't reach.\n", failed, len(results))
+		return false
+	}
+	return true
+}
+
+func elapsed(start time.Time) time.Duration {
+	return time.Since(start).Round(time.Millisecond)
+}
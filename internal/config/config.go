@@ -15,26 +15,34 @@ const (
 	configDirName  = ".aws-hit-breaks"
 	configFileName = "config.json"
 	Version        = "1.0.0"
+
+	// DefaultAttributionTag is the tag key used to attribute savings when none is configured
+	DefaultAttributionTag = "team"
 )
 
 var (
-	// iamRoleARNPattern validates IAM role ARN format
-	iamRoleARNPattern = regexp.MustCompile(`^arn:aws:iam::\d{12}:role/[\w+=,.@-]+$`)
+	// iamRoleARNPattern validates IAM role ARN format in any partition, such
+	// as aws, aws-cn or aws-us-gov
+	iamRoleARNPattern = regexp.MustCompile(`^arn:aws(-[a-z]+)*:iam::\d{12}:role/[\w+=,./@-]+$`)
 
-	// validRegions is a list of valid AWS regions
-	validRegions = map[string]bool{
-		"us-east-1": true, "us-east-2": true, "us-west-1": true, "us-west-2": true,
-		"eu-west-1": true, "eu-west-2": true, "eu-west-3": true, "eu-central-1": true,
-		"eu-north-1": true, "ap-southeast-1": true, "ap-southeast-2": true,
-		"ap-northeast-1": true, "ap-northeast-2": true, "ap-south-1": true,
-		"sa-east-1": true, "ca-central-1": true,
-	}
+	// regionPattern matches the shape of every AWS region name, including
+	// GovCloud, China, ISO and European Sovereign Cloud partitions
+	regionPattern = regexp.MustCompile(`^[a-z]{2,4}(-[a-z]+)+-\d+$`)
 )
 
-// Manager handles configuration loading and saving
+//go:generate go run ./genregions
+
+// Manager handles configuration loading and saving. config.json is encrypted
+// at rest when encryption has been turned on and the OS keychain is
+// available; otherwise it is plaintext.
 type Manager struct {
 	configPath string
 	config     *models.Config
+
+	encrypted       bool // whether Save encrypts
+	encryptionKnown bool // set once Load or SetEncryption has decided
+	newKey          bool // encryption was just turned on, so Save may create the key
+	keys            keyStore
 }
 
 // NewManager creates a new configuration manager
@@ -73,10 +81,23 @@ func (m *Manager) Load() (*models.Config, error) {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
+	if env := parseEnvelope(data); env != nil {
+		if data, err = m.decrypt(env); err != nil {
+			return nil, err
+		}
+		m.encrypted = true
+	} else {
+		m.encrypted = false
+	}
+	m.encryptionKnown = true
+
 	var cfg models.Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	if err := validate(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	m.config = &cfg
 	return &cfg, nil
@@ -102,6 +123,19 @@ func (m *Manager) Save(cfg *models.Config) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	// Saving without loading first, as setup does, keeps the file's encryption
+	if !m.encryptionKnown {
+		if existing, err := os.ReadFile(m.configPath); err == nil {
+			m.encrypted = parseEnvelope(existing) != nil
+		}
+		m.encryptionKnown = true
+	}
+	if m.encrypted {
+		if data, err = m.encrypt(data); err != nil {
+			return err
+		}
+	}
+
 	// Write atomically by writing to temp file first
 	tmpPath := m.configPath + ".tmp"
 	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
@@ -117,22 +151,151 @@ func (m *Manager) Save(cfg *models.Config) error {
 	return nil
 }
 
+// Encrypted reports whether config.json is encrypted at rest
+func (m *Manager) Encrypted() bool {
+	return m.encrypted
+}
+
+// KeyStore names the OS keychain the config key is kept in, or "" if this
+// system has none
+func (m *Manager) KeyStore() string {
+	if keys := m.keyStore(); keys != nil {
+		return keys.Name()
+	}
+	return ""
+}
+
+// SetEncryption turns encryption of config.json on or off for the next Save.
+// Turning it on fails when this system has no usable keychain, leaving the
+// config in plaintext.
+func (m *Manager) SetEncryption(on bool) error {
+	if on && m.keyStore() == nil {
+		return fmt.Errorf("no OS keychain available: install secret-tool (libsecret) on Linux; macOS and Windows need no setup")
+	}
+	m.newKey = on && !m.encrypted
+	m.encrypted = on
+	m.encryptionKnown = true
+	return nil
+}
+
+// Plaintext returns config.json as it reads once decrypted, for copying it to
+// a machine without this one's key
+func (m *Manager) Plaintext() ([]byte, error) {
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if env := parseEnvelope(data); env != nil {
+		return m.decrypt(env)
+	}
+	return data, nil
+}
+
+func (m *Manager) keyStore() keyStore {
+	if m.keys == nil {
+		m.keys = osKeyStore(m.GetConfigDir())
+	}
+	return m.keys
+}
+
+func (m *Manager) decrypt(env *envelope) ([]byte, error) {
+	keys := m.keyStore()
+	if keys == nil {
+		return nil, fmt.Errorf("config is encrypted with a key in %s, which isn't available on this system", env.KeyStore)
+	}
+	key, err := keys.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config: %w", err)
+	}
+	data, err := env.open(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config: %w", err)
+	}
+	return data, nil
+}
+
+// encrypt seals a marshaled config with the key in the keychain. The key is
+// only created when encryption has just been turned on; otherwise a key that
+// can't be read, say from a locked keychain, fails the save rather than
+// being replaced.
+func (m *Manager) encrypt(data []byte) ([]byte, error) {
+	keys := m.keyStore()
+	if keys == nil {
+		return nil, fmt.Errorf("config encryption is on but no OS keychain is available")
+	}
+	key, err := keys.Get()
+	if err != nil {
+		if !m.newKey {
+			return nil, fmt.Errorf("failed to encrypt config, which was left unchanged: %w", err)
+		}
+		if key, err = newConfigKey(); err != nil {
+			return nil, err
+		}
+		if err := keys.Set(key); err != nil {
+			return nil, err
+		}
+	}
+	m.newKey = false
+	sealed, err := seal(data, key, keys.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt config: %w", err)
+	}
+	return sealed, nil
+}
+
+// validate rejects settings whose values awsbreak wouldn't understand, rather
+// than silently falling back to defaults
+func validate(cfg *models.Config) error {
+	if o := cfg.Output; o != nil {
+		switch o.Overflow {
+		case "", "truncate", "wrap", "none":
+		default:
+			return fmt.Errorf("output.overflow %q: use truncate, wrap or none", o.Overflow)
+		}
+		if o.Width < 0 {
+			return fmt.Errorf("output.width %d: must not be negative", o.Width)
+		}
+	}
+	if p := cfg.Protection; p != nil && p.IaC != nil {
+		switch p.IaC.Mode {
+		case "", "warn", "skip", "off":
+		default:
+			return fmt.Errorf("protection.iac.mode %q: use warn, skip or off", p.IaC.Mode)
+		}
+	}
+	if c := cfg.Concurrency; c != nil {
+		for svc, n := range c.PerService {
+			if n < 1 {
+				return fmt.Errorf("concurrency.per_service.%s %d: must be at least 1", svc, n)
+			}
+		}
+	}
+	return nil
+}
+
 // ValidateIAMRoleARN validates an IAM role ARN format
 func ValidateIAMRoleARN(arn string) error {
 	if !iamRoleARNPattern.MatchString(arn) {
-		return fmt.Errorf("invalid IAM role ARN format: expected arn:aws:iam::ACCOUNT_ID:role/ROLE_NAME")
+		return fmt.Errorf("invalid IAM role ARN format: expected arn:PARTITION:iam::ACCOUNT_ID:role/ROLE_NAME")
 	}
 	return nil
 }
 
-// ValidateRegion validates an AWS region
+// ValidateRegion checks that a region name is well formed. Regions newer than
+// this build are accepted; use IsKnownRegion to warn about them.
 func ValidateRegion(region string) error {
-	if !validRegions[region] {
-		return fmt.Errorf("invalid or unsupported region: %s", region)
+	if !regionPattern.MatchString(region) {
+		return fmt.Errorf("invalid region: %s", region)
 	}
 	return nil
 }
 
+// IsKnownRegion reports whether a region was in the SDK endpoint metadata when
+// awsbreak was built
+func IsKnownRegion(region string) bool {
+	return knownRegions[region]
+}
+
 // GetConfig returns the currently loaded config
 func (m *Manager) GetConfig() *models.Config {
 	return m.config
@@ -151,3 +314,11 @@ func (m *Manager) GetDefaultRegion() string {
 	}
 	return "us-east-1"
 }
+
+// GetAttributionTag returns the tag key used to attribute savings to teams
+func (m *Manager) GetAttributionTag() string {
+	if m.config != nil && m.config.AttributionTag != "" {
+		return m.config.AttributionTag
+	}
+	return DefaultAttributionTag
+}
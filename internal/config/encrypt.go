@@ -0,0 +1,98 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// configKeySize is the AES-256 key config.json is encrypted with
+	configKeySize = 32
+
+	encryptionAlgorithm = "aes-256-gcm"
+)
+
+// envelope is the on-disk form of an encrypted config.json. Its fields don't
+// overlap models.Config, so a plaintext config never looks encrypted.
+type envelope struct {
+	Encryption string `json:"encryption"`
+	KeyStore   string `json:"key_store"` // where the key is kept, for error messages
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// parseEnvelope returns the envelope in data, or nil if data is plaintext
+func parseEnvelope(data []byte) *envelope {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Encryption == "" {
+		return nil
+	}
+	return &env
+}
+
+// seal encrypts a marshaled config with key
+func seal(plain, key []byte, store string) ([]byte, error) {
+	aead, err := newConfigAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	env := envelope{
+		Encryption: encryptionAlgorithm,
+		KeyStore:   store,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plain, []byte(encryptionAlgorithm)),
+	}
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal encrypted config: %w", err)
+	}
+	return data, nil
+}
+
+// open decrypts an envelope with key
+func (env *envelope) open(key []byte) ([]byte, error) {
+	if env.Encryption != encryptionAlgorithm {
+		return nil, fmt.Errorf("unsupported config encryption %q", env.Encryption)
+	}
+	aead, err := newConfigAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted config is damaged")
+	}
+	plain, err := aead.Open(nil, env.Nonce, env.Ciphertext, []byte(env.Encryption))
+	if err != nil {
+		return nil, fmt.Errorf("config key doesn't match or config is damaged")
+	}
+	return plain, nil
+}
+
+func newConfigAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// newConfigKey generates a random config key
+func newConfigKey() ([]byte, error) {
+	key := make([]byte, configKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate config key: %w", err)
+	}
+	return key, nil
+}
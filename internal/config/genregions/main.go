@@ -0,0 +1,84 @@
+// Command genregions regenerates the known AWS region list in
+// internal/config/regions_gen.go from the endpoint metadata bundled with the
+// aws-sdk-go-v2 version in go.mod, so the list matches the SDK awsbreak is
+// built with and needs no network access beyond the module cache. Run it with
+// `go generate ./internal/config` after upgrading the SDK.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sdkModule holds the partition metadata the SDK resolves endpoints with
+const sdkModule = "github.com/aws/aws-sdk-go-v2"
+
+// partitionsFile is the SDK's partition metadata, relative to its module root
+const partitionsFile = "internal/endpoints/awsrulesfn/partitions.json"
+
+type partitions struct {
+	Partitions []struct {
+		ID      string                     `json:"id"`
+		Regions map[string]json.RawMessage `json:"regions"`
+	} `json:"partitions"`
+}
+
+func main() {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", sdkModule).Output()
+	if err != nil {
+		log.Fatalf("failed to locate %s: %v", sdkModule, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if dir == "" {
+		log.Fatalf("%s is not in the module cache; run go mod download", sdkModule)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(partitionsFile)))
+	if err != nil {
+		log.Fatalf("failed to read endpoint metadata: %v", err)
+	}
+
+	var data partitions
+	if err := json.Unmarshal(raw, &data); err != nil {
+		log.Fatalf("failed to parse endpoint metadata: %v", err)
+	}
+
+	var regions []string
+	for _, p := range data.Partitions {
+		for region := range p.Regions {
+			// Skip pseudo regions such as aws-global
+			if strings.HasPrefix(region, p.ID+"-") {
+				continue
+			}
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by genregions; DO NOT EDIT.\n\n")
+	b.WriteString("package config\n\n")
+	b.WriteString("// knownRegions are the regions, in every partition, in the SDK endpoint\n")
+	b.WriteString("// metadata when awsbreak was built\n")
+	b.WriteString("var knownRegions = map[string]bool{\n")
+	for _, r := range regions {
+		fmt.Fprintf(&b, "\t%q: true,\n", r)
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format generated code: %v", err)
+	}
+	if err := os.WriteFile("regions_gen.go", src, 0644); err != nil {
+		log.Fatalf("failed to write regions_gen.go: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// keychainService and keychainAccount name the config key's keychain item
+	keychainService = "awsbreak"
+	keychainAccount = "config-key"
+
+	// dpapiKeyFileName holds the DPAPI-protected config key on Windows
+	dpapiKeyFileName = "config.key"
+)
+
+// keyStore keeps the key config.json is encrypted with outside the config
+// directory, so a copy of the directory alone can't be decrypted
+type keyStore interface {
+	// Name describes the store for messages
+	Name() string
+	// Get returns the stored key
+	Get() ([]byte, error)
+	// Set stores the key, replacing any earlier one
+	Set(key []byte) error
+}
+
+// osKeyStore returns the current platform's key store, or nil if it has none
+// or the tool it needs isn't installed
+func osKeyStore(configDir string) keyStore {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macKeychain{}
+		}
+	case "windows":
+		if _, err := exec.LookPath("powershell"); err == nil {
+			return dpapiStore{path: filepath.Join(configDir, dpapiKeyFileName)}
+		}
+	default:
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return secretService{}
+		}
+	}
+	return nil
+}
+
+// macKeychain stores the key as a generic password in the login keychain
+type macKeychain struct{}
+
+func (macKeychain) Name() string { return "macOS Keychain" }
+
+func (macKeychain) Get() ([]byte, error) {
+	out, err := runKeyTool("", "security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config key from macOS Keychain: %w", err)
+	}
+	return decodeKey(out)
+}
+
+func (m macKeychain) Set(key []byte) error {
+	// The command goes to 'security -i' on stdin, keeping the key out of the
+	// process list. Interactive mode doesn't fail the process when the
+	// command fails, so the key is read back to check it was stored.
+	encoded := base64.StdEncoding.EncodeToString(key)
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l \"awsbreak config key\" -w %s\n",
+		keychainService, keychainAccount, encoded)
+	if _, err := runKeyTool(command, "security", "-i"); err != nil {
+		return fmt.Errorf("failed to store config key in macOS Keychain: %w", err)
+	}
+	stored, err := m.Get()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(stored, key) {
+		return fmt.Errorf("failed to store config key in macOS Keychain")
+	}
+	return nil
+}
+
+// secretService stores the key with the freedesktop Secret Service (GNOME
+// Keyring, KWallet) through secret-tool
+type secretService struct{}
+
+func (secretService) Name() string { return "Secret Service" }
+
+func (secretService) Get() ([]byte, error) {
+	out, err := runKeyTool("", "secret-tool", "lookup", "service", keychainService, "account", keychainAccount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config key from Secret Service: %w", err)
+	}
+	return decodeKey(out)
+}
+
+func (secretService) Set(key []byte) error {
+	// secret-tool reads the secret from stdin, keeping it off the command line
+	_, err := runKeyTool(base64.StdEncoding.EncodeToString(key),
+		"secret-tool", "store", "--label=awsbreak config key", "service", keychainService, "account", keychainAccount)
+	if err != nil {
+		return fmt.Errorf("failed to store config key in Secret Service: %w", err)
+	}
+	return nil
+}
+
+// dpapiStore keeps the key in a file protected with Windows DPAPI, which only
+// the same Windows user can unprotect
+type dpapiStore struct {
+	path string
+}
+
+// dpapiScript protects or unprotects base64 read from stdin for the current user
+const dpapiScript = `Add-Type -AssemblyName System.Security; ` +
+	`$in = [Convert]::FromBase64String([Console]::In.ReadToEnd().Trim()); ` +
+	`[Convert]::ToBase64String([Security.Cryptography.ProtectedData]::%s($in, $null, 'CurrentUser'))`
+
+func (dpapiStore) Name() string { return "Windows DPAPI" }
+
+func (s dpapiStore) Get() ([]byte, error) {
+	protected, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config key: %w", err)
+	}
+	out, err := runKeyTool(string(protected), "powershell", "-NoProfile", "-NonInteractive", "-Command",
+		fmt.Sprintf(dpapiScript, "Unprotect"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unprotect config key with DPAPI: %w", err)
+	}
+	return decodeKey(out)
+}
+
+func (s dpapiStore) Set(key []byte) error {
+	out, err := runKeyTool(base64.StdEncoding.EncodeToString(key), "powershell", "-NoProfile", "-NonInteractive", "-Command",
+		fmt.Sprintf(dpapiScript, "Protect"))
+	if err != nil {
+		return fmt.Errorf("failed to protect config key with DPAPI: %w", err)
+	}
+	if err := os.WriteFile(s.path, []byte(out+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write config key: %w", err)
+	}
+	return nil
+}
+
+// runKeyTool runs a keychain command with stdin as input and returns its
+// trimmed output. Errors carry the command's stderr.
+func runKeyTool(stdin, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return nil, fmt.Errorf("no config key stored")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("stored config key is damaged: %w", err)
+	}
+	if len(key) != configKeySize {
+		return nil, fmt.Errorf("stored config key is %d bytes, expected %d", len(key), configKeySize)
+	}
+	return key, nil
+}
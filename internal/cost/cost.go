@@ -0,0 +1,151 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// Estimator names accepted in cost.estimator in config.json
+const (
+	EstimatorStatic  = "static"
+	EstimatorPricing = "pricing-api"
+	EstimatorCSV     = "csv"
+)
+
+// Product is a billable unit, such as an EC2 instance type or RDS instance
+// class, in a region
+type Product struct {
+	Service models.ServiceType
+	Type    string
+	Region  string
+	Engine  string // RDS engine, e.g. "postgres"
+}
+
+// Estimator prices products. Implementations return false for products they
+// have no rate for, so estimators can be chained.
+type Estimator interface {
+	HourlyRate(ctx context.Context, p Product) (float64, bool)
+}
+
+// Chain is an estimator that uses the first estimator with a rate
+type Chain []Estimator
+
+// HourlyRate returns the first known rate
+func (c Chain) HourlyRate(ctx context.Context, p Product) (float64, bool) {
+	for _, e := range c {
+		if rate, ok := e.HourlyRate(ctx, p); ok {
+			return rate, true
+		}
+	}
+	return 0, false
+}
+
+// Model prices discovered resources with an estimator and an account-wide
+// discount, such as an EDP
+type Model struct {
+	estimator Estimator
+	discount  float64
+}
+
+// FromConfig builds the cost model selected in config. A nil config uses the
+// built-in static tables with no discount. awsCfg supplies credentials for
+// the Pricing API. When pricingDir is set, prices cached there by 'awsbreak
+// pricing update' take precedence over the static tables.
+func FromConfig(cfg *models.CostConfig, awsCfg aws.Config, pricingDir string) (*Model, error) {
+	fallback := Chain{Static{}}
+	if pricingDir != "" {
+		fallback = Chain{NewOfferRates(pricingDir), Static{}}
+	}
+
+	if cfg == nil {
+		return &Model{estimator: fallback}, nil
+	}
+
+	m := &Model{}
+	switch cfg.Estimator {
+	case "", EstimatorStatic:
+		m.estimator = fallback
+	case EstimatorPricing:
+		m.estimator = append(Chain{NewPricingEstimator(awsCfg)}, fallback...)
+	case EstimatorCSV:
+		rates, err := LoadCSV(cfg.RatesFile)
+		if err != nil {
+			return nil, err
+		}
+		m.estimator = append(Chain{rates}, fallback...)
+	default:
+		return nil, fmt.Errorf("unknown cost estimator %q (use %s, %s or %s)", cfg.Estimator, EstimatorStatic, EstimatorPricing, EstimatorCSV)
+	}
+
+	if cfg.DiscountPercent < 0 || cfg.DiscountPercent >= 100 {
+		return nil, fmt.Errorf("cost discount_percent must be between 0 and 100, got %g", cfg.DiscountPercent)
+	}
+	m.discount = cfg.DiscountPercent / 100
+	return m, nil
+}
+
+// Apply sets CostPerHour on each resource from the model. Resources the
+// estimator can't price keep their discovery estimate; the discount applies
+// to every resource.
+func (m *Model) Apply(ctx context.Context, resources []models.Resource) {
+	for i := range resources {
+		r := &resources[i]
+		if p, quantity, ok := productOf(*r); ok {
+			if rate, known := m.estimator.HourlyRate(ctx, p); known {
+				r.CostPerHour = rate * quantity
+			}
+		}
+		r.CostPerHour *= 1 - m.discount
+	}
+}
+
+// productOf returns the product a resource is billed as and how many units
+// of it are running
+func productOf(r models.Resource) (Product, float64, bool) {
+	p := Product{Service: r.ServiceType, Region: r.Region}
+
+	switch r.ServiceType {
+	case models.ServiceEC2:
+		p.Type, _ = r.Metadata["instance_type"].(string)
+		if r.Metadata["kind"] == "capacity_reservation" {
+			// Only idle slots are extra; used ones are billed as their instances
+			available, _ := r.Metadata["available_count"].(float64)
+			return p, available, p.Type != ""
+		}
+		return p, 1, p.Type != ""
+	case models.ServiceRDS:
+		// Aurora Serverless is billed per ACU; other clusters aren't priced
+		switch r.Metadata["serverless"] {
+		case "v1":
+			p.Type = "aurora-serverless-v1-acu"
+			acus, _ := r.Metadata["capacity_acus"].(float64)
+			return p, acus, true
+		case "v2":
+			p.Type = "aurora-serverless-v2-acu"
+			acus, _ := r.Metadata["savable_acus"].(float64)
+			return p, acus, true
+		}
+		if r.Metadata["is_cluster"] == true {
+			return p, 0, false
+		}
+		p.Type, _ = r.Metadata["instance_class"].(string)
+		p.Engine, _ = r.Metadata["engine"].(string)
+		return p, 1, p.Type != ""
+	case models.ServiceEKS:
+		// Node groups are billed as the EC2 instances they run
+		desired, _ := r.Metadata["original_desired_size"].(float64)
+		if types, ok := r.Metadata["instance_types"].([]string); ok && len(types) > 0 {
+			p.Type = types[0]
+		} else if types, ok := r.Metadata["instance_types"].([]any); ok && len(types) > 0 {
+			p.Type, _ = types[0].(string)
+		}
+		p.Service = models.ServiceEC2
+		return p, desired, p.Type != ""
+	}
+
+	return p, 0, false
+}
@@ -0,0 +1,85 @@
+package cost
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// wildcardRegion matches any region in a rates file
+const wildcardRegion = "*"
+
+// CSVRates prices products from a customer-supplied file of negotiated rates.
+// The file has a header row and the columns service, type, region and
+// hourly_rate, for example:
+//
+//	service,type,region,hourly_rate
+//	ec2,m5.large,us-east-1,0.081
+//	rds,db.r5.large,*,0.20
+//
+// A region of "*" (or empty) applies to every region without its own row.
+type CSVRates struct {
+	rates map[string]float64
+}
+
+// LoadCSV reads a rates file
+func LoadCSV(path string) (*CSVRates, error) {
+	if path == "" {
+		return nil, fmt.Errorf("cost estimator csv needs cost.rates_file in config.json")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates file: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("rates file %s is empty", path)
+	}
+
+	c := &CSVRates{rates: make(map[string]float64)}
+	for i, rec := range records[1:] {
+		line := i + 2
+		if len(rec) < 4 {
+			return nil, fmt.Errorf("rates file line %d: expected service,type,region,hourly_rate", line)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rec[3]), 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("rates file line %d: invalid hourly_rate %q", line, rec[3])
+		}
+
+		region := strings.TrimSpace(rec[2])
+		if region == "" {
+			region = wildcardRegion
+		}
+		service := models.ServiceType(strings.ToLower(strings.TrimSpace(rec[0])))
+		c.rates[rateKey(service, strings.TrimSpace(rec[1]), region)] = rate
+	}
+
+	return c, nil
+}
+
+// HourlyRate returns the negotiated rate for the product, preferring a
+// region-specific row over a wildcard
+func (c *CSVRates) HourlyRate(ctx context.Context, p Product) (float64, bool) {
+	if rate, ok := c.rates[rateKey(p.Service, p.Type, p.Region)]; ok {
+		return rate, true
+	}
+	rate, ok := c.rates[rateKey(p.Service, p.Type, wildcardRegion)]
+	return rate, ok
+}
+
+func rateKey(service models.ServiceType, productType, region string) string {
+	return string(service) + "|" + productType + "|" + region
+}
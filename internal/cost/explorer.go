@@ -0,0 +1,228 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// explorerRegion hosts the Cost Explorer endpoint
+	explorerRegion = "us-east-1"
+	// explorerMetric is the cost metric compared; unblended matches the bill
+	explorerMetric = "UnblendedCost"
+	// dateLayout is the date format Cost Explorer uses
+	dateLayout = "2006-01-02"
+)
+
+// billingServices maps each awsbreak service to the Cost Explorer service its
+// resources are billed under. Auto Scaling groups and EKS node groups run EC2
+// instances; NAT gateways bill under EC2 - Other.
+var billingServices = map[models.ServiceType]string{
+	models.ServiceEC2:         "Amazon Elastic Compute Cloud - Compute",
+	models.ServiceAutoScaling: "Amazon Elastic Compute Cloud - Compute",
+	models.ServiceEKS:         "Amazon Elastic Compute Cloud - Compute",
+	models.ServiceNetwork:     "EC2 - Other",
+	models.ServiceRDS:         "Amazon Relational Database Service",
+	models.ServiceECS:         "Amazon Elastic Container Service",
+	models.ServiceWorkSpaces:  "Amazon WorkSpaces",
+	models.ServiceAppStream:   "Amazon AppStream",
+	models.ServiceOpenSearch:  "Amazon OpenSearch Service",
+	models.ServiceDynamoDB:    "Amazon DynamoDB",
+	models.ServiceEMR:         "Amazon Elastic MapReduce",
+	models.ServiceKinesis:     "Amazon Kinesis",
+	models.ServiceLightsail:   "Amazon Lightsail",
+}
+
+// BillingService returns the Cost Explorer service name a resource type is
+// billed under
+func BillingService(t models.ServiceType) string {
+	return billingServices[t]
+}
+
+// ServiceSpend compares a billing service's average daily spend before and
+// during a brake
+type ServiceSpend struct {
+	Service       string // Cost Explorer service name
+	BaselineDaily float64
+	BrakedDaily   float64
+	BrakedDays    int
+}
+
+// Saved returns the actual savings over the braked days
+func (s ServiceSpend) Saved() float64 {
+	return (s.BaselineDaily - s.BrakedDaily) * float64(s.BrakedDays)
+}
+
+// Explorer reads actual spend from the Cost Explorer API. Each request costs
+// $0.01.
+type Explorer struct {
+	client *costexplorer.Client
+}
+
+// NewExplorer creates a Cost Explorer client using the given credentials
+func NewExplorer(cfg aws.Config) *Explorer {
+	cfg.Region = explorerRegion
+	return &Explorer{client: costexplorer.NewFromConfig(cfg)}
+}
+
+// Compare returns the average daily spend of each service awsbreak manages
+// in region over [baselineStart, brakedStart) and [brakedStart, brakedEnd).
+// Days are whole UTC days, as Cost Explorer reports them.
+func (e *Explorer) Compare(ctx context.Context, region string, baselineStart, brakedStart, brakedEnd time.Time) ([]ServiceSpend, error) {
+	baselineDays := int(brakedStart.Sub(baselineStart).Hours() / 24)
+	brakedDays := int(brakedEnd.Sub(brakedStart).Hours() / 24)
+	if baselineDays < 1 || brakedDays < 1 {
+		return nil, fmt.Errorf("need at least one full day before and during the brake")
+	}
+
+	daily, err := e.dailySpend(ctx, region, baselineStart, brakedEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	split := brakedStart.UTC().Format(dateLayout)
+	totals := make(map[string]*ServiceSpend)
+	for day, services := range daily {
+		for service, amount := range services {
+			s, ok := totals[service]
+			if !ok {
+				s = &ServiceSpend{Service: service, BrakedDays: brakedDays}
+				totals[service] = s
+			}
+			if day < split {
+				s.BaselineDaily += amount / float64(baselineDays)
+			} else {
+				s.BrakedDaily += amount / float64(brakedDays)
+			}
+		}
+	}
+
+	var spend []ServiceSpend
+	for _, s := range totals {
+		spend = append(spend, *s)
+	}
+	sort.Slice(spend, func(i, j int) bool {
+		return spend[i].Saved() > spend[j].Saved()
+	})
+	return spend, nil
+}
+
+// dailySpend returns spend per day and service for the services awsbreak manages
+func (e *Explorer) dailySpend(ctx context.Context, region string, start, end time.Time) (map[string]map[string]float64, error) {
+	seen := make(map[string]bool)
+	var services []string
+	for _, name := range billingServices {
+		if !seen[name] {
+			seen[name] = true
+			services = append(services, name)
+		}
+	}
+	sort.Strings(services)
+
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(start.UTC().Format(dateLayout)),
+			End:   aws.String(end.UTC().Format(dateLayout)),
+		},
+		Granularity: types.GranularityDaily,
+		Metrics:     []string{explorerMetric},
+		Filter: &types.Expression{
+			And: []types.Expression{
+				{Dimensions: &types.DimensionValues{Key: types.DimensionRegion, Values: []string{region}}},
+				{Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: services}},
+			},
+		},
+		GroupBy: []types.GroupDefinition{
+			{Type: types.GroupDefinitionTypeDimension, Key: aws.String(string(types.DimensionService))},
+		},
+	}
+
+	daily := make(map[string]map[string]float64)
+	for {
+		output, err := e.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query Cost Explorer: %w", err)
+		}
+
+		for _, period := range output.ResultsByTime {
+			day := aws.ToString(period.TimePeriod.Start)
+			if daily[day] == nil {
+				daily[day] = make(map[string]float64)
+			}
+			for _, group := range period.Groups {
+				if len(group.Keys) == 0 {
+					continue
+				}
+				metric, ok := group.Metrics[explorerMetric]
+				if !ok {
+					continue
+				}
+				amount, err := strconv.ParseFloat(aws.ToString(metric.Amount), 64)
+				if err != nil {
+					continue
+				}
+				daily[day][group.Keys[0]] += amount
+			}
+		}
+
+		if output.NextPageToken == nil {
+			break
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+
+	return daily, nil
+}
+
+// BudgetProgress is month-to-date spend measured against a monthly target
+type BudgetProgress struct {
+	Target      float64
+	Spent       float64 // through the end of yesterday, UTC
+	Projected   float64 // for the whole month at the month-to-date daily average
+	Days        int     // full days counted so far
+	DaysInMonth int
+}
+
+// OnTrack reports whether the month is projected to end within the target
+func (p BudgetProgress) OnTrack() bool {
+	return p.Projected <= p.Target
+}
+
+// MonthToDate returns this month's spend on the services awsbreak manages in
+// region, projected to the end of the month. Cost Explorer has no data for
+// the current day, so on the first of the month nothing is projected yet.
+func (e *Explorer) MonthToDate(ctx context.Context, region string, target float64, now time.Time) (BudgetProgress, error) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	progress := BudgetProgress{
+		Target:      target,
+		Days:        now.Day() - 1,
+		DaysInMonth: start.AddDate(0, 1, -1).Day(),
+	}
+	if progress.Days == 0 {
+		return progress, nil
+	}
+
+	daily, err := e.dailySpend(ctx, region, start, today)
+	if err != nil {
+		return progress, err
+	}
+	for _, services := range daily {
+		for _, amount := range services {
+			progress.Spent += amount
+		}
+	}
+	progress.Projected = progress.Spent / float64(progress.Days) * float64(progress.DaysInMonth)
+	return progress, nil
+}
@@ -0,0 +1,309 @@
+package cost
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	pricingDirName = "pricing"
+
+	// offerHost serves the public price list for the aws and aws-us-gov
+	// partitions; the China regions have their own
+	offerHost   = "https://pricing.us-east-1.amazonaws.com"
+	offerHostCN = "https://pricing.cn-northwest-1.amazonaws.com.cn"
+
+	// offerStaleAfter is how old cached prices get before commands suggest a refresh
+	offerStaleAfter = 90 * 24 * time.Hour
+)
+
+// offerCodes maps the services awsbreak prices to their price list offer codes
+var offerCodes = map[models.ServiceType]string{
+	models.ServiceEC2: "AmazonEC2",
+	models.ServiceRDS: "AmazonRDS",
+}
+
+// Offer is the on-demand rates extracted from a region's price list offer files
+type Offer struct {
+	Region string `json:"region"`
+	// PublishedAt is the publication date of the oldest offer file used
+	PublishedAt  time.Time          `json:"published_at"`
+	DownloadedAt time.Time          `json:"downloaded_at"`
+	Rates        map[string]float64 `json:"rates"` // rateKey -> hourly rate
+}
+
+// Stale reports whether the cached prices are old enough to refresh
+func (o *Offer) Stale() bool {
+	return time.Since(o.PublishedAt) > offerStaleAfter
+}
+
+// OfferCache stores rates downloaded by 'awsbreak pricing update' in the
+// config directory, one file per region, so estimates work offline
+type OfferCache struct {
+	dir    string
+	client *http.Client
+}
+
+// NewOfferCache creates a cache under the config directory
+func NewOfferCache(configDir string) *OfferCache {
+	return &OfferCache{
+		dir:    filepath.Join(configDir, pricingDirName),
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// Update downloads the EC2 and RDS offer files for a region and caches their
+// on-demand rates. The EC2 file for a large region is several hundred
+// megabytes; it is streamed and only matching rows are kept.
+func (c *OfferCache) Update(ctx context.Context, region string) (*Offer, error) {
+	offer := &Offer{
+		Region:       region,
+		DownloadedAt: time.Now(),
+		Rates:        make(map[string]float64),
+	}
+
+	for _, service := range []models.ServiceType{models.ServiceEC2, models.ServiceRDS} {
+		published, err := c.download(ctx, service, region, offer.Rates)
+		if err != nil {
+			return nil, err
+		}
+		if offer.PublishedAt.IsZero() || published.Before(offer.PublishedAt) {
+			offer.PublishedAt = published
+		}
+	}
+
+	if err := c.save(offer); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// Load returns the cached rates for a region, or nil if none were downloaded
+func (c *OfferCache) Load(region string) (*Offer, error) {
+	data, err := os.ReadFile(c.path(region))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached pricing for %s: %w", region, err)
+	}
+
+	var offer Offer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, fmt.Errorf("failed to parse cached pricing for %s: %w", region, err)
+	}
+	return &offer, nil
+}
+
+// List returns the cached offers of every region
+func (c *OfferCache) List() ([]*Offer, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached pricing: %w", err)
+	}
+
+	var offers []*Offer
+	for _, f := range files {
+		offer, err := c.Load(strings.TrimSuffix(filepath.Base(f), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		if offer != nil {
+			offers = append(offers, offer)
+		}
+	}
+	return offers, nil
+}
+
+func (c *OfferCache) save(offer *Offer) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create pricing directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(offer, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pricing: %w", err)
+	}
+
+	// Write atomically so an interrupted update keeps the previous prices
+	path := c.path(offer.Region)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write pricing: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save pricing: %w", err)
+	}
+	return nil
+}
+
+func (c *OfferCache) path(region string) string {
+	return filepath.Join(c.dir, region+".json")
+}
+
+// download streams a service's CSV offer file for a region into rates and
+// returns its publication date
+func (c *OfferCache) download(ctx context.Context, service models.ServiceType, region string, rates map[string]float64) (time.Time, error) {
+	host := offerHost
+	if strings.HasPrefix(region, "cn-") {
+		host = offerHostCN
+	}
+	url := fmt.Sprintf("%s/offers/v1.0/aws/%s/current/%s/index.csv", host, offerCodes[service], region)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to download %s prices for %s: %w", service, region, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("failed to download %s prices for %s: %s", service, region, resp.Status)
+	}
+
+	return parseOffer(resp.Body, service, region, rates)
+}
+
+// parseOffer reads a CSV offer file. The file starts with metadata rows such
+// as "Publication Date", followed by a header row and one row per price.
+func parseOffer(r io.Reader, service models.ServiceType, region string, rates map[string]float64) (time.Time, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var published time.Time
+	col := make(map[string]int)
+	for {
+		rec, err := reader.Read()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read %s offer file header: %w", service, err)
+		}
+		if len(rec) >= 2 && rec[0] == "Publication Date" {
+			published, _ = time.Parse(time.RFC3339, rec[1])
+		}
+		if len(rec) > 0 && rec[0] == "SKU" {
+			for i, name := range rec {
+				col[name] = i
+			}
+			break
+		}
+	}
+
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read %s offer file: %w", service, err)
+		}
+
+		if field(rec, "TermType") != "OnDemand" || field(rec, "Unit") != "Hrs" {
+			continue
+		}
+		rate, err := strconv.ParseFloat(field(rec, "PricePerUnit"), 64)
+		if err != nil || rate <= 0 {
+			continue
+		}
+
+		switch service {
+		case models.ServiceEC2:
+			if field(rec, "Product Family") != "Compute Instance" ||
+				field(rec, "Operating System") != "Linux" ||
+				field(rec, "Tenancy") != "Shared" ||
+				field(rec, "Pre Installed S/W") != "NA" ||
+				field(rec, "CapacityStatus") != "Used" {
+				continue
+			}
+			rates[rateKey(service, field(rec, "Instance Type"), region)] = rate
+		case models.ServiceRDS:
+			if field(rec, "Product Family") != "Database Instance" ||
+				field(rec, "Deployment Option") != "Single-AZ" {
+				continue
+			}
+			engine := engineOf(field(rec, "Database Engine"))
+			if engine == "" {
+				continue
+			}
+			rates[rateKey(service, field(rec, "Instance Type"), region)+"|"+engine] = rate
+		}
+	}
+
+	return published, nil
+}
+
+// engineOf maps a price list databaseEngine value back to an RDS engine name
+func engineOf(databaseEngine string) string {
+	for engine, name := range rdsEngines {
+		if name == databaseEngine {
+			return engine
+		}
+	}
+	return ""
+}
+
+// OfferRates prices products from the offer cache. Regions are loaded on first
+// use; regions that were never downloaded have no rates.
+type OfferRates struct {
+	cache *OfferCache
+
+	mu     sync.Mutex
+	offers map[string]*Offer
+}
+
+// NewOfferRates creates an estimator backed by the offer cache in configDir
+func NewOfferRates(configDir string) *OfferRates {
+	return &OfferRates{
+		cache:  NewOfferCache(configDir),
+		offers: make(map[string]*Offer),
+	}
+}
+
+// HourlyRate returns the cached on-demand rate for the product. RDS products
+// without a known engine are priced as MySQL.
+func (e *OfferRates) HourlyRate(ctx context.Context, p Product) (float64, bool) {
+	e.mu.Lock()
+	offer, loaded := e.offers[p.Region]
+	if !loaded {
+		offer, _ = e.cache.Load(p.Region)
+		e.offers[p.Region] = offer
+	}
+	e.mu.Unlock()
+	if offer == nil {
+		return 0, false
+	}
+
+	key := rateKey(p.Service, p.Type, p.Region)
+	if p.Service != models.ServiceRDS {
+		rate, ok := offer.Rates[key]
+		return rate, ok
+	}
+	if rate, ok := offer.Rates[key+"|"+p.Engine]; ok {
+		return rate, true
+	}
+	rate, ok := offer.Rates[key+"|mysql"]
+	return rate, ok
+}
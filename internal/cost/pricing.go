@@ -0,0 +1,150 @@
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// pricingRegion is a region that hosts the Pricing API endpoint
+const pricingRegion = "us-east-1"
+
+// rdsEngines maps RDS engine names to the Pricing API databaseEngine values
+var rdsEngines = map[string]string{
+	"mysql":             "MySQL",
+	"postgres":          "PostgreSQL",
+	"mariadb":           "MariaDB",
+	"aurora-mysql":      "Aurora MySQL",
+	"aurora-postgresql": "Aurora PostgreSQL",
+}
+
+// PricingEstimator looks up on-demand Linux prices from the AWS Pricing API.
+// Lookups are cached for the life of the estimator.
+type PricingEstimator struct {
+	client *pricing.Client
+
+	mu    sync.Mutex
+	cache map[Product]cachedRate
+}
+
+type cachedRate struct {
+	rate float64
+	ok   bool
+}
+
+// NewPricingEstimator creates an estimator using the given credentials
+func NewPricingEstimator(cfg aws.Config) *PricingEstimator {
+	cfg.Region = pricingRegion
+	return &PricingEstimator{
+		client: pricing.NewFromConfig(cfg),
+		cache:  make(map[Product]cachedRate),
+	}
+}
+
+// HourlyRate returns the on-demand price of the product. Failed lookups
+// report no rate so a fallback estimator is used.
+func (e *PricingEstimator) HourlyRate(ctx context.Context, p Product) (float64, bool) {
+	e.mu.Lock()
+	cached, hit := e.cache[p]
+	e.mu.Unlock()
+	if hit {
+		return cached.rate, cached.ok
+	}
+
+	rate, ok := e.lookup(ctx, p)
+
+	e.mu.Lock()
+	e.cache[p] = cachedRate{rate: rate, ok: ok}
+	e.mu.Unlock()
+	return rate, ok
+}
+
+func (e *PricingEstimator) lookup(ctx context.Context, p Product) (float64, bool) {
+	filter := func(field, value string) types.Filter {
+		return types.Filter{
+			Type:  types.FilterTypeTermMatch,
+			Field: aws.String(field),
+			Value: aws.String(value),
+		}
+	}
+
+	input := &pricing.GetProductsInput{MaxResults: aws.Int32(10)}
+	switch p.Service {
+	case models.ServiceEC2:
+		input.ServiceCode = aws.String("AmazonEC2")
+		input.Filters = []types.Filter{
+			filter("instanceType", p.Type),
+			filter("regionCode", p.Region),
+			filter("operatingSystem", "Linux"),
+			filter("tenancy", "Shared"),
+			filter("preInstalledSw", "NA"),
+			filter("capacitystatus", "Used"),
+		}
+	case models.ServiceRDS:
+		engine, ok := rdsEngines[strings.ToLower(p.Engine)]
+		if !ok {
+			return 0, false
+		}
+		input.ServiceCode = aws.String("AmazonRDS")
+		input.Filters = []types.Filter{
+			filter("instanceType", p.Type),
+			filter("regionCode", p.Region),
+			filter("databaseEngine", engine),
+			filter("deploymentOption", "Single-AZ"),
+		}
+	default:
+		return 0, false
+	}
+
+	output, err := e.client.GetProducts(ctx, input)
+	if err != nil {
+		logging.Debug("price lookup failed", "service", p.Service, "type", p.Type, "region", p.Region, "error", err)
+		return 0, false
+	}
+	for _, item := range output.PriceList {
+		if rate, ok := onDemandRate(item); ok {
+			return rate, true
+		}
+	}
+	return 0, false
+}
+
+// onDemandRate extracts the first non-zero hourly USD on-demand price from a
+// Pricing API price list document
+func onDemandRate(doc string) (float64, bool) {
+	var product struct {
+		Terms struct {
+			OnDemand map[string]struct {
+				PriceDimensions map[string]struct {
+					Unit         string            `json:"unit"`
+					PricePerUnit map[string]string `json:"pricePerUnit"`
+				} `json:"priceDimensions"`
+			} `json:"OnDemand"`
+		} `json:"terms"`
+	}
+	if err := json.Unmarshal([]byte(doc), &product); err != nil {
+		return 0, false
+	}
+
+	for _, term := range product.Terms.OnDemand {
+		for _, dim := range term.PriceDimensions {
+			if !strings.HasPrefix(dim.Unit, "Hrs") {
+				continue
+			}
+			rate, err := strconv.ParseFloat(dim.PricePerUnit["USD"], 64)
+			if err == nil && rate > 0 {
+				return rate, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package cost
+
+import (
+	"context"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// staticRates are approximate us-east-1 on-demand Linux prices
+var staticRates = map[models.ServiceType]map[string]float64{
+	models.ServiceEC2: {
+		"t2.micro":   0.0116,
+		"t2.small":   0.023,
+		"t2.medium":  0.0464,
+		"t2.large":   0.0928,
+		"t3.micro":   0.0104,
+		"t3.small":   0.0208,
+		"t3.medium":  0.0416,
+		"t3.large":   0.0832,
+		"m5.large":   0.096,
+		"m5.xlarge":  0.192,
+		"m5.2xlarge": 0.384,
+		"c5.large":   0.085,
+		"c5.xlarge":  0.17,
+		"r5.large":   0.126,
+		"r5.xlarge":  0.252,
+	},
+	models.ServiceRDS: {
+		"db.t3.micro":  0.017,
+		"db.t3.small":  0.034,
+		"db.t3.medium": 0.068,
+		"db.t3.large":  0.136,
+		"db.m5.large":  0.171,
+		"db.m5.xlarge": 0.342,
+		"db.r5.large":  0.24,
+		"db.r5.xlarge": 0.48,
+		// Aurora Serverless, per ACU
+		"aurora-serverless-v1-acu": 0.06,
+		"aurora-serverless-v2-acu": 0.12,
+	},
+}
+
+// Static prices products from built-in tables. It needs no credentials but
+// ignores region and engine.
+type Static struct{}
+
+// HourlyRate returns the built-in rate for the product type
+func (Static) HourlyRate(ctx context.Context, p Product) (float64, bool) {
+	return StaticRate(p.Service, p.Type)
+}
+
+// StaticRate returns the built-in hourly rate for an instance type or class
+func StaticRate(service models.ServiceType, productType string) (float64, bool) {
+	rate, ok := staticRates[service][productType]
+	return rate, ok
+}
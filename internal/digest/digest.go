@@ -0,0 +1,168 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/notify"
+)
+
+const (
+	// topBurnerCount is how many of the most expensive running resources to include
+	topBurnerCount = 5
+	// sendTimeout bounds each delivery attempt
+	sendTimeout = 30 * time.Second
+)
+
+// Digest summarizes awsbreak activity over a period
+type Digest struct {
+	Since       time.Time         `json:"since"`
+	Until       time.Time         `json:"until"`
+	PauseRuns   int               `json:"pause_runs"`
+	ResumeRuns  int               `json:"resume_runs"`
+	PausedHours float64           `json:"paused_hours"`
+	Savings     float64           `json:"savings"`
+	TopBurners  []models.Resource `json:"top_burners"`
+	Drift       []models.Resource `json:"drift"`
+}
+
+// Build creates a digest from the ledger, the resources currently running and
+// the resources awsbreak believes are still paused. Paused resources that are
+// running again without a recorded resume are reported as drift.
+func Build(entries []ledger.Entry, running, paused []models.Resource, since, until time.Time) *Digest {
+	d := &Digest{Since: since, Until: until}
+
+	for _, e := range entries {
+		if e.Timestamp.Before(since) || e.Timestamp.After(until) {
+			continue
+		}
+		switch e.Operation {
+		case "pause":
+			d.PauseRuns++
+		case "resume":
+			d.ResumeRuns++
+		}
+	}
+
+	for _, ts := range ledger.Leaderboard(entries, "", since, until) {
+		d.PausedHours += ts.PausedHours
+		d.Savings += ts.Savings
+	}
+
+	runningKeys := models.NewKeySet(running...)
+	for _, r := range paused {
+		if runningKeys.Has(r) {
+			d.Drift = append(d.Drift, r)
+		}
+	}
+
+	burners := append([]models.Resource(nil), running...)
+	sort.Slice(burners, func(i, j int) bool {
+		return burners[i].CostPerHour > burners[j].CostPerHour
+	})
+	if len(burners) > topBurnerCount {
+		burners = burners[:topBurnerCount]
+	}
+	d.TopBurners = burners
+
+	return d
+}
+
+// Text renders the digest as plain text suitable for chat or email
+func (d *Digest) Text() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "awsbreak weekly digest (%s - %s)\n\n",
+		d.Since.Format("Jan 2"), d.Until.Format("Jan 2, 2006"))
+	fmt.Fprintf(&b, "Brake runs: %d pause, %d resume\n", d.PauseRuns, d.ResumeRuns)
+	fmt.Fprintf(&b, "Paused hours: %.1f\n", d.PausedHours)
+	fmt.Fprintf(&b, "Estimated savings: $%.2f\n", d.Savings)
+
+	if len(d.TopBurners) > 0 {
+		b.WriteString("\nTop remaining burners:\n")
+		for _, r := range d.TopBurners {
+			fmt.Fprintf(&b, "  - %s %s: $%.2f/month\n", r.ServiceType, r.ResourceID, r.CostPerHour*24*30)
+		}
+	}
+
+	if len(d.Drift) > 0 {
+		b.WriteString("\nDrift (paused by awsbreak, now running again):\n")
+		for _, r := range d.Drift {
+			fmt.Fprintf(&b, "  - %s %s\n", r.ServiceType, r.ResourceID)
+		}
+	}
+
+	return b.String()
+}
+
+// SendSlack posts the digest to a Slack incoming webhook. A nil tmpl posts
+// the built-in text as a code block.
+func SendSlack(ctx context.Context, webhookURL string, tmpl *template.Template, d *Digest) error {
+	payload, err := json.Marshal(map[string]string{
+		"text": notify.Render(tmpl, d, "```"+d.Text()+"```"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// SendEmail emails the digest through an SMTP server. A nil tmpl sends the
+// built-in text.
+func SendEmail(cfg models.EmailConfig, tmpl *template.Template, d *Digest) error {
+	if len(cfg.To) == 0 {
+		return fmt.Errorf("no email recipients configured")
+	}
+
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	addr := fmt.Sprintf("%s:%d", cfg.SMTPHost, port)
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: awsbreak weekly digest: $%.2f saved\r\n", d.Savings)
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notify.Render(tmpl, d, d.Text()), "\n", "\r\n"))
+
+	if err := smtp.SendMail(addr, auth, cfg.From, cfg.To, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send digest email: %w", err)
+	}
+	return nil
+}
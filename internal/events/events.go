@@ -0,0 +1,200 @@
+// Package events records what happened during each pause or resume run as
+// typed events, one file per run, so a run can be replayed step by step when
+// debugging or auditing it.
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	runsDirName = "runs"
+	// Retention is how long a run's event log is kept after its last event
+	Retention = 90 * 24 * time.Hour
+)
+
+// Type names what an event records
+type Type string
+
+// Event types, in the order they happen in a run
+const (
+	RunStarted         Type = "RunStarted"
+	RunRecovered       Type = "RunRecovered" // an interrupted run was picked up again
+	ResourceDiscovered Type = "ResourceDiscovered"
+	PauseIssued        Type = "PauseIssued"
+	ResumeIssued       Type = "ResumeIssued"
+	OperationAccepted  Type = "OperationAccepted" // AWS accepted the call; the state isn't observed yet
+	StateReached       Type = "StateReached"      // the state was observed by discovering the resource again
+	OperationFailed    Type = "OperationFailed"
+	RunCompleted       Type = "RunCompleted"
+)
+
+// Event is one step of a run. Run events set Operation, Region and the
+// counts; resource events set the resource fields.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type Type      `json:"type"`
+
+	Operation string        `json:"operation,omitempty"`
+	Region    string        `json:"region,omitempty"`
+	Resources int           `json:"resources,omitempty"`
+	Succeeded int           `json:"succeeded,omitempty"`
+	Failed    int           `json:"failed,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Snapshot  string        `json:"snapshot,omitempty"`
+
+	Service     models.ServiceType   `json:"service,omitempty"`
+	ResourceID  string               `json:"resource_id,omitempty"`
+	State       models.ResourceState `json:"state,omitempty"`
+	CostPerHour float64              `json:"cost_per_hour,omitempty"`
+	Retries     int                  `json:"retries,omitempty"`
+	Error       string               `json:"error,omitempty"`
+}
+
+// ForResource returns an event about one resource
+func ForResource(t Type, r models.Resource) Event {
+	return Event{
+		Type:        t,
+		Service:     r.ServiceType,
+		ResourceID:  r.ResourceID,
+		State:       r.CurrentState,
+		CostPerHour: r.CostPerHour,
+	}
+}
+
+// Log is the event file of one run
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog opens the event log of a run, stored under the config directory
+func NewLog(configDir, runID string) *Log {
+	return &Log{path: filepath.Join(configDir, runsDirName, runID+".jsonl")}
+}
+
+// Append adds events to the log, stamping any without a time
+func (l *Log) Append(events ...Event) error {
+	var buf []byte
+	now := time.Now()
+	for _, e := range events {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create runs directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buf); err != nil {
+		return fmt.Errorf("failed to write events: %w", err)
+	}
+	return nil
+}
+
+// Events reads the log in the order events were written. It returns
+// os.ErrNotExist, wrapped, when the run has no log.
+func (l *Log) Events() ([]Event, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return events, nil
+}
+
+// Run is a run with an event log
+type Run struct {
+	ID       string
+	Modified time.Time
+}
+
+// Runs lists the runs with event logs, most recently active first
+func Runs(configDir string) ([]Run, error) {
+	entries, err := os.ReadDir(filepath.Join(configDir, runsDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	var runs []Run
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		runs = append(runs, Run{ID: id, Modified: info.ModTime()})
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Modified.After(runs[j].Modified)
+	})
+	return runs, nil
+}
+
+// Prune deletes the event logs of runs with no events since cutoff and
+// returns how many it deleted
+func Prune(configDir string, cutoff time.Time) (int, error) {
+	runs, err := Runs(configDir)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, r := range runs {
+		if !r.Modified.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(configDir, runsDirName, r.ID+".jsonl")); err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("failed to delete event log of run %s: %w", r.ID, err)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
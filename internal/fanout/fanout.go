@@ -0,0 +1,113 @@
+package fanout
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// DefaultGlobal is the default cap on tasks running at once
+	DefaultGlobal = 16
+	// DefaultPerAccount is the default cap on tasks running at once in one
+	// account, keeping each account under its API rate limits
+	DefaultPerAccount = 4
+)
+
+// Status is the state of a task
+type Status int
+
+const (
+	StatusPending Status = iota
+	StatusRunning
+	StatusDone
+	StatusFailed
+)
+
+// Task is one unit of work, identified by account, region and service
+type Task struct {
+	Account string
+	Region  string
+	Service string
+	Run     func(ctx context.Context) error
+}
+
+// Event reports a task changing status
+type Event struct {
+	Task   *Task
+	Status Status
+	Err    error
+}
+
+// Limits caps how many tasks run at once
+type Limits struct {
+	Global     int
+	PerAccount int
+}
+
+// Run executes tasks in parallel within the limits and returns each task's
+// error, in task order. observe, if set, is called for every status change;
+// calls are serialized.
+func Run(ctx context.Context, tasks []Task, limits Limits, observe func(Event)) []error {
+	if limits.Global <= 0 {
+		limits.Global = DefaultGlobal
+	}
+	if limits.PerAccount <= 0 {
+		limits.PerAccount = DefaultPerAccount
+	}
+
+	var observeMu sync.Mutex
+	notify := func(e Event) {
+		if observe == nil {
+			return
+		}
+		observeMu.Lock()
+		defer observeMu.Unlock()
+		observe(e)
+	}
+
+	global := make(chan struct{}, limits.Global)
+	perAccount := make(map[string]chan struct{})
+	for _, t := range tasks {
+		if _, ok := perAccount[t.Account]; !ok {
+			perAccount[t.Account] = make(chan struct{}, limits.PerAccount)
+		}
+	}
+
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+
+	for i := range tasks {
+		t := &tasks[i]
+		notify(Event{Task: t, Status: StatusPending})
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Take the account slot first so a task waiting on a busy account
+			// doesn't hold a global slot other accounts could use
+			account := perAccount[t.Account]
+			account <- struct{}{}
+			defer func() { <-account }()
+			global <- struct{}{}
+			defer func() { <-global }()
+
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				notify(Event{Task: t, Status: StatusFailed, Err: err})
+				return
+			}
+
+			notify(Event{Task: t, Status: StatusRunning})
+			if err := t.Run(ctx); err != nil {
+				errs[i] = err
+				notify(Event{Task: t, Status: StatusFailed, Err: err})
+				return
+			}
+			notify(Event{Task: t, Status: StatusDone})
+		}(i)
+	}
+
+	wg.Wait()
+	return errs
+}
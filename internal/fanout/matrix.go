@@ -0,0 +1,222 @@
+package fanout
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/textwidth"
+)
+
+// redrawInterval is how often a live matrix is repainted
+const redrawInterval = 250 * time.Millisecond
+
+// Column widths. Account labels shrink towards minLabelWidth to fit Width.
+const (
+	labelWidth    = 28
+	minLabelWidth = 12
+	cellWidth     = 14
+)
+
+type cell struct {
+	total, running, done, failed int
+}
+
+// Matrix shows task progress as an account × region grid. On a terminal it
+// redraws in place; otherwise it is printed once when stopped.
+type Matrix struct {
+	// Width, if positive, is the number of columns lines should fit in
+	Width int
+
+	w      io.Writer
+	live   bool
+	labels map[string]string
+
+	mu      sync.Mutex
+	rows    []string
+	cols    []string
+	cells   map[string]*cell
+	drawn   int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewMatrix creates a matrix for the given tasks. labels optionally maps
+// account IDs to display names.
+func NewMatrix(w io.Writer, tasks []Task, labels map[string]string) *Matrix {
+	m := &Matrix{
+		w:      w,
+		live:   isTerminal(w),
+		labels: labels,
+		cells:  make(map[string]*cell),
+	}
+
+	seenRow := make(map[string]bool)
+	seenCol := make(map[string]bool)
+	for _, t := range tasks {
+		if !seenRow[t.Account] {
+			seenRow[t.Account] = true
+			m.rows = append(m.rows, t.Account)
+		}
+		if !seenCol[t.Region] {
+			seenCol[t.Region] = true
+			m.cols = append(m.cols, t.Region)
+		}
+		c := m.cell(t.Account, t.Region)
+		c.total++
+	}
+
+	return m
+}
+
+// Observe updates the matrix with a task event; pass it to Run
+func (m *Matrix) Observe(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.cell(e.Task.Account, e.Task.Region)
+	switch e.Status {
+	case StatusRunning:
+		c.running++
+	case StatusDone:
+		c.running--
+		c.done++
+	case StatusFailed:
+		if c.running > 0 {
+			c.running--
+		}
+		c.failed++
+	}
+}
+
+// Start begins redrawing the matrix in place on terminals
+func (m *Matrix) Start() {
+	if !m.live {
+		return
+	}
+
+	m.stop = make(chan struct{})
+	m.stopped = make(chan struct{})
+	go func() {
+		defer close(m.stopped)
+		ticker := time.NewTicker(redrawInterval)
+		defer ticker.Stop()
+		for {
+			m.draw()
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop draws the final state of the matrix
+func (m *Matrix) Stop() {
+	if m.stop != nil {
+		close(m.stop)
+		<-m.stopped
+	}
+	m.draw()
+}
+
+func (m *Matrix) cell(account, region string) *cell {
+	key := account + "|" + region
+	c, ok := m.cells[key]
+	if !ok {
+		c = &cell{}
+		m.cells[key] = c
+	}
+	return c
+}
+
+func (m *Matrix) draw() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	// Move back over the previous frame so it is overwritten in place
+	if m.live && m.drawn > 0 {
+		fmt.Fprintf(&b, "\033[%dA", m.drawn)
+	}
+
+	lines := 0
+	line := func(format string, args ...any) {
+		if m.live {
+			b.WriteString("\033[2K")
+		}
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\n")
+		lines++
+	}
+
+	width := labelWidth
+	if m.Width > 0 {
+		width = max(min(labelWidth, m.Width-3-len(m.cols)*(cellWidth+1)), minLabelWidth)
+	}
+
+	header := "   " + textwidth.Pad("ACCOUNT", width)
+	for _, col := range m.cols {
+		header += " " + textwidth.Pad(col, cellWidth)
+	}
+	line("%s", header)
+
+	var total, finished, failed int
+	for _, row := range m.rows {
+		label := row
+		if name := m.labels[row]; name != "" {
+			label = fmt.Sprintf("%s (%s)", name, row)
+		}
+
+		text := "   " + textwidth.Pad(textwidth.Truncate(label, width), width)
+		for _, col := range m.cols {
+			c := m.cells[row+"|"+col]
+			text += " " + textwidth.Pad(c.String(), cellWidth)
+			total += c.total
+			finished += c.done + c.failed
+			failed += c.failed
+		}
+		line("%s", text)
+	}
+
+	line("   %d/%d tasks finished, %d failed", finished, total, failed)
+
+	m.drawn = lines
+	io.WriteString(m.w, b.String())
+}
+
+// String renders a cell such as "✅ 6/6" or "🔄 2/6"
+func (c *cell) String() string {
+	if c == nil || c.total == 0 {
+		return "-"
+	}
+
+	icon := "⏳"
+	switch {
+	case c.done+c.failed == c.total && c.failed > 0:
+		icon = "❌"
+	case c.done == c.total:
+		icon = "✅"
+	case c.running > 0 || c.done+c.failed > 0:
+		icon = "🔄"
+	}
+	return fmt.Sprintf("%s %d/%d", icon, c.done+c.failed, c.total)
+}
+
+// isTerminal reports whether w is an interactive terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
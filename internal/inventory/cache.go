@@ -0,0 +1,263 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// DefaultTTL is how long a discovered inventory is considered fresh
+const DefaultTTL = 5 * time.Minute
+
+// accountTimeout bounds identifying the account when invalidating
+const accountTimeout = 10 * time.Second
+
+// Refresh controls whether a lookup may be answered from the cache
+type Refresh int
+
+const (
+	// RefreshIfStale serves the cached view unless it is older than the TTL
+	RefreshIfStale Refresh = iota
+	// RefreshAlways rediscovers and updates the cache; use before acting on resources
+	RefreshAlways
+	// RefreshNever only serves cached data, even if stale
+	RefreshNever
+)
+
+// ErrNotCached is returned by RefreshNever lookups when nothing is cached
+var ErrNotCached = fmt.Errorf("no cached inventory")
+
+// Discoverer finds resources in a region
+type Discoverer interface {
+	DiscoverAll(ctx context.Context, region string) ([]models.Resource, *models.DiscoveryReport, error)
+}
+
+// AccountIdentifier is implemented by discoverers that can say which AWS
+// account they read. The cache keys views by account, so switching profile
+// or role never serves another account's inventory.
+type AccountIdentifier interface {
+	AccountID(ctx context.Context) (string, error)
+}
+
+// View is a consistent, timestamped inventory of one region
+type View struct {
+	Region    string            `json:"region"`
+	Resources []models.Resource `json:"resources"`
+	Refreshed time.Time         `json:"refreshed"`
+	// Took is how long discovery ran
+	Took time.Duration `json:"took,omitempty"`
+	// Discovery says which services couldn't be fully read
+	Discovery *models.DiscoveryReport `json:"discovery,omitempty"`
+
+	live bool // discovered by the Get that returned it
+}
+
+// Age returns how long ago the view was discovered
+func (v *View) Age() time.Duration {
+	return time.Since(v.Refreshed)
+}
+
+// LiveTook returns how long discovery ran if the view was discovered for
+// this call, or zero if it came from the cache: a cached view's Took belongs
+// to an earlier run, not the one about to use it
+func (v *View) LiveTook() time.Duration {
+	if !v.live {
+		return 0
+	}
+	return v.Took
+}
+
+// Cache shares discovered inventories between the CLI, the daemon and the
+// server. Views are kept in memory and, when a directory is given, persisted
+// so separate awsbreak processes see the same data. Views are keyed by
+// account and region; a discoverer that isn't an AccountIdentifier is never
+// served from the cache.
+type Cache struct {
+	discoverer Discoverer
+	dir        string
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	views   map[string]*View
+	account string
+}
+
+// NewCache creates an inventory cache. An empty dir keeps the cache in memory only.
+func NewCache(discoverer Discoverer, dir string, ttl time.Duration) *Cache {
+	return &Cache{
+		discoverer: discoverer,
+		dir:        dir,
+		ttl:        ttl,
+		views:      make(map[string]*View),
+	}
+}
+
+// Get returns the inventory for a region according to the refresh policy.
+// Resources are sorted by key so paging over a view is stable.
+func (c *Cache) Get(ctx context.Context, region string, refresh Refresh) (*View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Without knowing the account no view can safely be shared
+	key, err := c.key(ctx, region)
+	if err != nil {
+		if refresh == RefreshNever {
+			return nil, ErrNotCached
+		}
+		refresh = RefreshAlways
+	}
+
+	if refresh != RefreshAlways {
+		view := c.cached(key)
+		if view != nil && (refresh == RefreshNever || view.Age() < c.ttl) {
+			return view, nil
+		}
+		if refresh == RefreshNever {
+			return nil, ErrNotCached
+		}
+	}
+
+	start := time.Now()
+	resources, report, err := c.discoverer.DiscoverAll(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Key() < resources[j].Key()
+	})
+	if resources == nil {
+		resources = []models.Resource{}
+	}
+
+	view := &View{Region: region, Resources: resources, Refreshed: time.Now(), Took: time.Since(start), Discovery: report}
+	if key != "" {
+		c.views[key] = view
+
+		// Persistence is best effort; the in-memory view is still valid
+		c.persist(key, view)
+	}
+
+	live := *view
+	live.live = true
+	return &live, nil
+}
+
+// Invalidate drops the cached view for a region, e.g. after pausing or
+// resuming. When the account can't be identified the region is dropped for
+// every account.
+func (c *Cache) Invalidate(region string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), accountTimeout)
+	defer cancel()
+	key, err := c.key(ctx, region)
+	if err == nil {
+		delete(c.views, key)
+		if c.dir != "" {
+			os.Remove(c.path(key))
+		}
+		return
+	}
+
+	for k, view := range c.views {
+		if view.Region == region {
+			delete(c.views, k)
+		}
+	}
+	if c.dir != "" {
+		paths, _ := filepath.Glob(filepath.Join(c.dir, "*", region+".json"))
+		for _, path := range paths {
+			os.Remove(path)
+		}
+	}
+}
+
+// key returns the account-qualified key views of a region are cached under
+func (c *Cache) key(ctx context.Context, region string) (string, error) {
+	if c.account == "" {
+		identifier, ok := c.discoverer.(AccountIdentifier)
+		if !ok {
+			return "", fmt.Errorf("discoverer can't identify its account")
+		}
+		account, err := identifier.AccountID(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.account = account
+	}
+	return c.account + "/" + region, nil
+}
+
+// cached returns the current view for a region. With persistence enabled the
+// file is authoritative: a missing file means another process invalidated the
+// region, and a newer file means another process refreshed it.
+func (c *Cache) cached(key string) *View {
+	view := c.views[key]
+	if c.dir == "" {
+		return view
+	}
+
+	info, err := os.Stat(c.path(key))
+	if err != nil {
+		delete(c.views, key)
+		return nil
+	}
+	if view != nil && !info.ModTime().After(view.Refreshed) {
+		return view
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return view
+	}
+
+	var loaded View
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return view
+	}
+
+	c.views[key] = &loaded
+	return &loaded
+}
+
+func (c *Cache) persist(key string, view *View) {
+	if c.dir == "" {
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	data, err := json.Marshal(view)
+	if err != nil {
+		return
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return
+	}
+
+	// Stamp the file with the discovery time so cached() can tell whether
+	// another process has written a newer view since
+	os.Chtimes(path, view.Refreshed, view.Refreshed)
+}
+
+// path returns the file a view is persisted in: <dir>/<account>/<region>.json
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(key)+".json")
+}
@@ -0,0 +1,331 @@
+// Package issues files tracker issues for resources that keep failing to
+// pause or resume, so recurring problems get tracked instead of re-ignored
+// every night.
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/ledger"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// DefaultAfterRuns is how many runs in a row a resource must fail in
+	// before an issue is filed
+	DefaultAfterRuns = 3
+	// EnvToken supplies the tracker token when the config doesn't
+	EnvToken = "AWSBREAK_ISSUE_TOKEN"
+
+	filedFileName = "issues.json"
+	sendTimeout   = 30 * time.Second
+)
+
+// Issue is a tracker issue about one resource
+type Issue struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// Tracker opens issues and returns their URL
+type Tracker interface {
+	Create(ctx context.Context, issue Issue) (string, error)
+}
+
+// FromConfig returns the tracker selected in config
+func FromConfig(cfg *models.IssueConfig) (Tracker, error) {
+	token := cfg.Token
+	if token == "" {
+		token = os.Getenv(EnvToken)
+	}
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("issues.endpoint is required")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no issue tracker token: set issues.token or %s", EnvToken)
+	}
+
+	switch cfg.Tracker {
+	case "github":
+		return &GitHubTracker{Endpoint: strings.TrimSuffix(cfg.Endpoint, "/"), Token: token}, nil
+	case "jira":
+		if cfg.Project == "" {
+			return nil, fmt.Errorf("issues.project is required for Jira")
+		}
+		return &JiraTracker{Endpoint: strings.TrimSuffix(cfg.Endpoint, "/"), Token: token, Project: cfg.Project, IssueType: cfg.IssueType}, nil
+	default:
+		return nil, fmt.Errorf("unknown issues.tracker %q (use github or jira)", cfg.Tracker)
+	}
+}
+
+// Failure is a resource that failed the same operation in several runs in a row
+type Failure struct {
+	Resource  models.Resource
+	Operation string
+	Runs      []models.OperationResult // oldest first, ending with the latest run
+}
+
+// Key identifies the resource and operation across runs
+func (f Failure) Key() string {
+	return f.Operation + " " + f.Resource.Key()
+}
+
+// legacyKey is the key issues were filed under before ARNs were recorded
+func (f Failure) legacyKey() string {
+	return f.Operation + " " + f.Resource.LegacyKey()
+}
+
+// Issue describes the failure for a tracker, with the failing results
+// attached as a JSON report
+func (f Failure) Issue(labels []string) Issue {
+	latest := f.Runs[len(f.Runs)-1]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "awsbreak failed to %s %s %s in %s in the last %d runs.\n\n",
+		f.Operation, f.Resource.ServiceType, f.Resource.ResourceID, f.Resource.Region, len(f.Runs))
+	fmt.Fprintf(&b, "Latest error (%s):\n\n    %s\n\n", latest.Timestamp.UTC().Format(time.RFC3339), latest.Error)
+	if link := f.Resource.ConsoleURL(); link != "" {
+		fmt.Fprintf(&b, "Console: %s\n\n", link)
+	}
+
+	report, _ := json.MarshalIndent(f.Runs, "", "  ")
+	b.WriteString("Report:\n\n```json\n")
+	b.Write(report)
+	b.WriteString("\n```\n")
+
+	return Issue{
+		Title:  fmt.Sprintf("awsbreak: %s of %s %s keeps failing", f.Operation, f.Resource.ServiceType, f.Resource.ResourceID),
+		Body:   b.String(),
+		Labels: labels,
+	}
+}
+
+// Recurring returns the failures in latest, the run that just finished, that
+// also failed in each of the previous runs of the same operation and region
+// where the resource took part, at least afterRuns times in a row. latest is
+// passed in rather than read from the end of entries, where another run may
+// have been recorded since; an entry with its ID in entries is skipped.
+func Recurring(entries []ledger.Entry, latest ledger.Entry, afterRuns int) []Failure {
+	if afterRuns < 1 {
+		afterRuns = DefaultAfterRuns
+	}
+
+	var failures []Failure
+	for _, r := range latest.Results {
+		if r.Success {
+			continue
+		}
+		runs := []models.OperationResult{r}
+	history:
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+			if e.Operation != latest.Operation || e.Region != latest.Region {
+				continue
+			}
+			if latest.ID != "" && e.ID == latest.ID {
+				continue
+			}
+			for _, prev := range e.Results {
+				if !prev.Resource.SameAs(r.Resource) {
+					continue
+				}
+				if prev.Success {
+					break history
+				}
+				runs = append([]models.OperationResult{prev}, runs...)
+				break
+			}
+		}
+		if len(runs) >= afterRuns {
+			failures = append(failures, Failure{Resource: r.Resource, Operation: latest.Operation, Runs: runs})
+		}
+	}
+	return failures
+}
+
+// Filed records the issues already opened, so a failure that keeps
+// recurring gets one issue rather than one per run
+type Filed struct {
+	path   string
+	Issues map[string]FiledIssue `json:"issues"`
+}
+
+// FiledIssue is an issue opened for a failure
+type FiledIssue struct {
+	URL   string    `json:"url"`
+	Filed time.Time `json:"filed"`
+}
+
+// LoadFiled reads the filed issues stored in the config directory
+func LoadFiled(configDir string) (*Filed, error) {
+	f := &Filed{path: filepath.Join(configDir, filedFileName), Issues: make(map[string]FiledIssue)}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("failed to read filed issues: %w", err)
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse filed issues: %w", err)
+	}
+	if f.Issues == nil {
+		f.Issues = make(map[string]FiledIssue)
+	}
+	return f, nil
+}
+
+// Has reports whether an issue was already filed for the failure, including
+// under the key older versions recorded it with
+func (f *Filed) Has(failure Failure) bool {
+	if _, ok := f.Issues[failure.Key()]; ok {
+		return true
+	}
+	_, ok := f.Issues[failure.legacyKey()]
+	return ok
+}
+
+// Add records the issue filed for a failure
+func (f *Filed) Add(failure Failure, url string) {
+	f.Issues[failure.Key()] = FiledIssue{URL: url, Filed: time.Now()}
+}
+
+// Forget drops issues for failures that succeeded in results, so a new
+// streak later files a new issue
+func (f *Filed) Forget(operation string, results []models.OperationResult) {
+	for _, r := range results {
+		if r.Success {
+			failure := Failure{Resource: r.Resource, Operation: operation}
+			delete(f.Issues, failure.Key())
+			delete(f.Issues, failure.legacyKey())
+		}
+	}
+}
+
+// Save writes the filed issues back to the config directory
+func (f *Filed) Save() error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal filed issues: %w", err)
+	}
+	if err := os.WriteFile(f.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save filed issues: %w", err)
+	}
+	return nil
+}
+
+// GitHubTracker opens GitHub issues. Endpoint is the repository's API URL,
+// e.g. https://api.github.com/repos/OWNER/REPO.
+type GitHubTracker struct {
+	Endpoint string
+	Token    string
+}
+
+// Create opens an issue in the repository
+func (t *GitHubTracker) Create(ctx context.Context, issue Issue) (string, error) {
+	body := map[string]any{"title": issue.Title, "body": issue.Body}
+	if len(issue.Labels) > 0 {
+		body["labels"] = issue.Labels
+	}
+
+	var created struct {
+		URL string `json:"html_url"`
+	}
+	headers := map[string]string{
+		"Authorization": "Bearer " + t.Token,
+		"Accept":        "application/vnd.github+json",
+	}
+	if err := postJSON(ctx, "GitHub", t.Endpoint+"/issues", headers, body, &created); err != nil {
+		return "", err
+	}
+	return created.URL, nil
+}
+
+// JiraTracker opens Jira issues through the REST API. Endpoint is the site,
+// e.g. https://yourco.atlassian.net; a token of the form email:api-token is
+// sent as basic auth, anything else as a bearer token.
+type JiraTracker struct {
+	Endpoint  string
+	Token     string
+	Project   string
+	IssueType string // default "Bug"
+}
+
+// Create opens an issue in the project
+func (t *JiraTracker) Create(ctx context.Context, issue Issue) (string, error) {
+	issueType := t.IssueType
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	fields := map[string]any{
+		"project":     map[string]string{"key": t.Project},
+		"issuetype":   map[string]string{"name": issueType},
+		"summary":     issue.Title,
+		"description": jiraMarkup(issue.Body),
+	}
+	if len(issue.Labels) > 0 {
+		fields["labels"] = issue.Labels
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + t.Token}
+	if strings.Contains(t.Token, ":") {
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(t.Token))
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := postJSON(ctx, "Jira", t.Endpoint+"/rest/api/2/issue", headers, map[string]any{"fields": fields}, &created); err != nil {
+		return "", err
+	}
+	return t.Endpoint + "/browse/" + created.Key, nil
+}
+
+// jiraMarkup converts the Markdown code fence in an issue body to Jira wiki markup
+func jiraMarkup(body string) string {
+	body = strings.Replace(body, "```json\n", "{code:json}\n", 1)
+	return strings.Replace(body, "\n```\n", "\n{code}\n", 1)
+}
+
+func postJSON(ctx context.Context, tracker, url string, headers map[string]string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s issue: %w", tracker, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", tracker, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to %s: %w", tracker, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", tracker, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to read %s response: %w", tracker, err)
+	}
+	return nil
+}
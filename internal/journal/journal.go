@@ -0,0 +1,376 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	journalDirName = "journal"
+	failedDirName  = "failed"
+
+	// HeartbeatInterval is how often a running operation touches its entry,
+	// and staleAfter how long an entry goes untouched before its run counts
+	// as interrupted rather than still going in another process
+	HeartbeatInterval = 30 * time.Second
+	staleAfter        = 4 * HeartbeatInterval
+)
+
+// Entry is an in-flight pause or resume. It is written before any resource is
+// touched, again just before each resource's first API call, and as each
+// resource finishes, so an interrupted run can be completed or rolled back
+// later without losing the metadata needed to restore resources.
+type Entry struct {
+	ID         string                   `json:"id"`
+	Operation  string                   `json:"operation"` // "pause" or "resume"
+	Region     string                   `json:"region"`
+	SnapshotID string                   `json:"snapshot_id,omitempty"` // snapshot taken by a pause or restored by a resume
+	RoleARN    string                   `json:"role_arn,omitempty"`    // member account role for organization runs
+	Started    time.Time                `json:"started"`
+	Finished   time.Time                `json:"finished,omitempty"`
+	Discovery  time.Duration            `json:"discovery,omitempty"` // spent finding the resources before the run
+	Resources  []models.Resource        `json:"resources"`
+	Issued     []string                 `json:"issued,omitempty"` // keys of resources whose first API call was sent
+	Results    []models.OperationResult `json:"results,omitempty"`
+
+	// Batched is set on runs that are part of a larger one, such as retries
+	// during triage, whose caller records them in the ledger, notifies and
+	// files issues once for the lot
+	Batched bool `json:"-"`
+}
+
+// Duration returns how long the run took, or 0 while it is unfinished
+func (e *Entry) Duration() time.Duration {
+	if e.Finished.IsZero() {
+		return 0
+	}
+	return e.Finished.Sub(e.Started)
+}
+
+// Remaining returns the resources that have no recorded result yet
+func (e *Entry) Remaining() []models.Resource {
+	done := make(map[string]bool, len(e.Results))
+	for _, r := range e.Results {
+		done[r.Resource.Key()] = true
+	}
+
+	var remaining []models.Resource
+	for _, r := range e.Resources {
+		if !done[r.Key()] {
+			remaining = append(remaining, r)
+		}
+	}
+	return remaining
+}
+
+// InFlight returns the resources whose API call was sent but whose result
+// wasn't recorded; an interrupted run may or may not have changed them
+func (e *Entry) InFlight() []models.Resource {
+	done := make(map[string]bool, len(e.Results))
+	for _, r := range e.Results {
+		done[r.Resource.Key()] = true
+	}
+	issued := make(map[string]bool, len(e.Issued))
+	for _, key := range e.Issued {
+		issued[key] = true
+	}
+
+	var inFlight []models.Resource
+	for _, r := range e.Resources {
+		if issued[r.Key()] && !done[r.Key()] {
+			inFlight = append(inFlight, r)
+		}
+	}
+	return inFlight
+}
+
+// Changed returns the resources the run changed or may have changed: those
+// that succeeded and those in flight when it stopped. Rolling back an
+// interrupted run reverses these.
+func (e *Entry) Changed() []models.Resource {
+	var changed []models.Resource
+	for _, r := range e.Results {
+		if r.Success {
+			changed = append(changed, r.Resource)
+		}
+	}
+	return append(changed, e.InFlight()...)
+}
+
+// FailedResources returns the resources whose operation failed
+func (e *Entry) FailedResources() []models.Resource {
+	var failed []models.Resource
+	for _, r := range e.Results {
+		if !r.Success {
+			failed = append(failed, r.Resource)
+		}
+	}
+	return failed
+}
+
+// Journal persists in-flight operations under the config directory
+type Journal struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewJournal creates a journal storing entries under the config directory
+func NewJournal(configDir string) *Journal {
+	return &Journal{
+		dir: filepath.Join(configDir, journalDirName),
+	}
+}
+
+// Begin records that an operation is about to start. The entry's Operation,
+// Region and Resources must be set; ID and Started are filled in.
+func (j *Journal) Begin(e *Entry) error {
+	e.Started = time.Now()
+	e.ID = fmt.Sprintf("%s-%s-%s", e.Operation, e.Region, e.Started.UTC().Format("20060102-150405.000"))
+	if len(e.Resources) > 0 && e.Resources[0].AccountID != "" {
+		e.ID = e.Resources[0].AccountID + "-" + e.ID
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.write(e)
+}
+
+// Record adds a finished resource to an entry
+func (j *Journal) Record(e *Entry, result models.OperationResult) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e.Results = append(e.Results, result)
+	return j.write(e)
+}
+
+// Issue records that a resource's first API call is about to be sent
+func (j *Journal) Issue(e *Entry, r models.Resource) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e.Issued = append(e.Issued, r.Key())
+	return j.write(e)
+}
+
+// Update saves changes to an in-flight entry
+func (j *Journal) Update(e *Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.write(e)
+}
+
+// Finish removes a completed entry. Entries with failed resources are kept
+// aside so the failures can be retried.
+func (j *Journal) Finish(e *Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(e.FailedResources()) > 0 {
+		if err := os.MkdirAll(j.failedDir(), 0700); err != nil {
+			return fmt.Errorf("failed to create journal directory: %w", err)
+		}
+		if err := os.Rename(j.path(e.ID), filepath.Join(j.failedDir(), e.ID+".json")); err != nil {
+			return fmt.Errorf("failed to keep failed journal entry: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.Remove(j.path(e.ID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal entry: %w", err)
+	}
+	return nil
+}
+
+// Abandon removes an interrupted entry that won't be finished, because its
+// run was rolled back or another run took over its resources. An entry
+// whose run has come back to life is kept.
+func (j *Journal) Abandon(e *Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.Live(e) {
+		return fmt.Errorf("journal entry %s is in use by a running operation; kept", e.ID)
+	}
+
+	if err := os.Remove(j.path(e.ID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal entry: %w", err)
+	}
+	return nil
+}
+
+// Heartbeat keeps touching an entry while its run goes on, so other
+// processes can tell it from an interrupted one, until stop is called
+func (j *Journal) Heartbeat(e *Entry) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				j.mu.Lock()
+				// The entry is gone once the run finishes
+				os.Chtimes(j.path(e.ID), now, now)
+				j.mu.Unlock()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// Live reports whether an entry's run is still going, in this process or
+// another, judging by its heartbeat
+func (j *Journal) Live(e *Entry) bool {
+	info, err := os.Stat(j.path(e.ID))
+	return err == nil && time.Since(info.ModTime()) < staleAfter
+}
+
+// Pending returns entries left behind by interrupted runs, oldest first.
+// Entries whose run is still live are left out.
+func (j *Journal) Pending() ([]*Entry, error) {
+	all, err := readEntries(j.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, e := range all {
+		if !j.Live(e) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Started.Before(entries[b].Started)
+	})
+	return entries, nil
+}
+
+// Failed returns completed entries that still have failed resources, newest first
+func (j *Journal) Failed() ([]*Entry, error) {
+	entries, err := readEntries(j.failedDir())
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Started.After(entries[b].Started)
+	})
+	return entries, nil
+}
+
+// Discard removes a failed entry once its failures have been retried
+func (j *Journal) Discard(e *Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.Remove(filepath.Join(j.failedDir(), e.ID+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal entry: %w", err)
+	}
+	return nil
+}
+
+// DropFailures removes the failures of resources that have since been
+// retried from a failed entry; the retry's own entry now tracks them. The
+// entry is discarded once it has no failures left.
+func (j *Journal) DropFailures(e *Entry, resources []models.Resource) error {
+	drop := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		drop[r.Key()] = true
+	}
+
+	kept := e.Results[:0:0]
+	for _, r := range e.Results {
+		if r.Success || !drop[r.Resource.Key()] {
+			kept = append(kept, r)
+		}
+	}
+	e.Results = kept
+
+	if len(e.FailedResources()) == 0 {
+		return j.Discard(e)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(j.failedDir(), e.ID+".json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to update journal entry: %w", err)
+	}
+	return nil
+}
+
+func readEntries(dir string) ([]*Entry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal directory: %w", err)
+	}
+
+	var entries []*Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal entry: %w", err)
+		}
+
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("failed to parse journal entry %s: %w", f.Name(), err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+// write saves an entry atomically so a crash mid-write never corrupts it
+func (j *Journal) write(e *Entry) error {
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+
+	path := j.path(e.ID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save journal entry: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) path(id string) string {
+	return filepath.Join(j.dir, id+".json")
+}
+
+func (j *Journal) failedDir() string {
+	return filepath.Join(j.dir, failedDirName)
+}
@@ -0,0 +1,280 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	ledgerFileName = "ledger.jsonl"
+	// UntaggedTeam is the team name used for resources without the attribution tag
+	UntaggedTeam = "(untagged)"
+	// SmallRunHourlyRate is the hourly burn below which a pause stops too
+	// little for a slow discovery to be worth it. At $0.05/hour a nightly
+	// 12-hour pause saves about $18 a month, less than a single t3.small left
+	// running; below that, halving how often the schedule runs loses a few
+	// dollars at most, so the warning suggests it rather than faster scans.
+	SmallRunHourlyRate = 0.05
+)
+
+// Entry records the results of a single pause or resume run
+type Entry struct {
+	ID         string                   `json:"id"`
+	Timestamp  time.Time                `json:"timestamp"`
+	Region     string                   `json:"region"`
+	Operation  string                   `json:"operation"` // "pause", "resume"
+	SnapshotID string                   `json:"snapshot_id,omitempty"`
+	Results    []models.OperationResult `json:"results"`
+	Duration   time.Duration            `json:"duration,omitempty"`  // how long the operation ran
+	Discovery  time.Duration            `json:"discovery,omitempty"` // discovery before it, when known
+}
+
+// Succeeded returns the number of successful results
+func (e Entry) Succeeded() int {
+	count := 0
+	for _, r := range e.Results {
+		if r.Success {
+			count++
+		}
+	}
+	return count
+}
+
+// HourlyRate returns the estimated hourly cost of the resources the run
+// changed: the burn a pause stopped, or a resume restarted
+func (e Entry) HourlyRate() float64 {
+	var total float64
+	for _, r := range e.Results {
+		if r.Success {
+			total += r.Resource.CostPerHour
+		}
+	}
+	return total
+}
+
+// MonthlyRate returns the estimated monthly cost of the resources the run
+// changed: money saved per month for a pause, spend restored for a resume
+func (e Entry) MonthlyRate() float64 {
+	return e.HourlyRate() * 24 * 30
+}
+
+// Took returns the time spent on the run, discovery included
+func (e Entry) Took() time.Duration {
+	return e.Discovery + e.Duration
+}
+
+// Efficiency describes how long the run took against the burn it changed,
+// e.g. "took 4m12s and stops $1.83/hour", or "" for runs recorded before
+// durations were
+func (e Entry) Efficiency() string {
+	if e.Took() == 0 {
+		return ""
+	}
+	verb := "stops"
+	if e.Operation == "resume" {
+		verb = "restarts"
+	}
+	return fmt.Sprintf("took %s and %s $%.2f/hour", e.Took().Round(time.Second), verb, e.HourlyRate())
+}
+
+// DiscoveryOverhead reports whether discovery took longer than the pause
+// itself while stopping less than SmallRunHourlyRate: on an account this
+// small, scanning is most of the work and schedules can run less often
+func (e Entry) DiscoveryOverhead() bool {
+	return e.Operation == "pause" && e.Discovery > e.Duration && e.HourlyRate() < SmallRunHourlyRate
+}
+
+// Interval is a period during which a resource was paused by awsbreak
+type Interval struct {
+	Resource models.Resource
+	Start    time.Time
+	End      time.Time // zero while the resource is still paused
+}
+
+// TeamSavings summarizes savings attributed to a single team
+type TeamSavings struct {
+	Team        string  `json:"team"`
+	Resources   int     `json:"resources"`
+	PausedHours float64 `json:"paused_hours"`
+	Savings     float64 `json:"savings"`
+}
+
+// Ledger is an append-only record of pause and resume runs
+type Ledger struct {
+	path string
+}
+
+// NewLedger creates a ledger stored in the given configuration directory
+func NewLedger(configDir string) *Ledger {
+	return &Ledger{
+		path: filepath.Join(configDir, ledgerFileName),
+	}
+}
+
+// NewEntry creates a ledger entry for the given operation results
+func NewEntry(operation, region string, results []models.OperationResult) Entry {
+	now := time.Now()
+	return Entry{
+		ID:        now.UTC().Format("20060102-150405"),
+		Timestamp: now,
+		Region:    region,
+		Operation: operation,
+		Results:   results,
+	}
+}
+
+// Append adds an entry to the end of the ledger
+func (l *Ledger) Append(entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+// Entries reads all entries from the ledger in the order they were written
+func (l *Ledger) Entries() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse ledger entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %w", err)
+	}
+
+	return entries, nil
+}
+
+// PausedIntervals pairs successful pauses with the next successful resume of the same resource
+func PausedIntervals(entries []Entry) []Interval {
+	var intervals []Interval
+	open := make(map[string]int) // resource key -> index into intervals
+
+	for _, entry := range entries {
+		for _, result := range entry.Results {
+			if !result.Success {
+				continue
+			}
+
+			key := result.Resource.Key()
+			switch entry.Operation {
+			case "pause":
+				if _, ok := open[key]; ok {
+					continue
+				}
+				open[key] = len(intervals)
+				intervals = append(intervals, Interval{
+					Resource: result.Resource,
+					Start:    result.Timestamp,
+				})
+			case "resume":
+				// Pauses recorded by older versions are keyed without the ARN
+				if _, ok := open[key]; !ok {
+					key = result.Resource.LegacyKey()
+				}
+				if i, ok := open[key]; ok {
+					intervals[i].End = result.Timestamp
+					delete(open, key)
+				}
+			}
+		}
+	}
+
+	return intervals
+}
+
+// Leaderboard ranks teams by the savings from resources paused between since and until.
+// Teams are taken from the value of tagKey on each resource.
+func Leaderboard(entries []Entry, tagKey string, since, until time.Time) []TeamSavings {
+	byTeam := make(map[string]*TeamSavings)
+
+	for _, interval := range PausedIntervals(entries) {
+		hours := interval.overlapHours(since, until)
+		if hours <= 0 {
+			continue
+		}
+
+		team := interval.Resource.Tags[tagKey]
+		if team == "" {
+			team = UntaggedTeam
+		}
+
+		ts, ok := byTeam[team]
+		if !ok {
+			ts = &TeamSavings{Team: team}
+			byTeam[team] = ts
+		}
+		ts.Resources++
+		ts.PausedHours += hours
+		ts.Savings += hours * interval.Resource.CostPerHour
+	}
+
+	board := make([]TeamSavings, 0, len(byTeam))
+	for _, ts := range byTeam {
+		board = append(board, *ts)
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Savings != board[j].Savings {
+			return board[i].Savings > board[j].Savings
+		}
+		return board[i].Team < board[j].Team
+	})
+
+	return board
+}
+
+// overlapHours returns how many hours of the interval fall between since and until
+func (i Interval) overlapHours(since, until time.Time) float64 {
+	start := i.Start
+	if start.Before(since) {
+		start = since
+	}
+	end := i.End
+	if end.IsZero() || end.After(until) {
+		end = until
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"context"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// APIOptions returns SDK middleware that logs every AWS API call when
+// tracing is on, or nil. Only the operation, timing, attempts and outcome
+// are logged - never request bodies or credentials.
+func APIOptions() []func(*middleware.Stack) error {
+	if !traceAWS {
+		return nil
+	}
+	return []func(*middleware.Stack) error{addTrace}
+}
+
+func addTrace(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AWSBreakTrace",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, md, err := next.HandleInitialize(ctx, in)
+
+			args := []any{
+				"service", awsmiddleware.GetServiceID(ctx),
+				"operation", awsmiddleware.GetOperationName(ctx),
+				"region", awsmiddleware.GetRegion(ctx),
+				"duration", time.Since(start).Round(time.Millisecond),
+			}
+			if id, ok := awsmiddleware.GetRequestIDMetadata(md); ok {
+				args = append(args, "request_id", id)
+			}
+			if attempts, ok := retry.GetAttemptResults(md); ok && len(attempts.Results) > 1 {
+				args = append(args, "attempts", len(attempts.Results))
+			}
+			if err != nil {
+				logger.Debug("aws call failed", append(args, "error", err)...)
+			} else {
+				logger.Debug("aws call", args...)
+			}
+			return out, md, err
+		}), middleware.After)
+}
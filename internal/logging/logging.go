@@ -0,0 +1,120 @@
+// Package logging provides awsbreak's diagnostic log. It is separate from the
+// user-facing output: nothing is logged unless --verbose or --log-file is set.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Options selects where diagnostic logs go
+type Options struct {
+	Verbose  bool   // debug logs to stderr
+	File     string // debug logs as JSON lines appended to this file
+	TraceAWS bool   // log every AWS API call
+}
+
+var (
+	logger   = slog.New(discardHandler{})
+	traceAWS bool
+)
+
+// Setup configures the diagnostic log. The returned function closes the log
+// file, if any.
+func Setup(opts Options) (func() error, error) {
+	var handlers []slog.Handler
+	closeFn := func() error { return nil }
+
+	if opts.Verbose {
+		handlers = append(handlers, slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(opts.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		handlers = append(handlers, slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		closeFn = f.Close
+	}
+
+	switch len(handlers) {
+	case 0:
+		logger = slog.New(discardHandler{})
+	case 1:
+		logger = slog.New(handlers[0])
+	default:
+		logger = slog.New(multiHandler(handlers))
+	}
+	traceAWS = opts.TraceAWS && len(handlers) > 0
+
+	return closeFn, nil
+}
+
+// Logger returns the diagnostic logger
+func Logger() *slog.Logger {
+	return logger
+}
+
+// Debug logs at debug level
+func Debug(msg string, args ...any) {
+	logger.Debug(msg, args...)
+}
+
+// Warn logs at warn level
+func Warn(msg string, args ...any) {
+	logger.Warn(msg, args...)
+}
+
+// discardHandler drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// multiHandler sends each record to every handler that accepts its level
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
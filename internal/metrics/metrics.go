@@ -0,0 +1,167 @@
+// Package metrics keeps the gauges and counters 'awsbreak daemon' exposes
+// in the Prometheus text format, so cost dashboards can scrape the brake
+// system directly or through an OpenTelemetry collector.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// Metrics holds the daemon's state. A nil *Metrics ignores every update, so
+// code shared with one-off commands can record unconditionally.
+type Metrics struct {
+	mu         sync.Mutex
+	paused     map[string]int          // resources waiting to resume, by region
+	burn       map[string]float64      // $/hour found running at the last discovery, by region
+	operations map[[2]string]int       // finished operations by operation and result
+	lastRun    map[[2]string]time.Time // last scheduled run by action and region
+	lastOK     map[[2]string]bool      // whether that run succeeded
+}
+
+// New creates empty metrics
+func New() *Metrics {
+	return &Metrics{
+		paused:     make(map[string]int),
+		burn:       make(map[string]float64),
+		operations: make(map[[2]string]int),
+		lastRun:    make(map[[2]string]time.Time),
+		lastOK:     make(map[[2]string]bool),
+	}
+}
+
+// SetPaused records how many resources in a region are paused
+func (m *Metrics) SetPaused(region string, n int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused[region] = n
+}
+
+// SetBurn records the hourly cost of what discovery found running in a region
+func (m *Metrics) SetBurn(region string, resources []models.Resource) {
+	if m == nil {
+		return
+	}
+	var total float64
+	for _, r := range resources {
+		total += r.CostPerHour
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.burn[region] = total
+}
+
+// RecordResults counts the results of a pause or resume
+func (m *Metrics) RecordResults(operation string, results []models.OperationResult) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range results {
+		result := "succeeded"
+		if !r.Success {
+			result = "failed"
+		}
+		m.operations[[2]string{operation, result}]++
+	}
+}
+
+// RecordRun records when a scheduled action last ran in a region and whether
+// it succeeded
+func (m *Metrics) RecordRun(action, region string, at time.Time, ok bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{action, region}
+	m.lastRun[key] = at
+	m.lastOK[key] = ok
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.Write(w)
+}
+
+// Write writes the metrics in the Prometheus text exposition format
+func (m *Metrics) Write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gauge(w, "awsbreak_paused_resources", "Resources paused by awsbreak and waiting to resume.")
+	for _, region := range sortedKeys(m.paused) {
+		sample(w, "awsbreak_paused_resources", float64(m.paused[region]), "region", region)
+	}
+
+	gauge(w, "awsbreak_discovered_burn_dollars_per_hour", "Estimated hourly cost of resources found running at the last discovery.")
+	for _, region := range sortedKeys(m.burn) {
+		sample(w, "awsbreak_discovered_burn_dollars_per_hour", m.burn[region], "region", region)
+	}
+
+	fmt.Fprintln(w, "# HELP awsbreak_operations_total Pause and resume operations finished, by result.")
+	fmt.Fprintln(w, "# TYPE awsbreak_operations_total counter")
+	for _, k := range sortedPairs(m.operations) {
+		sample(w, "awsbreak_operations_total", float64(m.operations[k]), "operation", k[0], "result", k[1])
+	}
+
+	gauge(w, "awsbreak_last_run_timestamp_seconds", "When each scheduled action last ran in each region, as a Unix timestamp.")
+	for _, k := range sortedPairs(m.lastRun) {
+		sample(w, "awsbreak_last_run_timestamp_seconds", float64(m.lastRun[k].Unix()), "action", k[0], "region", k[1])
+	}
+
+	gauge(w, "awsbreak_last_run_success", "Whether each scheduled action's last run in each region succeeded (1) or failed (0).")
+	for _, k := range sortedPairs(m.lastOK) {
+		ok := 0.0
+		if m.lastOK[k] {
+			ok = 1
+		}
+		sample(w, "awsbreak_last_run_success", ok, "action", k[0], "region", k[1])
+	}
+}
+
+func gauge(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// sample writes one sample with labels given as name, value pairs
+func sample(w io.Writer, name string, value float64, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	fmt.Fprintf(w, "%s{%s} %g\n", name, strings.Join(pairs, ","), value)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPairs[V any](m map[[2]string]V) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0]+"/"+keys[i][1] < keys[j][0]+"/"+keys[j][1]
+	})
+	return keys
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ConsoleURL returns the resource's page in the AWS console, or "" when
+// there is none. The domain follows the region's partition.
+func (r Resource) ConsoleURL() string {
+	region := r.Region
+	if region == "" {
+		return ""
+	}
+	host := consoleHost(region)
+	kind, _ := r.Metadata["kind"].(string)
+	id := url.QueryEscape(r.ResourceID)
+
+	switch r.ServiceType {
+	case ServiceEC2:
+		switch kind {
+		case "spot_fleet":
+			return fmt.Sprintf("https://%s/ec2/home?region=%s#SpotInstances:", host, region)
+		case "capacity_reservation":
+			return fmt.Sprintf("https://%s/ec2/home?region=%s#CapacityReservationDetails:crId=%s", host, region, id)
+		}
+		return fmt.Sprintf("https://%s/ec2/home?region=%s#InstanceDetails:instanceId=%s", host, region, id)
+	case ServiceRDS:
+		isCluster := r.Metadata["is_cluster"] == true
+		return fmt.Sprintf("https://%s/rds/home?region=%s#database:id=%s;is-cluster=%t", host, region, id, isCluster)
+	case ServiceECS:
+		clusterARN, _ := r.Metadata["cluster_arn"].(string)
+		cluster := clusterARN[strings.LastIndex(clusterARN, "/")+1:]
+		if cluster == "" {
+			return ""
+		}
+		return fmt.Sprintf("https://%s/ecs/v2/clusters/%s/services/%s/health?region=%s",
+			host, url.PathEscape(cluster), url.PathEscape(r.ResourceID), region)
+	case ServiceAutoScaling:
+		return fmt.Sprintf("https://%s/ec2/home?region=%s#AutoScalingGroupDetails:id=%s", host, region, id)
+	case ServiceEKS:
+		cluster, name, _ := strings.Cut(r.ResourceID, "/")
+		return fmt.Sprintf("https://%s/eks/home?region=%s#/clusters/%s/nodegroups/%s",
+			host, region, url.PathEscape(cluster), url.PathEscape(name))
+	case ServiceWorkSpaces:
+		return fmt.Sprintf("https://%s/workspaces/home?region=%s#listworkspaces:search=%s", host, region, id)
+	case ServiceEMR:
+		return fmt.Sprintf("https://%s/emr/home?region=%s#/clusterDetails/%s", host, region, id)
+	case ServiceKinesis:
+		return fmt.Sprintf("https://%s/kinesis/home?region=%s#/streams/details/%s/monitoring", host, region, id)
+	case ServiceLightsail:
+		// The Lightsail console lives on its own domain in the commercial partition
+		switch kind {
+		case "database":
+			return fmt.Sprintf("https://lightsail.aws.amazon.com/ls/webapp/%s/databases/%s/connect", region, url.PathEscape(r.ResourceID))
+		case "container_service":
+			return fmt.Sprintf("https://lightsail.aws.amazon.com/ls/webapp/%s/container-services/%s/deployments", region, url.PathEscape(r.ResourceID))
+		}
+		return fmt.Sprintf("https://lightsail.aws.amazon.com/ls/webapp/%s/instances/%s/connect", region, url.PathEscape(r.ResourceID))
+	case ServiceDynamoDB:
+		return fmt.Sprintf("https://%s/dynamodbv2/home?region=%s#table?name=%s", host, region, id)
+	case ServiceOpenSearch:
+		return fmt.Sprintf("https://%s/aos/home?region=%s#opensearch/domains/%s", host, region, id)
+	case ServiceAppStream:
+		return fmt.Sprintf("https://%s/appstream2/home?region=%s#/fleets/%s", host, region, id)
+	case ServiceNetwork:
+		if kind == "elastic_ip" {
+			return fmt.Sprintf("https://%s/ec2/home?region=%s#ElasticIpDetails:AllocationId=%s", host, region, id)
+		}
+		return fmt.Sprintf("https://%s/vpcconsole/home?region=%s#NatGatewayDetails:natGatewayId=%s", host, region, id)
+	}
+	return ""
+}
+
+// MarshalJSON adds console_url to the resource's JSON, so API responses,
+// webhook payloads and saved files link straight to the console. It is not
+// read back.
+func (r Resource) MarshalJSON() ([]byte, error) {
+	type resource Resource
+	return json.Marshal(struct {
+		resource
+		ConsoleURL string `json:"console_url,omitempty"`
+	}{resource(r), r.ConsoleURL()})
+}
+
+// consoleHost returns the console domain for a region's partition
+func consoleHost(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "console.amazonaws.cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "console.amazonaws-us-gov.com"
+	default:
+		return region + ".console.aws.amazon.com"
+	}
+}
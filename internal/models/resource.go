@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,6 +14,15 @@ const (
 	ServiceRDS         ServiceType = "rds"
 	ServiceECS         ServiceType = "ecs"
 	ServiceAutoScaling ServiceType = "autoscaling"
+	ServiceNetwork     ServiceType = "network"
+	ServiceEKS         ServiceType = "eks"
+	ServiceWorkSpaces  ServiceType = "workspaces"
+	ServiceAppStream   ServiceType = "appstream"
+	ServiceOpenSearch  ServiceType = "opensearch"
+	ServiceDynamoDB    ServiceType = "dynamodb"
+	ServiceEMR         ServiceType = "emr"
+	ServiceKinesis     ServiceType = "kinesis"
+	ServiceLightsail   ServiceType = "lightsail"
 )
 
 // ResourceState represents the current state of a resource
@@ -22,17 +33,94 @@ const (
 	StateStopped   ResourceState = "stopped"
 	StateAvailable ResourceState = "available"
 	StatePaused    ResourceState = "paused"
+	StateIdle      ResourceState = "idle"
 )
 
 // Resource represents an AWS resource that can be paused/resumed
 type Resource struct {
 	ServiceType  ServiceType       `json:"service_type"`
 	ResourceID   string            `json:"resource_id"`
+	ARN          string            `json:"arn,omitempty"`
 	Region       string            `json:"region"`
 	CurrentState ResourceState     `json:"current_state"`
 	Tags         map[string]string `json:"tags,omitempty"`
 	Metadata     map[string]any    `json:"metadata,omitempty"`
 	CostPerHour  float64           `json:"cost_per_hour,omitempty"`
+	AccountID    string            `json:"account_id,omitempty"` // set for organization runs
+}
+
+// Key returns a string that uniquely identifies the resource across services,
+// regions and, for organization runs, accounts. The ARN is used when known so
+// same-named resources in different clusters stay distinct.
+func (r Resource) Key() string {
+	if r.ARN != "" {
+		return r.ARN
+	}
+	return r.LegacyKey()
+}
+
+// LegacyKey returns the key used before ARNs were recorded. Snapshots,
+// journals and ledgers written by older versions are keyed this way.
+func (r Resource) LegacyKey() string {
+	if r.AccountID != "" {
+		return fmt.Sprintf("%s:%s:%s:%s", r.AccountID, r.ServiceType, r.Region, r.ResourceID)
+	}
+	return fmt.Sprintf("%s:%s:%s", r.ServiceType, r.Region, r.ResourceID)
+}
+
+// SameAs reports whether r and o are the same resource. When either was
+// recorded without an ARN they are compared by their legacy keys.
+func (r Resource) SameAs(o Resource) bool {
+	if r.ARN != "" && o.ARN != "" {
+		return r.ARN == o.ARN
+	}
+	return r.LegacyKey() == o.LegacyKey()
+}
+
+// KeySet is a set of resources that matches resources recorded without an
+// ARN against the same resources discovered with one
+type KeySet struct {
+	keys   map[string]bool
+	legacy map[string]bool // legacy keys of every resource
+	bare   map[string]bool // legacy keys of resources without an ARN
+}
+
+// NewKeySet returns a set holding resources
+func NewKeySet(resources ...Resource) *KeySet {
+	s := &KeySet{keys: make(map[string]bool), legacy: make(map[string]bool), bare: make(map[string]bool)}
+	for _, r := range resources {
+		s.Add(r)
+	}
+	return s
+}
+
+// Add puts r in the set
+func (s *KeySet) Add(r Resource) {
+	s.keys[r.Key()] = true
+	s.legacy[r.LegacyKey()] = true
+	if r.ARN == "" {
+		s.bare[r.LegacyKey()] = true
+	}
+}
+
+// Has reports whether the set holds a resource that is the same as r
+func (s *KeySet) Has(r Resource) bool {
+	if s.keys[r.Key()] {
+		return true
+	}
+	if r.ARN == "" {
+		return s.legacy[r.LegacyKey()]
+	}
+	return s.bare[r.LegacyKey()]
+}
+
+// Matches reports whether ref names the resource, either as its full ARN or
+// as its resource ID
+func (r Resource) Matches(ref string) bool {
+	if strings.HasPrefix(ref, "arn:") {
+		return r.ARN == ref
+	}
+	return r.ResourceID == ref
 }
 
 // OperationResult captures the result of a pause/resume operation
@@ -44,17 +132,31 @@ type OperationResult struct {
 	Timestamp time.Time     `json:"timestamp"`
 	Duration  time.Duration `json:"duration,omitempty"`
 	Error     string        `json:"error,omitempty"`
+	Retries   int           `json:"retries,omitempty"` // attempts after the first
 }
 
 // AccountSnapshot stores the state of all resources before a pause operation
 type AccountSnapshot struct {
-	SnapshotID            string            `json:"snapshot_id"`
-	Timestamp             time.Time         `json:"timestamp"`
-	Region                string            `json:"region"`
-	Resources             []Resource        `json:"resources"`
-	OriginalStates        map[string]any    `json:"original_states"` // resource_id -> original config
-	OperationResults      []OperationResult `json:"operation_results,omitempty"`
-	TotalEstimatedSavings float64           `json:"total_estimated_savings"`
+	SnapshotID            string               `json:"snapshot_id"`
+	Timestamp             time.Time            `json:"timestamp"`
+	Region                string               `json:"region"`
+	AccountID             string               `json:"account_id,omitempty"` // member account for organization runs
+	Resources             []Resource           `json:"resources"`
+	OriginalStates        map[string]any       `json:"original_states"` // resource_id -> original config
+	OperationResults      []OperationResult    `json:"operation_results,omitempty"`
+	TotalEstimatedSavings float64              `json:"total_estimated_savings"`
+	Resumed               map[string]time.Time `json:"resumed,omitempty"` // resource key -> resume time
+}
+
+// PendingResources returns the resources in the snapshot that have not been resumed yet
+func (s *AccountSnapshot) PendingResources() []Resource {
+	var pending []Resource
+	for _, r := range s.Resources {
+		if _, ok := s.Resumed[r.Key()]; !ok {
+			pending = append(pending, r)
+		}
+	}
+	return pending
 }
 
 // Config stores the application configuration
@@ -63,13 +165,366 @@ type Config struct {
 	DefaultRegion string    `json:"default_region"`
 	CreatedAt     time.Time `json:"created_at"`
 	Version       string    `json:"version"`
+
+	// Profile is the shared AWS config profile credentials come from, e.g. an
+	// IAM Identity Center profile signed in with 'awsbreak login'
+	Profile string `json:"profile,omitempty"`
+
+	// Observer marks a read-only install set up with 'awsbreak setup
+	// --observer': every command runs in spectator mode
+	Observer bool `json:"observer,omitempty"`
+
+	// Session customizes the role sessions awsbreak starts
+	Session *SessionConfig `json:"session,omitempty"`
+
+	// ConnectionThreshold is the peak connection count that blocks stopping a database
+	ConnectionThreshold int `json:"connection_threshold,omitempty"`
+
+	// AttributionTag is the tag key used to attribute savings to teams
+	AttributionTag string `json:"attribution_tag,omitempty"`
+
+	// Digest configures the scheduled summary sent to Slack and/or email
+	Digest *DigestConfig `json:"digest,omitempty"`
+
+	// Schedules are recurring brake windows run by the daemon
+	Schedules []Schedule `json:"schedules,omitempty"`
+
+	// GracePeriodMinutes arms pauses instead of braking at once: the team is
+	// notified and anyone can run 'awsbreak cancel' before the brake fires.
+	// It applies to 'awsbreak', scheduled pauses and the Lambda runner;
+	// --after overrides it.
+	GracePeriodMinutes int `json:"grace_period_minutes,omitempty"`
+
+	// Groups name sets of resources that 'awsbreak open' resumes together
+	Groups []GroupConfig `json:"groups,omitempty"`
+
+	// Plans are saved selections braked and released with --plan
+	Plans []PlanConfig `json:"plans,omitempty"`
+
+	// Protection lists resources that are never paused without --force
+	Protection *ProtectionConfig `json:"protection,omitempty"`
+
+	// Ordering adjusts the order resources are paused and resumed in
+	Ordering *OrderingConfig `json:"ordering,omitempty"`
+
+	// Retry tunes retries of throttled and transient AWS errors
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// Concurrency limits how many AWS operations run at once
+	Concurrency *ConcurrencyConfig `json:"concurrency,omitempty"`
+
+	// Notifications announces every pause and resume to the team
+	Notifications *NotificationConfig `json:"notifications,omitempty"`
+
+	// Issues opens tracker issues for resources that keep failing
+	Issues *IssueConfig `json:"issues,omitempty"`
+
+	// Cost selects how resources are priced for savings estimates
+	Cost *CostConfig `json:"cost,omitempty"`
+
+	// SnapshotStorage moves snapshots off this machine, e.g. to a shared S3 bucket
+	SnapshotStorage *SnapshotStorageConfig `json:"snapshot_storage,omitempty"`
+
+	// Output controls how tables fit the terminal
+	Output *OutputConfig `json:"output,omitempty"`
+
+	// Canary tunes gradual pauses started with --canary or --canary-tag
+	Canary *CanaryConfig `json:"canary,omitempty"`
+
+	// Budget is the monthly spend 'awsbreak --check' warns about and tracks
+	Budget *BudgetConfig `json:"budget,omitempty"`
+
+	// OpenSearch tunes how OpenSearch domains are parked
+	OpenSearch *OpenSearchConfig `json:"opensearch,omitempty"`
+
+	// Verify tunes the sweep that confirms everything is off after a pause
+	Verify *VerifyConfig `json:"verify,omitempty"`
+}
+
+// VerifyConfig tunes the verification sweep run after each pause
+type VerifyConfig struct {
+	// Regions limits the sweep to these and the paused region; empty sweeps
+	// every region enabled for the account
+	Regions []string `json:"regions,omitempty"`
+	// Disabled skips the sweep
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// OpenSearchConfig tunes how OpenSearch domains are scaled down on pause
+type OpenSearchConfig struct {
+	// ParkedInstanceType moves paused domains' data nodes to this instance
+	// type, e.g. "t3.small.search". Empty keeps the current type and only
+	// reduces the node count.
+	ParkedInstanceType string `json:"parked_instance_type,omitempty"`
+}
+
+// BudgetConfig sets monthly spend limits for the dashboard and daemon
+type BudgetConfig struct {
+	MonthlyLimit float64 `json:"monthly_limit,omitempty"` // USD; --threshold overrides it
+	// MonthlyTarget is the spend goal, in USD, for the services awsbreak
+	// manages in the default region, tracked month to date with Cost Explorer
+	MonthlyTarget float64 `json:"monthly_target,omitempty"`
+	// TightenMinutes widens scheduled brake windows by this much while the
+	// target is at risk: pauses run earlier and resumes later. 0 leaves
+	// schedules alone.
+	TightenMinutes int `json:"tighten_minutes,omitempty"`
 }
 
-// CostReport summarizes cost savings
+// CanaryConfig tunes the soak between pausing a canary subset and the rest
+type CanaryConfig struct {
+	SoakMinutes int `json:"soak_minutes,omitempty"` // default 10; --soak overrides it
+	// AlarmPrefixes limits the CloudWatch alarms watched during the soak to
+	// names with these prefixes; empty watches every alarm in the region
+	AlarmPrefixes []string `json:"alarm_prefixes,omitempty"`
+}
+
+// CostConfig selects the cost model used for all savings math
+type CostConfig struct {
+	Estimator       string  `json:"estimator,omitempty"`        // "static" (default), "pricing-api" or "csv"
+	RatesFile       string  `json:"rates_file,omitempty"`       // negotiated rates for the csv estimator
+	DiscountPercent float64 `json:"discount_percent,omitempty"` // account-wide discount such as an EDP
+}
+
+// SnapshotStorageConfig selects where snapshots are stored
+type SnapshotStorageConfig struct {
+	Backend  string `json:"backend,omitempty"`    // "local" (default) or "s3"
+	Bucket   string `json:"bucket,omitempty"`     // S3 bucket for the s3 backend
+	Prefix   string `json:"prefix,omitempty"`     // key prefix, default "awsbreak/snapshots"
+	Region   string `json:"region,omitempty"`     // bucket region, default the configured region
+	KMSKeyID string `json:"kms_key_id,omitempty"` // use SSE-KMS with this key instead of SSE-S3
+}
+
+// OutputConfig controls table layout. By default tables fit the terminal and
+// piped output is never shortened.
+type OutputConfig struct {
+	Width    int    `json:"width,omitempty"`    // columns to fit, overriding the terminal width
+	Overflow string `json:"overflow,omitempty"` // "truncate" (default), "wrap" or "none"
+}
+
+// NotificationConfig lists channels that receive a summary after each run
+type NotificationConfig struct {
+	SlackWebhookURL string `json:"slack_webhook_url,omitempty"`
+	WebhookURL      string `json:"webhook_url,omitempty"` // receives the summary as JSON
+	SNSTopicARN     string `json:"sns_topic_arn,omitempty"`
+	// Templates replace the built-in summary per channel
+	Templates *MessageTemplates `json:"templates,omitempty"`
+}
+
+// MessageTemplates are Go text/template overrides for the messages sent to
+// each channel. Notification templates get the run summary as context and
+// digest templates the digest; {{.Text}} is the built-in message. Empty
+// fields keep the built-in message.
+type MessageTemplates struct {
+	Slack   string `json:"slack,omitempty"`
+	SNS     string `json:"sns,omitempty"`
+	Webhook string `json:"webhook,omitempty"` // the whole request body; must render JSON
+	Email   string `json:"email,omitempty"`   // digest email body
+}
+
+// IssueConfig files a GitHub or Jira issue when the same resource fails to
+// pause or resume several runs in a row
+type IssueConfig struct {
+	Tracker  string `json:"tracker"`  // "github" or "jira"
+	Endpoint string `json:"endpoint"` // GitHub: https://api.github.com/repos/OWNER/REPO; Jira: the site URL
+	// Token authenticates to the tracker; Jira accepts email:api-token.
+	// Empty reads AWSBREAK_ISSUE_TOKEN.
+	Token     string   `json:"token,omitempty"`
+	Project   string   `json:"project,omitempty"`    // Jira project key
+	IssueType string   `json:"issue_type,omitempty"` // Jira issue type, default "Bug"
+	Labels    []string `json:"labels,omitempty"`
+	AfterRuns int      `json:"after_runs,omitempty"` // failed runs in a row before filing, default 3
+}
+
+// RetryConfig tunes retries of failed pause and resume calls
+type RetryConfig struct {
+	MaxAttempts int `json:"max_attempts,omitempty"` // including the first; 1 disables retries
+	BaseDelayMs int `json:"base_delay_ms,omitempty"`
+	MaxDelayMs  int `json:"max_delay_ms,omitempty"`
+}
+
+// ConcurrencyConfig limits parallel AWS operations, overall and per service
+type ConcurrencyConfig struct {
+	Operations int `json:"operations,omitempty"` // pauses and resumes in flight, default 5
+	Discovery  int `json:"discovery,omitempty"`  // services discovered at once, default 4
+	// PerService caps operations in flight for a service, e.g. {"rds": 2}
+	PerService map[string]int `json:"per_service,omitempty"`
+	// Adaptive halves concurrency when AWS throttles calls and recovers it gradually
+	Adaptive bool `json:"adaptive,omitempty"`
+}
+
+// OrderingConfig overrides the default service order and declares
+// dependencies between resources
+type OrderingConfig struct {
+	// Priorities maps a service type to its rank; lower ranks resume first and pause last
+	Priorities map[string]int `json:"priorities,omitempty"`
+	// Dependencies maps a resource ID or ARN to the IDs or ARNs of resources it needs running
+	Dependencies map[string][]string `json:"dependencies,omitempty"`
+}
+
+// ProtectionConfig lists resources awsbreak must never touch
+type ProtectionConfig struct {
+	NamePatterns []string `json:"name_patterns,omitempty"` // globs matched against resource IDs and Name tags
+	ResourceIDs  []string `json:"resource_ids,omitempty"`  // resource IDs or ARNs
+	// SchedulerTags are extra tag keys that mark resources owned by another
+	// scheduler; a trailing "*" matches a prefix
+	SchedulerTags []string `json:"scheduler_tags,omitempty"`
+	// Critical tunes the built-in checks for infrastructure the account or
+	// awsbreak itself depends on
+	Critical *CriticalConfig `json:"critical,omitempty"`
+	// IaC tunes how resources managed by CloudFormation, Terraform and
+	// similar tools are treated
+	IaC *IaCConfig `json:"iac,omitempty"`
+}
+
+// IaCConfig tunes detection of resources managed by infrastructure as code.
+// Pausing them shows up as drift, and the next apply may start them again
+// or scale them back up.
+type IaCConfig struct {
+	Mode string `json:"mode,omitempty"` // "warn" (default), "skip" or "off"
+	// TerraformStates are local state files whose AWS resources count as
+	// managed by Terraform, e.g. saved with 'terraform state pull'
+	TerraformStates []string `json:"terraform_states,omitempty"`
+}
+
+// CriticalConfig tunes the checks that keep NAT instances, bastion hosts,
+// AWS managed Auto Scaling groups and production databases running. Tags are
+// given as key or key=value; values may be globs and match case-insensitively.
+type CriticalConfig struct {
+	Disabled          bool     `json:"disabled,omitempty"`            // turn the checks off
+	AllowNATInstances bool     `json:"allow_nat_instances,omitempty"` // pause instances that route traffic for their VPC
+	BastionTags       []string `json:"bastion_tags,omitempty"`        // default: role=bastion, bastion, name=*bastion*
+	ManagedGroupTags  []string `json:"managed_group_tags,omitempty"`  // extra tags of Auto Scaling groups another service manages
+	ProductionTags    []string `json:"production_tags,omitempty"`     // default: environment=prod*, env=prod*, stage=prod*
+}
+
+// Schedule is a recurring pause or resume at a local time of day
+type Schedule struct {
+	Name   string   `json:"name"`
+	Action string   `json:"action"` // "pause", "resume"
+	Days   []string `json:"days"`   // three-letter day names, e.g. "mon"
+	At     string   `json:"at"`     // 24-hour HH:MM local time
+	Region string   `json:"region,omitempty"`
+}
+
+// SessionConfig customizes how the awsbreak role is assumed
+type SessionConfig struct {
+	ExternalID      string `json:"external_id,omitempty"`      // default "aws-hit-breaks"; must match the trust policy
+	DurationMinutes int    `json:"duration_minutes,omitempty"` // 15-720, default 60; the role's MaxSessionDuration must allow it
+	Name            string `json:"name,omitempty"`             // CloudTrail session name; "{user}" becomes the local user name
+}
+
+// GroupConfig names the resources opened together by 'awsbreak open'. A group
+// that isn't configured selects resources tagged awsbreak:group=<name>.
+type GroupConfig struct {
+	Name   string `json:"name"`
+	Tag    string `json:"tag"`              // key or key=value
+	Region string `json:"region,omitempty"` // default: the default region
+}
+
+// PlanConfig is a saved selection of resources, braked with
+// 'awsbreak --plan <name>' and released with 'awsbreak --go --plan <name>'.
+// A resource is in the plan when it has every tag and, if services are
+// listed, is one of them.
+type PlanConfig struct {
+	Name     string        `json:"name"`
+	Tags     []string      `json:"tags,omitempty"`     // key or key=value
+	Services []ServiceType `json:"services,omitempty"` // default: all
+	Region   string        `json:"region,omitempty"`   // default: the default region
+}
+
+// DigestConfig configures delivery of the weekly digest
+type DigestConfig struct {
+	SlackWebhookURL string       `json:"slack_webhook_url,omitempty"`
+	Email           *EmailConfig `json:"email,omitempty"`
+	Weekday         string       `json:"weekday,omitempty"` // e.g. "monday"
+	Hour            int          `json:"hour,omitempty"`    // local hour of day, 0-23
+	// Templates replace the built-in digest text for Slack and email
+	Templates *MessageTemplates `json:"templates,omitempty"`
+}
+
+// EmailConfig holds SMTP settings for sending email
+type EmailConfig struct {
+	SMTPHost string   `json:"smtp_host"`
+	SMTPPort int      `json:"smtp_port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// CostReport summarizes cost savings. Resources are those running now; the
+// savings are what pausing them would save.
 type CostReport struct {
-	Resources       []Resource `json:"resources"`
-	HourlySavings   float64    `json:"hourly_savings"`
-	DailySavings    float64    `json:"daily_savings"`
-	MonthlySavings  float64    `json:"monthly_savings"`
-	GeneratedAt     time.Time  `json:"generated_at"`
+	Region         string     `json:"region,omitempty"`
+	Resources      []Resource `json:"resources"`
+	HourlySavings  float64    `json:"hourly_savings"`
+	DailySavings   float64    `json:"daily_savings"`
+	MonthlySavings float64    `json:"monthly_savings"`
+	GeneratedAt    time.Time  `json:"generated_at"`
+	// Comparison sets the report against a pause, when there is a snapshot
+	Comparison *CostComparison `json:"comparison,omitempty"`
+}
+
+// CostComparison compares hourly cost before and after a pause
+type CostComparison struct {
+	SnapshotID   string     `json:"snapshot_id"`
+	PausedAt     time.Time  `json:"paused_at"`
+	Paused       []Resource `json:"paused"`        // resources of the snapshot still paused
+	BeforeHourly float64    `json:"before_hourly"` // running now plus still paused
+	AfterHourly  float64    `json:"after_hourly"`  // running now
+}
+
+// SavedHourly returns what the pause is saving each hour
+func (c *CostComparison) SavedHourly() float64 {
+	return c.BeforeHourly - c.AfterHourly
+}
+
+// ServiceDiscovery is the outcome of discovering one service in a region
+type ServiceDiscovery struct {
+	ServiceType ServiceType   `json:"service_type"`
+	Resources   int           `json:"resources"`
+	Duration    time.Duration `json:"duration"`
+	Error       string        `json:"error,omitempty"`    // the service couldn't be discovered at all
+	Warnings    []string      `json:"warnings,omitempty"` // parts that were skipped, e.g. one ECS cluster
+}
+
+// DiscoveryReport records which services were discovered and which failed,
+// so an empty inventory can be told apart from one hiding an AccessDenied
+type DiscoveryReport struct {
+	Region   string             `json:"region"`
+	Services []ServiceDiscovery `json:"services"`
+}
+
+// Failed returns the services that couldn't be discovered
+func (r *DiscoveryReport) Failed() []ServiceDiscovery {
+	if r == nil {
+		return nil
+	}
+	var failed []ServiceDiscovery
+	for _, s := range r.Services {
+		if s.Error != "" {
+			failed = append(failed, s)
+		}
+	}
+	return failed
+}
+
+// Partial returns the services that were discovered with parts skipped
+func (r *DiscoveryReport) Partial() []ServiceDiscovery {
+	if r == nil {
+		return nil
+	}
+	var partial []ServiceDiscovery
+	for _, s := range r.Services {
+		if s.Error == "" && len(s.Warnings) > 0 {
+			partial = append(partial, s)
+		}
+	}
+	return partial
+}
+
+// Complete reports whether every service was fully discovered. A nil report,
+// from an inventory cached by an older version, counts as complete.
+func (r *DiscoveryReport) Complete() bool {
+	return len(r.Failed()) == 0 && len(r.Partial()) == 0
 }
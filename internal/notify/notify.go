@@ -0,0 +1,330 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/user"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// sendTimeout bounds each delivery attempt
+const sendTimeout = 30 * time.Second
+
+// Summary describes a finished pause or resume run
+type Summary struct {
+	Operation      string                   `json:"operation"`
+	Region         string                   `json:"region"`
+	AccountID      string                   `json:"account_id,omitempty"`
+	Actor          string                   `json:"actor"`
+	Succeeded      int                      `json:"succeeded"`
+	Failed         int                      `json:"failed"`
+	MonthlySavings float64                  `json:"monthly_savings"` // estimated, for resources paused
+	Failures       []models.OperationResult `json:"failures,omitempty"`
+	Timestamp      time.Time                `json:"timestamp"`
+
+	// Set for "closing" reminders that an opened group is about to re-pause,
+	// and for "armed" and "cancelled" brakes (Until is when the brake fires)
+	Group     string    `json:"group,omitempty"`
+	Requester string    `json:"requester,omitempty"`
+	Until     time.Time `json:"until,omitzero"`
+}
+
+// NewSummary summarizes the results of a run
+func NewSummary(operation, region string, results []models.OperationResult) Summary {
+	s := Summary{
+		Operation: operation,
+		Region:    region,
+		Actor:     actor(),
+		Timestamp: time.Now(),
+	}
+
+	for _, r := range results {
+		if r.Resource.AccountID != "" {
+			s.AccountID = r.Resource.AccountID
+		}
+		if !r.Success {
+			s.Failed++
+			s.Failures = append(s.Failures, r)
+			continue
+		}
+		s.Succeeded++
+		s.MonthlySavings += r.Resource.CostPerHour * 24 * 30
+	}
+	return s
+}
+
+// NewClosing warns that a group opened by requester pauses again at until
+func NewClosing(group, region, requester string, until time.Time) Summary {
+	return Summary{
+		Operation: "closing",
+		Region:    region,
+		Actor:     actor(),
+		Timestamp: time.Now(),
+		Group:     group,
+		Requester: requester,
+		Until:     until,
+	}
+}
+
+// NewArmed announces that requester armed the brake in a region to pause it at at
+func NewArmed(region, requester string, at time.Time) Summary {
+	return Summary{
+		Operation: "armed",
+		Region:    region,
+		Actor:     actor(),
+		Timestamp: time.Now(),
+		Requester: requester,
+		Until:     at,
+	}
+}
+
+// NewCancelled announces that the brake requester armed for at was called off
+func NewCancelled(region, requester string, at time.Time) Summary {
+	return Summary{
+		Operation: "cancelled",
+		Region:    region,
+		Actor:     actor(),
+		Timestamp: time.Now(),
+		Requester: requester,
+		Until:     at,
+	}
+}
+
+// Requester names who is running awsbreak, as user@host, for recording who
+// asked for something that happens later
+func Requester() string {
+	return actor()
+}
+
+// Text renders the summary as a short plain-text message
+func (s Summary) Text() string {
+	var b strings.Builder
+
+	where := s.Region
+	if s.AccountID != "" {
+		where = fmt.Sprintf("%s/%s", s.AccountID, s.Region)
+	}
+
+	if s.Operation == "closing" {
+		fmt.Fprintf(&b, "⏰ %s: %s in %s pauses again in %s (at %s)\n", s.Requester, s.Group, where,
+			time.Until(s.Until).Round(time.Minute), s.Until.Format("15:04 MST"))
+		b.WriteString("   Run 'awsbreak open --group " + s.Group + " --for <duration>' to keep it open longer.\n")
+		return b.String()
+	}
+
+	switch s.Operation {
+	case "armed":
+		fmt.Fprintf(&b, "⏳ %s armed the brake in %s: pausing in %s (at %s)\n", s.Requester, where,
+			time.Until(s.Until).Round(time.Minute), s.Until.Format("15:04 MST"))
+		b.WriteString("   Run 'awsbreak cancel' to call it off.\n")
+		return b.String()
+	case "cancelled":
+		fmt.Fprintf(&b, "✋ %s called off the brake %s armed in %s for %s\n", s.Actor, s.Requester, where, s.Until.Format("15:04 MST"))
+		return b.String()
+	}
+
+	if s.Operation == "pause" {
+		fmt.Fprintf(&b, "🛑 %s hit the brakes in %s: %d stopped", s.Actor, where, s.Succeeded)
+	} else {
+		fmt.Fprintf(&b, "🟢 %s released the brakes in %s: %d started", s.Actor, where, s.Succeeded)
+	}
+	if s.Failed > 0 {
+		fmt.Fprintf(&b, ", %d failed", s.Failed)
+	}
+	b.WriteString("\n")
+
+	if s.Operation == "pause" && s.MonthlySavings > 0 {
+		fmt.Fprintf(&b, "💰 Saving ~$%.2f/month\n", s.MonthlySavings)
+	}
+	for _, f := range s.Failures {
+		fmt.Fprintf(&b, "❌ %s %s: %s\n", f.Resource.ServiceType, f.Resource.ResourceID, f.Error)
+		if link := f.Resource.ConsoleURL(); link != "" {
+			fmt.Fprintf(&b, "   🔗 %s\n", link)
+		}
+	}
+
+	return b.String()
+}
+
+// Notifier delivers run summaries to one channel
+type Notifier interface {
+	Notify(ctx context.Context, s Summary) error
+}
+
+// FromConfig returns a notifier for every configured channel. awsCfg supplies
+// credentials for SNS. A channel whose template doesn't parse still gets a
+// notifier, using the built-in message, and the parse errors are returned.
+func FromConfig(cfg *models.NotificationConfig, awsCfg aws.Config) ([]Notifier, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	var texts models.MessageTemplates
+	if cfg.Templates != nil {
+		texts = *cfg.Templates
+	}
+	var errs []error
+	parse := func(channel, text string) *template.Template {
+		t, err := ParseTemplate(channel, text)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return t
+	}
+
+	var notifiers []Notifier
+	if cfg.SlackWebhookURL != "" {
+		notifiers = append(notifiers, &SlackNotifier{WebhookURL: cfg.SlackWebhookURL, Template: parse("Slack", texts.Slack)})
+	}
+	if cfg.WebhookURL != "" {
+		notifiers = append(notifiers, &WebhookNotifier{URL: cfg.WebhookURL, Template: parse("webhook", texts.Webhook)})
+	}
+	if cfg.SNSTopicARN != "" {
+		n := NewSNSNotifier(awsCfg, cfg.SNSTopicARN)
+		n.Template = parse("SNS", texts.SNS)
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, errors.Join(errs...)
+}
+
+// Send delivers a summary to every notifier and joins their errors
+func Send(ctx context.Context, notifiers []Notifier, s Summary) error {
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SlackNotifier posts summaries to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	Template   *template.Template // nil posts Summary.Text
+}
+
+// Notify posts the summary text to Slack
+func (n *SlackNotifier) Notify(ctx context.Context, s Summary) error {
+	return postJSON(ctx, "Slack", n.WebhookURL, map[string]string{"text": Render(n.Template, s, s.Text())})
+}
+
+// WebhookNotifier posts summaries as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	URL      string
+	Template *template.Template // renders the whole body; nil posts the Summary
+}
+
+// Notify posts the summary as JSON. A template that fails or doesn't render
+// valid JSON falls back to the Summary, with the failure in template_error
+// so the broken template is noticed without losing the message.
+func (n *WebhookNotifier) Notify(ctx context.Context, s Summary) error {
+	if n.Template == nil {
+		return postJSON(ctx, "webhook", n.URL, s)
+	}
+
+	var body bytes.Buffer
+	if err := n.Template.Execute(&body, s); err != nil {
+		return postJSON(ctx, "webhook", n.URL, fallbackBody{s, fmt.Sprintf("the webhook template failed: %v", err)})
+	}
+	if !json.Valid(body.Bytes()) {
+		return postJSON(ctx, "webhook", n.URL, fallbackBody{s, "the webhook template did not render valid JSON"})
+	}
+	return post(ctx, "webhook", n.URL, body.Bytes())
+}
+
+// fallbackBody is the webhook payload sent when its template fails
+type fallbackBody struct {
+	Summary
+	TemplateError string `json:"template_error"`
+}
+
+// SNSNotifier publishes summaries to an SNS topic
+type SNSNotifier struct {
+	client   *sns.Client
+	topicARN string
+	Template *template.Template // nil publishes Summary.Text
+}
+
+// NewSNSNotifier creates a notifier for a topic. The client is pointed at the
+// topic's own region, which may differ from the region being braked.
+func NewSNSNotifier(cfg aws.Config, topicARN string) *SNSNotifier {
+	if parsed, err := arn.Parse(topicARN); err == nil {
+		cfg.Region = parsed.Region
+	}
+	return &SNSNotifier{
+		client:   sns.NewFromConfig(cfg),
+		topicARN: topicARN,
+	}
+}
+
+// Notify publishes the summary text to the topic
+func (n *SNSNotifier) Notify(ctx context.Context, s Summary) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	_, err := n.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(n.topicARN),
+		Subject:  aws.String(fmt.Sprintf("awsbreak %s in %s", s.Operation, s.Region)),
+		Message:  aws.String(Render(n.Template, s, s.Text())),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to SNS topic %s: %w", n.topicARN, err)
+	}
+	return nil
+}
+
+func postJSON(ctx context.Context, channel, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s message: %w", channel, err)
+	}
+	return post(ctx, channel, url, payload)
+}
+
+func post(ctx context.Context, channel, url string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", channel, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to %s: %w", channel, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", channel, resp.Status)
+	}
+	return nil
+}
+
+// actor names who ran awsbreak, as user@host
+func actor() string {
+	name := "someone"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		name += "@" + host
+	}
+	return name
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the helpers available to message templates in addition
+// to the text/template builtins
+var TemplateFuncs = template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParseTemplate parses a channel's message template. An empty text returns a
+// nil template, meaning the built-in message is used.
+func ParseTemplate(channel, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	t, err := template.New(channel).Funcs(TemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", channel, err)
+	}
+	return t, nil
+}
+
+// Render executes a message template. A nil template renders fallback, and so
+// does a template that fails, with the error appended so the broken template
+// is noticed without losing the message.
+func Render(t *template.Template, data any, fallback string) string {
+	if t == nil {
+		return fallback
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return fmt.Sprintf("%s\n⚠️ The %s template failed: %v\n", fallback, t.Name(), err)
+	}
+	return b.String()
+}
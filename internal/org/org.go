@@ -0,0 +1,164 @@
+package org
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// Account is an active member account of an organization
+type Account struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// AccountReport is the outcome of discovering and optionally braking one account
+type AccountReport struct {
+	Account   Account                  `json:"account"`
+	Resources []models.Resource        `json:"resources"`
+	Skipped   []models.Resource        `json:"skipped,omitempty"` // report-only or blocked by safety checks
+	Results   []models.OperationResult `json:"results,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// MonthlyCost returns the estimated monthly cost of the discovered resources
+func (r AccountReport) MonthlyCost() float64 {
+	var total float64
+	for _, res := range r.Resources {
+		total += res.CostPerHour * 24 * 30
+	}
+	return total
+}
+
+// Succeeded returns the number of successful operations
+func (r AccountReport) Succeeded() int {
+	count := 0
+	for _, res := range r.Results {
+		if res.Success {
+			count++
+		}
+	}
+	return count
+}
+
+// ListAccounts returns the active accounts in the organization. When parentID
+// is an organizational unit or root ID, only accounts beneath it (including
+// nested OUs) are returned.
+func ListAccounts(ctx context.Context, cfg aws.Config, parentID string) ([]Account, error) {
+	client := organizations.NewFromConfig(cfg)
+
+	var (
+		accounts []Account
+		err      error
+	)
+	if parentID == "" {
+		accounts, err = listAll(ctx, client)
+	} else {
+		accounts, err = listUnder(ctx, client, parentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts, nil
+}
+
+func listAll(ctx context.Context, client *organizations.Client) ([]Account, error) {
+	var accounts []Account
+
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization accounts: %w", err)
+		}
+		accounts = append(accounts, activeAccounts(page.Accounts)...)
+	}
+
+	return accounts, nil
+}
+
+func listUnder(ctx context.Context, client *organizations.Client, parentID string) ([]Account, error) {
+	var accounts []Account
+
+	paginator := organizations.NewListAccountsForParentPaginator(client, &organizations.ListAccountsForParentInput{
+		ParentId: aws.String(parentID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts in %s: %w", parentID, err)
+		}
+		accounts = append(accounts, activeAccounts(page.Accounts)...)
+	}
+
+	// Recurse into child OUs
+	ouPaginator := organizations.NewListOrganizationalUnitsForParentPaginator(client, &organizations.ListOrganizationalUnitsForParentInput{
+		ParentId: aws.String(parentID),
+	})
+	for ouPaginator.HasMorePages() {
+		page, err := ouPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organizational units in %s: %w", parentID, err)
+		}
+		for _, ou := range page.OrganizationalUnits {
+			children, err := listUnder(ctx, client, aws.ToString(ou.Id))
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, children...)
+		}
+	}
+
+	return accounts, nil
+}
+
+func activeAccounts(accounts []types.Account) []Account {
+	var active []Account
+	for _, a := range accounts {
+		if a.Status != types.AccountStatusActive {
+			continue
+		}
+		active = append(active, Account{
+			ID:    aws.ToString(a.Id),
+			Name:  aws.ToString(a.Name),
+			Email: aws.ToString(a.Email),
+		})
+	}
+	return active
+}
+
+// FilterAccounts keeps accounts whose ID or name is in include (when non-empty)
+// and not in exclude
+func FilterAccounts(accounts []Account, include, exclude []string) []Account {
+	in := make(map[string]bool)
+	for _, v := range include {
+		in[v] = true
+	}
+	out := make(map[string]bool)
+	for _, v := range exclude {
+		out[v] = true
+	}
+
+	var filtered []Account
+	for _, a := range accounts {
+		if len(in) > 0 && !in[a.ID] && !in[a.Name] {
+			continue
+		}
+		if out[a.ID] || out[a.Name] {
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return filtered
+}
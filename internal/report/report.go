@@ -0,0 +1,224 @@
+// Package report renders cost reports as CSV, HTML or JSON files to share
+// with people who don't run awsbreak.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+// Formats are the report formats Write supports
+var Formats = []string{"csv", "html", "json"}
+
+// Build creates a report from the resources running in a region and, if
+// not nil, the snapshot of the last pause there
+func Build(region string, running []models.Resource, snapshot *models.AccountSnapshot, at time.Time) *models.CostReport {
+	r := &models.CostReport{
+		Region:      region,
+		Resources:   byCost(running),
+		GeneratedAt: at,
+	}
+
+	var runningHourly float64
+	for _, res := range running {
+		runningHourly += res.CostPerHour
+		// Report-only resources can't be paused, so pausing saves nothing on them
+		if !services.IsReportOnly(res) {
+			r.HourlySavings += res.CostPerHour
+		}
+	}
+	r.DailySavings = r.HourlySavings * 24
+	r.MonthlySavings = r.HourlySavings * 24 * 30
+
+	if snapshot != nil {
+		paused := snapshot.PendingResources()
+		c := &models.CostComparison{
+			SnapshotID:   snapshot.SnapshotID,
+			PausedAt:     snapshot.Timestamp,
+			Paused:       byCost(paused),
+			AfterHourly:  runningHourly,
+			BeforeHourly: runningHourly,
+		}
+		for _, res := range paused {
+			c.BeforeHourly += res.CostPerHour
+		}
+		r.Comparison = c
+	}
+	return r
+}
+
+// byCost returns resources sorted from the most to the least expensive
+func byCost(resources []models.Resource) []models.Resource {
+	sorted := append([]models.Resource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CostPerHour > sorted[j].CostPerHour
+	})
+	return sorted
+}
+
+// Write renders a report in one of Formats
+func Write(w io.Writer, r *models.CostReport, format string) error {
+	switch format {
+	case "csv":
+		return writeCSV(w, r)
+	case "html":
+		return writeHTML(w, r)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// row is one resource line of a report
+type row struct {
+	Resource models.Resource
+	Status   string // "running" or "paused"
+}
+
+// rows lists running resources, then those still paused
+func rows(r *models.CostReport) []*row {
+	var out []*row
+	for _, res := range r.Resources {
+		out = append(out, &row{Resource: res, Status: "running"})
+	}
+	if r.Comparison != nil {
+		for _, res := range r.Comparison.Paused {
+			out = append(out, &row{Resource: res, Status: "paused"})
+		}
+	}
+	return out
+}
+
+func writeCSV(w io.Writer, r *models.CostReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"status", "service", "resource_id", "name", "region", "state",
+		"hourly_cost", "daily_cost", "monthly_cost", "tags"})
+	for _, row := range rows(r) {
+		res := row.Resource
+		cw.Write([]string{
+			row.Status,
+			string(res.ServiceType),
+			res.ResourceID,
+			res.Tags["Name"],
+			res.Region,
+			string(res.CurrentState),
+			fmt.Sprintf("%.4f", res.CostPerHour),
+			fmt.Sprintf("%.2f", res.CostPerHour*24),
+			fmt.Sprintf("%.2f", res.CostPerHour*24*30),
+			formatTags(res.Tags),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+func writeHTML(w io.Writer, r *models.CostReport) error {
+	data := struct {
+		*models.CostReport
+		Rows []*row
+	}{r, rows(r)}
+	if err := htmlTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// formatTags renders tags as "key=value; key=value", sorted by key
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, "; ")
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"money":      func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"day":        func(hourly float64) string { return fmt.Sprintf("$%.2f", hourly*24) },
+	"month":      func(hourly float64) string { return fmt.Sprintf("$%.2f", hourly*24*30) },
+	"date":       func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"tags":       formatTags,
+	"reportOnly": services.IsReportOnly,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>awsbreak cost report{{if .Region}} - {{.Region}}{{end}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #222; }
+  h1 { margin-bottom: 0.2rem; }
+  .meta { color: #666; margin-bottom: 1.5rem; }
+  .cards { display: flex; gap: 1rem; flex-wrap: wrap; margin-bottom: 1.5rem; }
+  .card { border: 1px solid #ddd; border-radius: 6px; padding: 0.8rem 1.2rem; min-width: 10rem; }
+  .card .value { font-size: 1.5rem; font-weight: 600; }
+  .card .label { color: #666; font-size: 0.85rem; }
+  table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+  th, td { border-bottom: 1px solid #eee; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+  th { background: #f6f6f6; }
+  td.num { text-align: right; font-variant-numeric: tabular-nums; }
+  tr.paused td { color: #2a7a2a; }
+  .tags { color: #666; font-size: 0.8rem; }
+</style>
+</head>
+<body>
+<h1>awsbreak cost report</h1>
+<div class="meta">{{if .Region}}{{.Region}} · {{end}}generated {{date .GeneratedAt}}</div>
+
+<div class="cards">
+  <div class="card"><div class="value">{{money .MonthlySavings}}</div><div class="label">could be saved per month by pausing what runs now</div></div>
+  <div class="card"><div class="value">{{money .DailySavings}}</div><div class="label">could be saved per day</div></div>
+  {{- with .Comparison}}
+  <div class="card"><div class="value">{{month .BeforeHourly}}</div><div class="label">per month before the pause of {{date .PausedAt}}</div></div>
+  <div class="card"><div class="value">{{month .AfterHourly}}</div><div class="label">per month now</div></div>
+  <div class="card"><div class="value">{{month .SavedHourly}}</div><div class="label">saved per month by snapshot {{.SnapshotID}}</div></div>
+  {{- end}}
+</div>
+
+<table>
+  <tr>
+    <th>Status</th><th>Service</th><th>Resource</th><th>Region</th><th>State</th>
+    <th>Hourly</th><th>Daily</th><th>Monthly</th>
+  </tr>
+  {{- range .Rows}}
+  {{- $r := .Resource}}
+  <tr{{if eq .Status "paused"}} class="paused"{{end}}>
+    <td>{{.Status}}{{if reportOnly $r}} (report only){{end}}</td>
+    <td>{{$r.ServiceType}}</td>
+    <td>{{with $r.ConsoleURL}}<a href="{{.}}">{{$r.ResourceID}}</a>{{else}}{{$r.ResourceID}}{{end}}{{with index $r.Tags "Name"}}<br>{{.}}{{end}}{{with tags $r.Tags}}<div class="tags">{{.}}</div>{{end}}</td>
+    <td>{{$r.Region}}</td>
+    <td>{{$r.CurrentState}}</td>
+    <td class="num">{{printf "$%.4f" $r.CostPerHour}}</td>
+    <td class="num">{{day $r.CostPerHour}}</td>
+    <td class="num">{{month $r.CostPerHour}}</td>
+  </tr>
+  {{- else}}
+  <tr><td colspan="8">Nothing running or paused.</td></tr>
+  {{- end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,156 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/state"
+)
+
+const armedFileName = "armed.json"
+
+// ClaimLease is how long a claim on a firing brake holds. After it, a brake
+// left firing by a runner that crashed can be claimed again.
+const ClaimLease = time.Hour
+
+// AbandonAfter is how long past due a brake armed in the foreground may stay
+// listed before it is taken to be abandoned
+const AbandonAfter = 15 * time.Minute
+
+// Armed is a brake set to pause a region later, with 'awsbreak --after' or
+// a scheduled or Lambda pause with a grace period. Until At, anyone can call it off
+// with 'awsbreak cancel'.
+type Armed struct {
+	Region    string    `json:"region"`
+	Plan      string    `json:"plan,omitempty"` // pause only the resources of this plan
+	Requester string    `json:"requester"`
+	Source    string    `json:"source"` // what armed it, e.g. "--after" or "schedule nightly"
+	Armed     time.Time `json:"armed"`
+	At        time.Time `json:"at"`
+	// Daemon brakes are fired by 'awsbreak daemon' and Lambda ones by the
+	// Lambda runner; the others by the awsbreak process that armed them,
+	// which waits in the foreground
+	Daemon bool `json:"daemon,omitempty"`
+	Lambda bool `json:"lambda,omitempty"`
+	// Firing is set while a daemon pauses the region, under a claim that
+	// lasts ClaimLease; the brake can no longer be called off, and stays
+	// listed until the pause succeeds
+	Firing    bool      `json:"firing,omitempty"`
+	Claimed   time.Time `json:"claimed,omitzero"`     // when Firing was set
+	ClaimedBy string    `json:"claimed_by,omitempty"` // who set it, e.g. "user@host (pid 123)"
+}
+
+// Same reports whether two records are the same armed brake
+func (a Armed) Same(other Armed) bool {
+	return a.Region == other.Region && a.Armed.Equal(other.Armed)
+}
+
+// Abandoned reports whether a brake armed in the foreground was left behind
+// by its awsbreak process, which was killed or put to sleep: it was due more
+// than AbandonAfter before now and never fired
+func (a Armed) Abandoned(now time.Time) bool {
+	return !a.Daemon && !a.Lambda && !a.Firing && now.Sub(a.At) > AbandonAfter
+}
+
+// Claimable reports whether the brake is free to fire at now: not firing, or
+// left firing by a claim whose lease ran out
+func (a Armed) Claimable(now time.Time) bool {
+	return !a.Firing || now.Sub(a.Claimed) > ClaimLease
+}
+
+// Claim marks a brake as firing for owner. It returns false if the brake is
+// no longer armed or another runner holds a live claim on it, so two runners
+// sharing the store never fire the same brake.
+func Claim(ctx context.Context, store state.DocumentStore, armed Armed, owner string, now time.Time) (bool, error) {
+	var claimed bool
+	err := UpdateArmed(ctx, store, func(all []Armed) []Armed {
+		claimed = false
+		for i := range all {
+			if all[i].Same(armed) && all[i].Claimable(now) {
+				all[i].Firing, all[i].Claimed, all[i].ClaimedBy = true, now, owner
+				claimed = true
+			}
+		}
+		return all
+	})
+	return claimed, err
+}
+
+// Release drops the claim on a brake that failed to fire, so it is retried
+// or can be called off
+func Release(ctx context.Context, store state.DocumentStore, armed Armed) error {
+	return UpdateArmed(ctx, store, func(all []Armed) []Armed {
+		for i := range all {
+			if all[i].Same(armed) {
+				all[i].Firing, all[i].Claimed, all[i].ClaimedBy = false, time.Time{}, ""
+			}
+		}
+		return all
+	})
+}
+
+// Disarm takes a brake off the list, returning false if it was no longer
+// there
+func Disarm(ctx context.Context, store state.DocumentStore, armed Armed) (bool, error) {
+	var found bool
+	err := UpdateArmed(ctx, store, func(all []Armed) []Armed {
+		found = slices.ContainsFunc(all, armed.Same)
+		return slices.DeleteFunc(all, armed.Same)
+	})
+	return found, err
+}
+
+// armedUpdateAttempts bounds how often UpdateArmed retries when the armed
+// brakes change under it
+const armedUpdateAttempts = 5
+
+// LoadArmed reads the armed brakes, returning none if nothing is armed
+func LoadArmed(ctx context.Context, store state.DocumentStore) ([]Armed, error) {
+	armed, _, err := readArmed(ctx, store)
+	return armed, err
+}
+
+// UpdateArmed replaces the armed brakes with what update returns for the
+// current ones. update is run again on the fresh list if another process
+// changed it in between, so it must not have side effects.
+func UpdateArmed(ctx context.Context, store state.DocumentStore, update func([]Armed) []Armed) error {
+	for range armedUpdateAttempts {
+		armed, version, err := readArmed(ctx, store)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(update(armed), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal armed brakes: %w", err)
+		}
+		err = store.Write(ctx, armedFileName, data, version)
+		if !errors.Is(err, state.ErrConflict) {
+			if err != nil {
+				return fmt.Errorf("failed to save armed brakes: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to save armed brakes: they kept changing")
+}
+
+func readArmed(ctx context.Context, store state.DocumentStore) ([]Armed, string, error) {
+	data, version, err := store.Read(ctx, armedFileName)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read armed brakes: %w", err)
+	}
+	if data == nil {
+		return nil, "", nil
+	}
+
+	var armed []Armed
+	if err := json.Unmarshal(data, &armed); err != nil {
+		return nil, "", fmt.Errorf("failed to parse armed brakes: %w", err)
+	}
+	return armed, version, nil
+}
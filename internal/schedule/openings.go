@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const openingsFileName = "openings.json"
+
+// Opening is a group resumed for a limited time with 'awsbreak open'. The
+// daemon warns the requester at WarnAt and pauses the group again at Until.
+type Opening struct {
+	Group     string    `json:"group"`
+	Region    string    `json:"region"`
+	Tag       string    `json:"tag"` // key or key=value selecting the group's resources
+	Requester string    `json:"requester"`
+	Opened    time.Time `json:"opened"`
+	Until     time.Time `json:"until"`
+	WarnAt    time.Time `json:"warn_at"`
+	Warned    bool      `json:"warned,omitempty"`
+	// SnapshotID is the snapshot the group was resumed from; the re-pause is
+	// recorded in it, so one resume brings back the whole region
+	SnapshotID string `json:"snapshot_id,omitempty"`
+}
+
+// Same reports whether two openings are for the same group in the same region
+func (o Opening) Same(other Opening) bool {
+	return o.Group == other.Group && o.Region == other.Region
+}
+
+// LoadOpenings reads the open groups, returning none if nothing is open
+func LoadOpenings(configDir string) ([]Opening, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, openingsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read open groups: %w", err)
+	}
+
+	var openings []Opening
+	if err := json.Unmarshal(data, &openings); err != nil {
+		return nil, fmt.Errorf("failed to parse open groups: %w", err)
+	}
+	return openings, nil
+}
+
+// SaveOpenings writes the open groups atomically
+func SaveOpenings(configDir string, openings []Opening) error {
+	data, err := json.MarshalIndent(openings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal open groups: %w", err)
+	}
+
+	path := filepath.Join(configDir, openingsFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write open groups: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save open groups: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+var (
+	dayNames = map[string]time.Weekday{
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+
+	dayGroups = map[string][]string{
+		"daily":    {"mon", "tue", "wed", "thu", "fri", "sat", "sun"},
+		"weekdays": {"mon", "tue", "wed", "thu", "fri"},
+		"weekends": {"sat", "sun"},
+	}
+)
+
+// ParseDays parses a comma-separated day list such as "weekdays", "sat,sun" or
+// "monday,friday" into normalized three-letter day names
+func ParseDays(spec string) ([]string, error) {
+	seen := make(map[string]bool)
+	var days []string
+
+	add := func(day string) {
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	for _, part := range strings.Split(strings.ToLower(spec), ",") {
+		part = strings.TrimSpace(part)
+		if group, ok := dayGroups[part]; ok {
+			for _, day := range group {
+				add(day)
+			}
+			continue
+		}
+		if len(part) >= 3 {
+			if _, ok := dayNames[part[:3]]; ok {
+				add(part[:3])
+				continue
+			}
+		}
+		return nil, fmt.Errorf("invalid day %q: use mon-sun, weekdays, weekends or daily", part)
+	}
+
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no days given")
+	}
+	return days, nil
+}
+
+// ParseClock parses a 24-hour HH:MM time of day
+func ParseClock(at string) (hour, minute int, err error) {
+	h, m, ok := strings.Cut(at, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid time %q: expected HH:MM", at)
+	}
+
+	hour, err = strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid hour in %q", at)
+	}
+	minute, err = strconv.Atoi(m)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid minute in %q", at)
+	}
+
+	return hour, minute, nil
+}
+
+// Validate checks that a schedule is well formed
+func Validate(s models.Schedule) error {
+	if s.Name == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	if s.Action != "pause" && s.Action != "resume" {
+		return fmt.Errorf("invalid action %q: expected pause or resume", s.Action)
+	}
+	if _, err := ParseDays(strings.Join(s.Days, ",")); err != nil {
+		return err
+	}
+	if _, _, err := ParseClock(s.At); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Next returns the first time strictly after the given time at which the
+// schedule fires, in the location of after
+func Next(s models.Schedule, after time.Time) (time.Time, error) {
+	hour, minute, err := ParseClock(s.At)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	days := make(map[time.Weekday]bool)
+	for _, d := range s.Days {
+		wd, ok := dayNames[d]
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid day %q in schedule %s", d, s.Name)
+		}
+		days[wd] = true
+	}
+
+	// Check today plus the next seven days so a single-day schedule always matches
+	for i := 0; i <= 7; i++ {
+		day := after.AddDate(0, 0, i)
+		candidate := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, after.Location())
+		if days[candidate.Weekday()] && candidate.After(after) {
+			return candidate, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("schedule %s has no days", s.Name)
+}
+
+// On returns when the schedule fires on the day of t, in t's location, and
+// whether it fires that day at all
+func On(s models.Schedule, t time.Time) (time.Time, bool) {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	next, err := Next(s, start.Add(-time.Nanosecond))
+	if err != nil || next.YearDay() != start.YearDay() || next.Year() != start.Year() {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// Shift moves a schedule's time of day by d, staying within the same day:
+// shifts past midnight stop at 00:00 or 23:59
+func Shift(s models.Schedule, d time.Duration) (models.Schedule, error) {
+	hour, minute, err := ParseClock(s.At)
+	if err != nil {
+		return s, err
+	}
+
+	minutes := hour*60 + minute + int(d.Minutes())
+	minutes = max(0, min(minutes, 23*60+59))
+	s.At = fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+	return s, nil
+}
+
+// Due returns the schedules that fired in the window (since, now]
+func Due(schedules []models.Schedule, since, now time.Time) []models.Schedule {
+	var due []models.Schedule
+	for _, s := range schedules {
+		next, err := Next(s, since)
+		if err != nil {
+			continue
+		}
+		if !next.After(now) {
+			due = append(due, s)
+		}
+	}
+	return due
+}
+
+// Describe returns a short human readable description of a schedule
+func Describe(s models.Schedule) string {
+	days := strings.Join(s.Days, ",")
+	for name, group := range dayGroups {
+		if strings.Join(group, ",") == days {
+			days = name
+			break
+		}
+	}
+	return fmt.Sprintf("%s %s at %s", s.Action, days, s.At)
+}
@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFileName = "daemon-state.json"
+
+// State is the scheduler state the daemon persists between restarts
+type State struct {
+	LastCheck time.Time `json:"last_check"`
+	// Ran maps schedules that already ran in the window after LastCheck to
+	// the time they were due, so a daemon stopped part way through a batch
+	// doesn't run them again on restart
+	Ran map[string]time.Time `json:"ran,omitempty"`
+}
+
+// LoadState reads the scheduler state, returning an empty state if none is saved
+func LoadState(configDir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, stateFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, fmt.Errorf("failed to read scheduler state: %w", err)
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduler state: %w", err)
+	}
+	return &st, nil
+}
+
+// SaveState writes the scheduler state atomically
+func SaveState(configDir string, st *State) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduler state: %w", err)
+	}
+
+	path := filepath.Join(configDir, stateFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write scheduler state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save scheduler state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,491 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/inventory"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// DefaultPageSize is the number of resources returned when no limit is given
+	DefaultPageSize = 50
+	// MaxPageSize caps the limit query parameter
+	MaxPageSize = 500
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// SnapshotLister lists saved snapshots
+type SnapshotLister interface {
+	Snapshots(ctx context.Context) ([]*models.AccountSnapshot, error)
+}
+
+// Controller applies and releases brakes on behalf of HTTP clients
+type Controller interface {
+	SnapshotLister
+	Pause(ctx context.Context, region string) ([]models.OperationResult, error)
+	Resume(ctx context.Context, region string) ([]models.OperationResult, error)
+}
+
+// Server exposes awsbreak state over HTTP
+type Server struct {
+	cache      *inventory.Cache
+	controller Controller
+	snapshots  SnapshotLister
+	spectator  bool
+	region     string
+
+	// ctx outlives requests, so a client going away doesn't stop a brake
+	// half way through
+	ctx context.Context
+	// host is the host name the server was bound to, accepted in Host headers
+	host string
+
+	// opMu serializes pause and resume so two clicks can't race each other
+	opMu sync.Mutex
+}
+
+// ResourcePage is a page of resources returned by /resources
+type ResourcePage struct {
+	Resources  []models.Resource `json:"resources"`
+	Total      int               `json:"total"`
+	Offset     int               `json:"offset"`
+	Limit      int               `json:"limit"`
+	NextOffset *int              `json:"next_offset,omitempty"`
+	Refreshed  time.Time         `json:"refreshed"`
+}
+
+// Summary is the burn rate overview returned by /summary
+type Summary struct {
+	Region        string    `json:"region"`
+	Resources     int       `json:"resources"`
+	Actionable    int       `json:"actionable"`
+	BurnPerHour   float64   `json:"burn_per_hour"`
+	BurnPerMonth  float64   `json:"burn_per_month"`
+	Paused        int       `json:"paused"`
+	SavingPerHour float64   `json:"saving_per_hour"`
+	ReadOnly      bool      `json:"read_only"`
+	Spectator     bool      `json:"spectator"`
+	Refreshed     time.Time `json:"refreshed"`
+}
+
+// OperationResponse is returned by /pause and /resume
+type OperationResponse struct {
+	Results   []models.OperationResult `json:"results"`
+	Succeeded int                      `json:"succeeded"`
+	Failed    int                      `json:"failed"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// NewServer creates a new server for the given region. A nil controller makes
+// the server read-only.
+func NewServer(cache *inventory.Cache, controller Controller, region string) *Server {
+	s := &Server{
+		cache:      cache,
+		controller: controller,
+		region:     region,
+		ctx:        context.Background(),
+	}
+	if controller != nil {
+		s.snapshots = controller
+	}
+	return s
+}
+
+// NewSpectatorServer creates a read-only server for stakeholders. It shows
+// parked resources and snapshots like a full server, but has no controller,
+// so nothing it serves can pause or resume.
+func NewSpectatorServer(cache *inventory.Cache, snapshots SnapshotLister, region string) *Server {
+	return &Server{
+		cache:     cache,
+		snapshots: snapshots,
+		spectator: true,
+		region:    region,
+		ctx:       context.Background(),
+	}
+}
+
+// ListenAndServe serves the handler on addr until it fails. Pause and resume
+// run on ctx rather than the request's context, so they finish even if the
+// browser that started them disconnects.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	s.ctx = ctx
+	s.host = host
+
+	srv := &http.Server{
+		Addr:        addr,
+		Handler:     s.Handler(),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	return srv.ListenAndServe()
+}
+
+// Handler returns the HTTP handler for the server
+func (s *Server) Handler() http.Handler {
+	static, _ := fs.Sub(staticFiles, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /resources", s.handleResources)
+	mux.HandleFunc("GET /summary", s.handleSummary)
+	mux.HandleFunc("GET /snapshots", s.handleSnapshots)
+	mux.HandleFunc("POST /pause", s.handleOperation("pause"))
+	mux.HandleFunc("POST /resume", s.handleOperation("resume"))
+	return s.checkHost(mux)
+}
+
+// checkHost refuses requests whose Host header isn't an IP address,
+// localhost or the host the server is bound to. A DNS rebinding attack
+// reaches the server under the attacker's own domain name, so it is turned
+// away here even though the connection comes from the local browser.
+func (s *Server) checkHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+
+		allowed := net.ParseIP(host) != nil ||
+			strings.EqualFold(host, "localhost") ||
+			(s.host != "" && strings.EqualFold(host, s.host))
+		if !allowed {
+			writeError(w, http.StatusMisdirectedRequest, "unexpected Host header")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleResources serves the filtered, paginated inventory. Supported query
+// parameters: service, tag (key or key=value), region, state, min_cost (hourly
+// USD), limit and offset.
+func (s *Server) handleResources(w http.ResponseWriter, r *http.Request) {
+	view, err := s.inventory(r)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, offset, err := parsePaging(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var matched []models.Resource
+	for _, res := range view.Resources {
+		if filter.matches(res) {
+			matched = append(matched, res)
+		}
+	}
+
+	page := ResourcePage{
+		Resources: []models.Resource{},
+		Total:     len(matched),
+		Offset:    offset,
+		Limit:     limit,
+		Refreshed: view.Refreshed,
+	}
+	if offset < len(matched) {
+		end := min(offset+limit, len(matched))
+		page.Resources = matched[offset:end]
+		if end < len(matched) {
+			page.NextOffset = &end
+		}
+	}
+
+	writeJSON(w, r, http.StatusOK, page)
+}
+
+// handleSummary serves totals for the dashboard header
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	view, err := s.inventory(r)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	summary := Summary{
+		Region:    s.region,
+		Resources: len(view.Resources),
+		ReadOnly:  s.controller == nil,
+		Spectator: s.spectator,
+		Refreshed: view.Refreshed,
+	}
+	for _, res := range view.Resources {
+		summary.BurnPerHour += res.CostPerHour
+		if reportOnly, _ := res.Metadata["report_only"].(bool); !reportOnly {
+			summary.Actionable++
+		}
+	}
+	summary.BurnPerMonth = summary.BurnPerHour * 24 * 30
+
+	if s.snapshots != nil {
+		snapshots, err := s.snapshots.Snapshots(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, snap := range snapshots {
+			if snap.Region != s.region {
+				continue
+			}
+			for _, res := range snap.PendingResources() {
+				summary.Paused++
+				summary.SavingPerHour += res.CostPerHour
+			}
+		}
+	}
+
+	writeJSON(w, r, http.StatusOK, summary)
+}
+
+// handleSnapshots lists saved snapshots, newest first
+func (s *Server) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if s.snapshots == nil {
+		writeJSON(w, r, http.StatusOK, []*models.AccountSnapshot{})
+		return
+	}
+
+	snapshots, err := s.snapshots.Snapshots(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if snapshots == nil {
+		snapshots = []*models.AccountSnapshot{}
+	}
+
+	writeJSON(w, r, http.StatusOK, snapshots)
+}
+
+// handleOperation pauses or resumes the region. Requests must be JSON with
+// {"confirm": true}; requiring a JSON content type also forces browsers to
+// preflight cross-origin requests, so other sites can't trigger a brake.
+func (s *Server) handleOperation(operation string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.controller == nil {
+			message := "server is read-only"
+			if s.spectator {
+				message = "server is in spectator mode"
+			}
+			writeError(w, http.StatusForbidden, message)
+			return
+		}
+
+		if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "expected application/json")
+			return
+		}
+
+		var body struct {
+			Confirm bool `json:"confirm"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil || !body.Confirm {
+			writeError(w, http.StatusBadRequest, `confirmation required: send {"confirm": true}`)
+			return
+		}
+
+		if !s.opMu.TryLock() {
+			writeError(w, http.StatusConflict, "another operation is in progress")
+			return
+		}
+		defer s.opMu.Unlock()
+
+		var (
+			results []models.OperationResult
+			err     error
+		)
+		if operation == "pause" {
+			results, err = s.controller.Pause(s.ctx, s.region)
+		} else {
+			results, err = s.controller.Resume(s.ctx, s.region)
+		}
+
+		// The inventory is stale whatever happened
+		s.cache.Invalidate(s.region)
+
+		resp := OperationResponse{Results: results}
+		if resp.Results == nil {
+			resp.Results = []models.OperationResult{}
+		}
+		for _, res := range results {
+			if res.Success {
+				resp.Succeeded++
+			} else {
+				resp.Failed++
+			}
+		}
+
+		status := http.StatusOK
+		if err != nil {
+			resp.Error = err.Error()
+			status = http.StatusBadGateway
+		}
+		writeJSON(w, r, status, resp)
+	}
+}
+
+// inventory returns the region's inventory, rediscovering it when stale or
+// when the client passes refresh=true
+func (s *Server) inventory(r *http.Request) (*inventory.View, error) {
+	refresh := inventory.RefreshIfStale
+	if r.URL.Query().Get("refresh") == "true" {
+		refresh = inventory.RefreshAlways
+	}
+	return s.cache.Get(r.Context(), s.region, refresh)
+}
+
+type resourceFilter struct {
+	services map[models.ServiceType]bool
+	regions  map[string]bool
+	states   map[models.ResourceState]bool
+	tagKey   string
+	tagValue string
+	hasValue bool
+	minCost  float64
+}
+
+func parseFilter(r *http.Request) (resourceFilter, error) {
+	q := r.URL.Query()
+	f := resourceFilter{}
+
+	if v := q.Get("service"); v != "" {
+		f.services = make(map[models.ServiceType]bool)
+		for _, svc := range strings.Split(v, ",") {
+			f.services[models.ServiceType(strings.TrimSpace(svc))] = true
+		}
+	}
+	if v := q.Get("region"); v != "" {
+		f.regions = make(map[string]bool)
+		for _, region := range strings.Split(v, ",") {
+			f.regions[strings.TrimSpace(region)] = true
+		}
+	}
+	if v := q.Get("state"); v != "" {
+		f.states = make(map[models.ResourceState]bool)
+		for _, st := range strings.Split(v, ",") {
+			f.states[models.ResourceState(strings.TrimSpace(st))] = true
+		}
+	}
+	if v := q.Get("tag"); v != "" {
+		f.tagKey, f.tagValue, f.hasValue = strings.Cut(v, "=")
+	}
+	if v := q.Get("min_cost"); v != "" {
+		cost, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return f, errBadParam("min_cost")
+		}
+		f.minCost = cost
+	}
+
+	return f, nil
+}
+
+func (f resourceFilter) matches(r models.Resource) bool {
+	if f.services != nil && !f.services[r.ServiceType] {
+		return false
+	}
+	if f.regions != nil && !f.regions[r.Region] {
+		return false
+	}
+	if f.states != nil && !f.states[r.CurrentState] {
+		return false
+	}
+	if f.tagKey != "" {
+		value, ok := r.Tags[f.tagKey]
+		if !ok || (f.hasValue && value != f.tagValue) {
+			return false
+		}
+	}
+	return r.CostPerHour >= f.minCost
+}
+
+func parsePaging(r *http.Request) (limit, offset int, err error) {
+	q := r.URL.Query()
+
+	limit = DefaultPageSize
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return 0, 0, errBadParam("limit")
+		}
+		limit = min(limit, MaxPageSize)
+	}
+
+	if v := q.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errBadParam("offset")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// writeJSON writes a JSON response. Successful GETs carry an ETag and are
+// answered with 304 when the client already has the current representation;
+// actions and errors always get their body.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.Method == http.MethodGet && status == http.StatusOK {
+		sum := sha256.Sum256(data)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+
+		if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func errBadParam(name string) error {
+	return fmt.Errorf("invalid value for %s", name)
+}
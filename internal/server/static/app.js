@@ -0,0 +1,146 @@
+"use strict";
+
+const PAGE_SIZE = 50;
+let offset = 0;
+
+const $ = (id) => document.getElementById(id);
+const money = (n) => "$" + n.toFixed(2);
+
+async function getJSON(path) {
+  const resp = await fetch(path);
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+function cell(row, text, className) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  if (className) td.className = className;
+  row.appendChild(td);
+}
+
+function linkCell(row, text, href) {
+  if (!href) return cell(row, text);
+  const td = document.createElement("td");
+  const link = document.createElement("a");
+  link.textContent = text;
+  link.href = href;
+  link.target = "_blank";
+  link.rel = "noopener";
+  td.appendChild(link);
+  row.appendChild(td);
+}
+
+async function loadSummary(refresh) {
+  const s = await getJSON("/summary" + (refresh ? "?refresh=true" : ""));
+  $("region").textContent = s.region + " · refreshed " + new Date(s.refreshed).toLocaleTimeString();
+  $("burn").textContent = money(s.burn_per_month) + "/mo";
+  $("burn-hourly").textContent = money(s.burn_per_hour) + " per hour";
+  $("running").textContent = s.resources;
+  $("actionable").textContent = s.actionable + " can be stopped";
+  $("paused").textContent = s.paused;
+  $("saving").textContent = "saving " + money(s.saving_per_hour * 24 * 30) + "/mo";
+  $("actions").hidden = s.read_only;
+  $("spectator").hidden = !s.spectator;
+}
+
+async function loadResources() {
+  const params = new URLSearchParams({ limit: PAGE_SIZE, offset: offset });
+  if ($("filter-service").value) params.set("service", $("filter-service").value);
+  if ($("filter-tag").value.trim()) params.set("tag", $("filter-tag").value.trim());
+
+  const page = await getJSON("/resources?" + params);
+  const body = $("resources");
+  body.replaceChildren();
+
+  for (const r of page.resources) {
+    const row = document.createElement("tr");
+    cell(row, r.service_type);
+    linkCell(row, r.resource_id, r.console_url);
+    cell(row, r.current_state + (r.metadata && r.metadata.report_only ? " (report only)" : ""));
+    cell(row, money((r.cost_per_hour || 0) * 24 * 30), "num");
+    body.appendChild(row);
+  }
+  if (page.resources.length === 0) {
+    const row = document.createElement("tr");
+    cell(row, "✅ Nothing burning money.", "muted");
+    body.appendChild(row);
+  }
+
+  const last = Math.min(page.offset + page.resources.length, page.total);
+  $("page").textContent = page.total ? (page.offset + 1) + "–" + last + " of " + page.total : "";
+  $("prev").disabled = page.offset === 0;
+  $("next").disabled = page.next_offset == null;
+}
+
+async function loadSnapshots() {
+  const snapshots = await getJSON("/snapshots");
+  const body = $("snapshots");
+  body.replaceChildren();
+
+  for (const s of snapshots) {
+    const resumed = s.resumed ? Object.keys(s.resumed).length : 0;
+    const row = document.createElement("tr");
+    cell(row, s.snapshot_id);
+    cell(row, new Date(s.timestamp).toLocaleString());
+    cell(row, s.region);
+    cell(row, s.resources.length, "num");
+    cell(row, s.resources.length - resumed, "num");
+    body.appendChild(row);
+  }
+  if (snapshots.length === 0) {
+    const row = document.createElement("tr");
+    cell(row, "No snapshots yet.", "muted");
+    body.appendChild(row);
+  }
+}
+
+async function refresh(force) {
+  try {
+    await loadSummary(force);
+    await Promise.all([loadResources(), loadSnapshots()]);
+  } catch (err) {
+    $("status").textContent = "❌ " + err.message;
+  }
+}
+
+async function operate(operation, question) {
+  if (!confirm(question)) return;
+
+  $("pause").disabled = $("resume").disabled = true;
+  $("status").textContent = operation === "pause" ? "🛑 Stopping resources…" : "🚀 Starting resources…";
+
+  try {
+    const resp = await fetch("/" + operation, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ confirm: true }),
+    });
+    const body = await resp.json();
+    if (body.error) {
+      $("status").textContent = "❌ " + body.error;
+    } else {
+      $("status").textContent = "✅ " + body.succeeded + " succeeded, " + body.failed + " failed";
+    }
+  } catch (err) {
+    $("status").textContent = "❌ " + err.message;
+  } finally {
+    $("pause").disabled = $("resume").disabled = false;
+    offset = 0;
+    refresh(false);
+  }
+}
+
+$("refresh").onclick = () => refresh(true);
+$("pause").onclick = () => operate("pause",
+  "Hit the brakes on every running resource in this region?\n\nDatabases with live connections are skipped.");
+$("resume").onclick = () => operate("resume", "Release brakes and start everything from the latest snapshot?");
+$("prev").onclick = () => { offset = Math.max(0, offset - PAGE_SIZE); loadResources(); };
+$("next").onclick = () => { offset += PAGE_SIZE; loadResources(); };
+$("filter-service").onchange = () => { offset = 0; loadResources(); };
+$("filter-tag").onchange = () => { offset = 0; loadResources(); };
+
+refresh(false);
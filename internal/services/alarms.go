@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// AlarmWatcher reports CloudWatch alarms that start firing after a baseline,
+// such as alarms tripped by pausing a canary subset
+type AlarmWatcher struct {
+	client   *cloudwatch.Client
+	prefixes []string
+	baseline map[string]bool
+}
+
+// NewAlarmWatcher creates a watcher for alarms whose names start with one of
+// prefixes, or every alarm in the region if there are none
+func NewAlarmWatcher(cfg aws.Config, prefixes []string) *AlarmWatcher {
+	return &AlarmWatcher{
+		client:   cloudwatch.NewFromConfig(cfg),
+		prefixes: prefixes,
+	}
+}
+
+// Baseline records the alarms already firing, which Firing then ignores
+func (w *AlarmWatcher) Baseline(ctx context.Context) error {
+	firing, err := w.inAlarm(ctx)
+	if err != nil {
+		return err
+	}
+	w.baseline = make(map[string]bool)
+	for _, name := range firing {
+		w.baseline[name] = true
+	}
+	return nil
+}
+
+// Firing returns the alarms in the ALARM state that weren't at the baseline
+func (w *AlarmWatcher) Firing(ctx context.Context) ([]string, error) {
+	firing, err := w.inAlarm(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []string
+	for _, name := range firing {
+		if !w.baseline[name] {
+			fresh = append(fresh, name)
+		}
+	}
+	return fresh, nil
+}
+
+// inAlarm lists metric and composite alarms in the ALARM state
+func (w *AlarmWatcher) inAlarm(ctx context.Context) ([]string, error) {
+	prefixes := w.prefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, prefix := range prefixes {
+		input := &cloudwatch.DescribeAlarmsInput{
+			StateValue: types.StateValueAlarm,
+			AlarmTypes: []types.AlarmType{types.AlarmTypeMetricAlarm, types.AlarmTypeCompositeAlarm},
+		}
+		if prefix != "" {
+			input.AlarmNamePrefix = aws.String(prefix)
+		}
+
+		paginator := cloudwatch.NewDescribeAlarmsPaginator(w.client, input)
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe CloudWatch alarms: %w", err)
+			}
+			for _, a := range output.MetricAlarms {
+				if name := aws.ToString(a.AlarmName); !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			for _, a := range output.CompositeAlarms {
+				if name := aws.ToString(a.AlarmName); !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/appstream"
+	"github.com/aws/aws-sdk-go-v2/service/appstream/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// appStreamRates are approximate hourly prices per streaming instance type
+var appStreamRates = map[string]float64{
+	"stream.standard.small":  0.05,
+	"stream.standard.medium": 0.10,
+	"stream.standard.large":  0.20,
+	"stream.compute.large":   0.22,
+	"stream.memory.large":    0.30,
+}
+
+// AppStreamServiceManager handles Amazon AppStream 2.0 fleet operations.
+// Elastic fleets have no standing instances and are left alone.
+type AppStreamServiceManager struct {
+	client *appstream.Client
+	region string
+}
+
+// NewAppStreamServiceManager creates a new AppStream service manager
+func NewAppStreamServiceManager(cfg aws.Config) *AppStreamServiceManager {
+	return &AppStreamServiceManager{
+		client: appstream.NewFromConfig(cfg),
+		region: cfg.Region,
+	}
+}
+
+// ServiceType returns the service type
+func (m *AppStreamServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceAppStream
+}
+
+// Capabilities reports that fleets are stopped in place
+func (m *AppStreamServiceManager) Capabilities() Capabilities {
+	return Capabilities{Stop: true}
+}
+
+// Discover finds running Always-On and On-Demand fleets. A fleet whose tags
+// can't be read is skipped, since protection rules depend on them.
+func (m *AppStreamServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+	var skipped []error
+
+	input := &appstream.DescribeFleetsInput{}
+	for {
+		output, err := m.client.DescribeFleets(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe AppStream fleets: %w", err)
+		}
+
+		for _, fleet := range output.Fleets {
+			if fleet.State != types.FleetStateRunning || fleet.FleetType == types.FleetTypeElastic {
+				continue
+			}
+
+			tags, err := m.tags(ctx, fleet)
+			if err != nil {
+				logging.Warn("skipping AppStream fleet", "fleet", aws.ToString(fleet.Name), "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			resources = append(resources, m.fleetToResource(fleet, tags, region))
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
+	return resources, nil
+}
+
+func (m *AppStreamServiceManager) tags(ctx context.Context, fleet types.Fleet) (map[string]string, error) {
+	output, err := m.client.ListTagsForResource(ctx, &appstream.ListTagsForResourceInput{
+		ResourceArn: fleet.Arn,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags of AppStream fleet %s: %w", aws.ToString(fleet.Name), err)
+	}
+	return output.Tags, nil
+}
+
+// Pause stops a fleet. Stopping keeps its capacity settings.
+func (m *AppStreamServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	_, err := m.client.StopFleet(ctx, &appstream.StopFleetInput{
+		Name: aws.String(resource.ResourceID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stop AppStream fleet %s: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+// Resume restores a fleet's original desired capacity if it has changed
+// since the pause, then starts it
+func (m *AppStreamServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	name := resource.ResourceID
+
+	if err := m.restoreCapacity(ctx, resource); err != nil {
+		return err
+	}
+
+	_, err := m.client.StartFleet(ctx, &appstream.StartFleetInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start AppStream fleet %s: %w", name, err)
+	}
+	return nil
+}
+
+// restoreCapacity sets the desired instance count captured at discovery.
+// Multi-session fleets are sized in sessions and are left as they are.
+func (m *AppStreamServiceManager) restoreCapacity(ctx context.Context, resource models.Resource) error {
+	desired, ok := resource.Metadata["original_desired_instances"].(float64)
+	if !ok || desired <= 0 {
+		return nil
+	}
+	if sessions, _ := resource.Metadata["max_sessions_per_instance"].(float64); sessions > 1 {
+		return nil
+	}
+
+	output, err := m.client.DescribeFleets(ctx, &appstream.DescribeFleetsInput{
+		Names: []string{resource.ResourceID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe AppStream fleet %s: %w", resource.ResourceID, err)
+	}
+	if len(output.Fleets) > 0 {
+		if status := output.Fleets[0].ComputeCapacityStatus; status != nil && aws.ToInt32(status.Desired) == int32(desired) {
+			return nil
+		}
+	}
+
+	_, err = m.client.UpdateFleet(ctx, &appstream.UpdateFleetInput{
+		Name:            aws.String(resource.ResourceID),
+		ComputeCapacity: &types.ComputeCapacity{DesiredInstances: aws.Int32(int32(desired))},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore capacity of AppStream fleet %s: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+func (m *AppStreamServiceManager) fleetToResource(fleet types.Fleet, tags map[string]string, region string) models.Resource {
+	instanceType := aws.ToString(fleet.InstanceType)
+	var desired, running int32
+	if status := fleet.ComputeCapacityStatus; status != nil {
+		desired = aws.ToInt32(status.Desired)
+		running = aws.ToInt32(status.Running)
+	}
+
+	rate, ok := appStreamRates[instanceType]
+	if !ok {
+		rate = appStreamRates["stream.standard.medium"]
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceAppStream,
+		ResourceID:   aws.ToString(fleet.Name),
+		ARN:          aws.ToString(fleet.Arn),
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         tags,
+		Metadata: map[string]any{
+			"fleet_type":                 string(fleet.FleetType),
+			"instance_type":              instanceType,
+			"original_desired_instances": float64(desired),
+			"max_sessions_per_instance":  float64(aws.ToInt32(fleet.MaxSessionsPerInstance)),
+		},
+		CostPerHour: rate * float64(running),
+	}
+}
@@ -7,21 +7,42 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
 
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
 	"github.com/aicoder2009/aws-hit-breaks/internal/models"
 )
 
+// asgProcesses are the scaling processes suspended while a group is paused
+var asgProcesses = []string{
+	"Launch",
+	"Terminate",
+	"HealthCheck",
+	"ReplaceUnhealthy",
+	"AZRebalance",
+	"AlarmNotification",
+	"ScheduledActions",
+	"AddToLoadBalancer",
+}
+
+// nodegroupTag is the tag EKS puts on the Auto Scaling groups of managed node
+// groups. EKSServiceManager scales those, so they aren't discovered here.
+const nodegroupTag = "eks:nodegroup-name"
+
 // ASGServiceManager handles Auto Scaling Group operations
 type ASGServiceManager struct {
-	client *autoscaling.Client
-	region string
+	client    *autoscaling.Client
+	ecsClient *ecs.Client
+	region    string
 }
 
 // NewASGServiceManager creates a new Auto Scaling Group service manager
 func NewASGServiceManager(cfg aws.Config) *ASGServiceManager {
 	return &ASGServiceManager{
-		client: autoscaling.NewFromConfig(cfg),
-		region: cfg.Region,
+		client:    autoscaling.NewFromConfig(cfg),
+		ecsClient: ecs.NewFromConfig(cfg),
+		region:    cfg.Region,
 	}
 }
 
@@ -30,10 +51,21 @@ func (m *ASGServiceManager) ServiceType() models.ServiceType {
 	return models.ServiceAutoScaling
 }
 
+// Capabilities reports that groups are scaled to zero instances
+func (m *ASGServiceManager) Capabilities() Capabilities {
+	return Capabilities{ScaleToZero: true}
+}
+
 // Discover finds all Auto Scaling Groups with running instances
 func (m *ASGServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
 	var resources []models.Resource
 
+	providers, err := m.managedCapacityProviders(ctx)
+	if err != nil {
+		// Groups are still paused, just without handing them back from ECS
+		logging.Warn("skipping ECS capacity provider lookup", "error", err)
+	}
+
 	paginator := autoscaling.NewDescribeAutoScalingGroupsPaginator(m.client, &autoscaling.DescribeAutoScalingGroupsInput{})
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
@@ -42,9 +74,18 @@ func (m *ASGServiceManager) Discover(ctx context.Context, region string) ([]mode
 		}
 
 		for _, asg := range output.AutoScalingGroups {
+			if isNodegroupASG(asg) {
+				continue
+			}
 			// Only include ASGs with desired capacity > 0 or running instances
 			if *asg.DesiredCapacity > 0 || len(asg.Instances) > 0 {
 				resource := m.asgToResource(asg, region)
+				if provider, ok := providers[aws.ToString(asg.AutoScalingGroupARN)]; ok {
+					resource.Metadata["capacity_provider"] = aws.ToString(provider.Name)
+					if provider.AutoScalingGroupProvider.ManagedTerminationProtection == ecstypes.ManagedTerminationProtectionEnabled {
+						resource.Metadata["managed_termination_protection"] = true
+					}
+				}
 				resources = append(resources, resource)
 			}
 		}
@@ -53,30 +94,47 @@ func (m *ASGServiceManager) Discover(ctx context.Context, region string) ([]mode
 	return resources, nil
 }
 
-// Pause suspends Auto Scaling processes and scales to zero
+// Pause scales a group to zero instances. MinSize is lowered to zero first
+// when it is above, since the desired capacity can't go below it. Plain
+// groups have their scaling processes suspended so nothing launches them
+// back; groups an ECS capacity provider scales have its managed scaling and
+// managed termination protection turned off instead, and the scale-in
+// protection ECS set on their instances removed, so the instances can
+// terminate.
 func (m *ASGServiceManager) Pause(ctx context.Context, resource models.Resource) error {
 	asgName := resource.ResourceID
 
-	// Suspend all scaling processes
-	_, err := m.client.SuspendProcesses(ctx, &autoscaling.SuspendProcessesInput{
-		AutoScalingGroupName: aws.String(asgName),
-		ScalingProcesses: []string{
-			"Launch",
-			"Terminate",
-			"HealthCheck",
-			"ReplaceUnhealthy",
-			"AZRebalance",
-			"AlarmNotification",
-			"ScheduledActions",
-			"AddToLoadBalancer",
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to suspend ASG processes for %s: %w", asgName, err)
+	provider, _ := resource.Metadata["capacity_provider"].(string)
+	if provider != "" {
+		if err := m.setManagedScaling(ctx, provider, ecstypes.ManagedScalingStatusDisabled, ecstypes.ManagedTerminationProtectionDisabled); err != nil {
+			return err
+		}
+		if err := m.unprotectInstances(ctx, resource); err != nil {
+			return err
+		}
+	} else {
+		_, err := m.client.SuspendProcesses(ctx, &autoscaling.SuspendProcessesInput{
+			AutoScalingGroupName: aws.String(asgName),
+			ScalingProcesses:     asgProcesses,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to suspend ASG processes for %s: %w", asgName, err)
+		}
 	}
 
-	// Scale to zero
-	_, err = m.client.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+	if minSize, _ := resource.Metadata["original_min_size"].(float64); minSize > 0 {
+		_, err := m.client.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(asgName),
+			MinSize:              aws.Int32(0),
+			DesiredCapacity:      aws.Int32(0),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scale ASG %s to zero: %w", asgName, err)
+		}
+		return nil
+	}
+
+	_, err := m.client.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
 		AutoScalingGroupName: aws.String(asgName),
 		DesiredCapacity:      aws.Int32(0),
 	})
@@ -87,7 +145,9 @@ func (m *ASGServiceManager) Pause(ctx context.Context, resource models.Resource)
 	return nil
 }
 
-// Resume restores Auto Scaling Group to its original state
+// Resume restores a group's MinSize and desired capacity, then resumes its
+// scaling processes or its capacity provider's managed scaling and, when it
+// had it, managed termination protection
 func (m *ASGServiceManager) Resume(ctx context.Context, resource models.Resource) error {
 	asgName := resource.ResourceID
 
@@ -97,36 +157,139 @@ func (m *ASGServiceManager) Resume(ctx context.Context, resource models.Resource
 		originalCapacity = int32(cap)
 	}
 
-	// Restore desired capacity
-	_, err := m.client.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+	if minSize, _ := resource.Metadata["original_min_size"].(float64); minSize > 0 {
+		_, err := m.client.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(asgName),
+			MinSize:              aws.Int32(int32(minSize)),
+			DesiredCapacity:      aws.Int32(max(originalCapacity, int32(minSize))),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore ASG %s capacity: %w", asgName, err)
+		}
+	} else {
+		_, err := m.client.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(asgName),
+			DesiredCapacity:      aws.Int32(originalCapacity),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore ASG %s capacity: %w", asgName, err)
+		}
+	}
+
+	if provider, _ := resource.Metadata["capacity_provider"].(string); provider != "" {
+		var protection ecstypes.ManagedTerminationProtection
+		if resource.Metadata["managed_termination_protection"] == true {
+			protection = ecstypes.ManagedTerminationProtectionEnabled
+		}
+		return m.setManagedScaling(ctx, provider, ecstypes.ManagedScalingStatusEnabled, protection)
+	}
+
+	_, err := m.client.ResumeProcesses(ctx, &autoscaling.ResumeProcessesInput{
 		AutoScalingGroupName: aws.String(asgName),
-		DesiredCapacity:      aws.Int32(originalCapacity),
+		ScalingProcesses:     asgProcesses,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to restore ASG %s capacity: %w", asgName, err)
+		return fmt.Errorf("failed to resume ASG processes for %s: %w", asgName, err)
 	}
 
-	// Resume all scaling processes
-	_, err = m.client.ResumeProcesses(ctx, &autoscaling.ResumeProcessesInput{
-		AutoScalingGroupName: aws.String(asgName),
-		ScalingProcesses: []string{
-			"Launch",
-			"Terminate",
-			"HealthCheck",
-			"ReplaceUnhealthy",
-			"AZRebalance",
-			"AlarmNotification",
-			"ScheduledActions",
-			"AddToLoadBalancer",
+	return nil
+}
+
+// managedCapacityProviders maps the ARNs of groups that ECS capacity
+// providers scale with managed scaling to the providers
+func (m *ASGServiceManager) managedCapacityProviders(ctx context.Context) (map[string]ecstypes.CapacityProvider, error) {
+	providers := make(map[string]ecstypes.CapacityProvider)
+	input := &ecs.DescribeCapacityProvidersInput{}
+	for {
+		output, err := m.ecsClient.DescribeCapacityProviders(ctx, input)
+		if err != nil {
+			return providers, fmt.Errorf("failed to describe ECS capacity providers: %w", err)
+		}
+		for _, cp := range output.CapacityProviders {
+			asgp := cp.AutoScalingGroupProvider
+			if asgp == nil || asgp.ManagedScaling == nil || asgp.ManagedScaling.Status != ecstypes.ManagedScalingStatusEnabled {
+				continue
+			}
+			providers[aws.ToString(asgp.AutoScalingGroupArn)] = cp
+		}
+		if output.NextToken == nil {
+			return providers, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// setManagedScaling turns a capacity provider's managed scaling on or off,
+// keeping its other managed scaling settings. Managed termination protection
+// is set in the same update, since ECS rejects it without managed scaling;
+// an empty protection leaves it as it is.
+func (m *ASGServiceManager) setManagedScaling(ctx context.Context, provider string, status ecstypes.ManagedScalingStatus, protection ecstypes.ManagedTerminationProtection) error {
+	output, err := m.ecsClient.DescribeCapacityProviders(ctx, &ecs.DescribeCapacityProvidersInput{
+		CapacityProviders: []string{provider},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe ECS capacity provider %s: %w", provider, err)
+	}
+	if len(output.CapacityProviders) == 0 || output.CapacityProviders[0].AutoScalingGroupProvider == nil {
+		return fmt.Errorf("ECS capacity provider %s not found", provider)
+	}
+
+	scaling := ecstypes.ManagedScaling{}
+	if current := output.CapacityProviders[0].AutoScalingGroupProvider.ManagedScaling; current != nil {
+		scaling = *current
+	}
+	scaling.Status = status
+
+	_, err = m.ecsClient.UpdateCapacityProvider(ctx, &ecs.UpdateCapacityProviderInput{
+		Name: aws.String(provider),
+		AutoScalingGroupProvider: &ecstypes.AutoScalingGroupProviderUpdate{
+			ManagedScaling:               &scaling,
+			ManagedTerminationProtection: protection,
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to resume ASG processes for %s: %w", asgName, err)
+		return fmt.Errorf("failed to set managed scaling of ECS capacity provider %s to %s: %w", provider, status, err)
+	}
+	return nil
+}
+
+// unprotectInstances lifts the scale-in protection ECS managed termination
+// protection set on a group's instances. ECS sets it again on the instances
+// it places tasks on after resume.
+func (m *ASGServiceManager) unprotectInstances(ctx context.Context, resource models.Resource) error {
+	var protected []string
+	if err := decodeMetadata(resource, "protected_instances", &protected); err != nil {
+		return err
+	}
+	if len(protected) == 0 {
+		return nil
 	}
 
+	// SetInstanceProtection takes at most 50 instances per call
+	for start := 0; start < len(protected); start += 50 {
+		batch := protected[start:min(start+50, len(protected))]
+		_, err := m.client.SetInstanceProtection(ctx, &autoscaling.SetInstanceProtectionInput{
+			AutoScalingGroupName: aws.String(resource.ResourceID),
+			InstanceIds:          batch,
+			ProtectedFromScaleIn: aws.Bool(false),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove scale-in protection in ASG %s: %w", resource.ResourceID, err)
+		}
+	}
 	return nil
 }
 
+// isNodegroupASG reports whether a group belongs to an EKS managed node group
+func isNodegroupASG(asg types.AutoScalingGroup) bool {
+	for _, tag := range asg.Tags {
+		if aws.ToString(tag.Key) == nodegroupTag {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *ASGServiceManager) asgToResource(asg types.AutoScalingGroup, region string) models.Resource {
 	// Extract tags
 	tags := make(map[string]string)
@@ -144,13 +307,23 @@ func (m *ASGServiceManager) asgToResource(asg types.AutoScalingGroup, region str
 		}
 	}
 
+	var protected []string
+	for _, inst := range asg.Instances {
+		if aws.ToBool(inst.ProtectedFromScaleIn) {
+			protected = append(protected, aws.ToString(inst.InstanceId))
+		}
+	}
+
 	metadata := map[string]any{
 		"original_desired_capacity": float64(*asg.DesiredCapacity),
-		"min_size":                  *asg.MinSize,
+		"original_min_size":         float64(aws.ToInt32(asg.MinSize)),
 		"max_size":                  *asg.MaxSize,
 		"instance_count":            len(asg.Instances),
 		"suspended_processes":       suspendedProcesses,
 	}
+	if len(protected) > 0 {
+		metadata["protected_instances"] = protected
+	}
 
 	if asg.LaunchConfigurationName != nil {
 		metadata["launch_configuration"] = *asg.LaunchConfigurationName
@@ -166,6 +339,7 @@ func (m *ASGServiceManager) asgToResource(asg types.AutoScalingGroup, region str
 	return models.Resource{
 		ServiceType:  models.ServiceAutoScaling,
 		ResourceID:   aws.ToString(asg.AutoScalingGroupName),
+		ARN:          aws.ToString(asg.AutoScalingGroupARN),
 		Region:       region,
 		CurrentState: models.StateRunning,
 		Tags:         tags,
@@ -0,0 +1,692 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	elb "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/globalaccelerator"
+	gatypes "github.com/aws/aws-sdk-go-v2/service/globalaccelerator/types"
+)
+
+// Kinds of audit finding
+const (
+	FindingLoadBalancer      = "load_balancer"
+	FindingVolume            = "ebs_volume"
+	FindingSnapshot          = "ebs_snapshot"
+	FindingStoppedInstance   = "stopped_instance"
+	FindingGlobalAccelerator = "global_accelerator"
+	FindingDynamoDBTable     = "dynamodb_table"
+)
+
+const (
+	// DefaultSnapshotAge is how old a snapshot must be before the audit reports it
+	DefaultSnapshotAge = 90 * 24 * time.Hour
+
+	hoursPerMonth = 24 * 30
+
+	// Hourly charges, excluding capacity units and data processing
+	loadBalancerHourlyCost        = 0.0225
+	gatewayLoadBalancerHourlyCost = 0.0125
+	classicLoadBalancerHourlyCost = 0.025
+	acceleratorHourlyCost         = 0.025
+
+	// snapshotGBMonthCost is the standard-tier snapshot rate; archive is a quarter of it
+	snapshotGBMonthCost        = 0.05
+	archiveSnapshotGBMonthCost = 0.0125
+
+	// Global Accelerator's API is only served from us-west-2
+	globalAcceleratorRegion = "us-west-2"
+
+	// tableUsageLookback is how far back DynamoDB table traffic is checked
+	tableUsageLookback = 7 * 24 * time.Hour
+	// unusedCapacityShare reports provisioned tables whose busiest hour used
+	// less than this share of their read and write capacity
+	unusedCapacityShare        = 0.2
+	dynamoDBStorageGBMonthCost = 0.25
+)
+
+// ebsGBMonthCost is the per-GB monthly storage rate for each volume type
+var ebsGBMonthCost = map[types.VolumeType]float64{
+	types.VolumeTypeGp2:      0.10,
+	types.VolumeTypeGp3:      0.08,
+	types.VolumeTypeIo1:      0.125,
+	types.VolumeTypeIo2:      0.125,
+	types.VolumeTypeSt1:      0.045,
+	types.VolumeTypeSc1:      0.015,
+	types.VolumeTypeStandard: 0.05,
+}
+
+// Finding is an idle resource that keeps costing money and that pausing
+// can't stop: it has to be deleted by someone who knows it's unused
+type Finding struct {
+	Kind        string  `json:"kind"`
+	ID          string  `json:"id"`
+	Name        string  `json:"name,omitempty"`
+	Region      string  `json:"region"` // "global" for accelerators
+	Reason      string  `json:"reason"`
+	MonthlyCost float64 `json:"monthly_cost"`
+}
+
+// AuditOptions tunes what the audit reports
+type AuditOptions struct {
+	// SnapshotAge is how old a snapshot must be to be reported (default 90 days)
+	SnapshotAge time.Duration
+}
+
+// Auditor finds idle resources: load balancers with no healthy targets,
+// unattached volumes, old snapshots, accelerators with no endpoints, the
+// volumes of stopped instances, and DynamoDB tables paying for capacity or
+// storage they don't use. It only reads.
+type Auditor struct {
+	ec2   *ec2.Client
+	elbv2 *elbv2.Client
+	elb   *elb.Client
+	ddb   *dynamodb.Client
+	cw    *cloudwatch.Client
+	ga    *globalaccelerator.Client // nil outside the commercial partition
+}
+
+// NewAuditor creates an auditor for the config's region
+func NewAuditor(cfg aws.Config) *Auditor {
+	a := &Auditor{
+		ec2:   ec2.NewFromConfig(cfg),
+		elbv2: elbv2.NewFromConfig(cfg),
+		elb:   elb.NewFromConfig(cfg),
+		ddb:   dynamodb.NewFromConfig(cfg),
+		cw:    cloudwatch.NewFromConfig(cfg),
+	}
+	if !strings.HasPrefix(cfg.Region, "cn-") && !strings.HasPrefix(cfg.Region, "us-gov-") {
+		a.ga = globalaccelerator.NewFromConfig(cfg, func(o *globalaccelerator.Options) {
+			o.Region = globalAcceleratorRegion
+		})
+	}
+	return a
+}
+
+// Audit runs every check in a region and returns the findings, most
+// expensive first. Checks that fail are skipped and reported in a
+// *PartialError alongside the other findings.
+func (a *Auditor) Audit(ctx context.Context, region string, opts AuditOptions) ([]Finding, error) {
+	if opts.SnapshotAge <= 0 {
+		opts.SnapshotAge = DefaultSnapshotAge
+	}
+
+	checks := []func(context.Context, string) ([]Finding, error){
+		a.idleLoadBalancers,
+		a.idleClassicLoadBalancers,
+		a.unattachedVolumes,
+		func(ctx context.Context, region string) ([]Finding, error) {
+			return a.oldSnapshots(ctx, region, opts.SnapshotAge)
+		},
+		a.stoppedInstances,
+		a.unusedTables,
+	}
+	if a.ga != nil {
+		checks = append(checks, a.idleAccelerators)
+	}
+
+	var findings []Finding
+	var errs []error
+	for _, check := range checks {
+		found, err := check(ctx, region)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		findings = append(findings, found...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].MonthlyCost > findings[j].MonthlyCost
+	})
+	if len(errs) > 0 {
+		return findings, &PartialError{Errs: errs}
+	}
+	return findings, nil
+}
+
+// idleLoadBalancers finds application, network and gateway load balancers
+// with no healthy target in any target group. A load balancer whose target
+// health can't be read is left out and the error returned with the findings.
+func (a *Auditor) idleLoadBalancers(ctx context.Context, region string) ([]Finding, error) {
+	var findings []Finding
+	var errs []error
+
+	paginator := elbv2.NewDescribeLoadBalancersPaginator(a.elbv2, &elbv2.DescribeLoadBalancersInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return findings, fmt.Errorf("failed to describe load balancers: %w", err)
+		}
+
+		for _, lb := range output.LoadBalancers {
+			if lb.State != nil && lb.State.Code != elbv2types.LoadBalancerStateEnumActive {
+				continue
+			}
+			arn := aws.ToString(lb.LoadBalancerArn)
+			groups, healthy, err := a.healthyTargets(ctx, arn)
+			if healthy > 0 {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			reason := fmt.Sprintf("%s load balancer with no target groups", lb.Type)
+			if groups > 0 {
+				reason = fmt.Sprintf("%s load balancer with no healthy targets in %d target group(s)", lb.Type, groups)
+			}
+			hourly := loadBalancerHourlyCost
+			if lb.Type == elbv2types.LoadBalancerTypeEnumGateway {
+				hourly = gatewayLoadBalancerHourlyCost
+			}
+			findings = append(findings, Finding{
+				Kind:        FindingLoadBalancer,
+				ID:          arn,
+				Name:        aws.ToString(lb.LoadBalancerName),
+				Region:      region,
+				Reason:      reason,
+				MonthlyCost: hourly * hoursPerMonth,
+			})
+		}
+	}
+
+	return findings, errors.Join(errs...)
+}
+
+// healthyTargets counts a load balancer's target groups and its healthy
+// targets. A Lambda target has no health unless health checks are turned on,
+// so a registered function counts unless it is reported unhealthy. Groups
+// whose health can't be read are skipped, and their errors returned with the
+// counts from the rest.
+func (a *Auditor) healthyTargets(ctx context.Context, loadBalancerARN string) (int, int, error) {
+	var groups, healthy int
+	var errs []error
+
+	paginator := elbv2.NewDescribeTargetGroupsPaginator(a.elbv2, &elbv2.DescribeTargetGroupsInput{
+		LoadBalancerArn: aws.String(loadBalancerARN),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to describe target groups of %s: %w", loadBalancerARN, err))
+			return groups, healthy, errors.Join(errs...)
+		}
+
+		for _, tg := range output.TargetGroups {
+			groups++
+			health, err := a.elbv2.DescribeTargetHealth(ctx, &elbv2.DescribeTargetHealthInput{
+				TargetGroupArn: tg.TargetGroupArn,
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to describe target health of %s: %w", aws.ToString(tg.TargetGroupArn), err))
+				continue
+			}
+			for _, t := range health.TargetHealthDescriptions {
+				if t.TargetHealth == nil {
+					continue
+				}
+				state := t.TargetHealth.State
+				if state == elbv2types.TargetHealthStateEnumHealthy ||
+					(tg.TargetType == elbv2types.TargetTypeEnumLambda && state != elbv2types.TargetHealthStateEnumUnhealthy) {
+					healthy++
+				}
+			}
+		}
+	}
+
+	return groups, healthy, errors.Join(errs...)
+}
+
+// idleClassicLoadBalancers finds classic load balancers with no instance in service
+func (a *Auditor) idleClassicLoadBalancers(ctx context.Context, region string) ([]Finding, error) {
+	var findings []Finding
+
+	paginator := elb.NewDescribeLoadBalancersPaginator(a.elb, &elb.DescribeLoadBalancersInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return findings, fmt.Errorf("failed to describe classic load balancers: %w", err)
+		}
+
+		for _, lb := range output.LoadBalancerDescriptions {
+			name := aws.ToString(lb.LoadBalancerName)
+			health, err := a.elb.DescribeInstanceHealth(ctx, &elb.DescribeInstanceHealthInput{
+				LoadBalancerName: lb.LoadBalancerName,
+			})
+			if err != nil {
+				return findings, fmt.Errorf("failed to describe instance health of %s: %w", name, err)
+			}
+
+			inService := 0
+			for _, s := range health.InstanceStates {
+				if aws.ToString(s.State) == "InService" {
+					inService++
+				}
+			}
+			if inService > 0 {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				Kind:        FindingLoadBalancer,
+				ID:          name,
+				Name:        name,
+				Region:      region,
+				Reason:      fmt.Sprintf("classic load balancer with no instances in service (%d registered)", len(lb.Instances)),
+				MonthlyCost: classicLoadBalancerHourlyCost * hoursPerMonth,
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// unattachedVolumes finds EBS volumes that aren't attached to an instance
+func (a *Auditor) unattachedVolumes(ctx context.Context, region string) ([]Finding, error) {
+	var findings []Finding
+
+	paginator := ec2.NewDescribeVolumesPaginator(a.ec2, &ec2.DescribeVolumesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("status"),
+				Values: []string{"available"},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return findings, fmt.Errorf("failed to describe unattached volumes: %w", err)
+		}
+
+		for _, v := range output.Volumes {
+			id := aws.ToString(v.VolumeId)
+			reason := fmt.Sprintf("unattached %d GiB %s volume", aws.ToInt32(v.Size), v.VolumeType)
+			if v.CreateTime != nil {
+				reason += fmt.Sprintf(", created %d days ago", daysSince(*v.CreateTime))
+			}
+			findings = append(findings, Finding{
+				Kind:        FindingVolume,
+				ID:          id,
+				Name:        ec2TagsToMap(v.Tags)["Name"],
+				Region:      region,
+				Reason:      reason,
+				MonthlyCost: volumeMonthlyCost(v),
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// oldSnapshots finds the account's EBS snapshots older than maxAge. Snapshots
+// behind the account's AMIs can't be deleted while the image exists, and AWS
+// Backup recovery points are expired by their backup plan, so neither is
+// reported.
+func (a *Auditor) oldSnapshots(ctx context.Context, region string, maxAge time.Duration) ([]Finding, error) {
+	var findings []Finding
+	cutoff := time.Now().Add(-maxAge)
+
+	backing, err := a.imageSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := ec2.NewDescribeSnapshotsPaginator(a.ec2, &ec2.DescribeSnapshotsInput{
+		OwnerIds: []string{"self"},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return findings, fmt.Errorf("failed to describe snapshots: %w", err)
+		}
+
+		for _, s := range output.Snapshots {
+			if s.StartTime == nil || s.StartTime.After(cutoff) {
+				continue
+			}
+			id := aws.ToString(s.SnapshotId)
+			if backing[id] || isBackupSnapshot(s) {
+				continue
+			}
+			rate := snapshotGBMonthCost
+			if s.StorageTier == types.StorageTierArchive {
+				rate = archiveSnapshotGBMonthCost
+			}
+			// Standard-tier snapshots are incremental, so the full size is an upper bound
+			findings = append(findings, Finding{
+				Kind:        FindingSnapshot,
+				ID:          id,
+				Name:        ec2TagsToMap(s.Tags)["Name"],
+				Region:      region,
+				Reason:      fmt.Sprintf("%d GiB snapshot of %s, %d days old (cost is at most this)", aws.ToInt32(s.VolumeSize), aws.ToString(s.VolumeId), daysSince(*s.StartTime)),
+				MonthlyCost: float64(aws.ToInt32(s.VolumeSize)) * rate,
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// imageSnapshots returns the IDs of the snapshots behind the account's AMIs
+func (a *Auditor) imageSnapshots(ctx context.Context) (map[string]bool, error) {
+	ids := make(map[string]bool)
+
+	paginator := ec2.NewDescribeImagesPaginator(a.ec2, &ec2.DescribeImagesInput{
+		Owners: []string{"self"},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe images: %w", err)
+		}
+		for _, image := range output.Images {
+			for _, bdm := range image.BlockDeviceMappings {
+				if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+					ids[*bdm.Ebs.SnapshotId] = true
+				}
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// isBackupSnapshot reports whether AWS Backup created a snapshot as a
+// recovery point. AWS Backup tags its snapshots with aws:backup: keys.
+func isBackupSnapshot(s types.Snapshot) bool {
+	for _, t := range s.Tags {
+		if strings.HasPrefix(aws.ToString(t.Key), "aws:backup:") {
+			return true
+		}
+	}
+	return false
+}
+
+// stoppedInstances finds stopped instances and what their volumes still cost
+func (a *Auditor) stoppedInstances(ctx context.Context, region string) ([]Finding, error) {
+	var findings []Finding
+
+	paginator := ec2.NewDescribeInstancesPaginator(a.ec2, &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{string(types.InstanceStateNameStopped)},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return findings, fmt.Errorf("failed to describe stopped instances: %w", err)
+		}
+
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				var volumeIDs []string
+				for _, bdm := range instance.BlockDeviceMappings {
+					if bdm.Ebs != nil && bdm.Ebs.VolumeId != nil {
+						volumeIDs = append(volumeIDs, *bdm.Ebs.VolumeId)
+					}
+				}
+				if len(volumeIDs) == 0 {
+					continue
+				}
+
+				volumes, err := a.ec2.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{VolumeIds: volumeIDs})
+				if err != nil {
+					return findings, fmt.Errorf("failed to describe volumes of %s: %w", aws.ToString(instance.InstanceId), err)
+				}
+				var size int32
+				var cost float64
+				for _, v := range volumes.Volumes {
+					size += aws.ToInt32(v.Size)
+					cost += volumeMonthlyCost(v)
+				}
+
+				id := aws.ToString(instance.InstanceId)
+				findings = append(findings, Finding{
+					Kind:        FindingStoppedInstance,
+					ID:          id,
+					Name:        ec2TagsToMap(instance.Tags)["Name"],
+					Region:      region,
+					Reason:      fmt.Sprintf("stopped %s still paying for %d volume(s), %d GiB", instance.InstanceType, len(volumes.Volumes), size),
+					MonthlyCost: cost,
+				})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// idleAccelerators finds Global Accelerators with no healthy endpoint.
+// Accelerators are global, so they are reported whatever the region.
+func (a *Auditor) idleAccelerators(ctx context.Context, _ string) ([]Finding, error) {
+	var findings []Finding
+
+	paginator := globalaccelerator.NewListAcceleratorsPaginator(a.ga, &globalaccelerator.ListAcceleratorsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return findings, fmt.Errorf("failed to list accelerators: %w", err)
+		}
+
+		for _, acc := range output.Accelerators {
+			arn := aws.ToString(acc.AcceleratorArn)
+			endpoints, healthy, err := a.acceleratorEndpoints(ctx, arn)
+			if err != nil {
+				return findings, err
+			}
+			if healthy > 0 {
+				continue
+			}
+
+			reason := "accelerator with no endpoints"
+			if endpoints > 0 {
+				reason = fmt.Sprintf("accelerator with no healthy endpoints (%d configured)", endpoints)
+			}
+			if !aws.ToBool(acc.Enabled) {
+				reason = "disabled " + reason
+			}
+			findings = append(findings, Finding{
+				Kind:        FindingGlobalAccelerator,
+				ID:          arn,
+				Name:        aws.ToString(acc.Name),
+				Region:      "global",
+				Reason:      reason,
+				MonthlyCost: acceleratorHourlyCost * hoursPerMonth,
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// unusedTables finds provisioned DynamoDB tables that use little of their
+// capacity, and on-demand tables nobody reads or writes that still bill for
+// storage
+func (a *Auditor) unusedTables(ctx context.Context, region string) ([]Finding, error) {
+	var findings []Finding
+
+	paginator := dynamodb.NewListTablesPaginator(a.ddb, &dynamodb.ListTablesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return findings, fmt.Errorf("failed to list DynamoDB tables: %w", err)
+		}
+
+		for _, name := range output.TableNames {
+			described, err := a.ddb.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+			if err != nil {
+				return findings, fmt.Errorf("failed to describe DynamoDB table %s: %w", name, err)
+			}
+			table := described.Table
+			if table == nil || table.TableStatus != ddbtypes.TableStatusActive {
+				continue
+			}
+
+			peakRead, peakWrite, err := a.tableUsage(ctx, name)
+			if err != nil {
+				return findings, err
+			}
+			if f, ok := tableFinding(*table, peakRead, peakWrite); ok {
+				f.Region = region
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// tableFinding reports a table given the read and write units per second of
+// its busiest hour, if it is worth reporting
+func tableFinding(table ddbtypes.TableDescription, peakRead, peakWrite float64) (Finding, bool) {
+	f := Finding{Kind: FindingDynamoDBTable, ID: aws.ToString(table.TableName)}
+	idle := peakRead == 0 && peakWrite == 0
+
+	if !provisioned(table) {
+		gb := float64(aws.ToInt64(table.TableSizeBytes)) / (1 << 30)
+		if !idle || gb < 0.01 {
+			return f, false
+		}
+		f.Reason = fmt.Sprintf("on-demand table with no reads or writes in %d days, still storing %.2f GB", int(tableUsageLookback.Hours()/24), gb)
+		f.MonthlyCost = gb * dynamoDBStorageGBMonthCost
+		return f, true
+	}
+
+	read, write := throughput(table.ProvisionedThroughput)
+	if read == 0 && write == 0 {
+		return f, false
+	}
+	if idle {
+		f.Reason = fmt.Sprintf("%d RCU/%d WCU provisioned with no reads or writes in %d days", read, write, int(tableUsageLookback.Hours()/24))
+		f.MonthlyCost = (float64(read)*dynamoDBReadUnitHourlyCost + float64(write)*dynamoDBWriteUnitHourlyCost) * hoursPerMonth
+		return f, true
+	}
+	if peakRead >= unusedCapacityShare*float64(read) || peakWrite >= unusedCapacityShare*float64(write) {
+		return f, false
+	}
+
+	// Capacity above the busiest hour is what could be given up
+	spareRead := float64(read) - max(parkedCapacity, math.Ceil(peakRead))
+	spareWrite := float64(write) - max(parkedCapacity, math.Ceil(peakWrite))
+	f.Reason = fmt.Sprintf("busiest hour in %d days used %.0f%% of %d RCU and %.0f%% of %d WCU; lower it or switch to on-demand",
+		int(tableUsageLookback.Hours()/24), 100*peakRead/float64(read), read, 100*peakWrite/float64(write), write)
+	f.MonthlyCost = (max(0, spareRead)*dynamoDBReadUnitHourlyCost + max(0, spareWrite)*dynamoDBWriteUnitHourlyCost) * hoursPerMonth
+	return f, true
+}
+
+// tableUsage returns the read and write capacity units per second a table
+// consumed in its busiest hour over the lookback
+func (a *Auditor) tableUsage(ctx context.Context, table string) (read, write float64, err error) {
+	end := time.Now()
+	peak := func(metric string) (float64, error) {
+		output, err := a.cw.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+			Namespace:  aws.String("AWS/DynamoDB"),
+			MetricName: aws.String(metric),
+			Dimensions: []cwtypes.Dimension{
+				{Name: aws.String("TableName"), Value: aws.String(table)},
+			},
+			StartTime:  aws.Time(end.Add(-tableUsageLookback)),
+			EndTime:    aws.Time(end),
+			Period:     aws.Int32(3600),
+			Statistics: []cwtypes.Statistic{cwtypes.StatisticSum},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get %s for %s: %w", metric, table, err)
+		}
+
+		var busiest float64
+		for _, dp := range output.Datapoints {
+			busiest = max(busiest, aws.ToFloat64(dp.Sum))
+		}
+		return busiest / 3600, nil
+	}
+
+	if read, err = peak("ConsumedReadCapacityUnits"); err != nil {
+		return 0, 0, err
+	}
+	if write, err = peak("ConsumedWriteCapacityUnits"); err != nil {
+		return 0, 0, err
+	}
+	return read, write, nil
+}
+
+// acceleratorEndpoints counts an accelerator's endpoints and the healthy ones
+func (a *Auditor) acceleratorEndpoints(ctx context.Context, acceleratorARN string) (int, int, error) {
+	var endpoints, healthy int
+
+	listeners := globalaccelerator.NewListListenersPaginator(a.ga, &globalaccelerator.ListListenersInput{
+		AcceleratorArn: aws.String(acceleratorARN),
+	})
+	for listeners.HasMorePages() {
+		output, err := listeners.NextPage(ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list listeners of %s: %w", acceleratorARN, err)
+		}
+
+		for _, listener := range output.Listeners {
+			groups := globalaccelerator.NewListEndpointGroupsPaginator(a.ga, &globalaccelerator.ListEndpointGroupsInput{
+				ListenerArn: listener.ListenerArn,
+			})
+			for groups.HasMorePages() {
+				page, err := groups.NextPage(ctx)
+				if err != nil {
+					return 0, 0, fmt.Errorf("failed to list endpoint groups of %s: %w", aws.ToString(listener.ListenerArn), err)
+				}
+				for _, group := range page.EndpointGroups {
+					for _, e := range group.EndpointDescriptions {
+						endpoints++
+						if e.HealthState == gatypes.HealthStateHealthy {
+							healthy++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return endpoints, healthy, nil
+}
+
+// volumeMonthlyCost estimates a volume's monthly charge: storage, plus
+// provisioned IOPS and throughput above the gp3 baseline
+func volumeMonthlyCost(v types.Volume) float64 {
+	cost := float64(aws.ToInt32(v.Size)) * ebsGBMonthCost[v.VolumeType]
+	iops := float64(aws.ToInt32(v.Iops))
+
+	switch v.VolumeType {
+	case types.VolumeTypeGp3:
+		if iops > 3000 {
+			cost += (iops - 3000) * 0.005
+		}
+		if throughput := float64(aws.ToInt32(v.Throughput)); throughput > 125 {
+			cost += (throughput - 125) * 0.04
+		}
+	case types.VolumeTypeIo1, types.VolumeTypeIo2:
+		cost += iops * 0.065
+	}
+	return cost
+}
+
+func daysSince(t time.Time) int {
+	return int(time.Since(t).Hours() / 24)
+}
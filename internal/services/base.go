@@ -2,6 +2,10 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aicoder2009/aws-hit-breaks/internal/models"
 )
@@ -11,7 +15,9 @@ type ServiceManager interface {
 	// ServiceType returns the type of service this manager handles
 	ServiceType() models.ServiceType
 
-	// Discover finds all resources of this service type in the given region
+	// Discover finds all resources of this service type in the given region.
+	// When only part of the service can be read it returns what it found
+	// along with a *PartialError.
 	Discover(ctx context.Context, region string) ([]models.Resource, error)
 
 	// Pause stops/pauses a resource
@@ -19,4 +25,116 @@ type ServiceManager interface {
 
 	// Resume starts/resumes a resource
 	Resume(ctx context.Context, resource models.Resource) error
+
+	// Capabilities describes how this service's resources are braked
+	Capabilities() Capabilities
+}
+
+// Capabilities describes how a service manager brakes its resources, so the
+// orchestrator and CLI can warn, wait and track deadlines without knowing
+// each service
+type Capabilities struct {
+	// Stop means resources are stopped in place and keep their configuration
+	Stop bool
+	// ScaleToZero means resources are scaled down and resume restores the
+	// size saved in the snapshot
+	ScaleToZero bool
+	// Destructive means pausing deletes resources that resume recreates,
+	// possibly with new IDs
+	Destructive bool
+	// Wait means the manager is a Settler, so operations can be waited on
+	Wait bool
+	// RestartDeadline is how long AWS keeps a paused resource stopped before
+	// starting it again on its own; zero means it stays paused
+	RestartDeadline time.Duration
+}
+
+// ResourceCapabilities is implemented by managers whose resources don't all
+// brake the same way, such as EC2, which stops instances but cancels capacity
+// reservations
+type ResourceCapabilities interface {
+	// ResourceCapabilities returns how the manager brakes resource
+	ResourceCapabilities(resource models.Resource) Capabilities
+}
+
+// CapabilitiesOf returns how mgr brakes resource
+func CapabilitiesOf(mgr ServiceManager, resource models.Resource) Capabilities {
+	if rc, ok := mgr.(ResourceCapabilities); ok {
+		return rc.ResourceCapabilities(resource)
+	}
+	return mgr.Capabilities()
+}
+
+// PartialError is returned by Discover, alongside the resources it did find,
+// when parts of a service were skipped
+type PartialError struct {
+	Errs []error
+}
+
+func (e *PartialError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *PartialError) Unwrap() []error {
+	return e.Errs
+}
+
+// Settler is implemented by service managers whose pause or resume completes
+// asynchronously. The orchestrator waits on it before running resources that
+// depend on the settled one.
+type Settler interface {
+	// Settle waits until the operation on the resource has taken effect
+	Settle(ctx context.Context, resource models.Resource, operation string) error
+}
+
+// waitTimeout bounds a waiter's maximum wait by ctx's deadline, so a wait that
+// can't finish in time gives up rather than running into it
+func waitTimeout(ctx context.Context, limit time.Duration) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); left < limit {
+			// Waiters reject a non-positive maximum
+			return max(left, time.Second)
+		}
+	}
+	return limit
+}
+
+// decodeMetadata decodes a structured metadata value into dst. Metadata loaded
+// from a snapshot has been through JSON, so values are re-encoded rather than
+// type-asserted.
+func decodeMetadata(resource models.Resource, key string, dst any) error {
+	value, ok := resource.Metadata[key]
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s in resource metadata: %w", key, err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("invalid %s in resource metadata: %w", key, err)
+	}
+	return nil
+}
+
+// resourceARN builds an ARN for resources whose API responses don't include one
+func resourceARN(service, region, accountID, resource string) string {
+	partition := "aws"
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		partition = "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		partition = "aws-us-gov"
+	}
+	return fmt.Sprintf("arn:%s:%s:%s:%s:%s", partition, service, region, accountID, resource)
+}
+
+// IsReportOnly reports whether a resource is shown for cost visibility but never paused
+func IsReportOnly(resource models.Resource) bool {
+	return resource.Metadata["report_only"] == true
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// RDSBackupGBMonthCost is the charge for RDS backup storage beyond the free
+// allowance, which matches the size of the region's databases
+const RDSBackupGBMonthCost = 0.095
+
+// rdsStorageGBMonthCost is the per-GB monthly storage rate for each RDS
+// storage type; Multi-AZ instances pay it twice
+var rdsStorageGBMonthCost = map[string]float64{
+	"gp2":      0.115,
+	"gp3":      0.115,
+	"io1":      0.125,
+	"io2":      0.125,
+	"standard": 0.10,
+}
+
+// Charge kinds that keep billing while a resource is paused
+const (
+	ChargeVolumes    = "EBS volumes"
+	ChargeElasticIPs = "Elastic IPs"
+	ChargeStorage    = "RDS storage"
+	ChargeLightsail  = "Lightsail bundles"
+)
+
+// PausedCharge is what a paused resource keeps costing: stopping compute
+// doesn't stop the bill for its disks and addresses
+type PausedCharge struct {
+	Kind        string
+	MonthlyCost float64
+}
+
+// PausedCharges returns what a resource keeps costing per month once paused.
+// Costs come from the metadata recorded at discovery, so they work from a
+// snapshot without calling AWS.
+func PausedCharges(resource models.Resource) []PausedCharge {
+	var charges []PausedCharge
+	add := func(kind string, monthly float64) {
+		if monthly > 0 {
+			charges = append(charges, PausedCharge{Kind: kind, MonthlyCost: monthly})
+		}
+	}
+
+	switch resource.ServiceType {
+	case models.ServiceEC2:
+		if resource.Metadata["kind"] != nil {
+			// Spot Fleets and capacity reservations leave nothing behind
+			return nil
+		}
+		volumes, _ := resource.Metadata["ebs_monthly_cost"].(float64)
+		add(ChargeVolumes, volumes)
+		ips, _ := resource.Metadata["elastic_ips"].(float64)
+		add(ChargeElasticIPs, ips*elasticIPHourlyCost*hoursPerMonth)
+	case models.ServiceRDS:
+		if AuroraServerless(resource) != "" {
+			// Serverless clusters are scaled down, not stopped; their
+			// storage is billed either way and isn't counted as saved
+			return nil
+		}
+		add(ChargeStorage, rdsStorageMonthlyCost(resource))
+	case models.ServiceLightsail:
+		// Lightsail bills the whole bundle whether it runs or not
+		bundle, _ := resource.Metadata["bundle_monthly_cost"].(float64)
+		add(ChargeLightsail, bundle)
+	}
+	return charges
+}
+
+// rdsStorageMonthlyCost estimates the storage a stopped database keeps
+// paying for. Aurora bills the GB it actually stores, which the RDS API
+// doesn't report, so Aurora clusters count as nothing.
+func rdsStorageMonthlyCost(resource models.Resource) float64 {
+	engine, _ := resource.Metadata["engine"].(string)
+	if strings.HasPrefix(engine, "aurora") {
+		return 0
+	}
+
+	gb, _ := resource.Metadata["storage_gb"].(float64)
+	storageType, _ := resource.Metadata["storage_type"].(string)
+	rate, ok := rdsStorageGBMonthCost[storageType]
+	if !ok {
+		rate = rdsStorageGBMonthCost["gp2"]
+	}
+	cost := gb * rate
+	if resource.Metadata["multi_az"] == true {
+		cost *= 2
+	}
+	if iops, _ := resource.Metadata["provisioned_iops"].(float64); iops > 0 && strings.HasPrefix(storageType, "io") {
+		cost += iops * 0.10
+	}
+	return cost
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// DefaultServiceConcurrency caps services whose control planes throttle or
+// queue changes well below the global limit
+var DefaultServiceConcurrency = map[models.ServiceType]int{
+	models.ServiceRDS:        3,
+	models.ServiceOpenSearch: 2,
+}
+
+// Concurrency controls how many AWS operations run at once. Zero values use
+// MaxConcurrentOperations, MaxConcurrentDiscovery and DefaultServiceConcurrency.
+type Concurrency struct {
+	Operations int // pauses and resumes in flight across all services
+	Discovery  int // services discovered at once
+	// PerService caps operations in flight for individual services, on top of
+	// DefaultServiceConcurrency
+	PerService map[models.ServiceType]int
+	// Adaptive halves the operation limit whenever AWS throttles a call and
+	// raises it again one step at a time as calls succeed
+	Adaptive bool
+}
+
+// ConcurrencyFromConfig converts the config file's concurrency settings; nil
+// uses the defaults
+func ConcurrencyFromConfig(c *models.ConcurrencyConfig) Concurrency {
+	if c == nil {
+		return Concurrency{}
+	}
+	conc := Concurrency{
+		Operations: c.Operations,
+		Discovery:  c.Discovery,
+		Adaptive:   c.Adaptive,
+	}
+	if len(c.PerService) > 0 {
+		conc.PerService = make(map[models.ServiceType]int)
+		for svc, n := range c.PerService {
+			conc.PerService[models.ServiceType(svc)] = n
+		}
+	}
+	return conc
+}
+
+// limiter admits pause and resume operations within the global and
+// per-service limits
+type limiter struct {
+	mu       sync.Mutex
+	wake     chan struct{} // closed and replaced whenever a slot may have opened
+	max      int           // configured global limit
+	limit    int           // current global limit; lower than max after throttling
+	inFlight int
+	caps     map[models.ServiceType]int
+	running  map[models.ServiceType]int
+	adaptive bool
+	streak   int // successes since the limit last changed
+}
+
+func newLimiter(c Concurrency) *limiter {
+	limit := c.Operations
+	if limit <= 0 {
+		limit = MaxConcurrentOperations
+	}
+
+	caps := make(map[models.ServiceType]int)
+	for svc, n := range DefaultServiceConcurrency {
+		caps[svc] = n
+	}
+	for svc, n := range c.PerService {
+		caps[svc] = n
+	}
+
+	return &limiter{
+		wake:     make(chan struct{}),
+		max:      limit,
+		limit:    limit,
+		caps:     caps,
+		running:  make(map[models.ServiceType]int),
+		adaptive: c.Adaptive,
+	}
+}
+
+// acquire blocks until an operation on svc may start, or ctx is done
+func (l *limiter) acquire(ctx context.Context, svc models.ServiceType) error {
+	l.mu.Lock()
+	for l.inFlight >= l.limit || (l.caps[svc] > 0 && l.running[svc] >= l.caps[svc]) {
+		wake := l.wake
+		l.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+		l.mu.Lock()
+	}
+	l.inFlight++
+	l.running[svc]++
+	l.mu.Unlock()
+	return nil
+}
+
+// release ends an operation started with acquire
+func (l *limiter) release(svc models.ServiceType) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.running[svc]--
+	l.broadcast()
+}
+
+// broadcast wakes every waiting acquire; the caller holds l.mu
+func (l *limiter) broadcast() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// throttled backs the global limit off after AWS throttled a call
+func (l *limiter) throttled() {
+	if !l.adaptive {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.streak = 0
+	if l.limit > 1 {
+		l.limit = max(1, l.limit/2)
+		logging.Warn("throttled by AWS, reducing concurrency", "limit", l.limit)
+	}
+}
+
+// succeeded raises a backed-off limit by one after as many successful calls
+// as the current limit
+func (l *limiter) succeeded() {
+	if !l.adaptive {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit >= l.max {
+		return
+	}
+	l.streak++
+	if l.streak >= l.limit {
+		l.limit++
+		l.streak = 0
+		logging.Debug("raising concurrency", "limit", l.limit)
+		l.broadcast()
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// Default tags of the built-in critical infrastructure checks
+var (
+	defaultBastionTags    = []string{"role=bastion", "bastion", "name=*bastion*"}
+	defaultProductionTags = []string{"environment=prod*", "env=prod*", "stage=prod*"}
+)
+
+// managedGroupTags maps tags that AWS services put on the Auto Scaling groups
+// they manage to the service. Scaling these groups to zero is undone by the
+// service, or breaks it. Groups of ECS capacity providers tagged
+// AmazonECSManaged are only critical when their provider wasn't found, since
+// ASGServiceManager hands those back from the provider while paused.
+var managedGroupTags = map[string]string{
+	"eks:nodegroup-name":                "an EKS managed node group",
+	"elasticbeanstalk:environment-name": "Elastic Beanstalk",
+	ecsManagedTag:                       "an ECS capacity provider",
+	"aws:elasticmapreduce:job-flow-id":  "EMR",
+	"aws:gamelift:fleet:arn":            "GameLift",
+}
+
+// ecsManagedTag is the tag ECS puts on the Auto Scaling groups of capacity
+// providers
+const ecsManagedTag = "AmazonECSManaged"
+
+// criticalChecks recognizes resources whose pause would cut off access to
+// the account or the network awsbreak itself runs in
+type criticalChecks struct {
+	allowNAT       bool
+	bastionTags    []string
+	managedTags    map[string]string
+	productionTags []string
+}
+
+// newCriticalChecks creates the checks from config, which may be nil. It
+// returns nil when the checks are turned off.
+func newCriticalChecks(cfg *models.CriticalConfig) *criticalChecks {
+	c := &criticalChecks{
+		bastionTags:    defaultBastionTags,
+		managedTags:    make(map[string]string),
+		productionTags: defaultProductionTags,
+	}
+	for tag, service := range managedGroupTags {
+		c.managedTags[tag] = service
+	}
+	if cfg == nil {
+		return c
+	}
+	if cfg.Disabled {
+		return nil
+	}
+
+	c.allowNAT = cfg.AllowNATInstances
+	if len(cfg.BastionTags) > 0 {
+		c.bastionTags = cfg.BastionTags
+	}
+	if len(cfg.ProductionTags) > 0 {
+		c.productionTags = cfg.ProductionTags
+	}
+	for _, tag := range cfg.ManagedGroupTags {
+		c.managedTags[tag] = "another service"
+	}
+	return c
+}
+
+// reason returns why a resource is critical, or ""
+func (c *criticalChecks) reason(r models.Resource) string {
+	switch r.ServiceType {
+	case models.ServiceEC2:
+		if r.Metadata["nat_instance"] == true && !c.allowNAT {
+			return "NAT instance - its VPC would lose its route to AWS, cutting off SSM and awsbreak itself"
+		}
+		if tag := matchTags(r.Tags, c.bastionTags); tag != "" {
+			return fmt.Sprintf("bastion host (tag %s)", tag)
+		}
+	case models.ServiceAutoScaling:
+		_, ecsProvider := r.Metadata["capacity_provider"].(string)
+		for spec, service := range c.managedTags {
+			if ecsProvider && spec == ecsManagedTag {
+				continue
+			}
+			if tag := matchTags(r.Tags, []string{spec}); tag != "" {
+				return fmt.Sprintf("managed by %s (tag %s)", service, tag)
+			}
+		}
+	case models.ServiceRDS:
+		if r.Metadata["deletion_protection"] != true {
+			break
+		}
+		if tag := matchTags(r.Tags, c.productionTags); tag != "" {
+			return fmt.Sprintf("production database with deletion protection (tag %s)", tag)
+		}
+	}
+	return ""
+}
+
+// matchTags returns the first tag matching one of specs, given as key or
+// key=value with the value a glob, or "" if none does. Keys and values are
+// compared case-insensitively.
+func matchTags(tags map[string]string, specs []string) string {
+	for _, spec := range specs {
+		key, pattern, hasValue := strings.Cut(spec, "=")
+		for k, v := range tags {
+			if !strings.EqualFold(k, key) {
+				continue
+			}
+			if !hasValue {
+				return k
+			}
+			if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(v)); matched {
+				return fmt.Sprintf("%s=%s", k, v)
+			}
+		}
+	}
+	return ""
+}
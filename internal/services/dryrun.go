@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// Ways a planned operation is checked
+const (
+	CheckDryRun     = "dry run"    // the real API call with DryRun=true
+	CheckSimulation = "simulation" // the IAM policy simulator
+)
+
+// PlannedCheck is whether the role may pause or resume one resource
+type PlannedCheck struct {
+	Resource models.Resource
+	Actions  []string // IAM actions the operation calls
+	Denied   []string // actions the role isn't allowed
+	Method   string   // CheckDryRun or CheckSimulation
+	Error    string   // the check itself failed; the outcome is unknown
+}
+
+// OK reports whether every action was allowed
+func (c PlannedCheck) OK() bool {
+	return c.Error == "" && len(c.Denied) == 0
+}
+
+// OperationActions returns the IAM actions that pausing or resuming the
+// resource calls, or nil for resources awsbreak never touches
+func OperationActions(r models.Resource, operation string) []string {
+	pause := operation == "pause"
+	pick := func(pauseActions, resumeActions []string) []string {
+		if pause {
+			return pauseActions
+		}
+		return resumeActions
+	}
+
+	kind, _ := r.Metadata["kind"].(string)
+	switch r.ServiceType {
+	case models.ServiceEC2:
+		switch kind {
+		case "spot_fleet":
+			return []string{"ec2:ModifySpotFleetRequest"}
+		case "capacity_reservation":
+			return pick([]string{"ec2:CancelCapacityReservation"}, []string{"ec2:CreateCapacityReservation"})
+		}
+		return pick([]string{"ec2:StopInstances"}, []string{"ec2:StartInstances"})
+	case models.ServiceRDS:
+		if AuroraServerless(r) != "" {
+			return []string{"rds:ModifyDBCluster"}
+		}
+		if r.Metadata["is_cluster"] == true {
+			return pick([]string{"rds:StopDBCluster"}, []string{"rds:StartDBCluster"})
+		}
+		return pick([]string{"rds:StopDBInstance"}, []string{"rds:StartDBInstance"})
+	case models.ServiceECS:
+		return []string{"ecs:UpdateService"}
+	case models.ServiceAutoScaling:
+		scale := "autoscaling:SetDesiredCapacity"
+		if minSize, _ := r.Metadata["original_min_size"].(float64); minSize > 0 {
+			scale = "autoscaling:UpdateAutoScalingGroup"
+		}
+		if _, ok := r.Metadata["capacity_provider"].(string); ok {
+			pauseActions := []string{"ecs:UpdateCapacityProvider", scale}
+			if _, ok := r.Metadata["protected_instances"]; ok {
+				pauseActions = []string{"ecs:UpdateCapacityProvider", "autoscaling:SetInstanceProtection", scale}
+			}
+			return pick(pauseActions, []string{scale, "ecs:UpdateCapacityProvider"})
+		}
+		return pick([]string{"autoscaling:SuspendProcesses", scale},
+			[]string{scale, "autoscaling:ResumeProcesses"})
+	case models.ServiceEKS:
+		if kind == "fargate_profile" {
+			return nil
+		}
+		return []string{"eks:UpdateNodegroupConfig"}
+	case models.ServiceWorkSpaces:
+		// Only WorkSpaces paused by older versions were switched from AlwaysOn
+		if r.Metadata["original_running_mode"] == "ALWAYS_ON" {
+			return pick([]string{"workspaces:StopWorkspaces"},
+				[]string{"workspaces:StartWorkspaces", "workspaces:ModifyWorkspaceProperties"})
+		}
+		return pick([]string{"workspaces:StopWorkspaces"}, []string{"workspaces:StartWorkspaces"})
+	case models.ServiceOpenSearch:
+		return pick([]string{"cloudwatch:GetMetricStatistics", "es:UpdateDomainConfig"}, []string{"es:UpdateDomainConfig"})
+	case models.ServiceDynamoDB:
+		var targets []scalingTarget
+		if decodeMetadata(r, "auto_scaling", &targets) == nil && len(targets) > 0 {
+			return []string{"dynamodb:UpdateTable", "application-autoscaling:RegisterScalableTarget"}
+		}
+		return []string{"dynamodb:UpdateTable"}
+	case models.ServiceEMR:
+		modify := "elasticmapreduce:ModifyInstanceGroups"
+		if r.Metadata["collection"] == "INSTANCE_FLEET" {
+			modify = "elasticmapreduce:ModifyInstanceFleet"
+		}
+		if _, ok := r.Metadata["managed_scaling"]; ok {
+			return pick([]string{"elasticmapreduce:RemoveManagedScalingPolicy", modify},
+				[]string{modify, "elasticmapreduce:PutManagedScalingPolicy"})
+		}
+		return []string{modify}
+	case models.ServiceKinesis:
+		if r.Metadata["original_mode"] == "ON_DEMAND" {
+			return pick([]string{"kinesis:UpdateStreamMode", "kinesis:UpdateShardCount"}, []string{"kinesis:UpdateStreamMode"})
+		}
+		return []string{"kinesis:UpdateShardCount"}
+	case models.ServiceLightsail:
+		switch kind {
+		case "database":
+			return pick([]string{"lightsail:StopRelationalDatabase"}, []string{"lightsail:StartRelationalDatabase"})
+		case "container_service":
+			return []string{"lightsail:UpdateContainerService"}
+		}
+		return pick([]string{"lightsail:StopInstance"}, []string{"lightsail:StartInstance"})
+	case models.ServiceAppStream:
+		return pick([]string{"appstream:StopFleet"}, []string{"appstream:UpdateFleet", "appstream:StartFleet"})
+	case models.ServiceNetwork:
+		if kind == "elastic_ip" {
+			return nil
+		}
+		return pick([]string{"ec2:CreateTags", "ec2:DeleteNatGateway"},
+			[]string{"ec2:CreateNatGateway", "ec2:ReplaceRoute", "ec2:CreateRoute", "ec2:DeleteTags"})
+	}
+	return nil
+}
+
+// PlanChecker tells, before a real run, which planned operations would fail
+// with AccessDenied. EC2 instances are checked with DryRun calls made as the
+// role itself; everything else goes through the IAM policy simulator, which
+// needs credentials allowed iam:SimulatePrincipalPolicy on the role.
+type PlanChecker struct {
+	ec2Client *ec2.Client
+	iamClient *iam.Client
+	roleARN   string
+}
+
+// NewPlanChecker creates a plan checker. roleCfg holds the role's own
+// credentials; simCfg the credentials used to run the policy simulator. An
+// empty roleARN leaves simulated resources unchecked.
+func NewPlanChecker(roleCfg, simCfg aws.Config, roleARN string) *PlanChecker {
+	return &PlanChecker{
+		ec2Client: ec2.NewFromConfig(roleCfg),
+		iamClient: iam.NewFromConfig(simCfg),
+		roleARN:   roleARN,
+	}
+}
+
+// CheckAll checks the operation on every resource and returns one check per
+// resource awsbreak would act on, in resource order
+func (c *PlanChecker) CheckAll(ctx context.Context, resources []models.Resource, operation string) []PlannedCheck {
+	var checks []PlannedCheck
+	var simErr error
+
+	for _, r := range resources {
+		actions := OperationActions(r, operation)
+		if len(actions) == 0 || IsReportOnly(r) {
+			continue
+		}
+		check := PlannedCheck{Resource: r, Actions: actions}
+
+		switch {
+		case r.ServiceType == models.ServiceEC2 && r.Metadata["kind"] == nil:
+			check.Method = CheckDryRun
+			if err := c.dryRunInstance(ctx, r.ResourceID, operation); err != nil {
+				if IsAccessDenied(err) {
+					check.Denied = actions
+				} else {
+					check.Error = err.Error()
+				}
+			}
+		case c.roleARN == "":
+			check.Method = CheckSimulation
+			check.Error = "no role configured to simulate"
+		case simErr != nil:
+			// The simulator failed for an earlier resource and will again
+			check.Method = CheckSimulation
+			check.Error = simErr.Error()
+		default:
+			check.Method = CheckSimulation
+			denied, err := c.simulate(ctx, r, actions)
+			if err != nil {
+				if IsAccessDenied(err) {
+					simErr = err
+				}
+				check.Error = err.Error()
+			}
+			check.Denied = denied
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// dryRunInstance asks EC2 whether stopping or starting an instance would be
+// authorized, without doing it
+func (c *PlanChecker) dryRunInstance(ctx context.Context, instanceID, operation string) error {
+	var err error
+	if operation == "pause" {
+		_, err = c.ec2Client.StopInstances(ctx, &ec2.StopInstancesInput{
+			InstanceIds: []string{instanceID},
+			DryRun:      aws.Bool(true),
+		})
+	} else {
+		_, err = c.ec2Client.StartInstances(ctx, &ec2.StartInstancesInput{
+			InstanceIds: []string{instanceID},
+			DryRun:      aws.Bool(true),
+		})
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "DryRunOperation" {
+		return nil
+	}
+	if err == nil {
+		return fmt.Errorf("dry run of %s was not acknowledged", instanceID)
+	}
+	return err
+}
+
+// simulate evaluates the actions against the role's policies for the
+// resource's ARN and returns those that would be denied
+func (c *PlanChecker) simulate(ctx context.Context, r models.Resource, actions []string) ([]string, error) {
+	resourceARN := r.ARN
+	if resourceARN == "" {
+		resourceARN = "*"
+	}
+
+	output, err := c.iamClient.SimulatePrincipalPolicy(ctx, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(c.roleARN),
+		ActionNames:     actions,
+		ResourceArns:    []string{resourceARN},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate role policy: %w", err)
+	}
+
+	var denied []string
+	for _, result := range output.EvaluationResults {
+		if result.EvalDecision != iamtypes.PolicyEvaluationDecisionTypeAllowed {
+			denied = append(denied, aws.ToString(result.EvalActionName))
+		}
+	}
+	return denied, nil
+}
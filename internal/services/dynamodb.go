@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aastypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// Hourly prices of one provisioned capacity unit
+	dynamoDBReadUnitHourlyCost  = 0.00013
+	dynamoDBWriteUnitHourlyCost = 0.00065
+
+	// parkedCapacity is the least read or write capacity DynamoDB allows
+	parkedCapacity = 1
+
+	// dynamoDBActiveTimeout bounds waits for a table to finish a capacity change
+	dynamoDBActiveTimeout = 10 * time.Minute
+)
+
+// indexThroughput is the provisioned capacity of a global secondary index
+type indexThroughput struct {
+	Name  string `json:"name"`
+	Read  int64  `json:"read"`
+	Write int64  `json:"write"`
+}
+
+// scalingTarget is an Application Auto Scaling target on a table or index
+type scalingTarget struct {
+	ResourceID string `json:"resource_id"` // table/<name> or table/<name>/index/<index>
+	Dimension  string `json:"dimension"`
+	Min        int32  `json:"min"`
+	Max        int32  `json:"max"`
+}
+
+// DynamoDBServiceManager handles DynamoDB tables in provisioned capacity mode.
+// Tables can't be stopped, so pausing drops the table and its global
+// secondary indexes to 1 read and 1 write unit, pinning any auto scaling to
+// the same, and resuming restores the recorded capacity and scaling limits.
+type DynamoDBServiceManager struct {
+	client  *dynamodb.Client
+	scaling *applicationautoscaling.Client
+	region  string
+}
+
+// NewDynamoDBServiceManager creates a new DynamoDB service manager
+func NewDynamoDBServiceManager(cfg aws.Config) *DynamoDBServiceManager {
+	return &DynamoDBServiceManager{
+		client:  dynamodb.NewFromConfig(cfg),
+		scaling: applicationautoscaling.NewFromConfig(cfg),
+		region:  cfg.Region,
+	}
+}
+
+// ServiceType returns the service type
+func (m *DynamoDBServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceDynamoDB
+}
+
+// Capabilities reports that tables are scaled down to the minimum capacity
+// and that capacity changes can be waited on
+func (m *DynamoDBServiceManager) Capabilities() Capabilities {
+	return Capabilities{ScaleToZero: true, Wait: true}
+}
+
+// Discover finds provisioned tables with more than the minimum capacity on
+// the table or any global secondary index. On-demand tables only bill for
+// what they use and are left alone. A table whose tags can't be read is
+// skipped, since protection rules depend on them.
+func (m *DynamoDBServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	targets, err := m.scalingTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []models.Resource
+	var skipped []error
+	paginator := dynamodb.NewListTablesPaginator(m.client, &dynamodb.ListTablesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list DynamoDB tables: %w", err)
+		}
+
+		for _, name := range output.TableNames {
+			described, err := m.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+			if err != nil {
+				logging.Warn("skipping DynamoDB table", "table", name, "error", err)
+				skipped = append(skipped, fmt.Errorf("failed to describe DynamoDB table %s: %w", name, err))
+				continue
+			}
+			table := described.Table
+			if table == nil || table.TableStatus != types.TableStatusActive || !provisioned(*table) || !canPark(*table) {
+				continue
+			}
+
+			tags, err := m.tags(ctx, table)
+			if err != nil {
+				logging.Warn("skipping DynamoDB table", "table", name, "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			resources = append(resources, tableToResource(*table, tags, targets["table/"+name], region))
+		}
+	}
+
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
+	return resources, nil
+}
+
+// scalingTargets returns the region's DynamoDB auto scaling targets by table
+func (m *DynamoDBServiceManager) scalingTargets(ctx context.Context) (map[string][]scalingTarget, error) {
+	targets := make(map[string][]scalingTarget)
+	paginator := applicationautoscaling.NewDescribeScalableTargetsPaginator(m.scaling, &applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace: aastypes.ServiceNamespaceDynamodb,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe DynamoDB auto scaling: %w", err)
+		}
+		for _, t := range output.ScalableTargets {
+			id := aws.ToString(t.ResourceId)
+			table, _, _ := strings.Cut(id, "/index/")
+			targets[table] = append(targets[table], scalingTarget{
+				ResourceID: id,
+				Dimension:  string(t.ScalableDimension),
+				Min:        aws.ToInt32(t.MinCapacity),
+				Max:        aws.ToInt32(t.MaxCapacity),
+			})
+		}
+	}
+	return targets, nil
+}
+
+func (m *DynamoDBServiceManager) tags(ctx context.Context, table *types.TableDescription) (map[string]string, error) {
+	tags := make(map[string]string)
+	input := &dynamodb.ListTagsOfResourceInput{ResourceArn: table.TableArn}
+	for {
+		output, err := m.client.ListTagsOfResource(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tags of DynamoDB table %s: %w", aws.ToString(table.TableName), err)
+		}
+		for _, t := range output.Tags {
+			tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+		}
+		if output.NextToken == nil {
+			return tags, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// Pause pins auto scaling to the minimum, so it doesn't scale the table back
+// up, and drops the table and its indexes to the minimum capacity. If the
+// capacity can't be dropped, auto scaling gets its limits back, so a failed
+// pause doesn't leave the table pinned.
+func (m *DynamoDBServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	var targets []scalingTarget
+	if err := decodeMetadata(resource, "auto_scaling", &targets); err != nil {
+		return err
+	}
+	for i, t := range targets {
+		if err := m.registerTarget(ctx, t, parkedCapacity, parkedCapacity); err != nil {
+			return errors.Join(err, m.restoreTargets(ctx, targets[:i]))
+		}
+	}
+
+	table, err := m.describe(ctx, resource.ResourceID)
+	if err != nil {
+		return errors.Join(err, m.restoreTargets(ctx, targets))
+	}
+	var parked []indexThroughput
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		parked = append(parked, indexThroughput{Name: aws.ToString(gsi.IndexName), Read: parkedCapacity, Write: parkedCapacity})
+	}
+	if err := m.update(ctx, table, parkedCapacity, parkedCapacity, parked, "park"); err != nil {
+		return errors.Join(err, m.restoreTargets(ctx, targets))
+	}
+	return nil
+}
+
+// restoreTargets gives auto scaling targets back the limits recorded at
+// discovery
+func (m *DynamoDBServiceManager) restoreTargets(ctx context.Context, targets []scalingTarget) error {
+	var errs []error
+	for _, t := range targets {
+		if err := m.registerTarget(ctx, t, t.Min, t.Max); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Resume restores the capacity and auto scaling limits recorded at discovery
+func (m *DynamoDBServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	read, _ := resource.Metadata["original_read_capacity"].(float64)
+	write, _ := resource.Metadata["original_write_capacity"].(float64)
+	if read < parkedCapacity || write < parkedCapacity {
+		return fmt.Errorf("no original capacity recorded for DynamoDB table %s", resource.ResourceID)
+	}
+	var indexes []indexThroughput
+	if err := decodeMetadata(resource, "original_indexes", &indexes); err != nil {
+		return err
+	}
+	var targets []scalingTarget
+	if err := decodeMetadata(resource, "auto_scaling", &targets); err != nil {
+		return err
+	}
+
+	table, err := m.describe(ctx, resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	if err := m.update(ctx, table, int64(read), int64(write), indexes, "restore"); err != nil {
+		return err
+	}
+
+	// Auto scaling goes back on once the table has its capacity again
+	return m.restoreTargets(ctx, targets)
+}
+
+// Settle waits for a capacity change to finish, so the table can take its
+// full traffic again before what depends on it resumes
+func (m *DynamoDBServiceManager) Settle(ctx context.Context, resource models.Resource, operation string) error {
+	waiter := dynamodb.NewTableExistsWaiter(m.client)
+	err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(resource.ResourceID),
+	}, waitTimeout(ctx, dynamoDBActiveTimeout))
+	if err != nil {
+		return fmt.Errorf("DynamoDB table %s did not become active: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+func (m *DynamoDBServiceManager) describe(ctx context.Context, name string) (*types.TableDescription, error) {
+	output, err := m.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe DynamoDB table %s: %w", name, err)
+	}
+	if output.Table == nil || !provisioned(*output.Table) {
+		return nil, fmt.Errorf("DynamoDB table %s is no longer in provisioned capacity mode", name)
+	}
+	return output.Table, nil
+}
+
+// update sets the capacity of a table and its indexes. Only what changes is
+// sent, since DynamoDB rejects updates to the capacity a table already has.
+func (m *DynamoDBServiceManager) update(ctx context.Context, table *types.TableDescription, read, write int64, indexes []indexThroughput, action string) error {
+	name := aws.ToString(table.TableName)
+	input := &dynamodb.UpdateTableInput{TableName: table.TableName}
+
+	if pt := table.ProvisionedThroughput; pt == nil || aws.ToInt64(pt.ReadCapacityUnits) != read || aws.ToInt64(pt.WriteCapacityUnits) != write {
+		input.ProvisionedThroughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(read),
+			WriteCapacityUnits: aws.Int64(write),
+		}
+	}
+
+	current := make(map[string]*types.ProvisionedThroughputDescription)
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		current[aws.ToString(gsi.IndexName)] = gsi.ProvisionedThroughput
+	}
+	for _, idx := range indexes {
+		pt, ok := current[idx.Name]
+		if !ok {
+			logging.Warn("index no longer exists", "table", name, "index", idx.Name)
+			continue
+		}
+		if pt != nil && aws.ToInt64(pt.ReadCapacityUnits) == idx.Read && aws.ToInt64(pt.WriteCapacityUnits) == idx.Write {
+			continue
+		}
+		input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, types.GlobalSecondaryIndexUpdate{
+			Update: &types.UpdateGlobalSecondaryIndexAction{
+				IndexName: aws.String(idx.Name),
+				ProvisionedThroughput: &types.ProvisionedThroughput{
+					ReadCapacityUnits:  aws.Int64(idx.Read),
+					WriteCapacityUnits: aws.Int64(idx.Write),
+				},
+			},
+		})
+	}
+
+	if input.ProvisionedThroughput == nil && len(input.GlobalSecondaryIndexUpdates) == 0 {
+		return nil
+	}
+	if _, err := m.client.UpdateTable(ctx, input); err != nil {
+		return fmt.Errorf("failed to %s DynamoDB table %s: %w", action, name, err)
+	}
+	return nil
+}
+
+func (m *DynamoDBServiceManager) registerTarget(ctx context.Context, t scalingTarget, minCapacity, maxCapacity int32) error {
+	_, err := m.scaling.RegisterScalableTarget(ctx, &applicationautoscaling.RegisterScalableTargetInput{
+		ServiceNamespace:  aastypes.ServiceNamespaceDynamodb,
+		ResourceId:        aws.String(t.ResourceID),
+		ScalableDimension: aastypes.ScalableDimension(t.Dimension),
+		MinCapacity:       aws.Int32(minCapacity),
+		MaxCapacity:       aws.Int32(maxCapacity),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set auto scaling of %s to %d-%d: %w", t.ResourceID, minCapacity, maxCapacity, err)
+	}
+	return nil
+}
+
+// provisioned reports whether a table bills for provisioned capacity. Tables
+// created before billing modes existed have no summary and are provisioned.
+func provisioned(table types.TableDescription) bool {
+	return table.BillingModeSummary == nil || table.BillingModeSummary.BillingMode == types.BillingModeProvisioned
+}
+
+// canPark reports whether pausing would lower any of a table's capacity
+func canPark(table types.TableDescription) bool {
+	read, write := throughput(table.ProvisionedThroughput)
+	if read > parkedCapacity || write > parkedCapacity {
+		return true
+	}
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		if read, write := throughput(gsi.ProvisionedThroughput); read > parkedCapacity || write > parkedCapacity {
+			return true
+		}
+	}
+	return false
+}
+
+func throughput(pt *types.ProvisionedThroughputDescription) (read, write int64) {
+	if pt == nil {
+		return 0, 0
+	}
+	return aws.ToInt64(pt.ReadCapacityUnits), aws.ToInt64(pt.WriteCapacityUnits)
+}
+
+// capacityHourlyCost prices read and write capacity units above the parked minimum
+func capacityHourlyCost(read, write int64) float64 {
+	return float64(max(0, read-parkedCapacity))*dynamoDBReadUnitHourlyCost +
+		float64(max(0, write-parkedCapacity))*dynamoDBWriteUnitHourlyCost
+}
+
+func tableToResource(table types.TableDescription, tags map[string]string, targets []scalingTarget, region string) models.Resource {
+	read, write := throughput(table.ProvisionedThroughput)
+	cost := capacityHourlyCost(read, write)
+
+	indexes := []indexThroughput{}
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		r, w := throughput(gsi.ProvisionedThroughput)
+		indexes = append(indexes, indexThroughput{Name: aws.ToString(gsi.IndexName), Read: r, Write: w})
+		cost += capacityHourlyCost(r, w)
+	}
+	if targets == nil {
+		targets = []scalingTarget{}
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceDynamoDB,
+		ResourceID:   aws.ToString(table.TableName),
+		ARN:          aws.ToString(table.TableArn),
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         tags,
+		Metadata: map[string]any{
+			"original_read_capacity":  float64(read),
+			"original_write_capacity": float64(write),
+			"original_indexes":        indexes,
+			"auto_scaling":            targets,
+			"item_count":              float64(aws.ToInt64(table.ItemCount)),
+			"size_bytes":              float64(aws.ToInt64(table.TableSizeBytes)),
+		},
+		// Parked tables keep their minimum capacity, so only the difference is saved
+		CostPerHour: cost,
+	}
+}
@@ -5,23 +5,32 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 
+	"github.com/aicoder2009/aws-hit-breaks/internal/cost"
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
 	"github.com/aicoder2009/aws-hit-breaks/internal/models"
 )
 
 // EC2ServiceManager handles EC2 instance operations
 type EC2ServiceManager struct {
-	client *ec2.Client
-	region string
+	client       *ec2.Client
+	autoscaling  *autoscaling.Client
+	region       string
+	reservations bool
 }
 
-// NewEC2ServiceManager creates a new EC2 service manager
-func NewEC2ServiceManager(cfg aws.Config) *EC2ServiceManager {
+// NewEC2ServiceManager creates a new EC2 service manager. Capacity
+// reservations are only cancelled on pause when reservations is true;
+// otherwise they are reported only.
+func NewEC2ServiceManager(cfg aws.Config, reservations bool) *EC2ServiceManager {
 	return &EC2ServiceManager{
-		client: ec2.NewFromConfig(cfg),
-		region: cfg.Region,
+		client:       ec2.NewFromConfig(cfg),
+		autoscaling:  autoscaling.NewFromConfig(cfg),
+		region:       cfg.Region,
+		reservations: reservations,
 	}
 }
 
@@ -30,7 +39,22 @@ func (m *EC2ServiceManager) ServiceType() models.ServiceType {
 	return models.ServiceEC2
 }
 
-// Discover finds all running EC2 instances
+// Capabilities reports that instances are stopped in place
+func (m *EC2ServiceManager) Capabilities() Capabilities {
+	return Capabilities{Stop: true, Destructive: m.reservations}
+}
+
+// ResourceCapabilities reports that capacity reservations are cancelled on
+// pause and recreated, with a new ID, on resume
+func (m *EC2ServiceManager) ResourceCapabilities(resource models.Resource) Capabilities {
+	if resource.Metadata["kind"] == "capacity_reservation" {
+		return Capabilities{Destructive: true}
+	}
+	return Capabilities{Stop: true}
+}
+
+// Discover finds all running EC2 instances, active Spot Fleet requests and
+// active capacity reservations
 func (m *EC2ServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
 	var resources []models.Resource
 
@@ -53,17 +77,129 @@ func (m *EC2ServiceManager) Discover(ctx context.Context, region string) ([]mode
 
 		for _, reservation := range output.Reservations {
 			for _, instance := range reservation.Instances {
-				resource := m.instanceToResource(instance, region)
+				resource := m.instanceToResource(instance, aws.ToString(reservation.OwnerId), region)
 				resources = append(resources, resource)
 			}
 		}
 	}
 
+	if err := m.markAutoScalingMembers(ctx, resources); err != nil {
+		// Roles from older templates may lack the permission; the
+		// instances can still be listed, just without their group
+		logging.Warn("skipping Auto Scaling membership lookup", "error", err)
+	}
+	if err := m.recordVolumeCosts(ctx, resources); err != nil {
+		// Instances are still paused, just without their storage cost shown
+		logging.Warn("skipping EBS volume cost lookup", "error", err)
+	}
+
+	resources, fleets, err := m.discoverSpotFleets(ctx, resources, region)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, fleets...)
+
+	reservations, err := m.discoverCapacityReservations(ctx, region, m.reservations)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, reservations...)
+
 	return resources, nil
 }
 
-// Pause stops an EC2 instance
+// markAutoScalingMembers records the Auto Scaling group of instances that were
+// attached to one without carrying the group tag. Stopping such an instance
+// directly just makes the group replace it.
+func (m *EC2ServiceManager) markAutoScalingMembers(ctx context.Context, resources []models.Resource) error {
+	index := make(map[string]int)
+	var ids []string
+	for i, r := range resources {
+		if _, ok := r.Metadata["autoscaling_group"]; ok {
+			continue
+		}
+		index[r.ResourceID] = i
+		ids = append(ids, r.ResourceID)
+	}
+
+	// DescribeAutoScalingInstances accepts at most 50 IDs per call
+	for start := 0; start < len(ids); start += 50 {
+		end := min(start+50, len(ids))
+		paginator := autoscaling.NewDescribeAutoScalingInstancesPaginator(m.autoscaling, &autoscaling.DescribeAutoScalingInstancesInput{
+			InstanceIds: ids[start:end],
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to describe Auto Scaling instances: %w", err)
+			}
+			for _, instance := range output.AutoScalingInstances {
+				if i, ok := index[aws.ToString(instance.InstanceId)]; ok {
+					resources[i].Metadata["autoscaling_group"] = aws.ToString(instance.AutoScalingGroupName)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordVolumeCosts records what the EBS volumes of each instance cost per
+// month, since they keep billing while the instance is stopped
+func (m *EC2ServiceManager) recordVolumeCosts(ctx context.Context, resources []models.Resource) error {
+	index := make(map[string]int)
+	ids := make([]string, 0, len(resources))
+	for i, r := range resources {
+		index[r.ResourceID] = i
+		ids = append(ids, r.ResourceID)
+	}
+
+	// Filters accept at most 200 values
+	for start := 0; start < len(ids); start += 200 {
+		end := min(start+200, len(ids))
+		paginator := ec2.NewDescribeVolumesPaginator(m.client, &ec2.DescribeVolumesInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("attachment.instance-id"),
+					Values: ids[start:end],
+				},
+			},
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to describe EBS volumes: %w", err)
+			}
+			for _, v := range output.Volumes {
+				for _, attachment := range v.Attachments {
+					i, ok := index[aws.ToString(attachment.InstanceId)]
+					if !ok {
+						continue
+					}
+					md := resources[i].Metadata
+					monthly, _ := md["ebs_monthly_cost"].(float64)
+					md["ebs_monthly_cost"] = monthly + volumeMonthlyCost(v)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Pause stops an EC2 instance, scales a Spot Fleet to zero or cancels a
+// capacity reservation
 func (m *EC2ServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	switch resource.Metadata["kind"] {
+	case "spot_fleet":
+		return m.pauseSpotFleet(ctx, resource)
+	case "capacity_reservation":
+		if !m.reservations {
+			return fmt.Errorf("capacity reservation cancellation is disabled (use --include-reservations)")
+		}
+		return m.cancelCapacityReservation(ctx, resource)
+	}
+
 	input := &ec2.StopInstancesInput{
 		InstanceIds: []string{resource.ResourceID},
 	}
@@ -76,8 +212,16 @@ func (m *EC2ServiceManager) Pause(ctx context.Context, resource models.Resource)
 	return nil
 }
 
-// Resume starts an EC2 instance
+// Resume starts an EC2 instance, restores a Spot Fleet's capacity or
+// recreates a capacity reservation
 func (m *EC2ServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	switch resource.Metadata["kind"] {
+	case "spot_fleet":
+		return m.resumeSpotFleet(ctx, resource)
+	case "capacity_reservation":
+		return m.recreateCapacityReservation(ctx, resource)
+	}
+
 	input := &ec2.StartInstancesInput{
 		InstanceIds: []string{resource.ResourceID},
 	}
@@ -90,7 +234,7 @@ func (m *EC2ServiceManager) Resume(ctx context.Context, resource models.Resource
 	return nil
 }
 
-func (m *EC2ServiceManager) instanceToResource(instance types.Instance, region string) models.Resource {
+func (m *EC2ServiceManager) instanceToResource(instance types.Instance, ownerID, region string) models.Resource {
 	// Extract tags
 	tags := make(map[string]string)
 	for _, tag := range instance.Tags {
@@ -105,6 +249,9 @@ func (m *EC2ServiceManager) instanceToResource(instance types.Instance, region s
 		"availability_zone": aws.ToString(instance.Placement.AvailabilityZone),
 	}
 
+	if group := tags["aws:autoscaling:groupName"]; group != "" {
+		metadata["autoscaling_group"] = group
+	}
 	if instance.VpcId != nil {
 		metadata["vpc_id"] = *instance.VpcId
 	}
@@ -117,6 +264,24 @@ func (m *EC2ServiceManager) instanceToResource(instance types.Instance, region s
 	if instance.PublicIpAddress != nil {
 		metadata["public_ip"] = *instance.PublicIpAddress
 	}
+	// Elastic IPs stay allocated, and billed, while the instance is stopped;
+	// AWS-assigned public IPs are released instead
+	var elasticIPs int
+	for _, eni := range instance.NetworkInterfaces {
+		for _, addr := range eni.PrivateIpAddresses {
+			if addr.Association != nil && aws.ToString(addr.Association.IpOwnerId) != "amazon" {
+				elasticIPs++
+			}
+		}
+	}
+	if elasticIPs > 0 {
+		metadata["elastic_ips"] = float64(elasticIPs)
+	}
+	// NAT instances forward traffic for other hosts, so AWS's check that an
+	// instance is the source or destination of its traffic is turned off
+	if instance.SourceDestCheck != nil && !*instance.SourceDestCheck {
+		metadata["nat_instance"] = true
+	}
 
 	// Get cost estimate
 	costPerHour := estimateEC2Cost(string(instance.InstanceType), region)
@@ -124,6 +289,7 @@ func (m *EC2ServiceManager) instanceToResource(instance types.Instance, region s
 	return models.Resource{
 		ServiceType:  models.ServiceEC2,
 		ResourceID:   aws.ToString(instance.InstanceId),
+		ARN:          resourceARN("ec2", region, ownerID, "instance/"+aws.ToString(instance.InstanceId)),
 		Region:       region,
 		CurrentState: models.StateRunning,
 		Tags:         tags,
@@ -134,27 +300,8 @@ func (m *EC2ServiceManager) instanceToResource(instance types.Instance, region s
 
 // estimateEC2Cost returns estimated hourly cost for an EC2 instance type
 func estimateEC2Cost(instanceType, region string) float64 {
-	// Simplified pricing data - in production, use AWS Pricing API
-	pricing := map[string]float64{
-		"t2.micro":    0.0116,
-		"t2.small":    0.023,
-		"t2.medium":   0.0464,
-		"t2.large":    0.0928,
-		"t3.micro":    0.0104,
-		"t3.small":    0.0208,
-		"t3.medium":   0.0416,
-		"t3.large":    0.0832,
-		"m5.large":    0.096,
-		"m5.xlarge":   0.192,
-		"m5.2xlarge":  0.384,
-		"c5.large":    0.085,
-		"c5.xlarge":   0.17,
-		"r5.large":    0.126,
-		"r5.xlarge":   0.252,
-	}
-
-	if cost, ok := pricing[instanceType]; ok {
-		return cost
+	if rate, ok := cost.StaticRate(models.ServiceEC2, instanceType); ok {
+		return rate
 	}
 	return 0.05 // Default estimate
 }
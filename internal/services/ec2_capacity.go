@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// spotFleetTag is set by EC2 on instances launched by a Spot Fleet request
+const spotFleetTag = "aws:ec2spot:fleet-request-id"
+
+// discoverSpotFleets finds active Spot Fleet requests with capacity. Fleets
+// of type maintain are scaled to zero on pause; one-off request fleets can't
+// be modified and are reported only. The fleet's instances are removed from
+// instances, since stopping them individually makes the fleet replace them.
+func (m *EC2ServiceManager) discoverSpotFleets(ctx context.Context, instances []models.Resource, region string) ([]models.Resource, []models.Resource, error) {
+	var fleets []models.Resource
+
+	paginator := ec2.NewDescribeSpotFleetRequestsPaginator(m.client, &ec2.DescribeSpotFleetRequestsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to describe Spot Fleet requests: %w", err)
+		}
+
+		for _, req := range output.SpotFleetRequestConfigs {
+			if req.SpotFleetRequestState != types.BatchStateActive || req.SpotFleetRequestConfig == nil {
+				continue
+			}
+			if aws.ToInt32(req.SpotFleetRequestConfig.TargetCapacity) == 0 {
+				continue
+			}
+			fleets = append(fleets, spotFleetToResource(req, region))
+		}
+	}
+
+	if len(fleets) == 0 {
+		return instances, nil, nil
+	}
+
+	// Members are billed through the fleet: price it as the sum of its instances
+	index := make(map[string]int)
+	for i, f := range fleets {
+		index[f.ResourceID] = i
+	}
+	var kept []models.Resource
+	for _, inst := range instances {
+		if i, ok := index[inst.Tags[spotFleetTag]]; ok {
+			fleets[i].CostPerHour += inst.CostPerHour
+			continue
+		}
+		kept = append(kept, inst)
+	}
+
+	return kept, fleets, nil
+}
+
+func spotFleetToResource(req types.SpotFleetRequestConfig, region string) models.Resource {
+	cfg := req.SpotFleetRequestConfig
+	metadata := map[string]any{
+		"kind":                               "spot_fleet",
+		"fleet_type":                         string(cfg.Type),
+		"original_target_capacity":           float64(aws.ToInt32(cfg.TargetCapacity)),
+		"original_on_demand_target_capacity": float64(aws.ToInt32(cfg.OnDemandTargetCapacity)),
+	}
+	if cfg.Type != types.FleetTypeMaintain {
+		metadata["report_only"] = true
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceEC2,
+		ResourceID:   aws.ToString(req.SpotFleetRequestId),
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         ec2TagsToMap(req.Tags),
+		Metadata:     metadata,
+	}
+}
+
+// pauseSpotFleet scales a fleet to zero, terminating its instances. The
+// request stays open so resume can scale it back up.
+func (m *EC2ServiceManager) pauseSpotFleet(ctx context.Context, resource models.Resource) error {
+	_, err := m.client.ModifySpotFleetRequest(ctx, &ec2.ModifySpotFleetRequestInput{
+		SpotFleetRequestId:              aws.String(resource.ResourceID),
+		TargetCapacity:                  aws.Int32(0),
+		OnDemandTargetCapacity:          aws.Int32(0),
+		ExcessCapacityTerminationPolicy: types.ExcessCapacityTerminationPolicyDefault,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scale Spot Fleet %s to zero: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+// resumeSpotFleet restores a fleet's original target capacity
+func (m *EC2ServiceManager) resumeSpotFleet(ctx context.Context, resource models.Resource) error {
+	target, ok := resource.Metadata["original_target_capacity"].(float64)
+	if !ok {
+		return fmt.Errorf("missing original_target_capacity in resource metadata")
+	}
+	onDemand, _ := resource.Metadata["original_on_demand_target_capacity"].(float64)
+
+	_, err := m.client.ModifySpotFleetRequest(ctx, &ec2.ModifySpotFleetRequestInput{
+		SpotFleetRequestId:     aws.String(resource.ResourceID),
+		TargetCapacity:         aws.Int32(int32(target)),
+		OnDemandTargetCapacity: aws.Int32(int32(onDemand)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore Spot Fleet %s: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+// discoverCapacityReservations finds active On-Demand Capacity Reservations
+// with idle slots. A reservation bills for every slot whether or not an
+// instance uses it; the slots in use are already counted with their
+// instances, so the resource's cost covers the idle slots. Reservations are
+// reported only unless cancel is set, since recreating one gives it a new ID
+// and capacity may not be available again. Capacity Blocks and reservations
+// with a commitment can't be cancelled and are always reported only.
+func (m *EC2ServiceManager) discoverCapacityReservations(ctx context.Context, region string, cancel bool) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	paginator := ec2.NewDescribeCapacityReservationsPaginator(m.client, &ec2.DescribeCapacityReservationsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("state"),
+				Values: []string{string(types.CapacityReservationStateActive)},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe capacity reservations: %w", err)
+		}
+		for _, cr := range output.CapacityReservations {
+			// Fully used, it costs nothing beyond its instances
+			if aws.ToInt32(cr.AvailableInstanceCount) == 0 {
+				continue
+			}
+			r := capacityReservationToResource(cr, region)
+			if !cancel {
+				r.Metadata["report_only"] = true
+			}
+			resources = append(resources, r)
+		}
+	}
+
+	return resources, nil
+}
+
+func capacityReservationToResource(cr types.CapacityReservation, region string) models.Resource {
+	instanceType := aws.ToString(cr.InstanceType)
+	available := aws.ToInt32(cr.AvailableInstanceCount)
+
+	metadata := map[string]any{
+		"kind":                    "capacity_reservation",
+		"instance_type":           instanceType,
+		"instance_platform":       string(cr.InstancePlatform),
+		"availability_zone":       aws.ToString(cr.AvailabilityZone),
+		"tenancy":                 string(cr.Tenancy),
+		"instance_count":          float64(aws.ToInt32(cr.TotalInstanceCount)),
+		"available_count":         float64(available),
+		"ebs_optimized":           aws.ToBool(cr.EbsOptimized),
+		"instance_match_criteria": string(cr.InstanceMatchCriteria),
+		"end_date_type":           string(cr.EndDateType),
+	}
+	if cr.EndDate != nil {
+		metadata["end_date"] = cr.EndDate.UTC().Format(time.RFC3339)
+	}
+	if cr.PlacementGroupArn != nil {
+		metadata["placement_group_arn"] = *cr.PlacementGroupArn
+	}
+	if cr.OutpostArn != nil {
+		metadata["outpost_arn"] = *cr.OutpostArn
+	}
+	if cr.ReservationType == types.CapacityReservationTypeCapacityBlock || cr.CommitmentInfo != nil {
+		metadata["report_only"] = true
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceEC2,
+		ResourceID:   aws.ToString(cr.CapacityReservationId),
+		ARN:          aws.ToString(cr.CapacityReservationArn),
+		Region:       region,
+		CurrentState: models.StateAvailable,
+		Tags:         ec2TagsToMap(cr.Tags),
+		Metadata:     metadata,
+		CostPerHour:  estimateEC2Cost(instanceType, region) * float64(available),
+	}
+}
+
+// cancelCapacityReservation releases a reservation. Its settings are kept in
+// the snapshot so resume can create an identical one.
+func (m *EC2ServiceManager) cancelCapacityReservation(ctx context.Context, resource models.Resource) error {
+	_, err := m.client.CancelCapacityReservation(ctx, &ec2.CancelCapacityReservationInput{
+		CapacityReservationId: aws.String(resource.ResourceID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel capacity reservation %s: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+// recreateCapacityReservation creates a reservation matching a cancelled one.
+// The new reservation gets a new ID, so instances that target the old
+// reservation by ID must be pointed at it.
+func (m *EC2ServiceManager) recreateCapacityReservation(ctx context.Context, resource models.Resource) error {
+	md := resource.Metadata
+	count, _ := md["instance_count"].(float64)
+	if count < 1 {
+		return fmt.Errorf("missing instance_count in resource metadata")
+	}
+
+	input := &ec2.CreateCapacityReservationInput{
+		InstanceType:          aws.String(stringMeta(md, "instance_type")),
+		InstancePlatform:      types.CapacityReservationInstancePlatform(stringMeta(md, "instance_platform")),
+		AvailabilityZone:      aws.String(stringMeta(md, "availability_zone")),
+		InstanceCount:         aws.Int32(int32(count)),
+		Tenancy:               types.CapacityReservationTenancy(stringMeta(md, "tenancy")),
+		InstanceMatchCriteria: types.InstanceMatchCriteria(stringMeta(md, "instance_match_criteria")),
+		EndDateType:           types.EndDateType(stringMeta(md, "end_date_type")),
+	}
+	if ebs, ok := md["ebs_optimized"].(bool); ok {
+		input.EbsOptimized = aws.Bool(ebs)
+	}
+	if v := stringMeta(md, "end_date"); v != "" {
+		end, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("invalid end_date in resource metadata: %w", err)
+		}
+		if !end.After(time.Now()) {
+			return fmt.Errorf("capacity reservation %s would have expired at %s; not recreating it", resource.ResourceID, v)
+		}
+		input.EndDate = aws.Time(end)
+	}
+	if v := stringMeta(md, "placement_group_arn"); v != "" {
+		input.PlacementGroupArn = aws.String(v)
+	}
+	if v := stringMeta(md, "outpost_arn"); v != "" {
+		input.OutpostArn = aws.String(v)
+	}
+
+	var tags []types.Tag
+	for k, v := range resource.Tags {
+		// aws: tags are reserved and set by AWS
+		if !strings.HasPrefix(k, "aws:") {
+			tags = append(tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+	}
+	if len(tags) > 0 {
+		input.TagSpecifications = []types.TagSpecification{
+			{ResourceType: types.ResourceTypeCapacityReservation, Tags: tags},
+		}
+	}
+
+	if _, err := m.client.CreateCapacityReservation(ctx, input); err != nil {
+		return fmt.Errorf("failed to recreate capacity reservation %s: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+// stringMeta returns a string metadata value, or "" if absent
+func stringMeta(metadata map[string]any, key string) string {
+	s, _ := metadata[key].(string)
+	return s
+}
@@ -2,15 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
 	"github.com/aicoder2009/aws-hit-breaks/internal/models"
 )
 
+// ecsStableTimeout bounds how long pause waits for a service's tasks to stop
+const ecsStableTimeout = 10 * time.Minute
+
 // ECSServiceManager handles ECS service operations
 type ECSServiceManager struct {
 	client *ecs.Client
@@ -30,6 +36,11 @@ func (m *ECSServiceManager) ServiceType() models.ServiceType {
 	return models.ServiceECS
 }
 
+// Capabilities reports that services are scaled to zero tasks
+func (m *ECSServiceManager) Capabilities() Capabilities {
+	return Capabilities{ScaleToZero: true, Wait: true}
+}
+
 // Discover finds all running ECS services
 func (m *ECSServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
 	var resources []models.Resource
@@ -40,16 +51,25 @@ func (m *ECSServiceManager) Discover(ctx context.Context, region string) ([]mode
 		return nil, err
 	}
 
-	// For each cluster, list and describe services
+	// For each cluster, list and describe services. A cluster that can't be
+	// read doesn't hide the others.
+	var skipped []error
 	for _, clusterArn := range clusterArns {
 		services, err := m.discoverServicesInCluster(ctx, clusterArn, region)
-		if err != nil {
-			// Log error but continue with other clusters
-			continue
-		}
 		resources = append(resources, services...)
+		var partial *PartialError
+		switch {
+		case errors.As(err, &partial):
+			skipped = append(skipped, partial.Errs...)
+		case err != nil:
+			logging.Warn("skipping ECS cluster", "cluster", clusterArn, "error", err)
+			skipped = append(skipped, err)
+		}
 	}
 
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
 	return resources, nil
 }
 
@@ -80,7 +100,7 @@ func (m *ECSServiceManager) discoverServicesInCluster(ctx context.Context, clust
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list ECS services: %w", err)
+			return nil, fmt.Errorf("failed to list ECS services in %s: %w", clusterArn, err)
 		}
 		serviceArns = append(serviceArns, output.ServiceArns...)
 	}
@@ -90,6 +110,7 @@ func (m *ECSServiceManager) discoverServicesInCluster(ctx context.Context, clust
 	}
 
 	// Describe services (max 10 at a time)
+	var skipped []error
 	for i := 0; i < len(serviceArns); i += 10 {
 		end := i + 10
 		if end > len(serviceArns) {
@@ -102,8 +123,14 @@ func (m *ECSServiceManager) discoverServicesInCluster(ctx context.Context, clust
 			Services: batch,
 		})
 		if err != nil {
+			logging.Warn("skipping ECS services", "cluster", clusterArn, "services", batch, "error", err)
+			skipped = append(skipped, fmt.Errorf("failed to describe %d services in %s: %w", len(batch), clusterArn, err))
 			continue
 		}
+		for _, f := range output.Failures {
+			logging.Warn("ECS service not described", "arn", aws.ToString(f.Arn), "reason", aws.ToString(f.Reason))
+			skipped = append(skipped, fmt.Errorf("ECS service %s not described: %s", aws.ToString(f.Arn), aws.ToString(f.Reason)))
+		}
 
 		for _, svc := range output.Services {
 			// Only include services with running tasks
@@ -114,6 +141,9 @@ func (m *ECSServiceManager) discoverServicesInCluster(ctx context.Context, clust
 		}
 	}
 
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
 	return resources, nil
 }
 
@@ -160,6 +190,29 @@ func (m *ECSServiceManager) Resume(ctx context.Context, resource models.Resource
 	return nil
 }
 
+// Settle waits for a paused service to drain its tasks, so capacity providers
+// stopped after it aren't pulled out from under running tasks
+func (m *ECSServiceManager) Settle(ctx context.Context, resource models.Resource, operation string) error {
+	if operation != "pause" {
+		return nil
+	}
+
+	clusterArn, ok := resource.Metadata["cluster_arn"].(string)
+	if !ok {
+		return fmt.Errorf("missing cluster_arn in resource metadata")
+	}
+
+	waiter := ecs.NewServicesStableWaiter(m.client)
+	err := waiter.Wait(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(clusterArn),
+		Services: []string{resource.ResourceID},
+	}, waitTimeout(ctx, ecsStableTimeout))
+	if err != nil {
+		return fmt.Errorf("ECS service %s did not drain: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
 func (m *ECSServiceManager) serviceToResource(svc types.Service, clusterArn, region string) models.Resource {
 	// Extract tags
 	tags := make(map[string]string)
@@ -183,6 +236,7 @@ func (m *ECSServiceManager) serviceToResource(svc types.Service, clusterArn, reg
 	return models.Resource{
 		ServiceType:  models.ServiceECS,
 		ResourceID:   aws.ToString(svc.ServiceName),
+		ARN:          aws.ToString(svc.ServiceArn),
 		Region:       region,
 		CurrentState: models.StateRunning,
 		Tags:         tags,
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// EKSServiceManager handles EKS managed node group operations
+type EKSServiceManager struct {
+	client *eks.Client
+	region string
+}
+
+// NewEKSServiceManager creates a new EKS service manager
+func NewEKSServiceManager(cfg aws.Config) *EKSServiceManager {
+	return &EKSServiceManager{
+		client: eks.NewFromConfig(cfg),
+		region: cfg.Region,
+	}
+}
+
+// ServiceType returns the service type
+func (m *EKSServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceEKS
+}
+
+// Capabilities reports that node groups are scaled to zero nodes
+func (m *EKSServiceManager) Capabilities() Capabilities {
+	return Capabilities{ScaleToZero: true}
+}
+
+// Discover finds managed node groups with running nodes and Fargate profiles
+func (m *EKSServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	var clusters []string
+	paginator := eks.NewListClustersPaginator(m.client, &eks.ListClustersInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list EKS clusters: %w", err)
+		}
+		clusters = append(clusters, output.Clusters...)
+	}
+
+	for _, cluster := range clusters {
+		nodegroups, err := m.discoverNodegroups(ctx, cluster, region)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, nodegroups...)
+
+		profiles, err := m.discoverFargateProfiles(ctx, cluster, region)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, profiles...)
+	}
+
+	return resources, nil
+}
+
+func (m *EKSServiceManager) discoverNodegroups(ctx context.Context, cluster, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	paginator := eks.NewListNodegroupsPaginator(m.client, &eks.ListNodegroupsInput{
+		ClusterName: aws.String(cluster),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list node groups in %s: %w", cluster, err)
+		}
+
+		for _, name := range output.Nodegroups {
+			described, err := m.client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+				ClusterName:   aws.String(cluster),
+				NodegroupName: aws.String(name),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe node group %s/%s: %w", cluster, name, err)
+			}
+
+			ng := described.Nodegroup
+			if ng == nil || ng.ScalingConfig == nil || aws.ToInt32(ng.ScalingConfig.DesiredSize) == 0 {
+				continue
+			}
+			resources = append(resources, m.nodegroupToResource(*ng, region))
+		}
+	}
+
+	return resources, nil
+}
+
+func (m *EKSServiceManager) discoverFargateProfiles(ctx context.Context, cluster, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	paginator := eks.NewListFargateProfilesPaginator(m.client, &eks.ListFargateProfilesInput{
+		ClusterName: aws.String(cluster),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Fargate profiles in %s: %w", cluster, err)
+		}
+
+		for _, name := range output.FargateProfileNames {
+			// Fargate bills per running pod, so profiles are listed for visibility only
+			resources = append(resources, models.Resource{
+				ServiceType:  models.ServiceEKS,
+				ResourceID:   cluster + "/" + name,
+				Region:       region,
+				CurrentState: models.StateRunning,
+				Metadata: map[string]any{
+					"kind":         "fargate_profile",
+					"cluster_name": cluster,
+					"report_only":  true,
+				},
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+// Pause scales a managed node group to zero nodes
+func (m *EKSServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	cluster, nodegroup, err := nodegroupNames(resource)
+	if err != nil {
+		return err
+	}
+
+	// MaxSize must stay at least 1, so keep the original maximum
+	maxSize := int32(1)
+	if v, ok := resource.Metadata["original_max_size"].(float64); ok && v > 0 {
+		maxSize = int32(v)
+	}
+
+	_, err = m.client.UpdateNodegroupConfig(ctx, &eks.UpdateNodegroupConfigInput{
+		ClusterName:   aws.String(cluster),
+		NodegroupName: aws.String(nodegroup),
+		ScalingConfig: &types.NodegroupScalingConfig{
+			MinSize:     aws.Int32(0),
+			MaxSize:     aws.Int32(maxSize),
+			DesiredSize: aws.Int32(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scale EKS node group %s to zero: %w", resource.ResourceID, err)
+	}
+
+	return nil
+}
+
+// Resume restores a managed node group to its original scaling configuration
+func (m *EKSServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	cluster, nodegroup, err := nodegroupNames(resource)
+	if err != nil {
+		return err
+	}
+
+	scaling := &types.NodegroupScalingConfig{
+		MinSize:     aws.Int32(0),
+		MaxSize:     aws.Int32(1),
+		DesiredSize: aws.Int32(1), // Default
+	}
+	if v, ok := resource.Metadata["original_min_size"].(float64); ok {
+		scaling.MinSize = aws.Int32(int32(v))
+	}
+	if v, ok := resource.Metadata["original_max_size"].(float64); ok {
+		scaling.MaxSize = aws.Int32(int32(v))
+	}
+	if v, ok := resource.Metadata["original_desired_size"].(float64); ok {
+		scaling.DesiredSize = aws.Int32(int32(v))
+	}
+
+	_, err = m.client.UpdateNodegroupConfig(ctx, &eks.UpdateNodegroupConfigInput{
+		ClusterName:   aws.String(cluster),
+		NodegroupName: aws.String(nodegroup),
+		ScalingConfig: scaling,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore EKS node group %s: %w", resource.ResourceID, err)
+	}
+
+	return nil
+}
+
+func (m *EKSServiceManager) nodegroupToResource(ng types.Nodegroup, region string) models.Resource {
+	cluster := aws.ToString(ng.ClusterName)
+	name := aws.ToString(ng.NodegroupName)
+	desired := aws.ToInt32(ng.ScalingConfig.DesiredSize)
+
+	metadata := map[string]any{
+		"kind":                  "nodegroup",
+		"cluster_name":          cluster,
+		"nodegroup_name":        name,
+		"original_min_size":     float64(aws.ToInt32(ng.ScalingConfig.MinSize)),
+		"original_max_size":     float64(aws.ToInt32(ng.ScalingConfig.MaxSize)),
+		"original_desired_size": float64(desired),
+		"capacity_type":         string(ng.CapacityType),
+		"instance_types":        ng.InstanceTypes,
+	}
+
+	instanceType := ""
+	if len(ng.InstanceTypes) > 0 {
+		instanceType = ng.InstanceTypes[0]
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceEKS,
+		ResourceID:   cluster + "/" + name,
+		ARN:          aws.ToString(ng.NodegroupArn),
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         ng.Tags,
+		Metadata:     metadata,
+		CostPerHour:  estimateEC2Cost(instanceType, region) * float64(desired),
+	}
+}
+
+// nodegroupNames returns the cluster and node group names for a node group resource
+func nodegroupNames(resource models.Resource) (string, string, error) {
+	if resource.Metadata["kind"] != "nodegroup" {
+		return "", "", fmt.Errorf("%s is not a managed node group", resource.ResourceID)
+	}
+
+	cluster, ok := resource.Metadata["cluster_name"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("missing cluster_name in resource metadata")
+	}
+	nodegroup, ok := resource.Metadata["nodegroup_name"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("missing nodegroup_name in resource metadata")
+	}
+
+	return cluster, nodegroup, nil
+}
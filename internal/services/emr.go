@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/emr"
+	"github.com/aws/aws-sdk-go-v2/service/emr/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// emrSurcharge is EMR's per-instance charge on top of the EC2 price, as a
+	// share of it
+	emrSurcharge = 0.25
+	// emrMetricWindow is how far back HDFS utilization is read; EMR
+	// publishes it every five minutes
+	emrMetricWindow = 15 * time.Minute
+	// emrHDFSHeadroom is the share of the remaining core nodes' HDFS
+	// capacity the data may fill once the cluster is parked
+	emrHDFSHeadroom = 0.75
+)
+
+// emrNodes is the size of a core or task instance group or fleet. Groups
+// count instances; fleets count on-demand and spot capacity units.
+type emrNodes struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"` // CORE or TASK
+	InstanceType string `json:"instance_type,omitempty"`
+	Count        int32  `json:"count,omitempty"`
+	OnDemand     int32  `json:"on_demand,omitempty"`
+	Spot         int32  `json:"spot,omitempty"`
+}
+
+// EMRServiceManager handles EMR clusters. Clusters can't be stopped and a
+// terminated cluster can't be started again, so clusters are never
+// terminated, whether termination protected or not. Pausing scales task
+// nodes to zero and core nodes down to the fewest that HDFS replication
+// allows and its data fits on, with managed scaling removed so it doesn't
+// scale them back up; resuming restores the recorded sizes and scaling
+// policy.
+type EMRServiceManager struct {
+	client *emr.Client
+	cw     *cloudwatch.Client
+	region string
+}
+
+// NewEMRServiceManager creates a new EMR service manager
+func NewEMRServiceManager(cfg aws.Config) *EMRServiceManager {
+	return &EMRServiceManager{
+		client: emr.NewFromConfig(cfg),
+		cw:     cloudwatch.NewFromConfig(cfg),
+		region: cfg.Region,
+	}
+}
+
+// ServiceType returns the service type
+func (m *EMRServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceEMR
+}
+
+// Capabilities reports that clusters are scaled down rather than stopped
+func (m *EMRServiceManager) Capabilities() Capabilities {
+	return Capabilities{ScaleToZero: true}
+}
+
+// Discover finds running or waiting clusters with core or task nodes to
+// shed. A cluster whose nodes or HDFS utilization can't be read is skipped.
+func (m *EMRServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+	var skipped []error
+
+	paginator := emr.NewListClustersPaginator(m.client, &emr.ListClustersInput{
+		ClusterStates: []types.ClusterState{types.ClusterStateRunning, types.ClusterStateWaiting},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list EMR clusters: %w", err)
+		}
+
+		for _, summary := range output.Clusters {
+			resource, ok, err := m.clusterToResource(ctx, aws.ToString(summary.Id), region)
+			if err != nil {
+				logging.Warn("skipping EMR cluster", "cluster", aws.ToString(summary.Id), "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			if ok {
+				resources = append(resources, resource)
+			}
+		}
+	}
+
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
+	return resources, nil
+}
+
+// clusterToResource describes a cluster and its nodes, reporting false when
+// it is already as small as pausing would make it
+func (m *EMRServiceManager) clusterToResource(ctx context.Context, id, region string) (models.Resource, bool, error) {
+	output, err := m.client.DescribeCluster(ctx, &emr.DescribeClusterInput{ClusterId: aws.String(id)})
+	if err != nil {
+		return models.Resource{}, false, fmt.Errorf("failed to describe EMR cluster %s: %w", id, err)
+	}
+	cluster := output.Cluster
+	if cluster == nil {
+		return models.Resource{}, false, fmt.Errorf("EMR cluster %s not found", id)
+	}
+
+	nodes, err := m.nodes(ctx, cluster)
+	if err != nil {
+		return models.Resource{}, false, err
+	}
+	core, err := m.coreFloor(ctx, id, nodes)
+	if err != nil {
+		return models.Resource{}, false, err
+	}
+	var cost float64
+	shrinks := false
+	for _, n := range nodes {
+		parked := parkedNodes(n, core)
+		if nodeCount(parked) < nodeCount(n) {
+			shrinks = true
+		}
+		rate := estimateEC2Cost(n.InstanceType, region) * (1 + emrSurcharge)
+		cost += rate * float64(nodeCount(n)-nodeCount(parked))
+	}
+	if !shrinks {
+		return models.Resource{}, false, nil
+	}
+
+	limits, err := m.managedScaling(ctx, id)
+	if err != nil {
+		return models.Resource{}, false, err
+	}
+
+	tags := make(map[string]string)
+	for _, t := range cluster.Tags {
+		tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	metadata := map[string]any{
+		"name":                  aws.ToString(cluster.Name),
+		"release_label":         aws.ToString(cluster.ReleaseLabel),
+		"collection":            string(cluster.InstanceCollectionType),
+		"termination_protected": aws.ToBool(cluster.TerminationProtected),
+		"original_nodes":        nodes,
+	}
+	if limits != nil {
+		metadata["managed_scaling"] = limits
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceEMR,
+		ResourceID:   id,
+		ARN:          aws.ToString(cluster.ClusterArn),
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         tags,
+		Metadata:     metadata,
+		// The primary node and the core nodes HDFS needs keep running
+		CostPerHour: cost,
+	}, true, nil
+}
+
+// nodes returns a cluster's core and task instance groups or fleets
+func (m *EMRServiceManager) nodes(ctx context.Context, cluster *types.Cluster) ([]emrNodes, error) {
+	id := aws.ToString(cluster.Id)
+	var nodes []emrNodes
+
+	if cluster.InstanceCollectionType == types.InstanceCollectionTypeInstanceFleet {
+		paginator := emr.NewListInstanceFleetsPaginator(m.client, &emr.ListInstanceFleetsInput{ClusterId: aws.String(id)})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list instance fleets of EMR cluster %s: %w", id, err)
+			}
+			for _, f := range output.InstanceFleets {
+				if f.InstanceFleetType == types.InstanceFleetTypeMaster {
+					continue
+				}
+				n := emrNodes{
+					ID:       aws.ToString(f.Id),
+					Type:     string(f.InstanceFleetType),
+					OnDemand: aws.ToInt32(f.TargetOnDemandCapacity),
+					Spot:     aws.ToInt32(f.TargetSpotCapacity),
+				}
+				if len(f.InstanceTypeSpecifications) > 0 {
+					n.InstanceType = aws.ToString(f.InstanceTypeSpecifications[0].InstanceType)
+				}
+				nodes = append(nodes, n)
+			}
+		}
+		return nodes, nil
+	}
+
+	paginator := emr.NewListInstanceGroupsPaginator(m.client, &emr.ListInstanceGroupsInput{ClusterId: aws.String(id)})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instance groups of EMR cluster %s: %w", id, err)
+		}
+		for _, g := range output.InstanceGroups {
+			if g.InstanceGroupType == types.InstanceGroupTypeMaster {
+				continue
+			}
+			nodes = append(nodes, emrNodes{
+				ID:           aws.ToString(g.Id),
+				Type:         string(g.InstanceGroupType),
+				InstanceType: aws.ToString(g.InstanceType),
+				Count:        aws.ToInt32(g.RequestedInstanceCount),
+			})
+		}
+	}
+	return nodes, nil
+}
+
+// managedScaling returns a cluster's managed scaling limits, or nil if it has none
+func (m *EMRServiceManager) managedScaling(ctx context.Context, id string) (*types.ComputeLimits, error) {
+	output, err := m.client.GetManagedScalingPolicy(ctx, &emr.GetManagedScalingPolicyInput{ClusterId: aws.String(id)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get managed scaling of EMR cluster %s: %w", id, err)
+	}
+	if output.ManagedScalingPolicy == nil {
+		return nil, nil
+	}
+	return output.ManagedScalingPolicy.ComputeLimits, nil
+}
+
+// Pause removes managed scaling and shrinks the core and task nodes. HDFS
+// utilization is read again first, since data may have grown since
+// discovery. If the nodes can't be shrunk, managed scaling is put back.
+func (m *EMRServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	var nodes []emrNodes
+	if err := decodeMetadata(resource, "original_nodes", &nodes); err != nil {
+		return err
+	}
+	core, err := m.coreFloor(ctx, resource.ResourceID, nodes)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := resource.Metadata["managed_scaling"]; ok {
+		_, err := m.client.RemoveManagedScalingPolicy(ctx, &emr.RemoveManagedScalingPolicyInput{
+			ClusterId: aws.String(resource.ResourceID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove managed scaling from EMR cluster %s: %w", resource.ResourceID, err)
+		}
+	}
+
+	parked := make([]emrNodes, len(nodes))
+	for i, n := range nodes {
+		parked[i] = parkedNodes(n, core)
+	}
+	if err := m.resize(ctx, resource, parked, "park"); err != nil {
+		return errors.Join(err, m.restoreManagedScaling(ctx, resource))
+	}
+	return nil
+}
+
+// Resume restores the recorded node counts and managed scaling
+func (m *EMRServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	var nodes []emrNodes
+	if err := decodeMetadata(resource, "original_nodes", &nodes); err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no original nodes recorded for EMR cluster %s", resource.ResourceID)
+	}
+
+	if err := m.resize(ctx, resource, nodes, "restore"); err != nil {
+		return err
+	}
+	return m.restoreManagedScaling(ctx, resource)
+}
+
+// restoreManagedScaling puts back the managed scaling policy recorded at
+// discovery, if the cluster had one
+func (m *EMRServiceManager) restoreManagedScaling(ctx context.Context, resource models.Resource) error {
+	var limits *types.ComputeLimits
+	if err := decodeMetadata(resource, "managed_scaling", &limits); err != nil {
+		return err
+	}
+	if limits == nil {
+		return nil
+	}
+	_, err := m.client.PutManagedScalingPolicy(ctx, &emr.PutManagedScalingPolicyInput{
+		ClusterId:            aws.String(resource.ResourceID),
+		ManagedScalingPolicy: &types.ManagedScalingPolicy{ComputeLimits: limits},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore managed scaling of EMR cluster %s: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
+// resize sets the size of each instance group or fleet
+func (m *EMRServiceManager) resize(ctx context.Context, resource models.Resource, nodes []emrNodes, action string) error {
+	id := resource.ResourceID
+
+	if resource.Metadata["collection"] == string(types.InstanceCollectionTypeInstanceFleet) {
+		for _, n := range nodes {
+			_, err := m.client.ModifyInstanceFleet(ctx, &emr.ModifyInstanceFleetInput{
+				ClusterId: aws.String(id),
+				InstanceFleet: &types.InstanceFleetModifyConfig{
+					InstanceFleetId:        aws.String(n.ID),
+					TargetOnDemandCapacity: aws.Int32(n.OnDemand),
+					TargetSpotCapacity:     aws.Int32(n.Spot),
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to %s %s fleet of EMR cluster %s: %w", action, n.Type, id, err)
+			}
+		}
+		return nil
+	}
+
+	groups := make([]types.InstanceGroupModifyConfig, 0, len(nodes))
+	for _, n := range nodes {
+		groups = append(groups, types.InstanceGroupModifyConfig{
+			InstanceGroupId: aws.String(n.ID),
+			InstanceCount:   aws.Int32(n.Count),
+		})
+	}
+	_, err := m.client.ModifyInstanceGroups(ctx, &emr.ModifyInstanceGroupsInput{
+		ClusterId:      aws.String(id),
+		InstanceGroups: groups,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %s instance groups of EMR cluster %s: %w", action, id, err)
+	}
+	return nil
+}
+
+// parkedNodes returns the size a group or fleet is paused at: task nodes go
+// to zero, core nodes down to core, the floor coreFloor works out
+func parkedNodes(n emrNodes, core int32) emrNodes {
+	parked := n
+	parked.Count, parked.OnDemand, parked.Spot = 0, 0, 0
+	if n.Type != string(types.InstanceGroupTypeCore) {
+		return parked
+	}
+
+	switch {
+	case n.Count > 0:
+		parked.Count = min(n.Count, core)
+	case n.OnDemand > 0:
+		parked.OnDemand = min(n.OnDemand, core)
+	default:
+		parked.Spot = min(n.Spot, core)
+	}
+	return parked
+}
+
+// coreFloor returns the fewest core nodes a cluster can be parked at: no
+// fewer than HDFS replication needs, and enough to hold the data in HDFS at
+// emrHDFSHeadroom. Without the utilization to check, it is an error.
+func (m *EMRServiceManager) coreFloor(ctx context.Context, id string, nodes []emrNodes) (int32, error) {
+	var core int32
+	for _, n := range nodes {
+		if n.Type == string(types.InstanceGroupTypeCore) {
+			core += nodeCount(n)
+		}
+	}
+	if core == 0 {
+		return 0, nil
+	}
+
+	end := time.Now()
+	output, err := m.cw.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String("AWS/ElasticMapReduce"),
+		MetricName: aws.String("HDFSUtilization"),
+		Dimensions: []cwtypes.Dimension{{Name: aws.String("JobFlowId"), Value: aws.String(id)}},
+		StartTime:  aws.Time(end.Add(-emrMetricWindow)),
+		EndTime:    aws.Time(end),
+		Period:     aws.Int32(300),
+		Statistics: []cwtypes.Statistic{cwtypes.StatisticMaximum},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read HDFS utilization of EMR cluster %s: %w", id, err)
+	}
+	var latest *cwtypes.Datapoint
+	for i, dp := range output.Datapoints {
+		if latest == nil || aws.ToTime(dp.Timestamp).After(aws.ToTime(latest.Timestamp)) {
+			latest = &output.Datapoints[i]
+		}
+	}
+	if latest == nil {
+		return 0, fmt.Errorf("no recent HDFS utilization for EMR cluster %s", id)
+	}
+
+	// Utilization is a percentage of the current core nodes' capacity
+	forData := int32(math.Ceil(float64(core) * aws.ToFloat64(latest.Maximum) / 100 / emrHDFSHeadroom))
+	return min(max(minCoreNodes(nodes), forData), core), nil
+}
+
+// minCoreNodes returns the fewest core nodes the cluster's HDFS replication
+// factor allows. EMR sets the factor from the core node count: 1 below
+// four nodes, 2 below ten, otherwise 3.
+func minCoreNodes(nodes []emrNodes) int32 {
+	var core int32
+	for _, n := range nodes {
+		if n.Type == string(types.InstanceGroupTypeCore) {
+			core += max(n.Count, n.OnDemand+n.Spot)
+		}
+	}
+	switch {
+	case core >= 10:
+		return 3
+	case core >= 4:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// nodeCount returns the nodes or capacity units of a group or fleet
+func nodeCount(n emrNodes) int32 {
+	return n.Count + n.OnDemand + n.Spot
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// IaC modes: warn about resources managed by infrastructure as code, skip
+// them, or don't look for them
+const (
+	IaCWarn = "warn"
+	IaCSkip = "skip"
+	IaCOff  = "off"
+)
+
+// cloudFormationStackTag is set by CloudFormation on the resources of a stack
+const cloudFormationStackTag = "aws:cloudformation:stack-name"
+
+// managedByTags are tag keys teams commonly set to name the tool that owns a
+// resource, matched case-insensitively
+var managedByTags = []string{"managed_by", "managed-by", "managedby"}
+
+// iacTools are the managed-by tag values recognized as infrastructure as code
+var iacTools = map[string]bool{
+	"terraform":  true,
+	"opentofu":   true,
+	"pulumi":     true,
+	"cdk":        true,
+	"crossplane": true,
+}
+
+// iacDetector records which discovered resources an IaC tool manages, since
+// pausing them shows up as drift and the next apply may undo it
+type iacDetector struct {
+	terraform map[string]string // resource ID or ARN to Terraform address
+}
+
+// newIaCDetector creates a detector from the IaC config, which may be nil.
+// It returns nil when detection is off. State files that can't be read are
+// logged and left out.
+func newIaCDetector(cfg *models.IaCConfig) *iacDetector {
+	d := &iacDetector{terraform: make(map[string]string)}
+	if cfg == nil {
+		return d
+	}
+	if cfg.Mode == IaCOff {
+		return nil
+	}
+	for _, path := range cfg.TerraformStates {
+		if err := loadTerraformState(path, d.terraform); err != nil {
+			logging.Warn("skipping Terraform state", "path", path, "error", err)
+		}
+	}
+	return d
+}
+
+// annotate sets managed_by metadata on resources an IaC tool manages
+func (d *iacDetector) annotate(resources []models.Resource) {
+	if d == nil {
+		return
+	}
+	for i := range resources {
+		r := &resources[i]
+		if by := d.managedBy(*r); by != "" {
+			if r.Metadata == nil {
+				r.Metadata = make(map[string]any)
+			}
+			r.Metadata["managed_by"] = by
+		}
+	}
+}
+
+func (d *iacDetector) managedBy(r models.Resource) string {
+	if stack := r.Tags[cloudFormationStackTag]; stack != "" {
+		return "cloudformation:" + stack
+	}
+	if address := d.terraform[r.ResourceID]; address != "" {
+		return "terraform:" + address
+	}
+	if address := d.terraform[r.ARN]; r.ARN != "" && address != "" {
+		return "terraform:" + address
+	}
+	for key, value := range r.Tags {
+		for _, tag := range managedByTags {
+			if strings.EqualFold(key, tag) && iacTools[strings.ToLower(value)] {
+				return strings.ToLower(value)
+			}
+		}
+	}
+	return ""
+}
+
+// ManagedBy returns the IaC tool, and stack or address where known, that
+// manages a resource, e.g. "cloudformation:dev-stack", or "" if none does
+func ManagedBy(r models.Resource) string {
+	by, _ := r.Metadata["managed_by"].(string)
+	return by
+}
+
+// terraformState is the part of a Terraform state file (format version 4)
+// needed to map resources to their addresses
+type terraformState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			Attributes struct {
+				ID  string `json:"id"`
+				ARN string `json:"arn"`
+			} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// loadTerraformState adds the AWS resources in a local state file to
+// addresses, by ID and ARN. Remote state can be saved locally with
+// 'terraform state pull'.
+func loadTerraformState(path string, addresses map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read Terraform state: %w", err)
+	}
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse Terraform state: %w", err)
+	}
+	if state.Version != 4 {
+		return fmt.Errorf("unsupported Terraform state version %d", state.Version)
+	}
+
+	for _, res := range state.Resources {
+		if res.Mode != "managed" || !strings.HasPrefix(res.Type, "aws_") {
+			continue
+		}
+		address := res.Type + "." + res.Name
+		if res.Module != "" {
+			address = res.Module + "." + address
+		}
+		for _, inst := range res.Instances {
+			if id := inst.Attributes.ID; id != "" {
+				addresses[id] = address
+			}
+			if arn := inst.Attributes.ARN; arn != "" {
+				addresses[arn] = address
+			}
+		}
+	}
+	return nil
+}
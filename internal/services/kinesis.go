@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// Hourly prices of a provisioned shard and of an on-demand stream
+	kinesisShardHourlyCost    = 0.015
+	kinesisOnDemandHourlyCost = 0.04
+
+	// kinesisActiveTimeout bounds waits for a stream to finish resharding or
+	// switching capacity mode
+	kinesisActiveTimeout = 10 * time.Minute
+
+	// kinesisMaxReshardSteps is how many UpdateShardCount calls a pause or a
+	// resume may make: half the 10 Kinesis allows per stream per day, so
+	// both fit. Each call at most halves or doubles the stream, so streams
+	// above kinesisMaxShards are left alone.
+	kinesisMaxReshardSteps = 5
+	kinesisMaxShards       = 1 << kinesisMaxReshardSteps
+)
+
+// KinesisServiceManager handles Kinesis data streams. Streams can't be
+// stopped, so pausing switches on-demand streams to provisioned mode and
+// reshards them down to a single shard, the cheapest a stream can be, and
+// resuming reshards back up and restores the original mode. Records keep
+// flowing at the reduced throughput. AWS limits how often a stream can be
+// resharded or switch modes each day, so pausing and resuming the same
+// stream repeatedly in one day can fail.
+type KinesisServiceManager struct {
+	client *kinesis.Client
+	region string
+}
+
+// NewKinesisServiceManager creates a new Kinesis service manager
+func NewKinesisServiceManager(cfg aws.Config) *KinesisServiceManager {
+	return &KinesisServiceManager{
+		client: kinesis.NewFromConfig(cfg),
+		region: cfg.Region,
+	}
+}
+
+// ServiceType returns the service type
+func (m *KinesisServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceKinesis
+}
+
+// Capabilities reports that streams are scaled down rather than stopped
+func (m *KinesisServiceManager) Capabilities() Capabilities {
+	return Capabilities{ScaleToZero: true}
+}
+
+// Discover finds active streams that are on-demand or have more than one
+// shard. A stream whose tags can't be read is skipped, since protection
+// rules depend on them, and so is one with more than kinesisMaxShards
+// shards, which can't be resharded down and back up in one day.
+func (m *KinesisServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+	var skipped []error
+
+	paginator := kinesis.NewListStreamsPaginator(m.client, &kinesis.ListStreamsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Kinesis streams: %w", err)
+		}
+
+		for _, name := range output.StreamNames {
+			summary, err := m.describe(ctx, name)
+			if err != nil {
+				logging.Warn("skipping Kinesis stream", "stream", name, "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			if summary.StreamStatus != types.StreamStatusActive || (!onDemand(summary) && aws.ToInt32(summary.OpenShardCount) <= 1) {
+				continue
+			}
+			if shards := aws.ToInt32(summary.OpenShardCount); shards > kinesisMaxShards {
+				logging.Debug("skipping Kinesis stream with too many shards to reshard in a day", "stream", name, "shards", shards)
+				continue
+			}
+
+			tags, err := m.tags(ctx, name)
+			if err != nil {
+				logging.Warn("skipping Kinesis stream", "stream", name, "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			resources = append(resources, streamToResource(summary, tags, region))
+		}
+	}
+
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
+	return resources, nil
+}
+
+func (m *KinesisServiceManager) describe(ctx context.Context, name string) (*types.StreamDescriptionSummary, error) {
+	output, err := m.client.DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{
+		StreamName: aws.String(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe Kinesis stream %s: %w", name, err)
+	}
+	if output.StreamDescriptionSummary == nil {
+		return nil, fmt.Errorf("Kinesis stream %s not found", name)
+	}
+	return output.StreamDescriptionSummary, nil
+}
+
+func (m *KinesisServiceManager) tags(ctx context.Context, name string) (map[string]string, error) {
+	tags := make(map[string]string)
+	input := &kinesis.ListTagsForStreamInput{StreamName: aws.String(name)}
+	for {
+		output, err := m.client.ListTagsForStream(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tags of Kinesis stream %s: %w", name, err)
+		}
+		for _, t := range output.Tags {
+			tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+		}
+		if !aws.ToBool(output.HasMoreTags) || len(output.Tags) == 0 {
+			return tags, nil
+		}
+		input.ExclusiveStartTagKey = output.Tags[len(output.Tags)-1].Key
+	}
+}
+
+// Pause switches the stream to provisioned mode if needed and reshards it
+// down to one shard
+func (m *KinesisServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	summary, err := m.describe(ctx, resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	if onDemand(summary) {
+		if err := m.setMode(ctx, summary, types.StreamModeProvisioned); err != nil {
+			return err
+		}
+	}
+	return m.reshard(ctx, resource.ResourceID, 1)
+}
+
+// Resume reshards the stream back to its original shard count and restores
+// its original capacity mode
+func (m *KinesisServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	shards, _ := resource.Metadata["original_shard_count"].(float64)
+	if shards < 1 {
+		return fmt.Errorf("no original shard count recorded for Kinesis stream %s", resource.ResourceID)
+	}
+
+	// On-demand streams size themselves, so only provisioned ones are resharded
+	if resource.Metadata["original_mode"] != string(types.StreamModeOnDemand) {
+		return m.reshard(ctx, resource.ResourceID, int32(shards))
+	}
+
+	summary, err := m.describe(ctx, resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	if onDemand(summary) {
+		return nil
+	}
+	return m.setMode(ctx, summary, types.StreamModeOnDemand)
+}
+
+// setMode switches a stream's capacity mode and waits for it to finish
+func (m *KinesisServiceManager) setMode(ctx context.Context, summary *types.StreamDescriptionSummary, mode types.StreamMode) error {
+	name := aws.ToString(summary.StreamName)
+	_, err := m.client.UpdateStreamMode(ctx, &kinesis.UpdateStreamModeInput{
+		StreamARN:         summary.StreamARN,
+		StreamModeDetails: &types.StreamModeDetails{StreamMode: mode},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to switch Kinesis stream %s to %s mode: %w", name, mode, err)
+	}
+	return m.waitActive(ctx, name)
+}
+
+// reshard changes a provisioned stream's shard count. Kinesis only halves or
+// doubles a stream at a time, so larger changes take several steps; a change
+// needing more than kinesisMaxReshardSteps is refused before the first.
+func (m *KinesisServiceManager) reshard(ctx context.Context, name string, target int32) error {
+	for step := 0; ; step++ {
+		summary, err := m.describe(ctx, name)
+		if err != nil {
+			return err
+		}
+		current := aws.ToInt32(summary.OpenShardCount)
+		if current == target {
+			return nil
+		}
+		if step == 0 {
+			if steps := reshardSteps(current, target); steps > kinesisMaxReshardSteps {
+				return fmt.Errorf("resharding Kinesis stream %s from %d to %d shards takes %d steps, more than the %d allowed", name, current, target, steps, kinesisMaxReshardSteps)
+			}
+		}
+
+		next := min(max(target, (current+1)/2), current*2)
+		_, err = m.client.UpdateShardCount(ctx, &kinesis.UpdateShardCountInput{
+			StreamName:       aws.String(name),
+			TargetShardCount: aws.Int32(next),
+			ScalingType:      types.ScalingTypeUniformScaling,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reshard Kinesis stream %s from %d to %d shards: %w", name, current, next, err)
+		}
+		if err := m.waitActive(ctx, name); err != nil {
+			return err
+		}
+	}
+}
+
+// reshardSteps returns how many UpdateShardCount calls take a stream from
+// current to target shards
+func reshardSteps(current, target int32) int {
+	steps := 0
+	for current != target {
+		current = min(max(target, (current+1)/2), current*2)
+		steps++
+	}
+	return steps
+}
+
+func (m *KinesisServiceManager) waitActive(ctx context.Context, name string) error {
+	waiter := kinesis.NewStreamExistsWaiter(m.client)
+	err := waiter.Wait(ctx, &kinesis.DescribeStreamInput{StreamName: aws.String(name)}, waitTimeout(ctx, kinesisActiveTimeout))
+	if err != nil {
+		return fmt.Errorf("Kinesis stream %s did not become active: %w", name, err)
+	}
+	return nil
+}
+
+func onDemand(summary *types.StreamDescriptionSummary) bool {
+	return summary.StreamModeDetails != nil && summary.StreamModeDetails.StreamMode == types.StreamModeOnDemand
+}
+
+func streamToResource(summary *types.StreamDescriptionSummary, tags map[string]string, region string) models.Resource {
+	mode := types.StreamModeProvisioned
+	shards := aws.ToInt32(summary.OpenShardCount)
+
+	// A paused stream is one provisioned shard; only the difference is saved
+	cost := float64(shards-1) * kinesisShardHourlyCost
+	if onDemand(summary) {
+		mode = types.StreamModeOnDemand
+		cost = kinesisOnDemandHourlyCost - kinesisShardHourlyCost
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceKinesis,
+		ResourceID:   aws.ToString(summary.StreamName),
+		ARN:          aws.ToString(summary.StreamARN),
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         tags,
+		Metadata: map[string]any{
+			"original_mode":        string(mode),
+			"original_shard_count": float64(shards),
+			"retention_hours":      float64(aws.ToInt32(summary.RetentionPeriodHours)),
+		},
+		CostPerHour: cost,
+	}
+}
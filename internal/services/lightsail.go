@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lightsail"
+	"github.com/aws/aws-sdk-go-v2/service/lightsail/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// lightsailRegions are the regions Lightsail is offered in; elsewhere its
+// endpoint doesn't exist, so discovery finds nothing rather than failing
+var lightsailRegions = []string{
+	"us-east-1", "us-east-2", "us-west-2", "ca-central-1",
+	"eu-west-1", "eu-west-2", "eu-west-3", "eu-central-1", "eu-north-1",
+	"ap-south-1", "ap-northeast-1", "ap-northeast-2",
+	"ap-southeast-1", "ap-southeast-2", "ap-southeast-3",
+}
+
+// lightsailRestartDeadline is how long AWS lets a Lightsail database stay
+// stopped
+const lightsailRestartDeadline = 7 * 24 * time.Hour
+
+// LightsailServiceManager handles Lightsail instances, databases and
+// container services. Instances and databases are stopped and started;
+// container services are disabled and enabled again. Lightsail keeps
+// billing the bundle price of stopped instances and databases and of
+// disabled container services, so pausing them stops their workload but
+// not their cost: resources cost nothing per hour, and the bundle price is
+// recorded as what they keep costing while paused. AWS starts a database
+// stopped for seven days again.
+type LightsailServiceManager struct {
+	client *lightsail.Client
+	region string
+}
+
+// NewLightsailServiceManager creates a new Lightsail service manager
+func NewLightsailServiceManager(cfg aws.Config) *LightsailServiceManager {
+	return &LightsailServiceManager{
+		client: lightsail.NewFromConfig(cfg),
+		region: cfg.Region,
+	}
+}
+
+// ServiceType returns the service type
+func (m *LightsailServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceLightsail
+}
+
+// Capabilities reports that Lightsail resources are stopped in place
+func (m *LightsailServiceManager) Capabilities() Capabilities {
+	return Capabilities{Stop: true}
+}
+
+// ResourceCapabilities reports that AWS starts stopped databases again after
+// seven days, like RDS
+func (m *LightsailServiceManager) ResourceCapabilities(resource models.Resource) Capabilities {
+	if lightsailKind(resource) == "database" {
+		return Capabilities{Stop: true, RestartDeadline: lightsailRestartDeadline}
+	}
+	return m.Capabilities()
+}
+
+// Discover finds running instances and databases and enabled container
+// services, recording the bundle prices Lightsail publishes
+func (m *LightsailServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	if !slices.Contains(lightsailRegions, region) {
+		return nil, nil
+	}
+
+	instances, err := m.discoverInstances(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	databases, err := m.discoverDatabases(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := m.discoverContainerServices(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(instances, databases...), containers...), nil
+}
+
+func (m *LightsailServiceManager) discoverInstances(ctx context.Context, region string) ([]models.Resource, error) {
+	var running []types.Instance
+	input := &lightsail.GetInstancesInput{}
+	for {
+		output, err := m.client.GetInstances(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Lightsail instances: %w", err)
+		}
+		for _, instance := range output.Instances {
+			if instance.State != nil && aws.ToString(instance.State.Name) == "running" {
+				running = append(running, instance)
+			}
+		}
+		if aws.ToString(output.NextPageToken) == "" {
+			break
+		}
+		input.PageToken = output.NextPageToken
+	}
+	if len(running) == 0 {
+		return nil, nil
+	}
+
+	prices, err := m.instancePrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]models.Resource, 0, len(running))
+	for _, instance := range running {
+		bundle := aws.ToString(instance.BundleId)
+		resources = append(resources, models.Resource{
+			ServiceType:  models.ServiceLightsail,
+			ResourceID:   aws.ToString(instance.Name),
+			ARN:          aws.ToString(instance.Arn),
+			Region:       region,
+			CurrentState: models.StateRunning,
+			Tags:         lightsailTags(instance.Tags),
+			Metadata: map[string]any{
+				"kind":                "instance",
+				"bundle_id":           bundle,
+				"bundle_monthly_cost": prices[bundle],
+				"blueprint":           aws.ToString(instance.BlueprintId),
+				"static_ip":           aws.ToBool(instance.IsStaticIp),
+			},
+		})
+	}
+	return resources, nil
+}
+
+func (m *LightsailServiceManager) discoverDatabases(ctx context.Context, region string) ([]models.Resource, error) {
+	var available []types.RelationalDatabase
+	input := &lightsail.GetRelationalDatabasesInput{}
+	for {
+		output, err := m.client.GetRelationalDatabases(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Lightsail databases: %w", err)
+		}
+		for _, db := range output.RelationalDatabases {
+			if aws.ToString(db.State) == "available" {
+				available = append(available, db)
+			}
+		}
+		if aws.ToString(output.NextPageToken) == "" {
+			break
+		}
+		input.PageToken = output.NextPageToken
+	}
+	if len(available) == 0 {
+		return nil, nil
+	}
+
+	prices, err := m.databasePrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]models.Resource, 0, len(available))
+	for _, db := range available {
+		bundle := aws.ToString(db.RelationalDatabaseBundleId)
+		resources = append(resources, models.Resource{
+			ServiceType:  models.ServiceLightsail,
+			ResourceID:   aws.ToString(db.Name),
+			ARN:          aws.ToString(db.Arn),
+			Region:       region,
+			CurrentState: models.StateAvailable,
+			Tags:         lightsailTags(db.Tags),
+			Metadata: map[string]any{
+				"kind":                "database",
+				"bundle_id":           bundle,
+				"bundle_monthly_cost": prices[bundle],
+				"engine":              aws.ToString(db.Engine),
+			},
+		})
+	}
+	return resources, nil
+}
+
+func (m *LightsailServiceManager) discoverContainerServices(ctx context.Context, region string) ([]models.Resource, error) {
+	output, err := m.client.GetContainerServices(ctx, &lightsail.GetContainerServicesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Lightsail container services: %w", err)
+	}
+
+	var enabled []types.ContainerService
+	for _, cs := range output.ContainerServices {
+		if !aws.ToBool(cs.IsDisabled) && cs.State != types.ContainerServiceStateDisabled && cs.State != types.ContainerServiceStateDeleting {
+			enabled = append(enabled, cs)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil, nil
+	}
+
+	powers, err := m.client.GetContainerServicePowers(ctx, &lightsail.GetContainerServicePowersInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Lightsail container service prices: %w", err)
+	}
+	prices := make(map[string]float64)
+	for _, p := range powers.Powers {
+		prices[aws.ToString(p.Name)] = float64(aws.ToFloat32(p.Price))
+	}
+
+	resources := make([]models.Resource, 0, len(enabled))
+	for _, cs := range enabled {
+		// Each node of the service is billed the power's monthly price
+		scale := aws.ToInt32(cs.Scale)
+		resources = append(resources, models.Resource{
+			ServiceType:  models.ServiceLightsail,
+			ResourceID:   aws.ToString(cs.ContainerServiceName),
+			ARN:          aws.ToString(cs.Arn),
+			Region:       region,
+			CurrentState: models.StateRunning,
+			Tags:         lightsailTags(cs.Tags),
+			Metadata: map[string]any{
+				"kind":                "container_service",
+				"power":               string(cs.Power),
+				"scale":               float64(scale),
+				"bundle_monthly_cost": prices[string(cs.Power)] * float64(scale),
+			},
+		})
+	}
+	return resources, nil
+}
+
+// instancePrices returns the monthly price of each instance bundle,
+// including retired ones that existing instances may still use
+func (m *LightsailServiceManager) instancePrices(ctx context.Context) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	input := &lightsail.GetBundlesInput{IncludeInactive: aws.Bool(true)}
+	for {
+		output, err := m.client.GetBundles(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Lightsail bundles: %w", err)
+		}
+		for _, b := range output.Bundles {
+			prices[aws.ToString(b.BundleId)] = float64(aws.ToFloat32(b.Price))
+		}
+		if aws.ToString(output.NextPageToken) == "" {
+			return prices, nil
+		}
+		input.PageToken = output.NextPageToken
+	}
+}
+
+// databasePrices returns the monthly price of each database bundle
+func (m *LightsailServiceManager) databasePrices(ctx context.Context) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	input := &lightsail.GetRelationalDatabaseBundlesInput{IncludeInactive: aws.Bool(true)}
+	for {
+		output, err := m.client.GetRelationalDatabaseBundles(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Lightsail database bundles: %w", err)
+		}
+		for _, b := range output.Bundles {
+			prices[aws.ToString(b.BundleId)] = float64(aws.ToFloat32(b.Price))
+		}
+		if aws.ToString(output.NextPageToken) == "" {
+			return prices, nil
+		}
+		input.PageToken = output.NextPageToken
+	}
+}
+
+// Pause stops an instance or database, or disables a container service
+func (m *LightsailServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	name := aws.String(resource.ResourceID)
+
+	var err error
+	switch resource.Metadata["kind"] {
+	case "database":
+		_, err = m.client.StopRelationalDatabase(ctx, &lightsail.StopRelationalDatabaseInput{RelationalDatabaseName: name})
+	case "container_service":
+		_, err = m.client.UpdateContainerService(ctx, &lightsail.UpdateContainerServiceInput{
+			ServiceName: name,
+			IsDisabled:  aws.Bool(true),
+		})
+	default:
+		_, err = m.client.StopInstance(ctx, &lightsail.StopInstanceInput{InstanceName: name})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stop Lightsail %s %s: %w", lightsailKind(resource), resource.ResourceID, err)
+	}
+	return nil
+}
+
+// Resume starts an instance or database, or enables a container service
+func (m *LightsailServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	name := aws.String(resource.ResourceID)
+
+	var err error
+	switch resource.Metadata["kind"] {
+	case "database":
+		_, err = m.client.StartRelationalDatabase(ctx, &lightsail.StartRelationalDatabaseInput{RelationalDatabaseName: name})
+	case "container_service":
+		_, err = m.client.UpdateContainerService(ctx, &lightsail.UpdateContainerServiceInput{
+			ServiceName: name,
+			IsDisabled:  aws.Bool(false),
+		})
+	default:
+		_, err = m.client.StartInstance(ctx, &lightsail.StartInstanceInput{InstanceName: name})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start Lightsail %s %s: %w", lightsailKind(resource), resource.ResourceID, err)
+	}
+	return nil
+}
+
+// lightsailKind names a Lightsail resource's kind for messages
+func lightsailKind(resource models.Resource) string {
+	switch resource.Metadata["kind"] {
+	case "database":
+		return "database"
+	case "container_service":
+		return "container service"
+	}
+	return "instance"
+}
+
+func lightsailTags(tags []types.Tag) map[string]string {
+	out := make(map[string]string, len(tags))
+	for _, t := range tags {
+		out[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return out
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// natGatewayHourlyCost is the hourly charge for a NAT gateway, excluding data processing
+	natGatewayHourlyCost = 0.045
+	// elasticIPHourlyCost is the hourly charge for a public IPv4 address
+	elasticIPHourlyCost = 0.005
+	// natGatewayWaitTimeout bounds how long resume waits for a recreated NAT gateway
+	natGatewayWaitTimeout = 10 * time.Minute
+	// reservedAllocationTag marks Elastic IPs kept for a deleted NAT gateway
+	reservedAllocationTag = "awsbreak:nat-gateway"
+	// replacesNATTag marks a recreated NAT gateway with the ID of the one it
+	// replaces, so a retried resume reuses it rather than creating another
+	replacesNATTag = "awsbreak:replaces"
+)
+
+// natRoute is a route table entry that pointed at a NAT gateway before it was deleted
+type natRoute struct {
+	RouteTableID          string `json:"route_table_id"`
+	DestinationCIDR       string `json:"destination_cidr,omitempty"`
+	DestinationIPv6       string `json:"destination_ipv6,omitempty"`
+	DestinationPrefixList string `json:"destination_prefix_list,omitempty"`
+}
+
+// NetworkServiceManager handles NAT gateways and idle Elastic IPs
+type NetworkServiceManager struct {
+	client   *ec2.Client
+	region   string
+	teardown bool
+}
+
+// NewNetworkServiceManager creates a new network service manager. NAT gateways
+// are only deleted on pause when teardown is true; otherwise they are reported only.
+func NewNetworkServiceManager(cfg aws.Config, teardown bool) *NetworkServiceManager {
+	return &NetworkServiceManager{
+		client:   ec2.NewFromConfig(cfg),
+		region:   cfg.Region,
+		teardown: teardown,
+	}
+}
+
+// ServiceType returns the service type
+func (m *NetworkServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceNetwork
+}
+
+// Capabilities reports that NAT gateways are deleted on pause and recreated
+// on resume
+func (m *NetworkServiceManager) Capabilities() Capabilities {
+	return Capabilities{Destructive: true}
+}
+
+// Discover finds available NAT gateways and unattached Elastic IPs
+func (m *NetworkServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	natGateways, err := m.discoverNATGateways(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, natGateways...)
+
+	addresses, err := m.discoverIdleAddresses(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, addresses...)
+
+	return resources, nil
+}
+
+func (m *NetworkServiceManager) discoverNATGateways(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	paginator := ec2.NewDescribeNatGatewaysPaginator(m.client, &ec2.DescribeNatGatewaysInput{
+		Filter: []types.Filter{
+			{
+				Name:   aws.String("state"),
+				Values: []string{"available"},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe NAT gateways: %w", err)
+		}
+
+		for _, nat := range output.NatGateways {
+			routes, err := m.natRoutes(ctx, aws.ToString(nat.NatGatewayId))
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, m.natGatewayToResource(nat, routes, region))
+		}
+	}
+
+	return resources, nil
+}
+
+// natRoutes finds the route table entries that target a NAT gateway
+func (m *NetworkServiceManager) natRoutes(ctx context.Context, natGatewayID string) ([]natRoute, error) {
+	var routes []natRoute
+
+	paginator := ec2.NewDescribeRouteTablesPaginator(m.client, &ec2.DescribeRouteTablesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("route.nat-gateway-id"),
+				Values: []string{natGatewayID},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe route tables for %s: %w", natGatewayID, err)
+		}
+
+		for _, table := range output.RouteTables {
+			for _, route := range table.Routes {
+				if aws.ToString(route.NatGatewayId) != natGatewayID {
+					continue
+				}
+				routes = append(routes, natRoute{
+					RouteTableID:          aws.ToString(table.RouteTableId),
+					DestinationCIDR:       aws.ToString(route.DestinationCidrBlock),
+					DestinationIPv6:       aws.ToString(route.DestinationIpv6CidrBlock),
+					DestinationPrefixList: aws.ToString(route.DestinationPrefixListId),
+				})
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+func (m *NetworkServiceManager) discoverIdleAddresses(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	output, err := m.client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe Elastic IPs: %w", err)
+	}
+
+	for _, addr := range output.Addresses {
+		if addr.AssociationId != nil {
+			continue
+		}
+
+		tags := ec2TagsToMap(addr.Tags)
+		// Addresses held for a paused NAT gateway are restored on resume
+		if _, ok := tags[reservedAllocationTag]; ok {
+			continue
+		}
+
+		resources = append(resources, models.Resource{
+			ServiceType:  models.ServiceNetwork,
+			ResourceID:   aws.ToString(addr.AllocationId),
+			Region:       region,
+			CurrentState: models.StateIdle,
+			Tags:         tags,
+			Metadata: map[string]any{
+				"kind":        "elastic_ip",
+				"public_ip":   aws.ToString(addr.PublicIp),
+				"report_only": true,
+			},
+			CostPerHour: elasticIPHourlyCost,
+		})
+	}
+
+	return resources, nil
+}
+
+// Pause deletes a NAT gateway, keeping its Elastic IP allocated for resume
+func (m *NetworkServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	if resource.Metadata["kind"] != "nat_gateway" {
+		return fmt.Errorf("%s cannot be paused; release it manually to stop charges", resource.ResourceID)
+	}
+	if !m.teardown {
+		return fmt.Errorf("NAT gateway teardown is disabled (use --include-network)")
+	}
+
+	if allocationID, ok := resource.Metadata["allocation_id"].(string); ok && allocationID != "" {
+		_, err := m.client.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{allocationID},
+			Tags: []types.Tag{
+				{
+					Key:   aws.String(reservedAllocationTag),
+					Value: aws.String(resource.ResourceID),
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reserve Elastic IP %s: %w", allocationID, err)
+		}
+	}
+
+	_, err := m.client.DeleteNatGateway(ctx, &ec2.DeleteNatGatewayInput{
+		NatGatewayId: aws.String(resource.ResourceID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete NAT gateway %s: %w", resource.ResourceID, err)
+	}
+
+	return nil
+}
+
+// Resume recreates a NAT gateway and points its original routes at it. The
+// new gateway is tagged with the ID of the one it replaces, so a retried
+// resume picks it up again instead of creating a second one.
+func (m *NetworkServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	if resource.Metadata["kind"] != "nat_gateway" {
+		return fmt.Errorf("%s cannot be resumed", resource.ResourceID)
+	}
+
+	subnetID, ok := resource.Metadata["subnet_id"].(string)
+	if !ok {
+		return fmt.Errorf("missing subnet_id in resource metadata")
+	}
+	allocationID, _ := resource.Metadata["allocation_id"].(string)
+	connectivity, _ := resource.Metadata["connectivity_type"].(string)
+
+	newID, err := m.replacementNATGateway(ctx, resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	if newID == "" {
+		// A gateway recreated before carries the tag of the one it replaced
+		tags := slices.DeleteFunc(mapToEC2Tags(resource.Tags), func(t types.Tag) bool {
+			return aws.ToString(t.Key) == replacesNATTag
+		})
+		input := &ec2.CreateNatGatewayInput{
+			SubnetId:         aws.String(subnetID),
+			ConnectivityType: types.ConnectivityType(connectivity),
+			TagSpecifications: []types.TagSpecification{
+				{
+					ResourceType: types.ResourceTypeNatgateway,
+					Tags: append(tags, types.Tag{
+						Key:   aws.String(replacesNATTag),
+						Value: aws.String(resource.ResourceID),
+					}),
+				},
+			},
+		}
+		if allocationID != "" {
+			input.AllocationId = aws.String(allocationID)
+		}
+
+		output, err := m.client.CreateNatGateway(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to recreate NAT gateway %s: %w", resource.ResourceID, err)
+		}
+		newID = aws.ToString(output.NatGateway.NatGatewayId)
+	}
+
+	waiter := ec2.NewNatGatewayAvailableWaiter(m.client)
+	err = waiter.Wait(ctx, &ec2.DescribeNatGatewaysInput{
+		NatGatewayIds: []string{newID},
+	}, waitTimeout(ctx, natGatewayWaitTimeout))
+	if err != nil {
+		return fmt.Errorf("NAT gateway %s (replacing %s) did not become available: %w", newID, resource.ResourceID, err)
+	}
+
+	var routes []natRoute
+	if err := decodeMetadata(resource, "routes", &routes); err != nil {
+		return err
+	}
+	for _, route := range routes {
+		if err := m.restoreRoute(ctx, route, newID); err != nil {
+			return err
+		}
+	}
+
+	if allocationID != "" {
+		_, err := m.client.DeleteTags(ctx, &ec2.DeleteTagsInput{
+			Resources: []string{allocationID},
+			Tags:      []types.Tag{{Key: aws.String(reservedAllocationTag)}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to release reservation on Elastic IP %s: %w", allocationID, err)
+		}
+	}
+
+	return nil
+}
+
+// replacementNATGateway returns the pending or available NAT gateway an
+// earlier attempt created to replace natGatewayID, or "" if there is none
+func (m *NetworkServiceManager) replacementNATGateway(ctx context.Context, natGatewayID string) (string, error) {
+	output, err := m.client.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{
+		Filter: []types.Filter{
+			{
+				Name:   aws.String("tag:" + replacesNATTag),
+				Values: []string{natGatewayID},
+			},
+			{
+				Name:   aws.String("state"),
+				Values: []string{"pending", "available"},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look for a NAT gateway replacing %s: %w", natGatewayID, err)
+	}
+	if len(output.NatGateways) == 0 {
+		return "", nil
+	}
+	return aws.ToString(output.NatGateways[0].NatGatewayId), nil
+}
+
+// restoreRoute replaces the blackholed route, or recreates it if it was removed
+func (m *NetworkServiceManager) restoreRoute(ctx context.Context, route natRoute, natGatewayID string) error {
+	replace := &ec2.ReplaceRouteInput{
+		RouteTableId: aws.String(route.RouteTableID),
+		NatGatewayId: aws.String(natGatewayID),
+	}
+	create := &ec2.CreateRouteInput{
+		RouteTableId: aws.String(route.RouteTableID),
+		NatGatewayId: aws.String(natGatewayID),
+	}
+	switch {
+	case route.DestinationCIDR != "":
+		replace.DestinationCidrBlock = aws.String(route.DestinationCIDR)
+		create.DestinationCidrBlock = aws.String(route.DestinationCIDR)
+	case route.DestinationPrefixList != "":
+		replace.DestinationPrefixListId = aws.String(route.DestinationPrefixList)
+		create.DestinationPrefixListId = aws.String(route.DestinationPrefixList)
+	default:
+		replace.DestinationIpv6CidrBlock = aws.String(route.DestinationIPv6)
+		create.DestinationIpv6CidrBlock = aws.String(route.DestinationIPv6)
+	}
+
+	if _, err := m.client.ReplaceRoute(ctx, replace); err == nil {
+		return nil
+	}
+	if _, err := m.client.CreateRoute(ctx, create); err != nil {
+		return fmt.Errorf("failed to restore route in %s: %w", route.RouteTableID, err)
+	}
+	return nil
+}
+
+func (m *NetworkServiceManager) natGatewayToResource(nat types.NatGateway, routes []natRoute, region string) models.Resource {
+	metadata := map[string]any{
+		"kind":              "nat_gateway",
+		"subnet_id":         aws.ToString(nat.SubnetId),
+		"vpc_id":            aws.ToString(nat.VpcId),
+		"connectivity_type": string(nat.ConnectivityType),
+		"routes":            routes,
+	}
+
+	for _, addr := range nat.NatGatewayAddresses {
+		if addr.AllocationId != nil {
+			metadata["allocation_id"] = *addr.AllocationId
+			metadata["public_ip"] = aws.ToString(addr.PublicIp)
+			break
+		}
+	}
+
+	if !m.teardown {
+		metadata["report_only"] = true
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceNetwork,
+		ResourceID:   aws.ToString(nat.NatGatewayId),
+		Region:       region,
+		CurrentState: models.StateAvailable,
+		Tags:         ec2TagsToMap(nat.Tags),
+		Metadata:     metadata,
+		CostPerHour:  natGatewayHourlyCost,
+	}
+}
+
+func ec2TagsToMap(tags []types.Tag) map[string]string {
+	m := make(map[string]string)
+	for _, tag := range tags {
+		if tag.Key != nil && tag.Value != nil {
+			m[*tag.Key] = *tag.Value
+		}
+	}
+	return m
+}
+
+// mapToEC2Tags converts tags for a resource awsbreak creates, leaving out the
+// reserved aws: tags, which only AWS may set
+func mapToEC2Tags(m map[string]string) []types.Tag {
+	tags := make([]types.Tag, 0, len(m))
+	for k, v := range m {
+		if !strings.HasPrefix(k, "aws:") {
+			tags = append(tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+	}
+	return tags
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/opensearch"
+	"github.com/aws/aws-sdk-go-v2/service/opensearch/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// describeDomainsBatch is the most domains DescribeDomains accepts per call
+	describeDomainsBatch = 5
+	// openSearchMetricWindow is how far back the shard and storage metrics
+	// that size a parked domain are read
+	openSearchMetricWindow = 15 * time.Minute
+	// openSearchStorageHeadroom is the share of each parked node's storage
+	// the domain's data may fill. It stays below the 85% low disk watermark,
+	// past which OpenSearch stops allocating shards to a node.
+	openSearchStorageHeadroom = 0.75
+)
+
+// openSearchRates are approximate hourly prices per data node instance type
+var openSearchRates = map[string]float64{
+	"t3.small.search":  0.036,
+	"t3.medium.search": 0.073,
+	"m6g.large.search": 0.128,
+	"m5.large.search":  0.142,
+	"c6g.large.search": 0.113,
+	"r6g.large.search": 0.167,
+	"r5.large.search":  0.186,
+}
+
+// defaultOpenSearchRate prices instance types missing from openSearchRates
+const defaultOpenSearchRate = 0.15
+
+// OpenSearchServiceManager handles OpenSearch and Elasticsearch domain
+// operations. Domains can't be stopped, so pausing scales the data nodes down
+// to the fewest that still hold every shard copy and the stored data,
+// optionally on a cheaper parked instance type, and resuming restores the
+// recorded cluster config.
+type OpenSearchServiceManager struct {
+	client     *opensearch.Client
+	cw         *cloudwatch.Client
+	region     string
+	parkedType string
+}
+
+// NewOpenSearchServiceManager creates a new OpenSearch service manager. A
+// non-empty parkedType moves paused domains to that instance type.
+func NewOpenSearchServiceManager(cfg aws.Config, parkedType string) *OpenSearchServiceManager {
+	return &OpenSearchServiceManager{
+		client:     opensearch.NewFromConfig(cfg),
+		cw:         cloudwatch.NewFromConfig(cfg),
+		region:     cfg.Region,
+		parkedType: parkedType,
+	}
+}
+
+// ServiceType returns the service type
+func (m *OpenSearchServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceOpenSearch
+}
+
+// Capabilities reports that domains are scaled down to their parked size
+func (m *OpenSearchServiceManager) Capabilities() Capabilities {
+	return Capabilities{ScaleToZero: true}
+}
+
+// Discover finds domains that are larger than their parked size. A domain
+// whose tags or shard and storage metrics can't be read is skipped, since
+// protection rules depend on the one and a safe parked size on the other.
+func (m *OpenSearchServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	list, err := m.client.ListDomainNames(ctx, &opensearch.ListDomainNamesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OpenSearch domains: %w", err)
+	}
+
+	var names []string
+	for _, d := range list.DomainNames {
+		names = append(names, aws.ToString(d.DomainName))
+	}
+
+	var resources []models.Resource
+	var skipped []error
+	for start := 0; start < len(names); start += describeDomainsBatch {
+		end := min(start+describeDomainsBatch, len(names))
+		output, err := m.client.DescribeDomains(ctx, &opensearch.DescribeDomainsInput{
+			DomainNames: names[start:end],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe OpenSearch domains: %w", err)
+		}
+
+		for _, domain := range output.DomainStatusList {
+			if aws.ToBool(domain.Deleted) || domain.ClusterConfig == nil {
+				continue
+			}
+			if !m.canShrink(*domain.ClusterConfig, minDataNodes(*domain.ClusterConfig)) {
+				continue
+			}
+
+			parked, err := m.parkedCount(ctx, domain)
+			if err != nil {
+				logging.Warn("skipping OpenSearch domain", "domain", aws.ToString(domain.DomainName), "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			if !m.canShrink(*domain.ClusterConfig, parked) {
+				continue
+			}
+
+			tags, err := m.tags(ctx, domain)
+			if err != nil {
+				logging.Warn("skipping OpenSearch domain", "domain", aws.ToString(domain.DomainName), "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			resources = append(resources, m.domainToResource(domain, tags, parked, region))
+		}
+	}
+
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
+	return resources, nil
+}
+
+func (m *OpenSearchServiceManager) tags(ctx context.Context, domain types.DomainStatus) (map[string]string, error) {
+	output, err := m.client.ListTags(ctx, &opensearch.ListTagsInput{ARN: domain.ARN})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags of OpenSearch domain %s: %w", aws.ToString(domain.DomainName), err)
+	}
+
+	tags := make(map[string]string)
+	for _, t := range output.TagList {
+		tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return tags, nil
+}
+
+// Pause shrinks the domain's data nodes to the parked size. The size is
+// worked out again from current metrics, since shards and data may have grown
+// since discovery.
+func (m *OpenSearchServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	domain, err := m.describe(ctx, resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	parked, err := m.parkedCount(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("not parking OpenSearch domain %s: %w", resource.ResourceID, err)
+	}
+
+	cluster := *domain.ClusterConfig
+	cluster.InstanceCount = aws.Int32(parked)
+	if m.parkedType != "" {
+		cluster.InstanceType = types.OpenSearchPartitionInstanceType(m.parkedType)
+	}
+	return m.update(ctx, resource.ResourceID, cluster, "park")
+}
+
+// Resume restores the data node count and instance type recorded at discovery
+func (m *OpenSearchServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	count, ok := resource.Metadata["original_instance_count"].(float64)
+	if !ok || count < 1 {
+		return fmt.Errorf("no original instance count recorded for OpenSearch domain %s", resource.ResourceID)
+	}
+	instanceType, _ := resource.Metadata["original_instance_type"].(string)
+
+	domain, err := m.describe(ctx, resource.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	cluster := *domain.ClusterConfig
+	cluster.InstanceCount = aws.Int32(int32(count))
+	if instanceType != "" {
+		cluster.InstanceType = types.OpenSearchPartitionInstanceType(instanceType)
+	}
+	return m.update(ctx, resource.ResourceID, cluster, "restore")
+}
+
+// describe returns a domain's current status. Updates start from its cluster
+// config so settings awsbreak doesn't manage are sent back unchanged.
+func (m *OpenSearchServiceManager) describe(ctx context.Context, name string) (types.DomainStatus, error) {
+	output, err := m.client.DescribeDomain(ctx, &opensearch.DescribeDomainInput{
+		DomainName: aws.String(name),
+	})
+	if err != nil {
+		return types.DomainStatus{}, fmt.Errorf("failed to describe OpenSearch domain %s: %w", name, err)
+	}
+
+	domain := output.DomainStatus
+	if domain == nil || domain.ClusterConfig == nil {
+		return types.DomainStatus{}, fmt.Errorf("OpenSearch domain %s has no cluster config", name)
+	}
+	// A second change is rejected while a blue/green deployment is running
+	if aws.ToBool(domain.Processing) {
+		return types.DomainStatus{}, fmt.Errorf("OpenSearch domain %s is still applying a configuration change; try again once it finishes", name)
+	}
+	return *domain, nil
+}
+
+func (m *OpenSearchServiceManager) update(ctx context.Context, name string, cluster types.ClusterConfig, action string) error {
+	_, err := m.client.UpdateDomainConfig(ctx, &opensearch.UpdateDomainConfigInput{
+		DomainName:    aws.String(name),
+		ClusterConfig: &cluster,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %s OpenSearch domain %s: %w", action, name, err)
+	}
+	return nil
+}
+
+// canShrink reports whether parking on the given number of data nodes would
+// make the domain any smaller
+func (m *OpenSearchServiceManager) canShrink(cluster types.ClusterConfig, parked int32) bool {
+	if aws.ToInt32(cluster.InstanceCount) > parked {
+		return true
+	}
+	return m.parkedType != "" && string(cluster.InstanceType) != m.parkedType
+}
+
+// parkedCount returns how many data nodes the domain can be parked on: no
+// fewer than its zone layout allows, than the copies of each shard (a replica
+// is never placed on the node holding its primary), or than its data needs
+// at openSearchStorageHeadroom. Without the metrics to check, it is an error.
+func (m *OpenSearchServiceManager) parkedCount(ctx context.Context, domain types.DomainStatus) (int32, error) {
+	cluster := *domain.ClusterConfig
+	count := aws.ToInt32(cluster.InstanceCount)
+	name := aws.ToString(domain.DomainName)
+	floor := minDataNodes(cluster)
+	retype := m.parkedType != "" && string(cluster.InstanceType) != m.parkedType
+	if count <= floor && !retype {
+		return count, nil
+	}
+
+	active, err := m.latestMetric(ctx, domain, "Shards.active", cwtypes.StatisticMaximum)
+	if err != nil {
+		return 0, err
+	}
+	primary, err := m.latestMetric(ctx, domain, "Shards.activePrimary", cwtypes.StatisticMaximum)
+	if err != nil {
+		return 0, err
+	}
+	copies := int32(1)
+	if primary > 0 {
+		copies = int32(math.Ceil(active / primary))
+	}
+
+	// Both are in MB; FreeStorageSpace summed over the domain covers every data node
+	used, err := m.latestMetric(ctx, domain, "ClusterUsedSpace", cwtypes.StatisticMaximum)
+	if err != nil {
+		return 0, err
+	}
+	free, err := m.latestMetric(ctx, domain, "FreeStorageSpace", cwtypes.StatisticSum)
+	if err != nil {
+		return 0, err
+	}
+	perNode := (used + free) / float64(count)
+	if retype {
+		ebs := domain.EBSOptions
+		if ebs == nil || !aws.ToBool(ebs.EBSEnabled) {
+			return 0, fmt.Errorf("OpenSearch domain %s uses instance storage, so %s can't be checked to hold its data", name, m.parkedType)
+		}
+		perNode = float64(aws.ToInt32(ebs.VolumeSize)) * 1024
+	}
+	if perNode <= 0 {
+		return 0, fmt.Errorf("OpenSearch domain %s reports no storage", name)
+	}
+	forData := int32(math.Ceil(used / (perNode * openSearchStorageHeadroom)))
+	if forData > count {
+		return 0, fmt.Errorf("OpenSearch domain %s holds %.0f MB, more than %d parked node(s) can take", name, used, count)
+	}
+
+	parked := max(floor, copies, forData)
+	// Zone-aware domains need the same number of nodes in every zone
+	if zones := floor; zones > 1 && parked%zones != 0 {
+		parked += zones - parked%zones
+	}
+	return min(parked, count), nil
+}
+
+// latestMetric returns the most recent datapoint of a domain metric at one
+// minute resolution
+func (m *OpenSearchServiceManager) latestMetric(ctx context.Context, domain types.DomainStatus, metric string, stat cwtypes.Statistic) (float64, error) {
+	name := aws.ToString(domain.DomainName)
+	// The metrics are keyed by the owning account, the fifth field of the ARN
+	parts := strings.Split(aws.ToString(domain.ARN), ":")
+	if len(parts) < 6 {
+		return 0, fmt.Errorf("OpenSearch domain %s has no ARN to read metrics with", name)
+	}
+
+	end := time.Now()
+	output, err := m.cw.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String("AWS/ES"),
+		MetricName: aws.String(metric),
+		Dimensions: []cwtypes.Dimension{
+			{Name: aws.String("DomainName"), Value: aws.String(name)},
+			{Name: aws.String("ClientId"), Value: aws.String(parts[4])},
+		},
+		StartTime:  aws.Time(end.Add(-openSearchMetricWindow)),
+		EndTime:    aws.Time(end),
+		Period:     aws.Int32(60),
+		Statistics: []cwtypes.Statistic{stat},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s of OpenSearch domain %s: %w", metric, name, err)
+	}
+
+	var latest *cwtypes.Datapoint
+	for i, dp := range output.Datapoints {
+		if latest == nil || aws.ToTime(dp.Timestamp).After(aws.ToTime(latest.Timestamp)) {
+			latest = &output.Datapoints[i]
+		}
+	}
+	if latest == nil {
+		return 0, fmt.Errorf("no recent %s for OpenSearch domain %s", metric, name)
+	}
+	if stat == cwtypes.StatisticSum {
+		return aws.ToFloat64(latest.Sum), nil
+	}
+	return aws.ToFloat64(latest.Maximum), nil
+}
+
+// minDataNodes returns the fewest data nodes a domain's zone layout allows:
+// one per availability zone when zone awareness is on, otherwise one
+func minDataNodes(cluster types.ClusterConfig) int32 {
+	if !aws.ToBool(cluster.ZoneAwarenessEnabled) && !aws.ToBool(cluster.MultiAZWithStandbyEnabled) {
+		return 1
+	}
+	zones := int32(2)
+	if zc := cluster.ZoneAwarenessConfig; zc != nil && aws.ToInt32(zc.AvailabilityZoneCount) > 0 {
+		zones = aws.ToInt32(zc.AvailabilityZoneCount)
+	}
+	return zones
+}
+
+func openSearchRate(instanceType string) float64 {
+	if rate, ok := openSearchRates[instanceType]; ok {
+		return rate
+	}
+	return defaultOpenSearchRate
+}
+
+func (m *OpenSearchServiceManager) domainToResource(domain types.DomainStatus, tags map[string]string, parkedCount int32, region string) models.Resource {
+	cluster := *domain.ClusterConfig
+	instanceType := string(cluster.InstanceType)
+	count := aws.ToInt32(cluster.InstanceCount)
+
+	parkedType := instanceType
+	if m.parkedType != "" {
+		parkedType = m.parkedType
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceOpenSearch,
+		ResourceID:   aws.ToString(domain.DomainName),
+		ARN:          aws.ToString(domain.ARN),
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         tags,
+		Metadata: map[string]any{
+			"engine_version":          aws.ToString(domain.EngineVersion),
+			"original_instance_type":  instanceType,
+			"original_instance_count": float64(count),
+			"parked_instance_type":    parkedType,
+			"parked_instance_count":   float64(parkedCount),
+			"zone_awareness":          aws.ToBool(cluster.ZoneAwarenessEnabled),
+			"dedicated_master":        aws.ToBool(cluster.DedicatedMasterEnabled),
+		},
+		// Parked domains keep running, so only the difference is saved
+		CostPerHour: max(0, openSearchRate(instanceType)*float64(count)-openSearchRate(parkedType)*float64(parkedCount)),
+	}
+}
@@ -2,82 +2,413 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
+	"github.com/aicoder2009/aws-hit-breaks/internal/cost"
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
 	"github.com/aicoder2009/aws-hit-breaks/internal/models"
 )
 
 const (
-	// MaxConcurrentOperations limits concurrent AWS API calls to avoid rate limiting
+	// MaxConcurrentOperations is the default limit on concurrent pauses and
+	// resumes, to avoid rate limiting
 	MaxConcurrentOperations = 5
-	// MaxConcurrentDiscovery limits concurrent discovery operations
+	// MaxConcurrentDiscovery is the default limit on concurrent discovery operations
 	MaxConcurrentDiscovery = 4
 )
 
+// DefaultPriorities is the order services are resumed in; lower values start
+// first and pause last. Databases and networking come up before the compute
+// that uses them, and ECS services scale down before their capacity providers.
+var DefaultPriorities = map[models.ServiceType]int{
+	models.ServiceNetwork:     0,
+	models.ServiceRDS:         10,
+	models.ServiceEC2:         20,
+	models.ServiceAutoScaling: 20,
+	models.ServiceEKS:         20,
+	models.ServiceWorkSpaces:  20,
+	models.ServiceAppStream:   20,
+	models.ServiceOpenSearch:  10,
+	models.ServiceDynamoDB:    10,
+	models.ServiceKinesis:     10,
+	models.ServiceEMR:         20,
+	models.ServiceLightsail:   20,
+	models.ServiceECS:         30,
+}
+
+// Options controls optional orchestrator behavior
+type Options struct {
+	// IncludeNetwork allows NAT gateways to be deleted on pause and recreated on resume
+	IncludeNetwork bool
+	// IncludeReservations allows EC2 capacity reservations to be cancelled on
+	// pause and recreated on resume
+	IncludeReservations bool
+	// OnResult, if set, is called as each pause or resume finishes. Calls are serialized.
+	OnResult func(models.OperationResult)
+	// OnDiscoveryStart, if set, is called as each service starts discovery,
+	// and OnDiscovered as it finishes. Calls are serialized.
+	OnDiscoveryStart func(models.ServiceType)
+	OnDiscovered     func(models.ServiceDiscovery)
+	// OnIssue, if set, is called just before a resource's pause or resume is
+	// first attempted. Calls may be concurrent.
+	OnIssue func(resource models.Resource, operation string)
+	// Priorities overrides DefaultPriorities for individual services
+	Priorities map[models.ServiceType]int
+	// Dependencies maps a resource ID or ARN to the IDs or ARNs of resources it
+	// needs running.
+	// Dependencies are resumed first and paused last.
+	Dependencies map[string][]string
+	// Retry controls retries of throttled and transient failures. The zero
+	// value uses DefaultRetryPolicy.
+	Retry RetryPolicy
+	// Concurrency limits how many operations run at once; the zero value uses
+	// the defaults
+	Concurrency Concurrency
+	// OpenSearch tunes how OpenSearch domains are parked; nil only reduces
+	// their node count
+	OpenSearch *models.OpenSearchConfig
+	// Cost selects the cost model applied to discovered resources; nil uses
+	// the built-in rates
+	Cost *models.CostConfig
+	// Services limits the orchestrator to these services; empty means all.
+	// Managers for other services aren't created, so they make no API calls.
+	Services []models.ServiceType
+	// SkipServices leaves these services out
+	SkipServices []models.ServiceType
+	// IaC tunes detection of resources managed by infrastructure as code;
+	// nil detects them from tags only
+	IaC *models.IaCConfig
+	// PricingDir is the config directory holding prices cached by 'awsbreak
+	// pricing update'; cached prices replace the built-in rates. Empty uses
+	// only the built-in rates.
+	PricingDir string
+	// Managers adds managers for services awsbreak doesn't manage itself.
+	// They run alongside the built-in ones, ranked by Priorities (0 if unset).
+	Managers []ServiceManager
+}
+
+// OptionsFromConfig returns the options config.json sets: cost model,
+// OpenSearch parking, IaC detection, concurrency, retries and ordering. A nil
+// config uses the defaults.
+func OptionsFromConfig(cfg *models.Config) Options {
+	if cfg == nil {
+		return Options{}
+	}
+
+	opts := Options{
+		Cost:        cfg.Cost,
+		OpenSearch:  cfg.OpenSearch,
+		Concurrency: ConcurrencyFromConfig(cfg.Concurrency),
+	}
+	if cfg.Protection != nil {
+		opts.IaC = cfg.Protection.IaC
+	}
+
+	if r := cfg.Retry; r != nil {
+		opts.Retry = DefaultRetryPolicy
+		if r.MaxAttempts > 0 {
+			opts.Retry.MaxAttempts = r.MaxAttempts
+		}
+		if r.BaseDelayMs > 0 {
+			opts.Retry.BaseDelay = time.Duration(r.BaseDelayMs) * time.Millisecond
+		}
+		if r.MaxDelayMs > 0 {
+			opts.Retry.MaxDelay = time.Duration(r.MaxDelayMs) * time.Millisecond
+		}
+	}
+
+	if o := cfg.Ordering; o != nil {
+		opts.Priorities = make(map[models.ServiceType]int)
+		for svc, p := range o.Priorities {
+			opts.Priorities[models.ServiceType(svc)] = p
+		}
+		opts.Dependencies = o.Dependencies
+	}
+	return opts
+}
+
 // Orchestrator coordinates operations across all service managers
 type Orchestrator struct {
-	awsCfg   aws.Config
-	managers []ServiceManager
+	awsCfg       aws.Config
+	managers     []ServiceManager
+	onResult     func(models.OperationResult)
+	onIssue      func(models.Resource, string)
+	onStart      func(models.ServiceType)
+	onDiscovered func(models.ServiceDiscovery)
+	priorities   map[models.ServiceType]int
+	dependencies map[string][]string
+	retry        RetryPolicy
+	concurrency  Concurrency
+	costModel    *cost.Model
+	costErr      error
+	iac          *iacDetector
+
+	accountOnce sync.Once
+	accountID   string
+	accountErr  error
 }
 
-// NewOrchestrator creates a new orchestrator with all service managers
-func NewOrchestrator(cfg aws.Config) *Orchestrator {
+// NewOrchestrator creates a new orchestrator with the service managers opts selects
+func NewOrchestrator(cfg aws.Config, opts Options) *Orchestrator {
+	priorities := make(map[models.ServiceType]int)
+	for svc, p := range DefaultPriorities {
+		priorities[svc] = p
+	}
+	for svc, p := range opts.Priorities {
+		priorities[svc] = p
+	}
+
+	retry := opts.Retry
+	if retry.MaxAttempts == 0 {
+		retry = DefaultRetryPolicy
+	}
+
+	costModel, costErr := cost.FromConfig(opts.Cost, cfg, opts.PricingDir)
+
+	var parkedType string
+	if opts.OpenSearch != nil {
+		parkedType = opts.OpenSearch.ParkedInstanceType
+	}
+
 	return &Orchestrator{
-		awsCfg: cfg,
-		managers: []ServiceManager{
-			NewEC2ServiceManager(cfg),
-			NewRDSServiceManager(cfg),
-			NewECSServiceManager(cfg),
-			NewASGServiceManager(cfg),
-		},
+		awsCfg:       cfg,
+		costModel:    costModel,
+		costErr:      costErr,
+		iac:          newIaCDetector(opts.IaC),
+		retry:        retry,
+		concurrency:  opts.Concurrency,
+		onResult:     opts.OnResult,
+		onIssue:      opts.OnIssue,
+		onStart:      opts.OnDiscoveryStart,
+		onDiscovered: opts.OnDiscovered,
+		priorities:   priorities,
+		dependencies: opts.Dependencies,
+		managers:     newManagers(cfg, opts, parkedType),
+	}
+}
+
+// newManagers creates the managers of the services opts selects
+func newManagers(cfg aws.Config, opts Options, parkedType string) []ServiceManager {
+	constructors := []struct {
+		service models.ServiceType
+		create  func() ServiceManager
+	}{
+		{models.ServiceEC2, func() ServiceManager { return NewEC2ServiceManager(cfg, opts.IncludeReservations) }},
+		{models.ServiceRDS, func() ServiceManager { return NewRDSServiceManager(cfg) }},
+		{models.ServiceECS, func() ServiceManager { return NewECSServiceManager(cfg) }},
+		{models.ServiceAutoScaling, func() ServiceManager { return NewASGServiceManager(cfg) }},
+		{models.ServiceNetwork, func() ServiceManager { return NewNetworkServiceManager(cfg, opts.IncludeNetwork) }},
+		{models.ServiceEKS, func() ServiceManager { return NewEKSServiceManager(cfg) }},
+		{models.ServiceWorkSpaces, func() ServiceManager { return NewWorkSpacesServiceManager(cfg) }},
+		{models.ServiceAppStream, func() ServiceManager { return NewAppStreamServiceManager(cfg) }},
+		{models.ServiceOpenSearch, func() ServiceManager { return NewOpenSearchServiceManager(cfg, parkedType) }},
+		{models.ServiceDynamoDB, func() ServiceManager { return NewDynamoDBServiceManager(cfg) }},
+		{models.ServiceEMR, func() ServiceManager { return NewEMRServiceManager(cfg) }},
+		{models.ServiceKinesis, func() ServiceManager { return NewKinesisServiceManager(cfg) }},
+		{models.ServiceLightsail, func() ServiceManager { return NewLightsailServiceManager(cfg) }},
+	}
+
+	var managers []ServiceManager
+	for _, c := range constructors {
+		if Selected(c.service, opts.Services, opts.SkipServices) {
+			managers = append(managers, c.create())
+		}
 	}
+	return append(managers, opts.Managers...)
 }
 
-// DiscoverAll discovers all resources across all service types
-func (o *Orchestrator) DiscoverAll(ctx context.Context, region string) ([]models.Resource, error) {
+// Selected reports whether a service is among only (or only is empty) and
+// not among skip
+func Selected(service models.ServiceType, only, skip []models.ServiceType) bool {
+	return (len(only) == 0 || slices.Contains(only, service)) && !slices.Contains(skip, service)
+}
+
+// ParseServices turns service names such as "ec2" into service types,
+// rejecting names awsbreak doesn't manage
+func ParseServices(names []string) ([]models.ServiceType, error) {
+	var types []models.ServiceType
+	for _, name := range names {
+		service := models.ServiceType(strings.ToLower(strings.TrimSpace(name)))
+		if service == "" {
+			continue
+		}
+		if _, ok := DefaultPriorities[service]; !ok {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+		types = append(types, service)
+	}
+	return types, nil
+}
+
+// DiscoverAll discovers all resources across all service types. The report
+// says which services failed or were only partly read; an error is returned
+// only when every service failed or ctx was cancelled, which stops the
+// discoveries still running.
+func (o *Orchestrator) DiscoverAll(ctx context.Context, region string) ([]models.Resource, *models.DiscoveryReport, error) {
+	if o.costErr != nil {
+		return nil, nil, o.costErr
+	}
+
 	var (
 		allResources []models.Resource
 		mu           sync.Mutex
 		wg           sync.WaitGroup
-		errors       []error
+		failures     []error
 	)
+	report := &models.DiscoveryReport{
+		Region:   region,
+		Services: make([]models.ServiceDiscovery, len(o.managers)),
+	}
 
 	// Semaphore to limit concurrent discovery operations
-	sem := make(chan struct{}, MaxConcurrentDiscovery)
+	limit := o.concurrency.Discovery
+	if limit <= 0 {
+		limit = MaxConcurrentDiscovery
+	}
+	sem := make(chan struct{}, limit)
 
-	for _, mgr := range o.managers {
+	for i, mgr := range o.managers {
 		wg.Add(1)
-		go func(m ServiceManager) {
+		go func(i int, m ServiceManager) {
 			defer wg.Done()
 
-			// Acquire semaphore
-			sem <- struct{}{}
-			defer func() { <-sem }()
+			// Acquire semaphore, unless discovery was cancelled while waiting
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				report.Services[i] = models.ServiceDiscovery{ServiceType: m.ServiceType(), Error: ctx.Err().Error()}
+				mu.Unlock()
+				return
+			}
 
+			if o.onStart != nil {
+				mu.Lock()
+				o.onStart(m.ServiceType())
+				mu.Unlock()
+			}
+
+			start := time.Now()
 			resources, err := m.Discover(ctx, region)
+			duration := time.Since(start).Round(time.Millisecond)
 			mu.Lock()
 			defer mu.Unlock()
 
-			if err != nil {
-				errors = append(errors, fmt.Errorf("%s discovery failed: %w", m.ServiceType(), err))
+			sd := models.ServiceDiscovery{ServiceType: m.ServiceType(), Resources: len(resources), Duration: duration}
+			defer func() {
+				report.Services[i] = sd
+				if o.onDiscovered != nil {
+					o.onDiscovered(sd)
+				}
+			}()
+
+			var partial *PartialError
+			switch {
+			case errors.As(err, &partial):
+				logging.Warn("discovery incomplete", "service", m.ServiceType(), "region", region, "error", err)
+				for _, e := range partial.Errs {
+					sd.Warnings = append(sd.Warnings, e.Error())
+				}
+			case err != nil:
+				logging.Warn("discovery failed", "service", m.ServiceType(), "region", region, "error", err)
+				sd.Error = err.Error()
+				failures = append(failures, fmt.Errorf("%s discovery failed: %w", m.ServiceType(), err))
 				return
 			}
+			logging.Debug("discovered", "service", m.ServiceType(), "region", region,
+				"resources", len(resources), "duration", duration)
 			allResources = append(allResources, resources...)
-		}(mgr)
+		}(i, mgr)
 	}
 
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, report, fmt.Errorf("discovery cancelled: %w", err)
+	}
+
 	// Return resources even if some discoveries failed
-	if len(errors) > 0 && len(allResources) == 0 {
-		return nil, fmt.Errorf("all discoveries failed: %v", errors)
+	if len(failures) > 0 && len(failures) == len(o.managers) {
+		return nil, report, fmt.Errorf("all discoveries failed: %w", errors.Join(failures...))
+	}
+
+	o.costModel.Apply(ctx, allResources)
+	o.iac.annotate(allResources)
+	return allResources, report, nil
+}
+
+// AccountID returns the ID of the AWS account the orchestrator works in. It
+// is looked up once per orchestrator.
+func (o *Orchestrator) AccountID(ctx context.Context) (string, error) {
+	o.accountOnce.Do(func() {
+		output, err := sts.NewFromConfig(o.awsCfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			o.accountErr = fmt.Errorf("failed to identify AWS account: %w", err)
+			return
+		}
+		o.accountID = aws.ToString(output.Account)
+	})
+	return o.accountID, o.accountErr
+}
+
+// ServiceTypes returns the service types the orchestrator manages
+func (o *Orchestrator) ServiceTypes() []models.ServiceType {
+	types := make([]models.ServiceType, 0, len(o.managers))
+	for _, mgr := range o.managers {
+		types = append(types, mgr.ServiceType())
 	}
+	return types
+}
 
-	return allResources, nil
+// Capabilities returns how each managed service brakes its resources
+func (o *Orchestrator) Capabilities() map[models.ServiceType]Capabilities {
+	caps := make(map[models.ServiceType]Capabilities, len(o.managers))
+	for _, mgr := range o.managers {
+		caps[mgr.ServiceType()] = mgr.Capabilities()
+	}
+	return caps
+}
+
+// CapabilitiesOf returns how a resource is braked, or the zero value if its
+// service isn't managed
+func (o *Orchestrator) CapabilitiesOf(resource models.Resource) Capabilities {
+	mgr := o.getManager(resource.ServiceType)
+	if mgr == nil {
+		return Capabilities{}
+	}
+	return CapabilitiesOf(mgr, resource)
+}
+
+// Discover discovers resources of a single service type, letting callers
+// schedule discovery at a finer grain than DiscoverAll
+func (o *Orchestrator) Discover(ctx context.Context, region string, serviceType models.ServiceType) ([]models.Resource, error) {
+	if o.costErr != nil {
+		return nil, o.costErr
+	}
+
+	mgr := o.getManager(serviceType)
+	if mgr == nil {
+		return nil, fmt.Errorf("no manager for service type: %s", serviceType)
+	}
+
+	// A *PartialError comes back with the resources that were found
+	resources, err := mgr.Discover(ctx, region)
+	var partial *PartialError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, fmt.Errorf("%s discovery failed: %w", serviceType, err)
+	}
+	o.costModel.Apply(ctx, resources)
+	return resources, err
 }
 
 // PauseAll pauses all given resources
@@ -90,25 +421,50 @@ func (o *Orchestrator) ResumeAll(ctx context.Context, resources []models.Resourc
 	return o.executeOperation(ctx, resources, "resume")
 }
 
+// executeOperation runs the resources phase by phase. Resources within a phase
+// run concurrently; each phase finishes, and the resources a later phase
+// depends on settle, before the next begins.
 func (o *Orchestrator) executeOperation(ctx context.Context, resources []models.Resource, operation string) ([]models.OperationResult, error) {
+	phases, settle, err := o.phases(resources, operation)
+	if err != nil {
+		return nil, err
+	}
+
 	var (
 		results []models.OperationResult
 		mu      sync.Mutex
-		wg      sync.WaitGroup
 	)
 
-	// Semaphore to limit concurrent operations and avoid AWS rate limiting
-	sem := make(chan struct{}, MaxConcurrentOperations)
+	record := func(result models.OperationResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+		if o.onResult != nil {
+			o.onResult(result)
+		}
+	}
+
+	// One limiter spans all phases so throttling seen early keeps later
+	// phases backed off
+	lim := newLimiter(o.concurrency)
+	for _, phase := range phases {
+		o.runPhase(ctx, lim, phase, operation, settle, record)
+	}
+
+	return results, nil
+}
+
+// runPhase executes one phase. Resources in settle whose manager finishes
+// asynchronously are waited on, so the resources that depend on them start
+// against resources that are really stopped or available.
+func (o *Orchestrator) runPhase(ctx context.Context, lim *limiter, resources []models.Resource, operation string, settle map[string]bool, record func(models.OperationResult)) {
+	var wg sync.WaitGroup
 
 	for _, resource := range resources {
 		wg.Add(1)
 		go func(r models.Resource) {
 			defer wg.Done()
 
-			// Acquire semaphore
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
 			start := time.Now()
 			result := models.OperationResult{
 				Resource:  r,
@@ -116,27 +472,52 @@ func (o *Orchestrator) executeOperation(ctx context.Context, resources []models.
 				Timestamp: start,
 			}
 
+			// Limit concurrent operations to avoid AWS rate limiting
+			if err := lim.acquire(ctx, r.ServiceType); err != nil {
+				result.Success = false
+				result.Error = err.Error()
+				result.Message = fmt.Sprintf("Did not %s %s", operation, r.ResourceID)
+				result.Duration = time.Since(start)
+				record(result)
+				return
+			}
+			// The slot covers the AWS calls only; settling just waits, so it
+			// mustn't hold back other operations
+			var once sync.Once
+			release := func() { once.Do(func() { lim.release(r.ServiceType) }) }
+			defer release()
+
 			// Find the appropriate manager
 			mgr := o.getManager(r.ServiceType)
 			if mgr == nil {
 				result.Success = false
 				result.Error = fmt.Sprintf("no manager for service type: %s", r.ServiceType)
 				result.Duration = time.Since(start)
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
+				record(result)
 				return
 			}
 
-			// Execute the operation
-			var err error
-			if operation == "pause" {
-				err = mgr.Pause(ctx, r)
-			} else {
-				err = mgr.Resume(ctx, r)
+			if o.onIssue != nil {
+				o.onIssue(r, operation)
 			}
 
-			result.Duration = time.Since(start)
+			// Execute the operation, retrying throttled and transient failures
+			retries, err := o.retry.do(ctx, func() error {
+				var err error
+				if operation == "pause" {
+					err = mgr.Pause(ctx, r)
+				} else {
+					err = mgr.Resume(ctx, r)
+				}
+				if IsThrottled(err) {
+					lim.throttled()
+				} else if err == nil {
+					lim.succeeded()
+				}
+				return err
+			})
+			result.Retries = retries
+
 			if err != nil {
 				result.Success = false
 				result.Error = err.Error()
@@ -144,16 +525,116 @@ func (o *Orchestrator) executeOperation(ctx context.Context, resources []models.
 			} else {
 				result.Success = true
 				result.Message = fmt.Sprintf("Successfully %sd %s", operation, r.ResourceID)
+
+				// The operation went through; a slow settle only delays the next phase
+				release()
+				if settler, ok := mgr.(Settler); ok && settle[r.Key()] && CapabilitiesOf(mgr, r).Wait {
+					if err := settler.Settle(ctx, r, operation); err != nil {
+						result.Message += fmt.Sprintf(" (not settled: %v)", err)
+					}
+				}
 			}
 
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
+			result.Duration = time.Since(start)
+			logging.Debug(operation, "service", r.ServiceType, "resource", r.ResourceID,
+				"success", result.Success, "retries", result.Retries, "duration", result.Duration.Round(time.Millisecond), "error", result.Error)
+			record(result)
 		}(resource)
 	}
 
 	wg.Wait()
-	return results, nil
+}
+
+// phases orders resources into batches by service priority and explicit
+// dependencies. Resume runs batches lowest rank first; pause runs them in
+// reverse so dependencies stop last. It also returns the keys of resources to
+// settle: those of every phase another phase follows, and the explicit
+// dependencies on resume, which must be up before their dependents start, and
+// the dependents on pause, which must be down before their dependencies stop.
+func (o *Orchestrator) phases(resources []models.Resource, operation string) ([][]models.Resource, map[string]bool, error) {
+	rank := make([]int, len(resources))
+	index := make(map[string][]int)
+	for i, r := range resources {
+		rank[i] = o.priorities[r.ServiceType]
+		index[r.ResourceID] = append(index[r.ResourceID], i)
+		if r.ARN != "" {
+			index[r.ARN] = append(index[r.ARN], i)
+		}
+	}
+
+	// Push each resource after its dependencies. Without cycles ranks settle
+	// within len(resources) passes.
+	for pass := 0; ; pass++ {
+		changed := false
+		for i, r := range resources {
+			for _, dep := range o.dependenciesOf(r) {
+				for _, d := range index[dep] {
+					if rank[i] <= rank[d] {
+						rank[i] = rank[d] + 1
+						changed = true
+					}
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+		if pass >= len(resources) {
+			return nil, nil, fmt.Errorf("dependency cycle between resources")
+		}
+	}
+
+	settle := make(map[string]bool)
+	for _, r := range resources {
+		for _, dep := range o.dependenciesOf(r) {
+			for _, d := range index[dep] {
+				if operation == "pause" {
+					settle[r.Key()] = true
+				} else {
+					settle[resources[d].Key()] = true
+				}
+			}
+		}
+	}
+
+	byRank := make(map[int][]models.Resource)
+	var ranks []int
+	for i, r := range resources {
+		if _, ok := byRank[rank[i]]; !ok {
+			ranks = append(ranks, rank[i])
+		}
+		byRank[rank[i]] = append(byRank[rank[i]], r)
+	}
+
+	sort.Ints(ranks)
+	if operation == "pause" {
+		sort.Sort(sort.Reverse(sort.IntSlice(ranks)))
+	}
+
+	phases := make([][]models.Resource, 0, len(ranks))
+	for _, r := range ranks {
+		phases = append(phases, byRank[r])
+	}
+
+	// Priorities order services too: every phase but the last must settle
+	// before the next one runs, e.g. RDS up before ECS starts on resume, and
+	// ECS drained before its capacity providers scale in on pause
+	for _, phase := range phases[:max(len(phases)-1, 0)] {
+		for _, r := range phase {
+			settle[r.Key()] = true
+		}
+	}
+	return phases, settle, nil
+}
+
+// dependenciesOf returns the IDs and ARNs a resource depends on, whether
+// configured under its ID or its ARN
+func (o *Orchestrator) dependenciesOf(r models.Resource) []string {
+	deps := o.dependencies[r.ResourceID]
+	if r.ARN != "" {
+		deps = append(deps[:len(deps):len(deps)], o.dependencies[r.ARN]...)
+	}
+	return deps
 }
 
 func (o *Orchestrator) getManager(serviceType models.ServiceType) ServiceManager {
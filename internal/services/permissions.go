@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/appstream"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/emr"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/lightsail"
+	"github.com/aws/aws-sdk-go-v2/service/opensearch"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/workspaces"
+	"github.com/aws/smithy-go"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// probeTimeout bounds each permission probe so one unreachable endpoint
+// doesn't stall setup
+const probeTimeout = 15 * time.Second
+
+// accessDeniedCodes are the error codes AWS services return for a missing
+// IAM permission
+var accessDeniedCodes = map[string]bool{
+	"AccessDenied":          true,
+	"AccessDeniedException": true,
+	"UnauthorizedOperation": true,
+	"UnauthorizedException": true,
+	"AuthorizationError":    true,
+}
+
+// IsAccessDenied reports whether err is an AWS permission error
+func IsAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return accessDeniedCodes[apiErr.ErrorCode()]
+	}
+	return false
+}
+
+// PermissionProbe is a cheap, read-only call that proves the role holds a
+// permission awsbreak needs
+type PermissionProbe struct {
+	Service string
+	Action  string
+	check   func(ctx context.Context) error
+}
+
+// ProbeResult is the outcome of one permission probe
+type ProbeResult struct {
+	Service  string
+	Action   string
+	Duration time.Duration
+	Err      error
+}
+
+// OK reports whether the probe succeeded
+func (r ProbeResult) OK() bool {
+	return r.Err == nil
+}
+
+// Remediation explains how to fix a failed probe
+func (r ProbeResult) Remediation() string {
+	switch {
+	case r.Err == nil:
+		return ""
+	case IsAccessDenied(r.Err):
+		return fmt.Sprintf("The role is missing %s. Add it to the role's policy, or redeploy the awsbreak CloudFormation template.", r.Action)
+	case errors.Is(r.Err, context.DeadlineExceeded):
+		return fmt.Sprintf("%s did not answer within %s. Check network access to the %s endpoint.", r.Service, probeTimeout, r.Service)
+	case errors.Is(r.Err, context.Canceled):
+		return "Verification was cancelled."
+	default:
+		return fmt.Sprintf("Unexpected error from %s; it may not be available in this region.", r.Service)
+	}
+}
+
+// PermissionProbes returns a probe for each service awsbreak manages. EC2
+// stop permission is checked with a dry run; the rest use describe calls.
+func PermissionProbes(cfg aws.Config) []PermissionProbe {
+	ec2Client := ec2.NewFromConfig(cfg)
+	rdsClient := rds.NewFromConfig(cfg)
+	ecsClient := ecs.NewFromConfig(cfg)
+	asgClient := autoscaling.NewFromConfig(cfg)
+	eksClient := eks.NewFromConfig(cfg)
+	wsClient := workspaces.NewFromConfig(cfg)
+	asClient := appstream.NewFromConfig(cfg)
+	osClient := opensearch.NewFromConfig(cfg)
+	ddbClient := dynamodb.NewFromConfig(cfg)
+	emrClient := emr.NewFromConfig(cfg)
+	kinesisClient := kinesis.NewFromConfig(cfg)
+	lightsailClient := lightsail.NewFromConfig(cfg)
+	cwClient := cloudwatch.NewFromConfig(cfg)
+
+	return []PermissionProbe{
+		{Service: "EC2", Action: "ec2:DescribeInstances", check: func(ctx context.Context) error {
+			_, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{MaxResults: aws.Int32(5)})
+			return err
+		}},
+		{Service: "EC2", Action: "ec2:DescribeVolumes", check: func(ctx context.Context) error {
+			_, err := ec2Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{MaxResults: aws.Int32(5)})
+			return err
+		}},
+		{Service: "EC2", Action: "ec2:StopInstances", check: func(ctx context.Context) error {
+			// A dry run against a nonexistent instance is authorized before
+			// the instance is looked up
+			_, err := ec2Client.StopInstances(ctx, &ec2.StopInstancesInput{
+				InstanceIds: []string{"i-00000000000000000"},
+				DryRun:      aws.Bool(true),
+			})
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "DryRunOperation" || apiErr.ErrorCode() == "InvalidInstanceID.NotFound") {
+				return nil
+			}
+			return err
+		}},
+		{Service: "Network", Action: "ec2:DescribeNatGateways", check: func(ctx context.Context) error {
+			_, err := ec2Client.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{MaxResults: aws.Int32(5)})
+			return err
+		}},
+		{Service: "RDS", Action: "rds:DescribeDBInstances", check: func(ctx context.Context) error {
+			_, err := rdsClient.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{MaxRecords: aws.Int32(20)})
+			return err
+		}},
+		{Service: "ECS", Action: "ecs:ListClusters", check: func(ctx context.Context) error {
+			_, err := ecsClient.ListClusters(ctx, &ecs.ListClustersInput{MaxResults: aws.Int32(1)})
+			return err
+		}},
+		{Service: "Auto Scaling", Action: "autoscaling:DescribeAutoScalingGroups", check: func(ctx context.Context) error {
+			_, err := asgClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{MaxRecords: aws.Int32(1)})
+			return err
+		}},
+		{Service: "EKS", Action: "eks:ListClusters", check: func(ctx context.Context) error {
+			_, err := eksClient.ListClusters(ctx, &eks.ListClustersInput{MaxResults: aws.Int32(1)})
+			return err
+		}},
+		{Service: "WorkSpaces", Action: "workspaces:DescribeWorkspaces", check: func(ctx context.Context) error {
+			_, err := wsClient.DescribeWorkspaces(ctx, &workspaces.DescribeWorkspacesInput{Limit: aws.Int32(1)})
+			return err
+		}},
+		{Service: "AppStream", Action: "appstream:DescribeFleets", check: func(ctx context.Context) error {
+			_, err := asClient.DescribeFleets(ctx, &appstream.DescribeFleetsInput{})
+			return err
+		}},
+		{Service: "OpenSearch", Action: "es:ListDomainNames", check: func(ctx context.Context) error {
+			_, err := osClient.ListDomainNames(ctx, &opensearch.ListDomainNamesInput{})
+			return err
+		}},
+		{Service: "DynamoDB", Action: "dynamodb:ListTables", check: func(ctx context.Context) error {
+			_, err := ddbClient.ListTables(ctx, &dynamodb.ListTablesInput{Limit: aws.Int32(1)})
+			return err
+		}},
+		{Service: "EMR", Action: "elasticmapreduce:ListClusters", check: func(ctx context.Context) error {
+			_, err := emrClient.ListClusters(ctx, &emr.ListClustersInput{})
+			return err
+		}},
+		{Service: "Kinesis", Action: "kinesis:ListStreams", check: func(ctx context.Context) error {
+			_, err := kinesisClient.ListStreams(ctx, &kinesis.ListStreamsInput{Limit: aws.Int32(1)})
+			return err
+		}},
+		{Service: "Lightsail", Action: "lightsail:GetInstances", check: func(ctx context.Context) error {
+			_, err := lightsailClient.GetInstances(ctx, &lightsail.GetInstancesInput{})
+			return err
+		}},
+		{Service: "CloudWatch", Action: "cloudwatch:GetMetricStatistics", check: func(ctx context.Context) error {
+			end := time.Now()
+			_, err := cwClient.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+				Namespace:  aws.String("AWS/RDS"),
+				MetricName: aws.String("DatabaseConnections"),
+				StartTime:  aws.Time(end.Add(-5 * time.Minute)),
+				EndTime:    aws.Time(end),
+				Period:     aws.Int32(300),
+				Statistics: []cwtypes.Statistic{cwtypes.StatisticMaximum},
+			})
+			return err
+		}},
+	}
+}
+
+// SelectProbes drops the probes of services left out by only and skip (see
+// Selected). Probes of services awsbreak doesn't manage, such as CloudWatch,
+// are always kept.
+func SelectProbes(probes []PermissionProbe, only, skip []models.ServiceType) []PermissionProbe {
+	var kept []PermissionProbe
+	for _, p := range probes {
+		service := models.ServiceType(strings.ToLower(strings.ReplaceAll(p.Service, " ", "")))
+		if _, managed := DefaultPriorities[service]; managed && !Selected(service, only, skip) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// RunProbes runs probes in parallel and returns their results in probe order.
+// onDone, if set, is called as each probe finishes; calls are serialized.
+// Cancelling ctx stops probes that are still running.
+func RunProbes(ctx context.Context, probes []PermissionProbe, onDone func(ProbeResult)) []ProbeResult {
+	results := make([]ProbeResult, len(probes))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p PermissionProbe) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := p.check(probeCtx)
+			r := ProbeResult{Service: p.Service, Action: p.Action, Duration: time.Since(start), Err: err}
+
+			mu.Lock()
+			results[i] = r
+			if onDone != nil {
+				onDone(r)
+			}
+			mu.Unlock()
+		}(i, p)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// ConnectionLookback is how far back to look for database connections
+	ConnectionLookback = 1 * time.Hour
+	// DefaultConnectionThreshold is the peak connection count at which a database is considered in use
+	DefaultConnectionThreshold = 5
+)
+
+// ConnectionCheck is the result of checking a database for live connections
+type ConnectionCheck struct {
+	Resource    models.Resource
+	Connections float64
+	Blocked     bool
+	Error       string
+}
+
+// ConnectionChecker looks up recent connection counts for databases via CloudWatch
+type ConnectionChecker struct {
+	client    *cloudwatch.Client
+	threshold int
+}
+
+// NewConnectionChecker creates a new connection checker. A threshold of zero
+// or less uses DefaultConnectionThreshold.
+func NewConnectionChecker(cfg aws.Config, threshold int) *ConnectionChecker {
+	if threshold <= 0 {
+		threshold = DefaultConnectionThreshold
+	}
+	return &ConnectionChecker{
+		client:    cloudwatch.NewFromConfig(cfg),
+		threshold: threshold,
+	}
+}
+
+// CheckAll checks every RDS resource for connections in the lookback window.
+// Only databases that had at least one connection (or could not be checked)
+// are returned.
+func (c *ConnectionChecker) CheckAll(ctx context.Context, resources []models.Resource) []ConnectionCheck {
+	var checks []ConnectionCheck
+
+	for _, r := range resources {
+		if r.ServiceType != models.ServiceRDS {
+			continue
+		}
+
+		connections, err := c.peakConnections(ctx, r)
+		if err != nil {
+			checks = append(checks, ConnectionCheck{Resource: r, Error: err.Error()})
+			continue
+		}
+		if connections == 0 {
+			continue
+		}
+
+		checks = append(checks, ConnectionCheck{
+			Resource:    r,
+			Connections: connections,
+			Blocked:     connections >= float64(c.threshold),
+		})
+	}
+
+	return checks
+}
+
+// peakConnections returns the maximum DatabaseConnections datapoint in the lookback window
+func (c *ConnectionChecker) peakConnections(ctx context.Context, resource models.Resource) (float64, error) {
+	dimension := "DBInstanceIdentifier"
+	if resource.Metadata["is_cluster"] == true {
+		dimension = "DBClusterIdentifier"
+	}
+
+	end := time.Now()
+	output, err := c.client.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String("AWS/RDS"),
+		MetricName: aws.String("DatabaseConnections"),
+		Dimensions: []types.Dimension{
+			{
+				Name:  aws.String(dimension),
+				Value: aws.String(resource.ResourceID),
+			},
+		},
+		StartTime:  aws.Time(end.Add(-ConnectionLookback)),
+		EndTime:    aws.Time(end),
+		Period:     aws.Int32(300),
+		Statistics: []types.Statistic{types.StatisticMaximum},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get connection count for %s: %w", resource.ResourceID, err)
+	}
+
+	var peak float64
+	for _, dp := range output.Datapoints {
+		if v := aws.ToFloat64(dp.Maximum); v > peak {
+			peak = v
+		}
+	}
+
+	return peak, nil
+}
+
+// FilterBlocked removes blocked databases from the resource list
+func FilterBlocked(resources []models.Resource, checks []ConnectionCheck) []models.Resource {
+	blocked := make(map[string]bool)
+	for _, c := range checks {
+		if c.Blocked {
+			blocked[c.Resource.ResourceID] = true
+		}
+	}
+
+	var filtered []models.Resource
+	for _, r := range resources {
+		if r.ServiceType == models.ServiceRDS && blocked[r.ResourceID] {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// schedulerTags maps tag keys set by other schedulers to the tool that owns
+// them. Keys ending in "*" match as prefixes.
+var schedulerTags = map[string]string{
+	"Schedule":           "AWS Instance Scheduler",
+	"QSConfigId-*":       "Systems Manager Quick Setup",
+	"maid_offhours":      "Cloud Custodian",
+	"custodian_offhours": "Cloud Custodian",
+	"parkmycloud":        "ParkMyCloud",
+}
+
+// ProtectedResource is a resource the guard refuses to pause, and why
+type ProtectedResource struct {
+	Resource models.Resource
+	Reason   string
+}
+
+// Guard keeps configured, critical and externally managed resources from
+// being paused
+type Guard struct {
+	patterns      []string
+	ids           map[string]bool
+	schedulerTags map[string]string
+	critical      *criticalChecks // nil when turned off
+	skipIaC       bool
+}
+
+// NewGuard creates a guard from the protection config, which may be nil
+func NewGuard(cfg *models.ProtectionConfig) *Guard {
+	g := &Guard{
+		ids:           make(map[string]bool),
+		schedulerTags: make(map[string]string),
+	}
+	for key, tool := range schedulerTags {
+		g.schedulerTags[key] = tool
+	}
+
+	if cfg == nil {
+		g.critical = newCriticalChecks(nil)
+	} else {
+		g.critical = newCriticalChecks(cfg.Critical)
+		g.patterns = cfg.NamePatterns
+		g.skipIaC = cfg.IaC != nil && cfg.IaC.Mode == IaCSkip
+		for _, id := range cfg.ResourceIDs {
+			g.ids[id] = true
+		}
+		for _, key := range cfg.SchedulerTags {
+			g.schedulerTags[key] = "another scheduler"
+		}
+	}
+	return g
+}
+
+// Reason returns why a resource is protected, or "" if it may be paused
+func (g *Guard) Reason(r models.Resource) string {
+	if g.ids[r.ResourceID] || (r.ARN != "" && g.ids[r.ARN]) {
+		return "listed in protection.resource_ids"
+	}
+
+	name := r.Tags["Name"]
+	for _, pattern := range g.patterns {
+		if matched, _ := path.Match(pattern, r.ResourceID); matched {
+			return fmt.Sprintf("matches protected pattern %q", pattern)
+		}
+		if name == "" {
+			continue
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return fmt.Sprintf("name %q matches protected pattern %q", name, pattern)
+		}
+	}
+
+	// Pausing these would lock people, or awsbreak, out of the account
+	if g.critical != nil {
+		if reason := g.critical.reason(r); reason != "" {
+			return reason
+		}
+	}
+
+	// Something else keeps these running and would fight the pause
+	if group, _ := r.Metadata["autoscaling_group"].(string); group != "" {
+		return fmt.Sprintf("managed by Auto Scaling group %s", group)
+	}
+	if replicas, _ := r.Metadata["read_replicas"].(float64); replicas > 0 {
+		return fmt.Sprintf("has %.0f read replicas", replicas)
+	}
+	if by := ManagedBy(r); by != "" && g.skipIaC {
+		return fmt.Sprintf("managed by %s; pausing it would show up as drift", by)
+	}
+
+	// Two schedulers fighting over the same resource waste more than they save
+	if tool, tag := g.scheduler(r); tool != "" {
+		return fmt.Sprintf("scheduled by %s (tag %s)", tool, tag)
+	}
+
+	return ""
+}
+
+// scheduler returns the scheduler that owns a resource and the tag that gave it away
+func (g *Guard) scheduler(r models.Resource) (tool, tag string) {
+	keys := make([]string, 0, len(r.Tags))
+	for key := range r.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := r.Tags[key]
+		for pattern, owner := range g.schedulerTags {
+			prefix, isPrefix := strings.CutSuffix(pattern, "*")
+			if key == pattern || (isPrefix && strings.HasPrefix(key, prefix)) {
+				return owner, fmt.Sprintf("%s=%s", key, value)
+			}
+		}
+	}
+	return "", ""
+}
+
+// Split separates protected resources from the ones that may be paused
+func (g *Guard) Split(resources []models.Resource) (allowed []models.Resource, protected []ProtectedResource) {
+	for _, r := range resources {
+		if reason := g.Reason(r); reason != "" {
+			protected = append(protected, ProtectedResource{Resource: r, Reason: reason})
+			continue
+		}
+		allowed = append(allowed, r)
+	}
+	return allowed, protected
+}
@@ -3,14 +3,33 @@ package services
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 
+	"github.com/aicoder2009/aws-hit-breaks/internal/cost"
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
 	"github.com/aicoder2009/aws-hit-breaks/internal/models"
 )
 
+const (
+	// rdsAvailableTimeout bounds how long resume waits for a database to start
+	rdsAvailableTimeout = 20 * time.Minute
+	// rdsRestartDeadline is how long AWS lets a database stay stopped
+	rdsRestartDeadline = 7 * 24 * time.Hour
+
+	// serverlessV2MinACUs is the lowest minimum capacity every Aurora
+	// Serverless v2 engine version accepts
+	serverlessV2MinACUs = 0.5
+	// serverlessV1AutoPauseSeconds is how long a paused Serverless v1 cluster
+	// stays idle before it auto-pauses
+	serverlessV1AutoPauseSeconds = 300
+	// serverlessInstanceClass is the instance class of Serverless v2 instances
+	serverlessInstanceClass = "db.serverless"
+)
+
 // RDSServiceManager handles RDS instance and cluster operations
 type RDSServiceManager struct {
 	client *rds.Client
@@ -30,19 +49,35 @@ func (m *RDSServiceManager) ServiceType() models.ServiceType {
 	return models.ServiceRDS
 }
 
+// Capabilities reports that databases are stopped in place and restarted by
+// AWS after seven days
+func (m *RDSServiceManager) Capabilities() Capabilities {
+	return Capabilities{Stop: true, Wait: true, RestartDeadline: rdsRestartDeadline}
+}
+
+// ResourceCapabilities reports that Aurora Serverless clusters are scaled
+// down rather than stopped, so AWS never restarts them and there is nothing
+// to wait for
+func (m *RDSServiceManager) ResourceCapabilities(resource models.Resource) Capabilities {
+	if AuroraServerless(resource) != "" {
+		return Capabilities{ScaleToZero: true}
+	}
+	return m.Capabilities()
+}
+
 // Discover finds all running RDS instances and Aurora clusters
 func (m *RDSServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
 	var resources []models.Resource
 
 	// Discover RDS instances
-	instances, err := m.discoverInstances(ctx, region)
+	instances, serverless, err := m.discoverInstances(ctx, region)
 	if err != nil {
 		return nil, err
 	}
 	resources = append(resources, instances...)
 
 	// Discover Aurora clusters
-	clusters, err := m.discoverClusters(ctx, region)
+	clusters, err := m.discoverClusters(ctx, region, serverless)
 	if err != nil {
 		return nil, err
 	}
@@ -51,19 +86,25 @@ func (m *RDSServiceManager) Discover(ctx context.Context, region string) ([]mode
 	return resources, nil
 }
 
-func (m *RDSServiceManager) discoverInstances(ctx context.Context, region string) ([]models.Resource, error) {
+// discoverInstances returns the running standalone instances, and how many
+// Serverless v2 instances each Aurora cluster has
+func (m *RDSServiceManager) discoverInstances(ctx context.Context, region string) ([]models.Resource, map[string]int, error) {
 	var resources []models.Resource
+	serverless := make(map[string]int)
 
 	paginator := rds.NewDescribeDBInstancesPaginator(m.client, &rds.DescribeDBInstancesInput{})
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to describe RDS instances: %w", err)
+			return nil, nil, fmt.Errorf("failed to describe RDS instances: %w", err)
 		}
 
 		for _, instance := range output.DBInstances {
 			// Skip instances that are part of Aurora clusters (handled separately)
 			if instance.DBClusterIdentifier != nil {
+				if aws.ToString(instance.DBInstanceClass) == serverlessInstanceClass {
+					serverless[aws.ToString(instance.DBClusterIdentifier)]++
+				}
 				continue
 			}
 
@@ -77,10 +118,13 @@ func (m *RDSServiceManager) discoverInstances(ctx context.Context, region string
 		}
 	}
 
-	return resources, nil
+	return resources, serverless, nil
 }
 
-func (m *RDSServiceManager) discoverClusters(ctx context.Context, region string) ([]models.Resource, error) {
+// discoverClusters returns the available Aurora clusters. Serverless v1
+// clusters that already auto-pause and Serverless v2 clusters already at the
+// capacity floor are left out, since pausing them saves nothing.
+func (m *RDSServiceManager) discoverClusters(ctx context.Context, region string, serverless map[string]int) ([]models.Resource, error) {
 	var resources []models.Resource
 
 	paginator := rds.NewDescribeDBClustersPaginator(m.client, &rds.DescribeDBClustersInput{})
@@ -96,7 +140,27 @@ func (m *RDSServiceManager) discoverClusters(ctx context.Context, region string)
 				continue
 			}
 
-			resource := m.clusterToResource(cluster, region)
+			id := aws.ToString(cluster.DBClusterIdentifier)
+			// Lowering the capacity of a cluster that also has provisioned
+			// instances leaves those running, so only clusters made up of
+			// Serverless v2 instances alone are scaled down; the rest are
+			// stopped
+			serverlessInstances := serverless[id]
+			if serverlessInstances != len(cluster.DBClusterMembers) {
+				serverlessInstances = 0
+			}
+			switch {
+			case aws.ToString(cluster.EngineMode) == "serverless" &&
+				cluster.ScalingConfigurationInfo != nil && aws.ToBool(cluster.ScalingConfigurationInfo.AutoPause):
+				logging.Debug("skipping Aurora Serverless v1 cluster that auto-pauses", "cluster", id)
+				continue
+			case cluster.ServerlessV2ScalingConfiguration != nil && serverlessInstances > 0 &&
+				aws.ToFloat64(cluster.ServerlessV2ScalingConfiguration.MinCapacity) <= serverlessV2MinACUs:
+				logging.Debug("skipping Aurora Serverless v2 cluster at the capacity floor", "cluster", id)
+				continue
+			}
+
+			resource := m.clusterToResource(cluster, region, serverlessInstances)
 			resources = append(resources, resource)
 		}
 	}
@@ -104,10 +168,20 @@ func (m *RDSServiceManager) discoverClusters(ctx context.Context, region string)
 	return resources, nil
 }
 
-// Pause stops an RDS instance or cluster
+// Pause stops an RDS instance or cluster. Aurora Serverless clusters are
+// scaled down instead: v2 clusters have their minimum capacity lowered to the
+// floor, and v1 clusters are set to auto-pause when idle.
 func (m *RDSServiceManager) Pause(ctx context.Context, resource models.Resource) error {
 	isCluster := resource.Metadata["is_cluster"] == true
 
+	switch AuroraServerless(resource) {
+	case "v2":
+		maxCapacity, _ := resource.Metadata["original_max_capacity"].(float64)
+		return m.scaleServerlessV2(ctx, resource.ResourceID, serverlessV2MinACUs, maxCapacity)
+	case "v1":
+		return m.setAutoPause(ctx, resource.ResourceID, true, serverlessV1AutoPauseSeconds)
+	}
+
 	if isCluster {
 		_, err := m.client.StopDBCluster(ctx, &rds.StopDBClusterInput{
 			DBClusterIdentifier: aws.String(resource.ResourceID),
@@ -127,10 +201,21 @@ func (m *RDSServiceManager) Pause(ctx context.Context, resource models.Resource)
 	return nil
 }
 
-// Resume starts an RDS instance or cluster
+// Resume starts an RDS instance or cluster, or restores an Aurora Serverless
+// cluster's original capacity settings
 func (m *RDSServiceManager) Resume(ctx context.Context, resource models.Resource) error {
 	isCluster := resource.Metadata["is_cluster"] == true
 
+	switch AuroraServerless(resource) {
+	case "v2":
+		minCapacity, _ := resource.Metadata["original_min_capacity"].(float64)
+		maxCapacity, _ := resource.Metadata["original_max_capacity"].(float64)
+		return m.scaleServerlessV2(ctx, resource.ResourceID, minCapacity, maxCapacity)
+	case "v1":
+		seconds, _ := resource.Metadata["original_seconds_until_auto_pause"].(float64)
+		return m.setAutoPause(ctx, resource.ResourceID, false, int32(seconds))
+	}
+
 	if isCluster {
 		_, err := m.client.StartDBCluster(ctx, &rds.StartDBClusterInput{
 			DBClusterIdentifier: aws.String(resource.ResourceID),
@@ -150,6 +235,81 @@ func (m *RDSServiceManager) Resume(ctx context.Context, resource models.Resource
 	return nil
 }
 
+// scaleServerlessV2 sets the capacity range of an Aurora Serverless v2 cluster
+func (m *RDSServiceManager) scaleServerlessV2(ctx context.Context, cluster string, minCapacity, maxCapacity float64) error {
+	if minCapacity <= 0 || maxCapacity < minCapacity {
+		return fmt.Errorf("no valid capacity range recorded for Aurora Serverless v2 cluster %s", cluster)
+	}
+	_, err := m.client.ModifyDBCluster(ctx, &rds.ModifyDBClusterInput{
+		DBClusterIdentifier: aws.String(cluster),
+		ServerlessV2ScalingConfiguration: &types.ServerlessV2ScalingConfiguration{
+			MinCapacity: aws.Float64(minCapacity),
+			MaxCapacity: aws.Float64(maxCapacity),
+		},
+		ApplyImmediately: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set capacity of Aurora Serverless v2 cluster %s to %g-%g ACUs: %w", cluster, minCapacity, maxCapacity, err)
+	}
+	return nil
+}
+
+// setAutoPause turns auto-pause of an Aurora Serverless v1 cluster on or off
+func (m *RDSServiceManager) setAutoPause(ctx context.Context, cluster string, on bool, seconds int32) error {
+	scaling := &types.ScalingConfiguration{AutoPause: aws.Bool(on)}
+	if seconds > 0 {
+		scaling.SecondsUntilAutoPause = aws.Int32(seconds)
+	}
+	_, err := m.client.ModifyDBCluster(ctx, &rds.ModifyDBClusterInput{
+		DBClusterIdentifier:  aws.String(cluster),
+		ScalingConfiguration: scaling,
+		ApplyImmediately:     aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set auto-pause of Aurora Serverless v1 cluster %s: %w", cluster, err)
+	}
+	return nil
+}
+
+// AuroraServerless returns "v1" or "v2" for Aurora Serverless clusters and ""
+// for other databases. Serverless clusters are scaled down rather than
+// stopped, so AWS never restarts them.
+func AuroraServerless(resource models.Resource) string {
+	if resource.ServiceType != models.ServiceRDS {
+		return ""
+	}
+	serverless, _ := resource.Metadata["serverless"].(string)
+	return serverless
+}
+
+// Settle waits for a resumed instance or cluster to become available, so
+// services that depend on it start against a reachable database
+func (m *RDSServiceManager) Settle(ctx context.Context, resource models.Resource, operation string) error {
+	if operation != "resume" || AuroraServerless(resource) != "" {
+		return nil
+	}
+
+	if resource.Metadata["is_cluster"] == true {
+		waiter := rds.NewDBClusterAvailableWaiter(m.client)
+		err := waiter.Wait(ctx, &rds.DescribeDBClustersInput{
+			DBClusterIdentifier: aws.String(resource.ResourceID),
+		}, waitTimeout(ctx, rdsAvailableTimeout))
+		if err != nil {
+			return fmt.Errorf("RDS cluster %s did not become available: %w", resource.ResourceID, err)
+		}
+		return nil
+	}
+
+	waiter := rds.NewDBInstanceAvailableWaiter(m.client)
+	err := waiter.Wait(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(resource.ResourceID),
+	}, waitTimeout(ctx, rdsAvailableTimeout))
+	if err != nil {
+		return fmt.Errorf("RDS instance %s did not become available: %w", resource.ResourceID, err)
+	}
+	return nil
+}
+
 func (m *RDSServiceManager) instanceToResource(instance types.DBInstance, region string) models.Resource {
 	// Extract tags
 	tags := make(map[string]string)
@@ -164,11 +324,28 @@ func (m *RDSServiceManager) instanceToResource(instance types.DBInstance, region
 		"engine":         aws.ToString(instance.Engine),
 		"engine_version": aws.ToString(instance.EngineVersion),
 		"instance_class": aws.ToString(instance.DBInstanceClass),
-		"multi_az":       instance.MultiAZ,
+		"multi_az":       aws.ToBool(instance.MultiAZ),
+	}
+
+	if aws.ToBool(instance.DeletionProtection) {
+		metadata["deletion_protection"] = true
 	}
 
+	// Storage keeps billing while the database is stopped
 	if instance.AllocatedStorage != nil {
-		metadata["storage_gb"] = *instance.AllocatedStorage
+		metadata["storage_gb"] = float64(*instance.AllocatedStorage)
+	}
+	if instance.StorageType != nil {
+		metadata["storage_type"] = *instance.StorageType
+	}
+	if instance.Iops != nil {
+		metadata["provisioned_iops"] = float64(*instance.Iops)
+	}
+	if instance.BackupRetentionPeriod != nil {
+		metadata["backup_retention_days"] = float64(*instance.BackupRetentionPeriod)
+	}
+	if replicas := len(instance.ReadReplicaDBInstanceIdentifiers); replicas > 0 {
+		metadata["read_replicas"] = float64(replicas)
 	}
 
 	costPerHour := estimateRDSCost(aws.ToString(instance.DBInstanceClass), aws.ToString(instance.Engine), region)
@@ -176,6 +353,7 @@ func (m *RDSServiceManager) instanceToResource(instance types.DBInstance, region
 	return models.Resource{
 		ServiceType:  models.ServiceRDS,
 		ResourceID:   aws.ToString(instance.DBInstanceIdentifier),
+		ARN:          aws.ToString(instance.DBInstanceArn),
 		Region:       region,
 		CurrentState: models.StateAvailable,
 		Tags:         tags,
@@ -184,7 +362,9 @@ func (m *RDSServiceManager) instanceToResource(instance types.DBInstance, region
 	}
 }
 
-func (m *RDSServiceManager) clusterToResource(cluster types.DBCluster, region string) models.Resource {
+// clusterToResource converts an Aurora cluster. serverlessInstances is how
+// many Serverless v2 instances it has, or 0 when it has provisioned ones too.
+func (m *RDSServiceManager) clusterToResource(cluster types.DBCluster, region string, serverlessInstances int) models.Resource {
 	// Extract tags
 	tags := make(map[string]string)
 	for _, tag := range cluster.TagList {
@@ -199,36 +379,72 @@ func (m *RDSServiceManager) clusterToResource(cluster types.DBCluster, region st
 		"engine_version": aws.ToString(cluster.EngineVersion),
 	}
 
+	if aws.ToBool(cluster.DeletionProtection) {
+		metadata["deletion_protection"] = true
+	}
+
+	// Storage keeps billing while the cluster is stopped
 	if cluster.AllocatedStorage != nil {
-		metadata["storage_gb"] = *cluster.AllocatedStorage
+		metadata["storage_gb"] = float64(*cluster.AllocatedStorage)
+	}
+	if cluster.StorageType != nil {
+		metadata["storage_type"] = *cluster.StorageType
+	}
+	if cluster.Iops != nil {
+		metadata["provisioned_iops"] = float64(*cluster.Iops)
+	}
+	if cluster.BackupRetentionPeriod != nil {
+		metadata["backup_retention_days"] = float64(*cluster.BackupRetentionPeriod)
+	}
+
+	costPerHour := 0.10 // Aurora cluster base cost
+	if mode := aws.ToString(cluster.EngineMode); mode != "" {
+		metadata["engine_mode"] = mode
+	}
+	switch {
+	case aws.ToString(cluster.EngineMode) == "serverless":
+		// Serverless v1 bills the ACUs currently in use
+		acus := float64(aws.ToInt32(cluster.Capacity))
+		metadata["serverless"] = "v1"
+		metadata["capacity_acus"] = acus
+		if cluster.ScalingConfigurationInfo != nil {
+			metadata["original_seconds_until_auto_pause"] = float64(aws.ToInt32(cluster.ScalingConfigurationInfo.SecondsUntilAutoPause))
+		}
+		costPerHour = acus * serverlessACURate("aurora-serverless-v1-acu")
+	case cluster.ServerlessV2ScalingConfiguration != nil && serverlessInstances > 0:
+		// Each Serverless v2 instance bills at least the minimum capacity;
+		// pausing saves what lies above the floor
+		minCapacity := aws.ToFloat64(cluster.ServerlessV2ScalingConfiguration.MinCapacity)
+		metadata["serverless"] = "v2"
+		metadata["serverless_instances"] = float64(serverlessInstances)
+		metadata["original_min_capacity"] = minCapacity
+		metadata["original_max_capacity"] = aws.ToFloat64(cluster.ServerlessV2ScalingConfiguration.MaxCapacity)
+		savable := float64(serverlessInstances) * (minCapacity - serverlessV2MinACUs)
+		metadata["savable_acus"] = savable
+		costPerHour = savable * serverlessACURate("aurora-serverless-v2-acu")
 	}
 
 	return models.Resource{
 		ServiceType:  models.ServiceRDS,
 		ResourceID:   aws.ToString(cluster.DBClusterIdentifier),
+		ARN:          aws.ToString(cluster.DBClusterArn),
 		Region:       region,
 		CurrentState: models.StateAvailable,
 		Tags:         tags,
 		Metadata:     metadata,
-		CostPerHour:  0.10, // Aurora cluster base cost
+		CostPerHour:  costPerHour,
 	}
 }
 
+// serverlessACURate returns the hourly rate of one Aurora capacity unit
+func serverlessACURate(product string) float64 {
+	rate, _ := cost.StaticRate(models.ServiceRDS, product)
+	return rate
+}
+
 func estimateRDSCost(instanceClass, engine, region string) float64 {
-	// Simplified pricing
-	pricing := map[string]float64{
-		"db.t3.micro":  0.017,
-		"db.t3.small":  0.034,
-		"db.t3.medium": 0.068,
-		"db.t3.large":  0.136,
-		"db.m5.large":  0.171,
-		"db.m5.xlarge": 0.342,
-		"db.r5.large":  0.24,
-		"db.r5.xlarge": 0.48,
-	}
-
-	if cost, ok := pricing[instanceClass]; ok {
-		return cost
+	if rate, ok := cost.StaticRate(models.ServiceRDS, instanceClass); ok {
+		return rate
 	}
 	return 0.10 // Default estimate
 }
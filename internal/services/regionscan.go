@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/appstream"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/emr"
+	emrtypes "github.com/aws/aws-sdk-go-v2/service/emr/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/lightsail"
+	"github.com/aws/aws-sdk-go-v2/service/opensearch"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/resourceexplorer2"
+	retypes "github.com/aws/aws-sdk-go-v2/service/resourceexplorer2/types"
+	"github.com/aws/aws-sdk-go-v2/service/workspaces"
+)
+
+// regionScanConcurrency is how many regions are probed at once
+const regionScanConcurrency = 8
+
+// Sources of a region scan
+const (
+	ScanSourceResourceExplorer = "resource-explorer"
+	ScanSourceProbe            = "probe"
+)
+
+// searchResourceTypes are the Resource Explorer types of resources awsbreak manages
+var searchResourceTypes = []string{
+	"ec2:instance",
+	"ec2:natgateway",
+	"rds:db",
+	"rds:cluster",
+	"ecs:service",
+	"autoscaling:autoScalingGroup",
+	"eks:cluster",
+	"workspaces:workspace",
+	"appstream:fleet",
+	"es:domain",
+	"dynamodb:table",
+	"elasticmapreduce:cluster",
+	"kinesis:stream",
+	"lightsail:Instance",
+	"lightsail:RelationalDatabase",
+	"lightsail:ContainerService",
+}
+
+// RegionActivity is what a pre-scan found in one region
+type RegionActivity struct {
+	Region string
+	// Candidates counts resources that may need braking. It is a cheap
+	// estimate: full discovery decides what is actually running.
+	Candidates int
+	Source     string
+	Err        error // the region couldn't be probed at all and may hold resources
+}
+
+// RegionScanner finds the regions that hold resources before full discovery,
+// which is too slow to run blindly across every region
+type RegionScanner struct {
+	cfg aws.Config
+}
+
+// NewRegionScanner creates a region scanner using the config's credentials
+func NewRegionScanner(cfg aws.Config) *RegionScanner {
+	return &RegionScanner{cfg: cfg}
+}
+
+// Scan returns the regions that hold resources awsbreak manages, or that
+// couldn't be probed, sorted by region. An aggregator index in Resource Explorer
+// answers with one search for every region that has an index; it can lag new
+// resources by a few minutes. Enabled regions without an index, or every
+// enabled region when there is no aggregator, are probed with a few list calls.
+func (s *RegionScanner) Scan(ctx context.Context) ([]RegionActivity, error) {
+	regions, err := EnabledRegions(ctx, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	found, indexed, ok := s.searchIndex(ctx)
+	if !ok {
+		found, indexed = nil, nil
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	sem := make(chan struct{}, regionScanConcurrency)
+	for _, region := range regions {
+		if indexed[region] {
+			continue
+		}
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			activity := s.probe(ctx, region)
+			if activity.Candidates == 0 && activity.Err == nil {
+				return
+			}
+			mu.Lock()
+			found = append(found, activity)
+			mu.Unlock()
+		}(region)
+	}
+	wg.Wait()
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Region < found[j].Region
+	})
+	return found, nil
+}
+
+// EnabledRegions lists the regions enabled for the account cfg acts in
+func EnabledRegions(ctx context.Context, cfg aws.Config) ([]string, error) {
+	output, err := ec2.NewFromConfig(cfg).DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe regions: %w", err)
+	}
+
+	regions := make([]string, 0, len(output.Regions))
+	for _, r := range output.Regions {
+		regions = append(regions, aws.ToString(r.RegionName))
+	}
+	return regions, nil
+}
+
+// searchIndex counts resources per region with the account's Resource
+// Explorer aggregator index, and returns the regions that have an index and
+// so are covered by the search. It returns false when there is no aggregator
+// or it can't be searched, so the caller falls back to probing.
+func (s *RegionScanner) searchIndex(ctx context.Context) ([]RegionActivity, map[string]bool, bool) {
+	indexed := make(map[string]bool)
+	var aggregator string
+	pages := resourceexplorer2.NewListIndexesPaginator(resourceexplorer2.NewFromConfig(s.cfg), &resourceexplorer2.ListIndexesInput{})
+	for pages.HasMorePages() {
+		output, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, nil, false
+		}
+		for _, index := range output.Indexes {
+			region := aws.ToString(index.Region)
+			indexed[region] = true
+			if index.Type == retypes.IndexTypeAggregator {
+				aggregator = region
+			}
+		}
+	}
+	if aggregator == "" {
+		return nil, nil, false
+	}
+
+	// Searches go to the aggregator's region and use its default view
+	client := resourceexplorer2.NewFromConfig(s.cfg, func(o *resourceexplorer2.Options) {
+		o.Region = aggregator
+	})
+	query := "resourcetype:" + strings.Join(searchResourceTypes, " resourcetype:")
+
+	counts := make(map[string]int)
+	paginator := resourceexplorer2.NewSearchPaginator(client, &resourceexplorer2.SearchInput{
+		QueryString: aws.String(query),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, false
+		}
+		for _, r := range output.Resources {
+			counts[aws.ToString(r.Region)]++
+		}
+	}
+
+	found := make([]RegionActivity, 0, len(counts))
+	for region, n := range counts {
+		found = append(found, RegionActivity{Region: region, Candidates: n, Source: ScanSourceResourceExplorer})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Region < found[j].Region
+	})
+	return found, indexed, true
+}
+
+// probe counts candidate resources in a region with one cheap call per service
+func (s *RegionScanner) probe(ctx context.Context, region string) RegionActivity {
+	cfg := s.cfg.Copy()
+	cfg.Region = region
+	activity := RegionActivity{Region: region, Source: ScanSourceProbe}
+
+	probes := []struct {
+		action string
+		count  func() (int, error)
+	}{
+		{"ec2:DescribeInstances", func() (int, error) {
+			out, err := ec2.NewFromConfig(cfg).DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				Filters:    []types.Filter{{Name: aws.String("instance-state-name"), Values: []string{"running"}}},
+				MaxResults: aws.Int32(5),
+			})
+			if err != nil {
+				return 0, err
+			}
+			n := 0
+			for _, r := range out.Reservations {
+				n += len(r.Instances)
+			}
+			return n, nil
+		}},
+		{"ec2:DescribeNatGateways", func() (int, error) {
+			out, err := ec2.NewFromConfig(cfg).DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{
+				Filter:     []types.Filter{{Name: aws.String("state"), Values: []string{"available"}}},
+				MaxResults: aws.Int32(5),
+			})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.NatGateways), nil
+		}},
+		{"rds:DescribeDBInstances", func() (int, error) {
+			out, err := rds.NewFromConfig(cfg).DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{MaxRecords: aws.Int32(20)})
+			if err != nil {
+				return 0, err
+			}
+			n := 0
+			for _, db := range out.DBInstances {
+				if aws.ToString(db.DBInstanceStatus) == "available" {
+					n++
+				}
+			}
+			return n, nil
+		}},
+		{"ecs:ListClusters", func() (int, error) {
+			out, err := ecs.NewFromConfig(cfg).ListClusters(ctx, &ecs.ListClustersInput{MaxResults: aws.Int32(1)})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.ClusterArns), nil
+		}},
+		{"autoscaling:DescribeAutoScalingGroups", func() (int, error) {
+			out, err := autoscaling.NewFromConfig(cfg).DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{MaxRecords: aws.Int32(100)})
+			if err != nil {
+				return 0, err
+			}
+			n := 0
+			for _, g := range out.AutoScalingGroups {
+				if aws.ToInt32(g.DesiredCapacity) > 0 {
+					n++
+				}
+			}
+			return n, nil
+		}},
+		{"eks:ListClusters", func() (int, error) {
+			out, err := eks.NewFromConfig(cfg).ListClusters(ctx, &eks.ListClustersInput{MaxResults: aws.Int32(1)})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.Clusters), nil
+		}},
+		{"workspaces:DescribeWorkspaces", func() (int, error) {
+			out, err := workspaces.NewFromConfig(cfg).DescribeWorkspaces(ctx, &workspaces.DescribeWorkspacesInput{Limit: aws.Int32(1)})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.Workspaces), nil
+		}},
+		{"appstream:DescribeFleets", func() (int, error) {
+			out, err := appstream.NewFromConfig(cfg).DescribeFleets(ctx, &appstream.DescribeFleetsInput{})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.Fleets), nil
+		}},
+		{"es:ListDomainNames", func() (int, error) {
+			out, err := opensearch.NewFromConfig(cfg).ListDomainNames(ctx, &opensearch.ListDomainNamesInput{})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.DomainNames), nil
+		}},
+		{"dynamodb:ListTables", func() (int, error) {
+			out, err := dynamodb.NewFromConfig(cfg).ListTables(ctx, &dynamodb.ListTablesInput{Limit: aws.Int32(1)})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.TableNames), nil
+		}},
+		{"elasticmapreduce:ListClusters", func() (int, error) {
+			out, err := emr.NewFromConfig(cfg).ListClusters(ctx, &emr.ListClustersInput{
+				ClusterStates: []emrtypes.ClusterState{emrtypes.ClusterStateRunning, emrtypes.ClusterStateWaiting},
+			})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.Clusters), nil
+		}},
+		{"kinesis:ListStreams", func() (int, error) {
+			out, err := kinesis.NewFromConfig(cfg).ListStreams(ctx, &kinesis.ListStreamsInput{Limit: aws.Int32(1)})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.StreamNames), nil
+		}},
+		{"lightsail:GetInstances", func() (int, error) {
+			if !slices.Contains(lightsailRegions, region) {
+				return 0, nil
+			}
+			out, err := lightsail.NewFromConfig(cfg).GetInstances(ctx, &lightsail.GetInstancesInput{})
+			if err != nil {
+				return 0, err
+			}
+			return len(out.Instances), nil
+		}},
+	}
+
+	var failed []string
+	for _, p := range probes {
+		n, err := p.count()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", p.action, err))
+			continue
+		}
+		activity.Candidates += n
+	}
+	// Some services aren't offered in every region, and a role may lack one
+	// service's permissions; only a region where nothing answers is in doubt
+	if len(failed) == len(probes) {
+		activity.Err = fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return activity
+}
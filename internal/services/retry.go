@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/aws/smithy-go"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+)
+
+// RetryPolicy controls how failed pause and resume calls are retried
+type RetryPolicy struct {
+	MaxAttempts int           // attempts per resource, including the first
+	BaseDelay   time.Duration // delay cap before the first retry; doubles each attempt
+	MaxDelay    time.Duration // upper bound on any single delay
+}
+
+// DefaultRetryPolicy retries throttled and transient failures a few times
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// throttlingCodes are AWS error codes for calls rejected by rate limits
+var throttlingCodes = map[string]bool{
+	"Throttling":                true,
+	"ThrottlingException":       true,
+	"ThrottledException":        true,
+	"RequestThrottled":          true,
+	"RequestThrottledException": true,
+	"TooManyRequestsException":  true,
+	"RequestLimitExceeded":      true,
+}
+
+// retryableCodes are AWS error codes, besides throttling, worth retrying:
+// temporary capacity or service problems
+var retryableCodes = map[string]bool{
+	"InsufficientDBInstanceCapacity": true,
+	"InsufficientDBClusterCapacity":  true,
+	"InsufficientInstanceCapacity":   true,
+	"ServiceUnavailable":             true,
+	"InternalError":                  true,
+	"InternalFailure":                true,
+}
+
+// IsRetryable reports whether err is a throttling or transient AWS error
+func IsRetryable(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return throttlingCodes[apiErr.ErrorCode()] || retryableCodes[apiErr.ErrorCode()]
+	}
+	return false
+}
+
+// IsThrottled reports whether err is an AWS rate-limit error
+func IsThrottled(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return throttlingCodes[apiErr.ErrorCode()]
+	}
+	return false
+}
+
+// do runs fn until it succeeds, fails with a non-retryable error or runs out
+// of attempts, and returns how many times it was retried
+func (p RetryPolicy) do(ctx context.Context, fn func() error) (int, error) {
+	attempts := max(p.MaxAttempts, 1)
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return attempt - 1, err
+			case <-time.After(p.backoff(attempt)):
+			}
+		}
+
+		err = fn()
+		if err == nil || !IsRetryable(err) {
+			return attempt, err
+		}
+		logging.Debug("retryable error", "attempt", attempt+1, "of", attempts, "error", err)
+	}
+	return attempts - 1, err
+}
+
+// backoff returns a jittered delay before the given retry. Full jitter keeps
+// many resources throttled at once from retrying in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	limit := p.BaseDelay << (attempt - 1)
+	if limit <= 0 || (p.MaxDelay > 0 && limit > p.MaxDelay) {
+		limit = p.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit)) + 1)
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/workspaces"
+	"github.com/aws/aws-sdk-go-v2/service/workspaces/types"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// workspaceStateTimeout bounds waits for a WorkSpace to change state
+	workspaceStateTimeout = 10 * time.Minute
+	// workspacePollInterval is how often a WorkSpace's state is checked while waiting
+	workspacePollInterval = 15 * time.Second
+)
+
+// workspaceRates are approximate hourly AutoStop usage prices per compute type
+var workspaceRates = map[types.Compute]float64{
+	types.ComputeValue:       0.17,
+	types.ComputeStandard:    0.26,
+	types.ComputePerformance: 0.57,
+	types.ComputePower:       0.68,
+	types.ComputePowerpro:    1.42,
+	types.ComputeGraphics:    1.76,
+}
+
+// WorkSpacesServiceManager handles Amazon WorkSpaces operations. Only
+// AutoStop and manually stopped WorkSpaces are billed by the hour; AlwaysOn
+// WorkSpaces are billed monthly whether they run or not, so stopping one
+// saves nothing and they are left out.
+type WorkSpacesServiceManager struct {
+	client *workspaces.Client
+	sts    *sts.Client
+	region string
+
+	accountOnce sync.Once
+	accountID   string
+}
+
+// NewWorkSpacesServiceManager creates a new WorkSpaces service manager
+func NewWorkSpacesServiceManager(cfg aws.Config) *WorkSpacesServiceManager {
+	return &WorkSpacesServiceManager{
+		client: workspaces.NewFromConfig(cfg),
+		sts:    sts.NewFromConfig(cfg),
+		region: cfg.Region,
+	}
+}
+
+// ServiceType returns the service type
+func (m *WorkSpacesServiceManager) ServiceType() models.ServiceType {
+	return models.ServiceWorkSpaces
+}
+
+// Capabilities reports that WorkSpaces are stopped in place
+func (m *WorkSpacesServiceManager) Capabilities() Capabilities {
+	return Capabilities{Stop: true}
+}
+
+// Discover finds running WorkSpaces. A WorkSpace whose tags can't be read is
+// skipped, since protection rules depend on them.
+func (m *WorkSpacesServiceManager) Discover(ctx context.Context, region string) ([]models.Resource, error) {
+	var resources []models.Resource
+	var skipped []error
+
+	paginator := workspaces.NewDescribeWorkspacesPaginator(m.client, &workspaces.DescribeWorkspacesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe WorkSpaces: %w", err)
+		}
+
+		for _, ws := range output.Workspaces {
+			if ws.State != types.WorkspaceStateAvailable {
+				continue
+			}
+			if p := ws.WorkspaceProperties; p != nil && p.RunningMode == types.RunningModeAlwaysOn {
+				logging.Debug("skipping AlwaysOn WorkSpace, it is billed monthly", "workspace", aws.ToString(ws.WorkspaceId))
+				continue
+			}
+
+			tags, err := m.tags(ctx, aws.ToString(ws.WorkspaceId))
+			if err != nil {
+				logging.Warn("skipping WorkSpace", "workspace", aws.ToString(ws.WorkspaceId), "error", err)
+				skipped = append(skipped, err)
+				continue
+			}
+			resources = append(resources, m.workspaceToResource(ws, tags, m.account(ctx), region))
+		}
+	}
+
+	if len(skipped) > 0 {
+		return resources, &PartialError{Errs: skipped}
+	}
+	return resources, nil
+}
+
+// account returns the ID of the account the WorkSpaces belong to, for their
+// ARNs. DescribeWorkspaces doesn't return ARNs, and without the account they
+// are keyed by ID as before.
+func (m *WorkSpacesServiceManager) account(ctx context.Context) string {
+	m.accountOnce.Do(func() {
+		output, err := m.sts.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			logging.Debug("failed to identify account for WorkSpace ARNs", "error", err)
+			return
+		}
+		m.accountID = aws.ToString(output.Account)
+	})
+	return m.accountID
+}
+
+func (m *WorkSpacesServiceManager) tags(ctx context.Context, workspaceID string) (map[string]string, error) {
+	output, err := m.client.DescribeTags(ctx, &workspaces.DescribeTagsInput{
+		ResourceId: aws.String(workspaceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags of WorkSpace %s: %w", workspaceID, err)
+	}
+
+	tags := make(map[string]string)
+	for _, t := range output.TagList {
+		tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return tags, nil
+}
+
+// Pause stops a WorkSpace
+func (m *WorkSpacesServiceManager) Pause(ctx context.Context, resource models.Resource) error {
+	id := resource.ResourceID
+
+	output, err := m.client.StopWorkspaces(ctx, &workspaces.StopWorkspacesInput{
+		StopWorkspaceRequests: []types.StopRequest{{WorkspaceId: aws.String(id)}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stop WorkSpace %s: %w", id, err)
+	}
+	if len(output.FailedRequests) > 0 {
+		f := output.FailedRequests[0]
+		return fmt.Errorf("failed to stop WorkSpace %s: %s: %s", id, aws.ToString(f.ErrorCode), aws.ToString(f.ErrorMessage))
+	}
+
+	return nil
+}
+
+// Resume starts a WorkSpace. WorkSpaces paused by older versions may have
+// been switched from AlwaysOn to AutoStop; those are switched back.
+func (m *WorkSpacesServiceManager) Resume(ctx context.Context, resource models.Resource) error {
+	id := resource.ResourceID
+
+	output, err := m.client.StartWorkspaces(ctx, &workspaces.StartWorkspacesInput{
+		StartWorkspaceRequests: []types.StartRequest{{WorkspaceId: aws.String(id)}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start WorkSpace %s: %w", id, err)
+	}
+	if len(output.FailedRequests) > 0 {
+		f := output.FailedRequests[0]
+		return fmt.Errorf("failed to start WorkSpace %s: %s: %s", id, aws.ToString(f.ErrorCode), aws.ToString(f.ErrorMessage))
+	}
+
+	if resource.Metadata["original_running_mode"] == string(types.RunningModeAlwaysOn) {
+		if err := m.waitForState(ctx, id, types.WorkspaceStateAvailable); err != nil {
+			return err
+		}
+		if err := m.setRunningMode(ctx, id, types.RunningModeAlwaysOn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *WorkSpacesServiceManager) setRunningMode(ctx context.Context, id string, mode types.RunningMode) error {
+	_, err := m.client.ModifyWorkspaceProperties(ctx, &workspaces.ModifyWorkspacePropertiesInput{
+		WorkspaceId:         aws.String(id),
+		WorkspaceProperties: &types.WorkspaceProperties{RunningMode: mode},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to switch WorkSpace %s to %s: %w", id, mode, err)
+	}
+	return nil
+}
+
+// waitForState polls a WorkSpace until it reaches the wanted state
+func (m *WorkSpacesServiceManager) waitForState(ctx context.Context, id string, want types.WorkspaceState) error {
+	ctx, cancel := context.WithTimeout(ctx, workspaceStateTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(workspacePollInterval)
+	defer ticker.Stop()
+
+	for {
+		output, err := m.client.DescribeWorkspaces(ctx, &workspaces.DescribeWorkspacesInput{
+			WorkspaceIds: []string{id},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe WorkSpace %s: %w", id, err)
+		}
+		if len(output.Workspaces) == 0 {
+			return fmt.Errorf("WorkSpace %s not found", id)
+		}
+		if output.Workspaces[0].State == want {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("WorkSpace %s did not become %s: %w", id, want, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *WorkSpacesServiceManager) workspaceToResource(ws types.Workspace, tags map[string]string, accountID, region string) models.Resource {
+	var mode types.RunningMode
+	var compute types.Compute
+	if p := ws.WorkspaceProperties; p != nil {
+		mode = p.RunningMode
+		compute = p.ComputeTypeName
+	}
+
+	rate, ok := workspaceRates[compute]
+	if !ok {
+		rate = workspaceRates[types.ComputeStandard]
+	}
+
+	var arn string
+	if accountID != "" {
+		arn = resourceARN("workspaces", region, accountID, "workspace/"+aws.ToString(ws.WorkspaceId))
+	}
+
+	return models.Resource{
+		ServiceType:  models.ServiceWorkSpaces,
+		ResourceID:   aws.ToString(ws.WorkspaceId),
+		ARN:          arn,
+		Region:       region,
+		CurrentState: models.StateRunning,
+		Tags:         tags,
+		Metadata: map[string]any{
+			"user_name":             aws.ToString(ws.UserName),
+			"directory_id":          aws.ToString(ws.DirectoryId),
+			"bundle_id":             aws.ToString(ws.BundleId),
+			"compute_type":          string(compute),
+			"original_running_mode": string(mode),
+		},
+		CostPerHour: rate,
+	}
+}
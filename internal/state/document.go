@@ -0,0 +1,114 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrConflict is returned by DocumentStore.Write when the document changed
+// since it was read
+var ErrConflict = errors.New("document changed since it was read")
+
+// lockTimeout is how long LocalStore waits for another process's lock, and
+// how old a lock must be before it is taken to be left by a crashed process
+const lockTimeout = 10 * time.Second
+
+// DocumentStore keeps small JSON documents next to the snapshots, such as
+// the armed brakes, so that every machine sharing the snapshots sees them.
+// Writes are conditional on the version read, so concurrent updates don't
+// overwrite each other.
+type DocumentStore interface {
+	// Read returns a document and its version, or nil data and an empty
+	// version if it doesn't exist
+	Read(ctx context.Context, name string) ([]byte, string, error)
+	// Write replaces a document if it is still at version, where an empty
+	// version means it must not exist yet, and returns ErrConflict if not
+	Write(ctx context.Context, name string, data []byte, version string) error
+}
+
+// Read reads a document from the config directory. Its version is a hash of
+// its content.
+func (s *LocalStore) Read(_ context.Context, name string) ([]byte, string, error) {
+	data, err := os.ReadFile(filepath.Join(s.configDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, contentVersion(data), nil
+}
+
+// Write replaces a document in the config directory atomically, holding a
+// lock file while it checks the version
+func (s *LocalStore) Write(ctx context.Context, name string, data []byte, version string) error {
+	path := filepath.Join(s.configDir, name)
+	unlock, err := lockFile(ctx, path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		if version != "" {
+			return ErrConflict
+		}
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	case contentVersion(current) != version:
+		return ErrConflict
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save %s: %w", name, err)
+	}
+	return nil
+}
+
+// lockFile creates path exclusively, waiting while another process holds it,
+// and returns a function that removes it. A lock older than lockTimeout is
+// taken over.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", filepath.Base(path), err)
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockTimeout {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func contentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,224 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const (
+	// DefaultS3Prefix is the key prefix snapshots are stored under when none is configured
+	DefaultS3Prefix = "awsbreak/snapshots"
+
+	// s3Timeout bounds each S3 request
+	s3Timeout = 30 * time.Second
+)
+
+// S3Store keeps snapshots as JSON objects in an S3 bucket, so they survive
+// the loss of a laptop and are shared by a team and the Lambda runner.
+// Objects are encrypted at rest with SSE-S3, or SSE-KMS when a key is given.
+type S3Store struct {
+	client   *s3.Client
+	bucket   string
+	prefix   string
+	kmsKeyID string
+}
+
+// NewS3Store creates a store under prefix in bucket. An empty prefix uses
+// DefaultS3Prefix; an empty kmsKeyID uses SSE-S3.
+func NewS3Store(cfg aws.Config, bucket, prefix, kmsKeyID string) *S3Store {
+	if prefix == "" {
+		prefix = DefaultS3Prefix
+	}
+	return &S3Store{
+		client:   s3.NewFromConfig(cfg),
+		bucket:   bucket,
+		prefix:   strings.Trim(prefix, "/"),
+		kmsKeyID: kmsKeyID,
+	}
+}
+
+// Save writes a snapshot to the bucket
+func (s *S3Store) Save(ctx context.Context, snapshot *models.AccountSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(s.key(snapshot.SnapshotID)),
+		Body:                 bytes.NewReader(data),
+		ContentType:          aws.String("application/json"),
+		ServerSideEncryption: types.ServerSideEncryptionAes256,
+	}
+	if s.kmsKeyID != "" {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s3Timeout)
+	defer cancel()
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to write snapshot to %s: %w", s.Location(), err)
+	}
+	return nil
+}
+
+// Load reads a snapshot from the bucket
+func (s *S3Store) Load(ctx context.Context, snapshotID string) (*models.AccountSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3Timeout)
+	defer cancel()
+
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(snapshotID)),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, fmt.Errorf("snapshot not found: %s", snapshotID)
+		}
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", snapshotID, err)
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", snapshotID, err)
+	}
+
+	var snapshot models.AccountSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", snapshotID, err)
+	}
+	return &snapshot, nil
+}
+
+// List returns the IDs of the snapshots in the bucket from a listing of their
+// keys, without fetching them
+func (s *S3Store) List(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3Timeout)
+	defer cancel()
+
+	var ids []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + "/"),
+		// Documents live in a folder under the prefix; list only snapshots
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list snapshots in %s: %w", s.Location(), err)
+		}
+		for _, obj := range page.Contents {
+			name := path.Base(aws.ToString(obj.Key))
+			if strings.HasSuffix(name, ".json") {
+				ids = append(ids, strings.TrimSuffix(name, ".json"))
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Delete removes a snapshot from the bucket
+func (s *S3Store) Delete(ctx context.Context, snapshotID string) error {
+	ctx, cancel := context.WithTimeout(ctx, s3Timeout)
+	defer cancel()
+
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(snapshotID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot %s: %w", snapshotID, err)
+	}
+	return nil
+}
+
+// Location returns the bucket and prefix as an s3:// URL
+func (s *S3Store) Location() string {
+	return fmt.Sprintf("s3://%s/%s/", s.bucket, s.prefix)
+}
+
+// Read fetches a document from the bucket. Its version is the object's ETag.
+func (s *S3Store) Read(ctx context.Context, name string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3Timeout)
+	defer cancel()
+
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.documentKey(name)),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to read %s from %s: %w", name, s.Location(), err)
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s from %s: %w", name, s.Location(), err)
+	}
+	return data, aws.ToString(output.ETag), nil
+}
+
+// Write puts a document in the bucket with an S3 conditional write, so a
+// concurrent update fails with ErrConflict rather than being overwritten
+func (s *S3Store) Write(ctx context.Context, name string, data []byte, version string) error {
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(s.documentKey(name)),
+		Body:                 bytes.NewReader(data),
+		ContentType:          aws.String("application/json"),
+		ServerSideEncryption: types.ServerSideEncryptionAes256,
+	}
+	if s.kmsKeyID != "" {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+	}
+	if version == "" {
+		input.IfNoneMatch = aws.String("*")
+	} else {
+		input.IfMatch = aws.String(version)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s3Timeout)
+	defer cancel()
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "PreconditionFailed" || apiErr.ErrorCode() == "ConditionalRequestConflict") {
+			return ErrConflict
+		}
+		return fmt.Errorf("failed to write %s to %s: %w", name, s.Location(), err)
+	}
+	return nil
+}
+
+// documentKey is the key of a document, in a folder under the prefix that
+// List skips
+func (s *S3Store) documentKey(name string) string {
+	return s.prefix + "/state/" + name
+}
+
+func (s *S3Store) key(snapshotID string) string {
+	return s.prefix + "/" + snapshotID + ".json"
+}
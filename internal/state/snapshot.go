@@ -0,0 +1,251 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+const snapshotDirName = "snapshots"
+
+// SnapshotManager persists account snapshots so resources can be restored accurately
+type SnapshotManager struct {
+	store SnapshotStore
+}
+
+// NewSnapshotManager creates a snapshot manager storing snapshots under the config directory
+func NewSnapshotManager(configDir string) *SnapshotManager {
+	return NewSnapshotManagerWithStore(NewLocalStore(configDir))
+}
+
+// NewSnapshotManagerWithStore creates a snapshot manager backed by store, such
+// as a shared S3 bucket
+func NewSnapshotManagerWithStore(store SnapshotStore) *SnapshotManager {
+	return &SnapshotManager{store: store}
+}
+
+// NewSnapshot builds a snapshot from the successful results of a pause operation
+func NewSnapshot(region string, results []models.OperationResult) *models.AccountSnapshot {
+	now := time.Now()
+	snapshot := &models.AccountSnapshot{
+		SnapshotID:       "snap-" + now.UTC().Format("20060102-150405"),
+		Timestamp:        now,
+		Region:           region,
+		OriginalStates:   make(map[string]any),
+		OperationResults: results,
+	}
+
+	for _, r := range results {
+		if !r.Success {
+			continue
+		}
+		// Organization runs snapshot each member account separately
+		if r.Resource.AccountID != "" && snapshot.AccountID == "" {
+			snapshot.AccountID = r.Resource.AccountID
+			snapshot.SnapshotID = "snap-" + r.Resource.AccountID + "-" + now.UTC().Format("20060102-150405")
+		}
+		snapshot.Resources = append(snapshot.Resources, r.Resource)
+		snapshot.OriginalStates[r.Resource.Key()] = r.Resource.Metadata
+		snapshot.TotalEstimatedSavings += r.Resource.CostPerHour * 24 * 30
+	}
+
+	return snapshot
+}
+
+// NewPlannedSnapshot builds a snapshot of resources about to be paused. Saved
+// before the pause starts, it lets a run that is cut short still be resumed;
+// Complete then narrows it to what the pause stopped.
+func NewPlannedSnapshot(region string, resources []models.Resource) *models.AccountSnapshot {
+	planned := make([]models.OperationResult, len(resources))
+	for i, r := range resources {
+		planned[i] = models.OperationResult{Success: true, Resource: r, Operation: "pause"}
+	}
+	snapshot := NewSnapshot(region, planned)
+	snapshot.OperationResults = nil
+	return snapshot
+}
+
+// Complete replaces a planned snapshot's resources with those results
+// stopped and saves it, or deletes it if nothing was stopped
+func (m *SnapshotManager) Complete(ctx context.Context, snapshot *models.AccountSnapshot, results []models.OperationResult) error {
+	done := NewSnapshot(snapshot.Region, results)
+	if len(done.Resources) == 0 {
+		return m.store.Delete(ctx, snapshot.SnapshotID)
+	}
+	done.SnapshotID = snapshot.SnapshotID
+	done.Timestamp = snapshot.Timestamp
+	*snapshot = *done
+	return m.Save(ctx, snapshot)
+}
+
+// Save writes a snapshot to the store
+func (m *SnapshotManager) Save(ctx context.Context, snapshot *models.AccountSnapshot) error {
+	return m.store.Save(ctx, snapshot)
+}
+
+// Load reads a snapshot by ID
+func (m *SnapshotManager) Load(ctx context.Context, snapshotID string) (*models.AccountSnapshot, error) {
+	return m.store.Load(ctx, snapshotID)
+}
+
+// List returns all snapshots, newest first
+func (m *SnapshotManager) List(ctx context.Context) ([]*models.AccountSnapshot, error) {
+	ids, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*models.AccountSnapshot, 0, len(ids))
+	for _, id := range ids {
+		snapshot, err := m.store.Load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.After(snapshots[j].Timestamp)
+	})
+
+	return snapshots, nil
+}
+
+// Location describes where snapshots are kept
+func (m *SnapshotManager) Location() string {
+	return m.store.Location()
+}
+
+// Latest returns the most recent snapshot for a region that still has resources
+// waiting to be resumed, or nil if there is none
+func (m *SnapshotManager) Latest(ctx context.Context, region string) (*models.AccountSnapshot, error) {
+	return m.LatestForAccount(ctx, "", region)
+}
+
+// LatestForAccount is Latest for a member account of an organization run. An
+// empty account ID selects snapshots of the configured account.
+//
+// Snapshots are read newest first, by the time in their IDs, and only until
+// one matches, so a store full of old snapshots costs a listing and a fetch.
+func (m *SnapshotManager) LatestForAccount(ctx context.Context, accountID, region string) (*models.AccountSnapshot, error) {
+	ids, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return idTime(ids[i]) > idTime(ids[j])
+	})
+
+	for _, id := range ids {
+		s, err := m.store.Load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if s.AccountID == accountID && s.Region == region && len(s.PendingResources()) > 0 {
+			return s, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// idTime returns the UTC timestamp a snapshot ID ends with, as sortable text
+func idTime(id string) string {
+	const layout = "20060102-150405"
+	if len(id) < len(layout) {
+		return ""
+	}
+	return id[len(id)-len(layout):]
+}
+
+// PendingResources returns every resource in the region of the configured
+// account that awsbreak paused and has not resumed yet, across all snapshots
+func (m *SnapshotManager) PendingResources(ctx context.Context, region string) ([]models.Resource, error) {
+	snapshots, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []models.Resource
+	for _, s := range snapshots {
+		if s.AccountID == "" && s.Region == region {
+			pending = append(pending, s.PendingResources()...)
+		}
+	}
+	return pending, nil
+}
+
+// AddResults appends resources paused by a retry to the snapshot of the
+// original run and saves it. A resource the snapshot already holds, such as
+// one resumed for a while by 'awsbreak open' and paused again, is updated in
+// place and becomes pending again.
+func (m *SnapshotManager) AddResults(ctx context.Context, snapshot *models.AccountSnapshot, results []models.OperationResult) error {
+	if snapshot.OriginalStates == nil {
+		snapshot.OriginalStates = make(map[string]any)
+	}
+
+	for _, r := range results {
+		if !r.Success {
+			continue
+		}
+		i := slices.IndexFunc(snapshot.Resources, r.Resource.SameAs)
+		if i >= 0 {
+			delete(snapshot.Resumed, snapshot.Resources[i].Key())
+			delete(snapshot.Resumed, snapshot.Resources[i].LegacyKey())
+			snapshot.Resources[i] = r.Resource
+		} else {
+			snapshot.Resources = append(snapshot.Resources, r.Resource)
+			snapshot.TotalEstimatedSavings += r.Resource.CostPerHour * 24 * 30
+		}
+		delete(snapshot.Resumed, r.Resource.Key())
+		snapshot.OriginalStates[r.Resource.Key()] = r.Resource.Metadata
+		snapshot.OperationResults = append(snapshot.OperationResults, r)
+	}
+
+	return m.Save(ctx, snapshot)
+}
+
+// Prune deletes snapshots taken before cutoff whose resources have all been
+// resumed, and returns their IDs. Snapshots with resources still paused are
+// kept, since they are the only record of how to restore them.
+func (m *SnapshotManager) Prune(ctx context.Context, cutoff time.Time) ([]string, error) {
+	snapshots, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for _, s := range snapshots {
+		if !s.Timestamp.Before(cutoff) || len(s.PendingResources()) > 0 {
+			continue
+		}
+		if err := m.store.Delete(ctx, s.SnapshotID); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, s.SnapshotID)
+	}
+	return pruned, nil
+}
+
+// MarkResumed records the successfully resumed resources in the snapshot and saves it
+func (m *SnapshotManager) MarkResumed(ctx context.Context, snapshot *models.AccountSnapshot, results []models.OperationResult) error {
+	if snapshot.Resumed == nil {
+		snapshot.Resumed = make(map[string]time.Time)
+	}
+
+	for _, r := range results {
+		if r.Success {
+			snapshot.Resumed[r.Resource.Key()] = r.Timestamp
+			// Snapshots written by older versions hold resources without ARNs
+			if r.Resource.ARN != "" {
+				snapshot.Resumed[r.Resource.LegacyKey()] = r.Timestamp
+			}
+		}
+	}
+
+	return m.Save(ctx, snapshot)
+}
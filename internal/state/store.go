@@ -0,0 +1,128 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+)
+
+// SnapshotStore is where snapshots are persisted. Implementations only store
+// and fetch snapshots by ID; SnapshotManager builds lookups on top.
+type SnapshotStore interface {
+	// Save writes a snapshot, replacing any with the same ID
+	Save(ctx context.Context, snapshot *models.AccountSnapshot) error
+	// Load reads a snapshot by ID
+	Load(ctx context.Context, snapshotID string) (*models.AccountSnapshot, error)
+	// List returns the IDs of every stored snapshot in no particular order,
+	// without reading the snapshots
+	List(ctx context.Context) ([]string, error)
+	// Delete removes a snapshot
+	Delete(ctx context.Context, snapshotID string) error
+	// Location describes where snapshots are kept, for display
+	Location() string
+}
+
+// LocalStore keeps snapshots as JSON files in a directory, and documents in
+// the config directory above it
+type LocalStore struct {
+	dir       string
+	configDir string
+}
+
+// NewLocalStore creates a store in the snapshots directory under configDir
+func NewLocalStore(configDir string) *LocalStore {
+	return &LocalStore{
+		dir:       filepath.Join(configDir, snapshotDirName),
+		configDir: configDir,
+	}
+}
+
+// Save writes a snapshot to disk
+func (s *LocalStore) Save(_ context.Context, snapshot *models.AccountSnapshot) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	// Write atomically by writing to temp file first
+	path := s.path(snapshot.SnapshotID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads a snapshot by ID
+func (s *LocalStore) Load(_ context.Context, snapshotID string) (*models.AccountSnapshot, error) {
+	data, err := os.ReadFile(s.path(snapshotID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("snapshot not found: %s", snapshotID)
+		}
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot models.AccountSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", snapshotID, err)
+	}
+
+	return &snapshot, nil
+}
+
+// List returns the IDs of the snapshots on disk
+func (s *LocalStore) List(context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	var ids []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(e.Name(), ".json"))
+	}
+
+	return ids, nil
+}
+
+// Delete removes a snapshot file
+func (s *LocalStore) Delete(_ context.Context, snapshotID string) error {
+	if err := os.Remove(s.path(snapshotID)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("snapshot not found: %s", snapshotID)
+		}
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// Location returns the snapshot directory
+func (s *LocalStore) Location() string {
+	return s.dir
+}
+
+func (s *LocalStore) path(snapshotID string) string {
+	return filepath.Join(s.dir, snapshotID+".json")
+}
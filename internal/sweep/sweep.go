@@ -0,0 +1,199 @@
+// Package sweep runs and records the verification sweep after a pause: a
+// check across services and regions that nothing billable by the hour is
+// still running, with a checksum so the saved record can be matched to what
+// was printed.
+package sweep
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+)
+
+const (
+	sweepsFileName = "sweeps.jsonl"
+	// regionConcurrency is how many regions are rediscovered at once
+	regionConcurrency = 4
+)
+
+// Discoverer rediscovers every enabled service in one region
+type Discoverer func(ctx context.Context, region string) ([]models.Resource, *models.DiscoveryReport, error)
+
+// Region is what a sweep found in one region
+type Region struct {
+	Region     string            `json:"region"`
+	Services   []string          `json:"services"`              // fully checked
+	Incomplete []string          `json:"incomplete,omitempty"`  // couldn't be fully checked
+	Running    []models.Resource `json:"running,omitempty"`     // still billing; awsbreak could stop them
+	ReportOnly []models.Resource `json:"report_only,omitempty"` // still billing; awsbreak can't stop them
+	Protected  int               `json:"protected,omitempty"`   // excluded by protection rules
+	Error      string            `json:"error,omitempty"`       // the region couldn't be discovered
+}
+
+// NewRegion builds a region's result from its discovery report and the
+// running resources found, already split into stoppable and report-only,
+// with the number of protected resources left out
+func NewRegion(region string, discovery *models.DiscoveryReport, running, reportOnly []models.Resource, protected int) Region {
+	r := Region{Region: region, Running: running, ReportOnly: reportOnly, Protected: protected}
+	if discovery == nil {
+		return r
+	}
+	for _, s := range discovery.Services {
+		if s.Error != "" || len(s.Warnings) > 0 {
+			r.Incomplete = append(r.Incomplete, string(s.ServiceType))
+		} else {
+			r.Services = append(r.Services, string(s.ServiceType))
+		}
+	}
+	return r
+}
+
+// Clear reports whether the region was fully checked and nothing awsbreak
+// can stop is running
+func (r Region) Clear() bool {
+	return r.Error == "" && len(r.Incomplete) == 0 && len(r.Running) == 0
+}
+
+// Report is one sweep across regions
+type Report struct {
+	Time    time.Time `json:"time"`
+	Account string    `json:"account,omitempty"` // identity the sweep ran as
+	Regions []Region  `json:"regions"`
+	// RegionsError is set when the account's enabled regions couldn't be
+	// listed, so only the paused region was swept
+	RegionsError string `json:"regions_error,omitempty"`
+	Checksum     string `json:"checksum,omitempty"`
+}
+
+// Regions returns the regions to sweep after a pause in paused: paused and
+// the regions in only when it isn't empty, otherwise every region enabled for
+// the account, paused first. If the enabled regions can't be listed, just
+// paused is returned with the error.
+func Regions(ctx context.Context, awsCfg aws.Config, paused string, only []string) ([]string, error) {
+	var err error
+	if len(only) == 0 {
+		only, err = services.EnabledRegions(ctx, awsCfg)
+	}
+
+	regions := []string{paused}
+	seen := map[string]bool{paused: true}
+	for _, r := range only {
+		if r != "" && !seen[r] {
+			seen[r] = true
+			regions = append(regions, r)
+		}
+	}
+	return regions, err
+}
+
+// Run rediscovers each region and reports what is still running. guard
+// leaves protected resources out of the all clear. The report's checksum is
+// not set, so callers can add to it first.
+func Run(ctx context.Context, regions []string, discover Discoverer, guard *services.Guard) *Report {
+	report := &Report{Time: time.Now().UTC(), Regions: make([]Region, len(regions))}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, regionConcurrency)
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			resources, discovery, err := discover(ctx, region)
+			if err != nil {
+				report.Regions[i] = Region{Region: region, Error: err.Error()}
+				return
+			}
+			var running, reportOnly []models.Resource
+			for _, r := range resources {
+				if services.IsReportOnly(r) {
+					reportOnly = append(reportOnly, r)
+				} else {
+					running = append(running, r)
+				}
+			}
+			running, protected := guard.Split(running)
+			report.Regions[i] = NewRegion(region, discovery, running, reportOnly, len(protected))
+		}(i, region)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// AllClear reports whether every region is clear and every enabled region
+// was swept
+func (r *Report) AllClear() bool {
+	if len(r.Regions) == 0 || r.RegionsError != "" {
+		return false
+	}
+	for _, region := range r.Regions {
+		if !region.Clear() {
+			return false
+		}
+	}
+	return true
+}
+
+// MonthlyCost returns the estimated monthly cost of what is still running,
+// including resources awsbreak can't stop
+func (r *Report) MonthlyCost() float64 {
+	var total float64
+	for _, region := range r.Regions {
+		for _, res := range region.Running {
+			total += res.CostPerHour * 24 * 30
+		}
+		for _, res := range region.ReportOnly {
+			total += res.CostPerHour * 24 * 30
+		}
+	}
+	return total
+}
+
+// Seal sets Checksum to a SHA-256 digest of the rest of the report, so the
+// saved record can be matched to the checksum that was printed. It is not a
+// signature: it catches a record that was copied wrong or edited by
+// accident, not one rewritten on purpose along with its checksum.
+func (r *Report) Seal() error {
+	unsealed := *r
+	unsealed.Checksum = ""
+	data, err := json.Marshal(unsealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sweep report: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	r.Checksum = "sha256:" + hex.EncodeToString(sum[:])
+	return nil
+}
+
+// Save appends the report to the sweep history in the config directory
+func Save(configDir string, r *Report) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sweep report: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(configDir, sweepsFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open sweep history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write sweep report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package textwidth measures and fits text by the columns it takes in a
+// terminal, where emoji and East Asian characters are two columns wide and
+// combining marks take none.
+package textwidth
+
+import (
+	"strings"
+	"unicode"
+)
+
+// emoji lists the ranges of emoji terminals draw two columns wide
+var emoji = [][2]rune{
+	{0x231A, 0x231B},   // watch, hourglass
+	{0x23E9, 0x23EC},   // media controls
+	{0x23F0, 0x23F0},   // alarm clock
+	{0x23F3, 0x23F3},   // hourglass with sand
+	{0x25FD, 0x25FE},   // small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2648, 0x2653},   // zodiac
+	{0x267F, 0x267F},   // wheelchair
+	{0x2693, 0x2693},   // anchor
+	{0x26A1, 0x26A1},   // high voltage
+	{0x26AA, 0x26AB},   // circles
+	{0x26BD, 0x26BE},   // balls
+	{0x26C4, 0x26C5},   // snowman, sun behind cloud
+	{0x26CE, 0x26CE},   // Ophiuchus
+	{0x26D4, 0x26D4},   // no entry
+	{0x26EA, 0x26EA},   // church
+	{0x26F2, 0x26F3},   // fountain, golf
+	{0x26F5, 0x26F5},   // sailboat
+	{0x26FA, 0x26FA},   // tent
+	{0x26FD, 0x26FD},   // fuel pump
+	{0x2705, 0x2705},   // check mark button
+	{0x270A, 0x270B},   // raised fists
+	{0x2728, 0x2728},   // sparkles
+	{0x274C, 0x274C},   // cross mark
+	{0x274E, 0x274E},   // cross mark button
+	{0x2753, 0x2755},   // question and exclamation marks
+	{0x2757, 0x2757},   // exclamation mark
+	{0x2795, 0x2797},   // plus, minus, divide
+	{0x27B0, 0x27B0},   // curly loop
+	{0x27BF, 0x27BF},   // double curly loop
+	{0x2B1B, 0x2B1C},   // large squares
+	{0x2B50, 0x2B50},   // star
+	{0x2B55, 0x2B55},   // hollow circle
+	{0x1F004, 0x1F004}, // mahjong tile
+	{0x1F0CF, 0x1F0CF}, // joker
+	{0x1F18E, 0x1F18E}, // AB button
+	{0x1F191, 0x1F19A}, // squared words
+	{0x1F200, 0x1F251}, // enclosed ideographs
+	{0x1F300, 0x1F64F}, // pictographs and emoticons
+	{0x1F680, 0x1F6FF}, // transport and map
+	{0x1F7E0, 0x1F7EB}, // colored circles and squares
+	{0x1F90C, 0x1F9FF}, // supplemental pictographs
+	{0x1FA70, 0x1FAFF}, // pictographs extended
+}
+
+// textEmoji lists symbols drawn one column wide on their own that are emoji
+// when followed by U+FE0F, such as the warning sign
+var textEmoji = [][2]rune{
+	{0x2139, 0x2139}, // information
+	{0x2194, 0x2199}, // arrows
+	{0x21A9, 0x21AA}, // hooked arrows
+	{0x23CF, 0x23CF}, // eject
+	{0x23ED, 0x23EF}, // track controls
+	{0x23F1, 0x23F2}, // stopwatch, timer clock
+	{0x23F8, 0x23FA}, // pause, stop, record
+	{0x2600, 0x2604}, // weather
+	{0x260E, 0x260E}, // telephone
+	{0x2611, 0x2611}, // ballot box with check
+	{0x2618, 0x2618}, // shamrock
+	{0x261D, 0x261D}, // index pointing up
+	{0x2620, 0x2620}, // skull and crossbones
+	{0x2622, 0x2623}, // radioactive, biohazard
+	{0x2626, 0x2626}, // orthodox cross
+	{0x262A, 0x262A}, // star and crescent
+	{0x262E, 0x262F}, // peace, yin yang
+	{0x2638, 0x263A}, // wheel of dharma, faces
+	{0x2640, 0x2640}, // female sign
+	{0x2642, 0x2642}, // male sign
+	{0x265F, 0x2660}, // chess pawn, spade
+	{0x2663, 0x2663}, // club
+	{0x2665, 0x2666}, // heart, diamond
+	{0x2668, 0x2668}, // hot springs
+	{0x267B, 0x267B}, // recycling
+	{0x267E, 0x267E}, // infinity
+	{0x2692, 0x2692}, // hammer and pick
+	{0x2694, 0x2697}, // crossed swords to alembic
+	{0x2699, 0x2699}, // gear
+	{0x269B, 0x269C}, // atom, fleur-de-lis
+	{0x26A0, 0x26A0}, // warning
+	{0x26A7, 0x26A7}, // transgender symbol
+	{0x26B0, 0x26B1}, // coffin, urn
+	{0x26C8, 0x26C8}, // cloud with lightning and rain
+	{0x26CF, 0x26CF}, // pick
+	{0x26D1, 0x26D1}, // rescue worker's helmet
+	{0x26D3, 0x26D3}, // chains
+	{0x26E9, 0x26E9}, // shinto shrine
+	{0x26F0, 0x26F1}, // mountain, umbrella on ground
+	{0x26F4, 0x26F4}, // ferry
+	{0x26F7, 0x26F9}, // skier to person bouncing ball
+	{0x2702, 0x2702}, // scissors
+	{0x2708, 0x2709}, // airplane, envelope
+	{0x270C, 0x270D}, // victory hand, writing hand
+	{0x270F, 0x270F}, // pencil
+	{0x2712, 0x2712}, // black nib
+	{0x2714, 0x2714}, // check mark
+	{0x2716, 0x2716}, // multiply
+	{0x271D, 0x271D}, // latin cross
+	{0x2721, 0x2721}, // star of David
+	{0x2733, 0x2734}, // eight-spoked asterisk, eight-pointed star
+	{0x2744, 0x2744}, // snowflake
+	{0x2747, 0x2747}, // sparkle
+	{0x2763, 0x2764}, // heart exclamation, red heart
+	{0x27A1, 0x27A1}, // right arrow
+	{0x2934, 0x2935}, // curved arrows
+	{0x2B05, 0x2B07}, // left, up and down arrows
+}
+
+// eastAsian lists the ranges of East Asian characters terminals draw two
+// columns wide
+var eastAsian = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK radicals and punctuation
+	{0x3041, 0x33FF},   // kana and CJK compatibility
+	{0x3400, 0x4DBF},   // CJK extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x20000, 0x3FFFD}, // CJK extensions
+}
+
+// RuneWidth returns the columns r takes
+func RuneWidth(r rune) int {
+	if r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	if inRanges(r, emoji) || inRanges(r, eastAsian) {
+		return 2
+	}
+	return 1
+}
+
+// IsEmoji reports whether r is an emoji, drawn wide or, like the warning
+// sign, one column wide unless followed by U+FE0F
+func IsEmoji(r rune) bool {
+	return inRanges(r, emoji) || inRanges(r, textEmoji)
+}
+
+// inRanges reports whether r falls in one of ranges, which are sorted
+func inRanges(r rune, ranges [][2]rune) bool {
+	for _, rg := range ranges {
+		if r < rg[0] {
+			return false
+		}
+		if r <= rg[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Width returns the columns s takes
+func Width(s string) int {
+	n := 0
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// Pad left-aligns s in n columns
+func Pad(s string, n int) string {
+	return s + strings.Repeat(" ", max(n-Width(s), 0))
+}
+
+// PadLeft right-aligns s in n columns
+func PadLeft(s string, n int) string {
+	return strings.Repeat(" ", max(n-Width(s), 0)) + s
+}
+
+// Truncate shortens s to at most n columns, replacing its middle with an
+// ellipsis so both ends survive: ARNs and generated names differ at the tail
+func Truncate(s string, n int) string {
+	if Width(s) <= n {
+		return s
+	}
+	if n < 3 {
+		head, _ := split(s, n)
+		return head
+	}
+
+	head, _ := split(s, (n-1)/2)
+	tailWidth := n - 1 - Width(head)
+	runes := []rune(s)
+	start := len(runes)
+	for w := 0; start > 0; start-- {
+		rw := RuneWidth(runes[start-1])
+		if w+rw > tailWidth {
+			break
+		}
+		w += rw
+	}
+	return head + "…" + string(runes[start:])
+}
+
+// Wrap breaks s into lines of at most n columns
+func Wrap(s string, n int) []string {
+	if n <= 0 {
+		return []string{s}
+	}
+	var lines []string
+	for Width(s) > n {
+		head, rest := split(s, n)
+		if head == "" {
+			// A character wider than the column goes on a line of its own
+			r := []rune(rest)
+			head, rest = string(r[:1]), string(r[1:])
+		}
+		lines = append(lines, head)
+		s = rest
+	}
+	return append(lines, s)
+}
+
+// split cuts s after at most n columns
+func split(s string, n int) (string, string) {
+	w := 0
+	for i, r := range s {
+		rw := RuneWidth(r)
+		if w+rw > n {
+			return s[:i], s[i:]
+		}
+		w += rw
+	}
+	return s, ""
+}
@@ -0,0 +1,311 @@
+// Package awsbreak lets Go programs discover, pause and resume AWS resources
+// the same way the awsbreak CLI does, without shelling out to it.
+//
+// The types here are the stable API. They are aliases of the types the CLI
+// uses internally, so snapshots written by a Client can be resumed with
+// `awsbreak --go` and vice versa.
+//
+//	client, err := awsbreak.New(awsCfg, awsbreak.Options{})
+//	resources, err := client.Discover(ctx, "us-east-1")
+//	results, err := client.Pause(ctx, awsbreak.Actionable(resources))
+//	snapshot, err := client.SaveSnapshot(ctx, "us-east-1", results)
+//	...
+//	results, err = client.ResumeSnapshot(ctx, snapshot)
+package awsbreak
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aicoder2009/aws-hit-breaks/internal/config"
+	"github.com/aicoder2009/aws-hit-breaks/internal/journal"
+	"github.com/aicoder2009/aws-hit-breaks/internal/logging"
+	"github.com/aicoder2009/aws-hit-breaks/internal/models"
+	"github.com/aicoder2009/aws-hit-breaks/internal/services"
+	"github.com/aicoder2009/aws-hit-breaks/internal/state"
+)
+
+// Resource is an AWS resource that can be paused and resumed
+type Resource = models.Resource
+
+// ResourceState is the state of a resource when it was discovered
+type ResourceState = models.ResourceState
+
+// ServiceType identifies the AWS service a resource belongs to
+type ServiceType = models.ServiceType
+
+// OperationResult is the outcome of pausing or resuming one resource
+type OperationResult = models.OperationResult
+
+// Snapshot records what a pause stopped so it can be restored exactly
+type Snapshot = models.AccountSnapshot
+
+// ServiceManager discovers, pauses and resumes the resources of one service.
+// Implement it and pass it in Options.Managers to brake a service awsbreak
+// doesn't manage itself.
+type ServiceManager = services.ServiceManager
+
+// Settler is implemented by ServiceManagers whose resources take a while to
+// reach their new state. Resources that others depend on are settled before
+// the next phase starts.
+type Settler = services.Settler
+
+// Capabilities describes how a ServiceManager brakes its resources
+type Capabilities = services.Capabilities
+
+// PartialError is returned by a ServiceManager's Discover, alongside the
+// resources it found, when parts of the service couldn't be read
+type PartialError = services.PartialError
+
+// DiscoveryReport says which services couldn't be fully discovered
+type DiscoveryReport = models.DiscoveryReport
+
+// CostConfig selects the cost model used for resource estimates
+type CostConfig = models.CostConfig
+
+// SnapshotStore persists snapshots. Use NewS3SnapshotStore to share
+// snapshots through S3, or implement it for another backend.
+type SnapshotStore = state.SnapshotStore
+
+// NewS3SnapshotStore stores snapshots under prefix in an S3 bucket, encrypted
+// with SSE-S3 or, if kmsKeyID is set, SSE-KMS
+func NewS3SnapshotStore(cfg aws.Config, bucket, prefix, kmsKeyID string) SnapshotStore {
+	return state.NewS3Store(cfg, bucket, prefix, kmsKeyID)
+}
+
+// RetryPolicy controls retries of throttled and transient AWS errors
+type RetryPolicy = services.RetryPolicy
+
+// Concurrency limits how many operations run at once, overall and per service
+type Concurrency = services.Concurrency
+
+// Supported services
+const (
+	ServiceEC2         = models.ServiceEC2
+	ServiceRDS         = models.ServiceRDS
+	ServiceECS         = models.ServiceECS
+	ServiceAutoScaling = models.ServiceAutoScaling
+	ServiceNetwork     = models.ServiceNetwork
+	ServiceEKS         = models.ServiceEKS
+	ServiceWorkSpaces  = models.ServiceWorkSpaces
+	ServiceAppStream   = models.ServiceAppStream
+	ServiceOpenSearch  = models.ServiceOpenSearch
+	ServiceDynamoDB    = models.ServiceDynamoDB
+	ServiceEMR         = models.ServiceEMR
+	ServiceKinesis     = models.ServiceKinesis
+	ServiceLightsail   = models.ServiceLightsail
+)
+
+// Options configures a Client
+type Options struct {
+	// IncludeNetwork allows NAT gateways to be deleted on pause and recreated on resume
+	IncludeNetwork bool
+	// IncludeReservations allows idle EC2 capacity reservations to be
+	// cancelled on pause and recreated, with new IDs, on resume
+	IncludeReservations bool
+	// SnapshotDir is where snapshots and cached prices are stored. Empty uses
+	// the awsbreak CLI configuration directory, sharing them with the CLI.
+	SnapshotDir string
+	// SnapshotStore, if set, stores snapshots instead of SnapshotDir
+	SnapshotStore SnapshotStore
+	// Cost selects the cost model; nil uses the built-in rates
+	Cost *CostConfig
+	// Retry controls retries; the zero value uses the CLI defaults
+	Retry RetryPolicy
+	// Concurrency limits parallel operations; the zero value uses the CLI defaults
+	Concurrency Concurrency
+	// OnResult, if set, is called as each pause or resume finishes. Calls are serialized.
+	OnResult func(OperationResult)
+	// Priorities overrides the default rank of individual services; lower
+	// ranks resume first and pause last
+	Priorities map[ServiceType]int
+	// Dependencies maps a resource ID or ARN to the IDs or ARNs of resources
+	// it needs running. Dependencies are resumed first and paused last.
+	Dependencies map[string][]string
+	// Managers adds managers for services awsbreak doesn't manage itself
+	Managers []ServiceManager
+	// Journal records each pause and resume in SnapshotDir as it runs, so a
+	// run interrupted part way can be finished with `awsbreak recover`.
+	// Journaled runs are serialized and filed under the first resource's region.
+	Journal bool
+}
+
+// Client discovers, pauses and resumes resources in one AWS account
+type Client struct {
+	orchestrator *services.Orchestrator
+	snapshots    *state.SnapshotManager
+	journal      *journal.Journal
+
+	run   sync.Mutex // serializes journaled runs
+	entry *journal.Entry
+}
+
+// New creates a client using the given AWS credentials
+func New(cfg aws.Config, opts Options) (*Client, error) {
+	dir := opts.SnapshotDir
+	if dir == "" {
+		mgr, err := config.NewManager()
+		if err != nil {
+			return nil, err
+		}
+		dir = mgr.GetConfigDir()
+	}
+
+	snapshots := state.NewSnapshotManager(dir)
+	if opts.SnapshotStore != nil {
+		snapshots = state.NewSnapshotManagerWithStore(opts.SnapshotStore)
+	}
+
+	c := &Client{snapshots: snapshots}
+	if opts.Journal {
+		c.journal = journal.NewJournal(dir)
+	}
+
+	c.orchestrator = services.NewOrchestrator(cfg, services.Options{
+		IncludeNetwork:      opts.IncludeNetwork,
+		IncludeReservations: opts.IncludeReservations,
+		OnResult: func(result OperationResult) {
+			if c.entry != nil {
+				if err := c.journal.Record(c.entry, result); err != nil {
+					logging.Warn("failed to journal result", "resource", result.Resource.ResourceID, "error", err)
+				}
+			}
+			if opts.OnResult != nil {
+				opts.OnResult(result)
+			}
+		},
+		OnIssue: func(r Resource, _ string) {
+			if c.entry != nil {
+				if err := c.journal.Issue(c.entry, r); err != nil {
+					logging.Warn("failed to journal resource", "resource", r.ResourceID, "error", err)
+				}
+			}
+		},
+		Priorities:   opts.Priorities,
+		Dependencies: opts.Dependencies,
+		Managers:     opts.Managers,
+		Cost:         opts.Cost,
+		Retry:        opts.Retry,
+		Concurrency:  opts.Concurrency,
+		PricingDir:   dir,
+	})
+	return c, nil
+}
+
+// Discover returns the running resources in a region, including report-only
+// resources that are never paused
+func (c *Client) Discover(ctx context.Context, region string) ([]Resource, error) {
+	resources, _, err := c.orchestrator.DiscoverAll(ctx, region)
+	return resources, err
+}
+
+// DiscoverWithReport is Discover plus a report of the services that couldn't
+// be fully discovered, e.g. because of missing permissions
+func (c *Client) DiscoverWithReport(ctx context.Context, region string) ([]Resource, *DiscoveryReport, error) {
+	return c.orchestrator.DiscoverAll(ctx, region)
+}
+
+// Capabilities returns how each supported service brakes its resources,
+// e.g. whether pausing deletes them or AWS restarts them after a while
+func (c *Client) Capabilities() map[ServiceType]Capabilities {
+	return c.orchestrator.Capabilities()
+}
+
+// Pause stops the given resources, skipping report-only ones. Save the
+// results with SaveSnapshot to be able to restore them later.
+func (c *Client) Pause(ctx context.Context, resources []Resource) ([]OperationResult, error) {
+	return c.execute(ctx, "pause", Actionable(resources))
+}
+
+// Resume starts the given resources
+func (c *Client) Resume(ctx context.Context, resources []Resource) ([]OperationResult, error) {
+	return c.execute(ctx, "resume", resources)
+}
+
+// execute runs an operation, journaling it when Options.Journal is set
+func (c *Client) execute(ctx context.Context, operation string, resources []Resource) ([]OperationResult, error) {
+	run := c.orchestrator.PauseAll
+	if operation == "resume" {
+		run = c.orchestrator.ResumeAll
+	}
+	if c.journal == nil || len(resources) == 0 {
+		return run(ctx, resources)
+	}
+
+	c.run.Lock()
+	defer c.run.Unlock()
+
+	entry := &journal.Entry{Operation: operation, Region: resources[0].Region, Resources: resources}
+	if err := c.journal.Begin(entry); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+	c.entry = entry
+	defer func() { c.entry = nil }()
+
+	results, err := run(ctx, resources)
+	if err != nil && ctx.Err() != nil {
+		// Left in place for `awsbreak recover`
+		return results, err
+	}
+	if ferr := c.journal.Finish(entry); ferr != nil && err == nil {
+		err = fmt.Errorf("failed to update journal: %w", ferr)
+	}
+	return results, err
+}
+
+// SaveSnapshot records the resources a pause stopped. It returns nil if
+// nothing was stopped.
+func (c *Client) SaveSnapshot(ctx context.Context, region string, results []OperationResult) (*Snapshot, error) {
+	snapshot := state.NewSnapshot(region, results)
+	if len(snapshot.Resources) == 0 {
+		return nil, nil
+	}
+	if err := c.snapshots.Save(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// ResumeSnapshot resumes the resources in a snapshot that haven't been
+// resumed yet and records which ones came back
+func (c *Client) ResumeSnapshot(ctx context.Context, snapshot *Snapshot) ([]OperationResult, error) {
+	results, err := c.Resume(ctx, snapshot.PendingResources())
+	if err != nil {
+		return results, err
+	}
+	if err := c.snapshots.MarkResumed(ctx, snapshot, results); err != nil {
+		return results, fmt.Errorf("failed to update snapshot: %w", err)
+	}
+	return results, nil
+}
+
+// Snapshots returns every stored snapshot, newest first
+func (c *Client) Snapshots(ctx context.Context) ([]*Snapshot, error) {
+	return c.snapshots.List(ctx)
+}
+
+// Snapshot loads a snapshot by ID
+func (c *Client) Snapshot(ctx context.Context, id string) (*Snapshot, error) {
+	return c.snapshots.Load(ctx, id)
+}
+
+// LatestSnapshot returns the newest snapshot in a region with resources still
+// paused, or nil if there is none
+func (c *Client) LatestSnapshot(ctx context.Context, region string) (*Snapshot, error) {
+	return c.snapshots.Latest(ctx, region)
+}
+
+// Actionable drops report-only resources, which are shown for cost
+// visibility but never paused
+func Actionable(resources []Resource) []Resource {
+	var actionable []Resource
+	for _, r := range resources {
+		if !services.IsReportOnly(r) {
+			actionable = append(actionable, r)
+		}
+	}
+	return actionable
+}